@@ -1,9 +1,13 @@
 package ae
 
 import (
+	"bytes"
+	"fmt"
+	"iter"
 	"maps"
 	"slices"
-	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,13 +18,24 @@ type Ae struct {
 	msg string
 	// userMsg is a user-friendly error message that can be safely displayed to end users
 	userMsg string
+	// userMsgs holds translations of the user message, keyed by locale
+	userMsgs map[string]string
 	// hint provides additional guidance or suggestions for resolving the error
 	hint string
 	// recoverable indicates whether the error is recoverable
 	recoverable bool
+	// recoverableSet marks recoverable as set explicitly, so classifiers
+	// don't change it
+	recoverableSet bool
 
 	// timestamp is the time the error occurred
 	timestamp time.Time
+	// autoStamped marks timestamp as taken automatically when the error was
+	// finalized (see SetAutoTimestamp)
+	autoStamped bool
+	// initStamped marks an automatic timestamp taken during package
+	// initialization; From doesn't copy it
+	initStamped bool
 
 	// code is an error code that can be used for programmatic error handling
 	code string
@@ -31,6 +46,8 @@ type Ae struct {
 	traceId string
 	// spanId identifies a specific operation within a trace
 	spanId string
+	// requestId identifies the request being served when the error occurred
+	requestId string
 
 	// tags are used to categorize and filter errors
 	tags map[string]struct{}
@@ -39,12 +56,98 @@ type Ae struct {
 
 	// causes contains the underlying errors that led to this error
 	causes []error
+	// deepCauseTypes marks the cause.types attribute as listing the types
+	// of the whole cause tree, stamped under CauseTypesDeep
+	deepCauseTypes bool
+	// causesInMsg marks the causes, by index, whose text is part of msg,
+	// as added by Builder.Msgf and Builder.MsgFrom; Error doesn't repeat them
+	causesInMsg []bool
+	// source is the error a builder was created from with From, cleared
+	// when the error is finalized
+	source error
+	// classifyErrs are the foreign errors the classifiers are applied to
+	// when the error is finalized, unless noClassify is set
+	classifyErrs []error
+	noClassify   bool
 	// related contains errors that are related to this error, but not a direct cause
 	// also includes errors that occurred during the handling of the cause(s)
 	related []error
 
 	// stacks contains the stack traces associated with this error
 	stacks []*Stack
+
+	// fingerprint overrides the fingerprint computed by Fingerprint
+	fingerprint string
+
+	// sortedTags caches the sorted tags; set when the error is finalized
+	sortedTags *tagCache
+	// errorText caches the Error() text; set when the error is finalized
+	errorText *errorCache
+}
+
+// tagCache holds the sorted tags of a finalized error, computed once.
+type tagCache struct {
+	once   sync.Once
+	sorted []string
+}
+
+// tagList returns the sorted tags, computed once per finalized error. The
+// result must not be modified.
+func (a Ae) tagList() []string {
+	if a.sortedTags == nil {
+		return slices.Sorted(maps.Keys(a.tags))
+	}
+
+	a.sortedTags.once.Do(func() {
+		a.sortedTags.sorted = slices.Sorted(maps.Keys(a.tags))
+	})
+
+	return a.sortedTags.sorted
+}
+
+// errorCache holds the Error() text of a finalized error, computed once per
+// errorTextGeneration.
+type errorCache struct {
+	entry atomic.Pointer[errorCacheEntry]
+}
+
+// errorCacheEntry is the Error() text computed under the text settings of
+// generation gen.
+type errorCacheEntry struct {
+	gen  uint64
+	text string
+}
+
+// asAe returns err as an *Ae if it is an Ae or *Ae.
+func asAe(err error) (*Ae, bool) {
+	switch x := err.(type) {
+	case *Ae:
+		return x, x != nil
+	case Ae:
+		return &x, true
+	default:
+		return nil, false
+	}
+}
+
+// attrSeq iterates over the attributes of err, without copying them if err
+// is an Ae.
+func attrSeq(err error) iter.Seq2[string, any] {
+	if a, ok := asAe(err); ok {
+		return a.AttrSeq()
+	}
+
+	return maps.All(Attributes(err))
+}
+
+// tagSeq iterates over the tags of err, sorted lexically, without copying
+// them if err is an Ae.
+func tagSeq(err error) iter.Seq[string] {
+	if a, ok := asAe(err); ok {
+		return a.TagSeq()
+	}
+
+	return slices.Values(Tags(err))
 }
 
 // ErrorMessage returns the internal error message.
@@ -57,6 +160,12 @@ func (a Ae) ErrorUserMessage() string {
 	return a.userMsg
 }
 
+// ErrorUserMessages returns a copy of the translated user messages, keyed by
+// locale.
+func (a Ae) ErrorUserMessages() map[string]string {
+	return maps.Clone(a.userMsgs)
+}
+
 // ErrorHint returns additional guidance for resolving the error.
 func (a Ae) ErrorHint() string {
 	return a.hint
@@ -105,14 +214,43 @@ func (a Ae) ErrorSpanId() string {
 	return a.spanId
 }
 
-// ErrorTags returns a slice of all tags associated with this error.
+// ErrorRequestId returns the ID of the request being served.
+func (a Ae) ErrorRequestId() string {
+	return a.requestId
+}
+
+// ErrorFingerprint returns the fingerprint set via Builder.Fingerprint.
+func (a Ae) ErrorFingerprint() string {
+	return a.fingerprint
+}
+
+// ErrorTags returns a slice of all tags associated with this error, sorted
+// lexically.
 func (a Ae) ErrorTags() []string {
-	return slices.Collect(maps.Keys(a.tags))
+	if len(a.tags) == 0 {
+		return nil
+	}
+
+	return slices.Clone(a.tagList())
 }
 
-// ErrorAttributes returns a copy of the error's attributes map.
+// ErrorAttributes returns a copy of the error's attributes map. Container
+// values are deep-copied, see SetAttrDeepCopy.
 func (a Ae) ErrorAttributes() map[string]any {
-	return maps.Clone(a.attributes)
+	return copyAttributes(a.attributes)
+}
+
+// AttrSeq returns an iterator over the error's attributes, in no particular
+// order. Unlike ErrorAttributes nothing is copied, so container values must
+// not be modified.
+func (a Ae) AttrSeq() iter.Seq2[string, any] {
+	return maps.All(a.attributes)
+}
+
+// TagSeq returns an iterator over the error's tags, sorted lexically. Unlike
+// ErrorTags the tags are not copied.
+func (a Ae) TagSeq() iter.Seq[string] {
+	return slices.Values(a.tagList())
 }
 
 // ErrorCauses returns a copy of the underlying errors that caused this error.
@@ -127,40 +265,159 @@ func (a Ae) ErrorRelated() []error {
 }
 
 // ErrorStacks returns a copy of the stack traces associated with this error.
+// Lazily captured frames are resolved before returning.
 func (a Ae) ErrorStacks() []*Stack {
+	for _, st := range a.stacks {
+		st.resolve()
+	}
+
 	return slices.Clone(a.stacks)
 }
 
 // Error implements the error interface by returning a string representation of the error.
 // It includes the main error message and any underlying causes.
+// The text is built on the first call and cached, as errors are not modified
+// once finalized; it is rebuilt after SetErrorTextLimits or
+// SetMaxTraversalDepth change how it is rendered.
 func (a Ae) Error() string {
-	var errMsg strings.Builder
-	errMsg.WriteString(a.msg)
-
-	if len(a.causes) > 0 {
-		errMsg.WriteString(": ")
-
-		if len(a.causes) == 1 {
-			errMsg.WriteString(a.causes[0].Error())
-		} else {
-			errMsg.WriteString("[")
-			for i, cause := range a.causes {
-				if i > 0 {
-					errMsg.WriteString("; ")
-				}
-				errMsg.WriteString(cause.Error())
+	if a.errorText == nil {
+		return a.errorString()
+	}
+
+	gen := errorTextGeneration.Load()
+	if e := a.errorText.entry.Load(); e != nil && e.gen == gen {
+		return e.text
+	}
+
+	text := a.errorString()
+	a.errorText.entry.Store(&errorCacheEntry{gen: gen, text: text})
+	return text
+}
+
+// errorString builds the text returned by Error.
+func (a Ae) errorString() string {
+	var buf bytes.Buffer
+	a.writeErrorText(&buf, 0)
+	return buf.String()
+}
+
+// writeErrorText writes the text returned by Error for an error depth levels
+// below the one Error was called on. The text of nested Ae causes is written
+// directly rather than through their Error method, so the nesting is cut at
+// the maximum traversal depth.
+func (a Ae) writeErrorText(buf *bytes.Buffer, depth int) {
+	buf.WriteString(a.msg)
+	causes := a.textCauses()
+	if len(causes) == 0 {
+		return
+	}
+
+	if depth >= traversalDepth() {
+		buf.WriteString(": ")
+		buf.WriteString(truncatedMessage)
+		return
+	}
+
+	buf.WriteString(": ")
+
+	if len(causes) == 1 {
+		writeCauseText(buf, causes[0], depth+1)
+		return
+	}
+
+	limits := activeErrorTextLimits.Load()
+	buf.WriteString("[")
+	start := buf.Len()
+	for i, cause := range causes {
+		if i > 0 {
+			if limits.exceeded(i, buf.Len()-start) {
+				fmt.Fprintf(buf, "; … and %d more", len(causes)-i)
+				break
 			}
-			errMsg.WriteString("]")
+			buf.WriteString("; ")
 		}
+		writeCauseText(buf, cause, depth+1)
+	}
+	buf.WriteString("]")
+}
+
+// printedCauses returns the causes the text printers list below err: all of
+// them but the errors that are not *Ae and whose text is part of the message
+// of err, such as the error a builder was created from with From; they add
+// nothing the message doesn't show. *Ae causes are kept for their metadata.
+func printedCauses(err error) []error {
+	a, ok := asAe(err)
+	if !ok || len(a.causesInMsg) == 0 {
+		return Causes(err)
 	}
 
-	return errMsg.String()
+	causes := make([]error, 0, len(a.causes))
+	for i, cause := range a.causes {
+		if i < len(a.causesInMsg) && a.causesInMsg[i] {
+			if _, ok := asAe(cause); !ok {
+				continue
+			}
+		}
+		causes = append(causes, cause)
+	}
+
+	return causes
+}
+
+// textCauses returns the causes Error lists after the message: all of them
+// but those already part of the message.
+func (a Ae) textCauses() []error {
+	if len(a.causesInMsg) == 0 {
+		return a.causes
+	}
+
+	causes := make([]error, 0, len(a.causes))
+	for i, cause := range a.causes {
+		if i >= len(a.causesInMsg) || !a.causesInMsg[i] {
+			causes = append(causes, cause)
+		}
+	}
+
+	return causes
+}
+
+// writeCauseText writes the text of cause, depth levels below the error Error
+// was called on.
+func writeCauseText(buf *bytes.Buffer, cause error, depth int) {
+	if a, ok := asAe(cause); ok {
+		a.writeErrorText(buf, depth)
+		return
+	}
+
+	buf.WriteString(cause.Error())
 }
 
 // Unwrap returns the underlying errors that caused this error.
 // This implements the errors.Unwrap interface.
+//
+// Unlike ErrorCauses, the returned slice is not a copy, so traversals such as
+// errors.Is don't allocate at every level; it must not be modified.
 func (a Ae) Unwrap() []error {
-	return a.ErrorCauses()
+	return a.causes
+}
+
+// Is reports whether a matches target by code: target carries a non-empty
+// error code (see ErrorCode) equal to a's. Failing that, it reports whether a
+// matcher registered for target with RegisterIsMatcher fires for a or the
+// non-ae errors below it. Identity and causes are already handled by
+// errors.Is itself; related errors are never considered, see IsRelated.
+func (a Ae) Is(target error) bool {
+	if a.code != "" {
+		if t, ok := target.(ErrorCode); ok && t.ErrorCode() == a.code {
+			return true
+		}
+	}
+
+	if fns := matchersFor(target); len(fns) > 0 {
+		return isMatchedBelow(&a, fns)
+	}
+
+	return false
 }
 
 // Print writes the formatted error to standard output using the provided printer options.
@@ -173,15 +430,20 @@ func (a Ae) Prints(opts ...PrinterOption) string {
 	return NewPrinter(opts...).Prints(a)
 }
 
-// clone creates and returns a deep copy of the Ae instance and its associated fields.
-func (a Ae) clone() Ae {
+// Clone returns a copy of the error that can be modified (e.g. via From)
+// without affecting the original. Tags, attributes, causes, related errors
+// and stacks are copied; container attribute values are deep-copied (see
+// SetAttrDeepCopy), while pointer values, causes and stacks themselves are
+// shared.
+func (a Ae) Clone() *Ae {
 	cpy := a
 
+	cpy.userMsgs = maps.Clone(a.userMsgs)
 	cpy.tags = maps.Clone(a.tags)
-	cpy.attributes = maps.Clone(a.attributes)
+	cpy.attributes = copyAttributes(a.attributes)
 	cpy.causes = slices.Clone(a.causes)
 	cpy.related = slices.Clone(a.related)
 	cpy.stacks = slices.Clone(a.stacks)
 
-	return cpy
+	return &cpy
 }
@@ -8,7 +8,25 @@ import (
 	"strings"
 )
 
+// Both Ae and *Ae implement slog.LogValuer: the pointer's method set holds
+// the value receiver's LogValue.
+var (
+	_ slog.LogValuer = Ae{}
+	_ slog.LogValuer = (*Ae)(nil)
+)
+
+// LogValue implements slog.LogValuer, rendering the error as a group of its
+// fields, causes and related errors. It is declared on the value receiver so
+// that slog resolves it whether an Ae or the *Ae returned by the builders is
+// logged; Go doesn't allow declaring it on the pointer as well.
 func (a Ae) LogValue() slog.Value {
+	return a.logValue(0)
+}
+
+// logValue is LogValue for an error depth levels below the one logged. Nested
+// Ae causes and related errors are resolved here rather than by the handler,
+// so the nesting is cut at the maximum traversal depth.
+func (a Ae) logValue(depth int) slog.Value {
 	rootAttrs := []slog.Attr{
 		slog.String("msg", a.msg),
 		slog.Bool("recoverable", a.recoverable),
@@ -21,46 +39,61 @@ func (a Ae) LogValue() slog.Value {
 		rootAttrs = append(rootAttrs, slog.String("hint", a.hint))
 	}
 	if !a.timestamp.IsZero() {
-		rootAttrs = append(rootAttrs, slog.Time("timestamp", a.timestamp))
+		ts := a.timestamp
+		if timestampUTC.Load() {
+			ts = ts.UTC()
+		}
+		rootAttrs = append(rootAttrs, slog.Time("timestamp", ts))
 	}
 	if a.code != "" {
 		rootAttrs = append(rootAttrs, slog.String("code", a.code))
 	}
+	if a.requestId != "" {
+		rootAttrs = append(rootAttrs, slog.String("request_id", a.requestId))
+	}
 	if a.exitCode > 0 {
 		rootAttrs = append(rootAttrs, slog.Int("exit_code", a.exitCode))
 	}
 
 	if len(a.tags) > 0 {
-		rootAttrs = append(rootAttrs, slog.String("tags", strings.Join(
-			slices.Collect(maps.Keys(a.tags)), ", ")),
-		)
+		rootAttrs = append(rootAttrs, slog.String("tags", strings.Join(a.tagList(), ", ")))
 	}
 
 	if len(a.attributes) > 0 {
 		var attrs []slog.Attr
-		for k, v := range a.attributes {
-			attrs = append(attrs, slog.Any(k, v))
+		for _, k := range slices.Sorted(maps.Keys(a.attributes)) {
+			attrs = append(attrs, slog.Any(k, a.attributes[k]))
 		}
 		rootAttrs = append(rootAttrs, slog.GroupAttrs("attributes", attrs...))
 	}
 
 	if len(a.causes) > 0 {
-		var causeAttrs []slog.Attr
-		for i, cause := range a.causes {
-			causeAttrs = append(causeAttrs, slog.Any(fmt.Sprintf("%d", i), cause))
-		}
-		rootAttrs = append(rootAttrs, slog.GroupAttrs("causes", causeAttrs...))
+		rootAttrs = append(rootAttrs, nestedLogAttr("causes", a.causes, depth))
 	}
 
 	if len(a.related) > 0 {
-		var relatedAttrs []slog.Attr
-		for i, rel := range a.related {
-			relatedAttrs = append(relatedAttrs, slog.Any(fmt.Sprintf("%d", i), rel))
-		}
-		rootAttrs = append(rootAttrs, slog.GroupAttrs("related", relatedAttrs...))
+		rootAttrs = append(rootAttrs, nestedLogAttr("related", a.related, depth))
 	}
 
 	return slog.GroupValue(
 		rootAttrs...,
 	)
 }
+
+// nestedLogAttr returns the group key of the causes or related errors of an
+// error depth levels below the one logged.
+func nestedLogAttr(key string, errs []error, depth int) slog.Attr {
+	if depth >= traversalDepth() {
+		return slog.String(key, truncatedMessage)
+	}
+
+	attrs := make([]slog.Attr, len(errs))
+	for i, err := range errs {
+		attrs[i] = slog.Any(fmt.Sprintf("%d", i), err)
+		if x, ok := asAe(err); ok {
+			attrs[i].Value = x.logValue(depth + 1)
+		}
+	}
+
+	return slog.GroupAttrs(key, attrs...)
+}
@@ -1,6 +1,7 @@
 package ae_test
 
 import (
+	"encoding/json"
 	"log/slog"
 	"strings"
 	"testing"
@@ -102,7 +103,7 @@ func TestAe_LogValue_RootFieldsPresent(t *testing.T) {
 }
 
 func TestAe_LogValue_OmitsEmptyFields(t *testing.T) {
-	t.Parallel()
+	setAutoTimestamp(t, false)
 
 	attrs := flattenAttrs(logValue(t, ae.New().Msg("plain")))
 
@@ -141,3 +142,24 @@ func TestAe_LogValue_CausesAndRelatedGrouped(t *testing.T) {
 		t.Errorf("related.0.msg = %v, want 'side-effect'", attrs["related.0.msg"])
 	}
 }
+
+func TestAe_LogValue_JSONHandler(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Code("USER_NOT_FOUND").Attr("user_id", 42).Msg("user not found")
+
+	for _, value := range []any{err, *err.(*ae.Ae)} {
+		var sb strings.Builder
+		slog.New(slog.NewJSONHandler(&sb, nil)).Error("request failed", "err", value)
+
+		var record struct {
+			Err map[string]any `json:"err"`
+		}
+		if jErr := json.Unmarshal([]byte(sb.String()), &record); jErr != nil {
+			t.Fatalf("%T: decoding the record: %v\n%s", value, jErr, sb.String())
+		}
+		if record.Err["msg"] != "user not found" || record.Err["code"] != "USER_NOT_FOUND" {
+			t.Errorf("%T: err = %v, want the structured group\n%s", value, record.Err, sb.String())
+		}
+	}
+}
@@ -2,9 +2,11 @@ package ae_test
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
 	"slices"
 	"strings"
+	"sync"
 	"testing"
 
 	"go.aledante.io/ae"
@@ -246,6 +248,7 @@ func TestAe_ImplementsAllInterfaces(t *testing.T) {
 		{"ErrorRelated", implements[ae.ErrorRelated](err)},
 		{"ErrorStacks", implements[ae.ErrorStacks](err)},
 		{"ErrorTimestamp", implements[ae.ErrorTimestamp](err)},
+		{"ErrorFingerprint", implements[ae.ErrorFingerprint](err)},
 	}
 	for _, c := range checks {
 		if !c.ok {
@@ -260,3 +263,163 @@ func implements[T any](v any) bool {
 	_, ok := v.(T)
 	return ok
 }
+
+func TestAe_IsMatchesByCode(t *testing.T) {
+	t.Parallel()
+
+	sentinel := ae.New().Code("NOT_FOUND").Msg("not found")
+	err := ae.Wrap("loading user", ae.New().Code("NOT_FOUND").Msg("select returned no rows"))
+
+	if !errors.Is(err, sentinel) {
+		t.Error("errors.Is did not match an equal code deep in the chain")
+	}
+	if errors.Is(err, ae.New().Code("CONFLICT").Msg("conflict")) {
+		t.Error("errors.Is matched a different code")
+	}
+	if errors.Is(ae.Msg("no code"), ae.Msg("no code")) {
+		t.Error("errors.Is matched two errors without codes")
+	}
+}
+
+func TestAe_IsMatchesSentinelThroughCauses(t *testing.T) {
+	t.Parallel()
+
+	sentinel := errors.New("sentinel")
+	err := ae.WrapMany("several", errors.New("other"), fmt.Errorf("ctx: %w", sentinel))
+
+	if !errors.Is(err, sentinel) {
+		t.Error("errors.Is did not find the sentinel among multiple causes")
+	}
+}
+
+func TestIsRelated(t *testing.T) {
+	t.Parallel()
+
+	sentinel := errors.New("cleanup failed")
+	inner := ae.New().Related(sentinel).Msg("inner")
+	err := ae.Wrap("outer", inner)
+
+	if errors.Is(err, sentinel) {
+		t.Error("errors.Is considered related errors")
+	}
+	if !ae.IsRelated(err, sentinel) {
+		t.Error("IsRelated did not find the related error of a cause")
+	}
+	if ae.IsRelated(err, errors.New("other")) || ae.IsRelated(nil, sentinel) {
+		t.Error("IsRelated matched an unrelated error")
+	}
+}
+
+func BenchmarkIs_DeepChain(b *testing.B) {
+	sentinel := errors.New("sentinel")
+	var err error = sentinel
+	for i := range 10 {
+		err = ae.WrapMany(fmt.Sprintf("level %d", i), errors.New("sibling"), err)
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if !errors.Is(err, sentinel) {
+			b.Fatal("sentinel not found")
+		}
+	}
+}
+
+func TestAe_CloneDeepCopiesContainerAttributes(t *testing.T) {
+	t.Parallel()
+
+	type payload struct{ N int }
+	ptr := &payload{N: 1}
+
+	var orig *ae.Ae
+	if !errors.As(ae.New().
+		Attr("nested", map[string]any{"k": "v", "list": []any{"a", map[string]any{"deep": 1}}}).
+		Attr("names", []string{"x"}).
+		Attr("ptr", ptr).
+		Msg("orig"), &orig) {
+		t.Fatal("not an *ae.Ae")
+	}
+
+	clone := orig.Clone()
+	if clone == orig {
+		t.Fatal("Clone returned the same pointer")
+	}
+
+	attrs := clone.ErrorAttributes()
+	nested := attrs["nested"].(map[string]any)
+	nested["k"] = "mutated"
+	nested["list"].([]any)[1].(map[string]any)["deep"] = 2
+	attrs["names"].([]string)[0] = "mutated"
+
+	want := ae.Attributes(orig)
+	if got := want["nested"].(map[string]any)["k"]; got != "v" {
+		t.Errorf("nested map mutation leaked into the original: %v", got)
+	}
+	if got := want["nested"].(map[string]any)["list"].([]any)[1].(map[string]any)["deep"]; got != 1 {
+		t.Errorf("deeply nested mutation leaked into the original: %v", got)
+	}
+	if got := want["names"].([]string)[0]; got != "x" {
+		t.Errorf("[]string mutation leaked into the original: %v", got)
+	}
+	if attrs["ptr"] != ptr {
+		t.Error("pointer attribute values should be shared, not copied")
+	}
+
+	// From relies on the same deep copy.
+	fromAttrs := ae.Attributes(ae.From(orig).Msg("copy"))
+	fromAttrs["nested"].(map[string]any)["k"] = "from"
+	if got := ae.Attributes(orig)["nested"].(map[string]any)["k"]; got != "v" {
+		t.Errorf("mutation through From leaked into the original: %v", got)
+	}
+}
+
+func TestSetAttrDeepCopy(t *testing.T) {
+	withPackageState(t, func() { ae.SetAttrDeepCopy(true) })
+
+	ae.SetAttrDeepCopy(false)
+	err := ae.New().Attr("nested", map[string]any{"k": "v"}).Msg("x")
+	ae.Attributes(err)["nested"].(map[string]any)["k"] = "shared"
+
+	if got := ae.Attributes(err)["nested"].(map[string]any)["k"]; got != "shared" {
+		t.Errorf("nested value = %v, want it shared without deep copies", got)
+	}
+}
+
+func TestAe_ErrorCachedAndConcurrencySafe(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Cause(errors.New("a"), ae.Wrap("b", errors.New("c"))).Msg("top")
+	const want = "top: [a; b: c]"
+
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := err.Error(); got != want {
+				t.Errorf("Error() = %q, want %q", got, want)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := ae.From(err).Cause(errors.New("d")).Msg("").Error(); got != "top: [a; b: c; d]" {
+		t.Errorf("Error() of a derived error = %q, want the new cause included", got)
+	}
+	if got := err.Error(); got != want {
+		t.Errorf("Error() after deriving = %q, want %q", got, want)
+	}
+}
+
+func BenchmarkError_TenCauses(b *testing.B) {
+	causes := make([]error, 10)
+	for i := range causes {
+		causes[i] = ae.Wrap(fmt.Sprintf("attempt %d", i), errors.New("connection refused"))
+	}
+	err := ae.New().Causes(causes).Msg("retries exhausted")
+
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = err.Error()
+	}
+}
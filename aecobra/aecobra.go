@@ -0,0 +1,73 @@
+// Package aecobra handles errors returned by cobra commands with the ae
+// printer and exit codes.
+package aecobra
+
+import (
+	"github.com/spf13/cobra"
+	"go.aledante.io/ae"
+)
+
+// Option configures WrapRunE.
+type Option func(c *config)
+
+type config struct {
+	// verboseFlag is the name of a bool flag switching to verbose output.
+	verboseFlag string
+	// printerOpts are applied on top of the CLI defaults.
+	printerOpts []ae.PrinterOption
+}
+
+// VerboseFlag names a bool flag (e.g. "verbose") that, when set on the
+// executed command, switches the error output to ae.PrintVerbose.
+// The flag is looked up in the command's flags, including persistent ones
+// inherited from parents; an undefined flag is treated as false.
+func VerboseFlag(name string) Option {
+	return func(c *config) {
+		c.verboseFlag = name
+	}
+}
+
+// PrinterOptions adds printer options applied on top of the CLI defaults.
+func PrinterOptions(opts ...ae.PrinterOption) Option {
+	return func(c *config) {
+		c.printerOpts = append(c.printerOpts, opts...)
+	}
+}
+
+// WrapRunE wraps a cobra RunE function. When fn returns a non-nil error, the
+// error is printed to the command's error writer (stderr by default) with
+// ae.PrintCompact — user message and hint included, stacks and trace IDs
+// omitted — and the process exits through ae.Exit with ae.ExitCode(err).
+//
+// The command's SilenceErrors and SilenceUsage are set before returning so
+// cobra does not print the error or the usage a second time. If the exiter
+// installed with ae.SetExiter returns, the error is returned to cobra.
+func WrapRunE(fn func(cmd *cobra.Command, args []string) error, opts ...Option) func(cmd *cobra.Command, args []string) error {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return func(cmd *cobra.Command, args []string) error {
+		err := fn(cmd, args)
+		if err == nil {
+			return nil
+		}
+
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+
+		printerOpts := []ae.PrinterOption{ae.PrintCompact()}
+		if c.verboseFlag != "" {
+			if verbose, fErr := cmd.Flags().GetBool(c.verboseFlag); fErr == nil && verbose {
+				printerOpts = append(printerOpts, ae.PrintVerbose())
+			}
+		}
+		printerOpts = append(printerOpts, c.printerOpts...)
+
+		ae.NewPrinter(printerOpts...).Fprint(cmd.ErrOrStderr(), err)
+		ae.Exit(err)
+
+		return err
+	}
+}
@@ -0,0 +1,91 @@
+package aecobra_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"go.aledante.io/ae"
+	"go.aledante.io/ae/aecobra"
+)
+
+// run executes a root command whose RunE returns runErr and captures the
+// exit code through ae.SetExiter. Tests using it mutate package state of ae
+// and therefore do not run in parallel.
+func run(t *testing.T, runErr error, args []string, opts ...aecobra.Option) (stdout, stderr string, code int) {
+	t.Helper()
+
+	code = -1
+	ae.SetExiter(func(c int) { code = c })
+	t.Cleanup(func() { ae.SetExiter(nil) })
+
+	cmd := &cobra.Command{
+		Use: "app",
+		RunE: aecobra.WrapRunE(func(*cobra.Command, []string) error {
+			return runErr
+		}, append([]aecobra.Option{aecobra.PrinterOptions(ae.NoPrintColors())}, opts...)...),
+	}
+	cmd.PersistentFlags().Bool("verbose", false, "print verbose errors")
+
+	var outBuf, errBuf bytes.Buffer
+	cmd.SetOut(&outBuf)
+	cmd.SetErr(&errBuf)
+	cmd.SetArgs(args)
+	_ = cmd.Execute()
+
+	return outBuf.String(), errBuf.String(), code
+}
+
+func TestWrapRunE_NilErrorDoesNotExit(t *testing.T) {
+	_, stderr, code := run(t, nil, nil)
+
+	if code != -1 {
+		t.Errorf("exited with %d on success", code)
+	}
+	if stderr != "" {
+		t.Errorf("stderr = %q, want empty", stderr)
+	}
+}
+
+func TestWrapRunE_PrintsOnceAndExitsWithExitCode(t *testing.T) {
+	err := ae.New().
+		ExitCode(3).
+		Hint("run app init first").
+		Stack().
+		UserMsg("config not found", "No configuration found.")
+
+	stdout, stderr, code := run(t, err, nil)
+
+	if code != 3 {
+		t.Errorf("exit code = %d, want 3", code)
+	}
+	if strings.Count(stderr, "config not found") != 1 {
+		t.Errorf("error not printed exactly once:\n%s", stderr)
+	}
+	for _, want := range []string{"No configuration found.", "run app init first"} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("stderr missing %q:\n%s", want, stderr)
+		}
+	}
+	if strings.Contains(stderr, "stack") {
+		t.Errorf("default output contains a stack section:\n%s", stderr)
+	}
+	if strings.Contains(stdout+stderr, "Usage:") {
+		t.Errorf("cobra printed the usage:\n%s%s", stdout, stderr)
+	}
+}
+
+func TestWrapRunE_VerboseFlagSwitchesToVerboseOutput(t *testing.T) {
+	err := ae.New().Stack().Msg("boom")
+
+	_, quiet, _ := run(t, err, nil, aecobra.VerboseFlag("verbose"))
+	_, verbose, _ := run(t, err, []string{"--verbose"}, aecobra.VerboseFlag("verbose"))
+
+	if strings.Contains(quiet, "stack") {
+		t.Errorf("output without --verbose contains a stack section:\n%s", quiet)
+	}
+	if !strings.Contains(verbose, "stack") {
+		t.Errorf("output with --verbose has no stack section:\n%s", verbose)
+	}
+}
@@ -0,0 +1,22 @@
+module go.aledante.io/ae/aecobra
+
+go 1.26.0
+
+require (
+	github.com/spf13/cobra v1.10.2
+	go.aledante.io/ae v0.0.0-00010101000000-000000000000
+)
+
+require (
+	github.com/DataDog/gostackparse v0.7.0 // indirect
+	github.com/fatih/color v1.18.0 // indirect
+	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	github.com/mattn/go-colorable v0.1.14 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/spf13/pflag v1.0.9 // indirect
+	go.opentelemetry.io/otel v1.38.0 // indirect
+	go.opentelemetry.io/otel/trace v1.38.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+)
+
+replace go.aledante.io/ae => ..
@@ -0,0 +1,325 @@
+// Package aeconnect converts between ae errors and connect-go errors.
+//
+// The interceptor returned by NewInterceptor turns ae errors returned by
+// handlers into *connect.Error values carrying a mapped connect code, the
+// user-safe message and a detail holding the ae code, trace/span IDs and a
+// whitelisted set of attributes. On the client side it turns *connect.Error
+// values back into ae errors, restoring the same metadata from the detail.
+package aeconnect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"connectrpc.com/connect"
+	"go.aledante.io/ae"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// MetaCode is the metadata (header/trailer) key carrying the ae error code.
+const MetaCode = "Ae-Code"
+
+// Keys used inside the structpb.Struct error detail.
+const (
+	detailMarker     = "ae"
+	detailCode       = "code"
+	detailTraceId    = "trace_id"
+	detailSpanId     = "span_id"
+	detailAttributes = "attributes"
+)
+
+// Option configures the conversion performed by the interceptor and by
+// ToConnectError / FromConnectError.
+type Option func(c *config)
+
+type config struct {
+	// attributes is the whitelist of attribute keys copied into error details.
+	attributes []string
+	// codes maps ae error codes onto connect codes.
+	codes map[string]connect.Code
+	// mapper overrides the code mapping entirely when set.
+	mapper func(err error) connect.Code
+	// fallbackMsg is sent when the error carries no user message.
+	fallbackMsg string
+	// locales selects the translated user message sent, see ae.UserMessageFor.
+	locales []string
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{
+		codes:       make(map[string]connect.Code),
+		fallbackMsg: "internal error",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// WithAttributes whitelists attribute keys that are forwarded to the peer in
+// the error detail. Attributes are never forwarded unless whitelisted.
+func WithAttributes(keys ...string) Option {
+	return func(c *config) {
+		c.attributes = append(c.attributes, keys...)
+	}
+}
+
+// WithCode maps the ae error code onto the given connect code.
+func WithCode(aeCode string, code connect.Code) Option {
+	return func(c *config) {
+		c.codes[aeCode] = code
+	}
+}
+
+// WithCodeMapper replaces the default code mapping with fn.
+func WithCodeMapper(fn func(err error) connect.Code) Option {
+	return func(c *config) {
+		c.mapper = fn
+	}
+}
+
+// WithFallbackMessage sets the message sent to the peer when the error has no
+// user message. The internal message is never sent.
+func WithFallbackMessage(msg string) Option {
+	return func(c *config) {
+		c.fallbackMsg = msg
+	}
+}
+
+// WithLocale sends the user message translated for the first of locales the
+// error has a translation for, falling back to its default user message. See
+// ae.UserMessageFor.
+func WithLocale(locales ...string) Option {
+	return func(c *config) {
+		c.locales = append(c.locales, locales...)
+	}
+}
+
+// NewInterceptor returns a connect.Interceptor converting handler errors into
+// *connect.Error values and client errors back into ae errors.
+func NewInterceptor(opts ...Option) connect.Interceptor {
+	return &interceptor{cfg: newConfig(opts)}
+}
+
+type interceptor struct {
+	cfg *config
+}
+
+func (i *interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		resp, err := next(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+
+		if req.Spec().IsClient {
+			return nil, i.cfg.fromConnect(err)
+		}
+		return nil, i.cfg.toConnect(ctx, err)
+	}
+}
+
+func (i *interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		return &clientConn{StreamingClientConn: next(ctx, spec), cfg: i.cfg}
+	}
+}
+
+func (i *interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		if err := next(ctx, conn); err != nil {
+			return i.cfg.toConnect(ctx, err)
+		}
+
+		return nil
+	}
+}
+
+// clientConn converts errors surfaced while receiving from a stream.
+type clientConn struct {
+	connect.StreamingClientConn
+	cfg *config
+}
+
+func (c *clientConn) Receive(msg any) error {
+	err := c.StreamingClientConn.Receive(msg)
+	if err == nil || errors.Is(err, io.EOF) {
+		return err
+	}
+
+	return c.cfg.fromConnect(err)
+}
+
+func (c *clientConn) CloseResponse() error {
+	if err := c.StreamingClientConn.CloseResponse(); err != nil {
+		return c.cfg.fromConnect(err)
+	}
+
+	return nil
+}
+
+// ToConnectError converts err into a *connect.Error. Trace and span IDs are
+// taken from the error, or from the span in ctx when the error has none.
+// Returns nil if err is nil. An err that already is a *connect.Error is
+// returned unchanged; one wrapping a *connect.Error inherits its code.
+func ToConnectError(ctx context.Context, err error, opts ...Option) *connect.Error {
+	if err == nil {
+		return nil
+	}
+
+	return newConfig(opts).toConnect(ctx, err)
+}
+
+// FromConnectError converts a *connect.Error found in err's chain into an ae
+// error carrying the code, trace/span IDs and attributes sent by the peer. The
+// connect error is kept as the cause. Errors without a *connect.Error in
+// their chain are returned unchanged; nil returns nil.
+func FromConnectError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return newConfig(nil).fromConnect(err)
+}
+
+func (c *config) toConnect(ctx context.Context, err error) *connect.Error {
+	//goland:noinspection GoTypeAssertionOnErrors
+	if cErr, ok := err.(*connect.Error); ok {
+		return cErr
+	}
+
+	msg := ae.UserMessageFor(err, c.locales...)
+	if msg == "" {
+		msg = c.fallbackMsg
+	}
+
+	out := connect.NewError(c.connectCode(err), errors.New(msg))
+
+	code := ae.Code(err)
+	if code != "" {
+		out.Meta().Set(MetaCode, code)
+	}
+
+	traceId, spanId := ae.TraceId(err), ae.SpanId(err)
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		if traceId == "" && span.HasTraceID() {
+			traceId = span.TraceID().String()
+		}
+		if spanId == "" && span.HasSpanID() {
+			spanId = span.SpanID().String()
+		}
+	}
+
+	fields := map[string]any{
+		detailMarker: true,
+	}
+	if code != "" {
+		fields[detailCode] = code
+	}
+	if traceId != "" {
+		fields[detailTraceId] = traceId
+	}
+	if spanId != "" {
+		fields[detailSpanId] = spanId
+	}
+
+	if attrs := c.whitelisted(ae.Attributes(err)); len(attrs) > 0 {
+		fields[detailAttributes] = attrs
+	}
+
+	if s, sErr := structpb.NewStruct(fields); sErr == nil {
+		if detail, dErr := connect.NewErrorDetail(s); dErr == nil {
+			out.AddDetail(detail)
+		}
+	}
+
+	return out
+}
+
+// whitelisted returns the whitelisted attributes, converted into values
+// structpb accepts. Values structpb cannot represent are sent as strings.
+func (c *config) whitelisted(attrs map[string]any) map[string]any {
+	out := make(map[string]any)
+	for k, v := range attrs {
+		if !slices.Contains(c.attributes, k) {
+			continue
+		}
+
+		if _, err := structpb.NewValue(v); err != nil {
+			v = fmt.Sprintf("%v", v)
+		}
+		out[k] = v
+	}
+
+	return out
+}
+
+func (c *config) connectCode(err error) connect.Code {
+	if c.mapper != nil {
+		return c.mapper(err)
+	}
+
+	if code, ok := c.codes[ae.Code(err)]; ok {
+		return code
+	}
+
+	var cErr *connect.Error
+	switch {
+	case errors.As(err, &cErr):
+		return cErr.Code()
+	case errors.Is(err, context.Canceled):
+		return connect.CodeCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return connect.CodeDeadlineExceeded
+	}
+
+	return connect.CodeUnknown
+}
+
+func (c *config) fromConnect(err error) error {
+	var cErr *connect.Error
+	if !errors.As(err, &cErr) {
+		return err
+	}
+
+	b := ae.New().
+		Attr("connect.code", cErr.Code().String()).
+		Cause(err)
+
+	if code := cErr.Meta().Get(MetaCode); code != "" {
+		b = b.Code(code)
+	}
+
+	for _, detail := range cErr.Details() {
+		msg, vErr := detail.Value()
+		if vErr != nil {
+			continue
+		}
+
+		s, ok := msg.(*structpb.Struct)
+		if !ok || !s.GetFields()[detailMarker].GetBoolValue() {
+			continue
+		}
+
+		fields := s.AsMap()
+		if code, ok := fields[detailCode].(string); ok {
+			b = b.Code(code)
+		}
+		if traceId, ok := fields[detailTraceId].(string); ok {
+			b = b.TraceId(traceId)
+		}
+		if spanId, ok := fields[detailSpanId].(string); ok {
+			b = b.SpanId(spanId)
+		}
+		if attrs, ok := fields[detailAttributes].(map[string]any); ok {
+			b = b.Attrs(attrs)
+		}
+	}
+
+	return b.UserMsg("remote call failed", cErr.Message())
+}
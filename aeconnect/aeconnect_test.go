@@ -0,0 +1,200 @@
+package aeconnect_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"go.aledante.io/ae"
+	"go.aledante.io/ae/aeconnect"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const procedure = "/test.v1.TestService/Call"
+
+// startServer serves a single unary procedure whose handler returns
+// handlerErr, with the ae interceptor installed on both ends. It returns a
+// client for the procedure.
+func startServer(t *testing.T, handlerErr error, opts ...aeconnect.Option) *connect.Client[emptypb.Empty, emptypb.Empty] {
+	t.Helper()
+
+	interceptors := connect.WithInterceptors(aeconnect.NewInterceptor(opts...))
+
+	mux := http.NewServeMux()
+	mux.Handle(procedure, connect.NewUnaryHandler(
+		procedure,
+		func(ctx context.Context, _ *connect.Request[emptypb.Empty]) (*connect.Response[emptypb.Empty], error) {
+			return nil, handlerErr
+		},
+		interceptors,
+	))
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return connect.NewClient[emptypb.Empty, emptypb.Empty](
+		srv.Client(),
+		srv.URL+procedure,
+		interceptors,
+	)
+}
+
+func TestInterceptor_RoundTripsCodeAttributesAndUserMessage(t *testing.T) {
+	t.Parallel()
+
+	handlerErr := ae.New().
+		Code("USER_NOT_FOUND").
+		Attr("user_id", 42).
+		Attr("secret", "hunter2").
+		TraceId("trace-1").
+		SpanId("span-1").
+		UserMsg("select from users returned no rows", "User not found.")
+
+	client := startServer(t, handlerErr,
+		aeconnect.WithAttributes("user_id"),
+		aeconnect.WithCode("USER_NOT_FOUND", connect.CodeNotFound),
+	)
+
+	_, err := client.CallUnary(context.Background(), connect.NewRequest(&emptypb.Empty{}))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if got := connect.CodeOf(err); got != connect.CodeNotFound {
+		t.Errorf("connect code = %v, want %v", got, connect.CodeNotFound)
+	}
+	if got := ae.Code(err); got != "USER_NOT_FOUND" {
+		t.Errorf("ae code = %q, want USER_NOT_FOUND", got)
+	}
+	if got := ae.UserMessage(err); got != "User not found." {
+		t.Errorf("user message = %q, want %q", got, "User not found.")
+	}
+	if got := ae.TraceId(err); got != "trace-1" {
+		t.Errorf("trace id = %q, want trace-1", got)
+	}
+	if got := ae.SpanId(err); got != "span-1" {
+		t.Errorf("span id = %q, want span-1", got)
+	}
+
+	attrs := ae.Attributes(err)
+	if got, ok := attrs["user_id"].(float64); !ok || got != 42 {
+		t.Errorf("attrs[user_id] = %v, want 42", attrs["user_id"])
+	}
+	if _, ok := attrs["secret"]; ok {
+		t.Errorf("non-whitelisted attribute leaked to the client: %v", attrs)
+	}
+
+	var cErr *connect.Error
+	if !errors.As(err, &cErr) {
+		t.Error("client error does not keep the *connect.Error as cause")
+	}
+}
+
+func TestInterceptor_NeverSendsInternalMessage(t *testing.T) {
+	t.Parallel()
+
+	client := startServer(t, ae.Msg("pq: connection to 10.0.0.3 refused"))
+
+	_, err := client.CallUnary(context.Background(), connect.NewRequest(&emptypb.Empty{}))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	var cErr *connect.Error
+	if !errors.As(err, &cErr) {
+		t.Fatalf("expected a *connect.Error in the chain, got %T", err)
+	}
+	if cErr.Message() != "internal error" {
+		t.Errorf("message = %q, want the fallback message", cErr.Message())
+	}
+	if connect.CodeOf(err) != connect.CodeUnknown {
+		t.Errorf("code = %v, want unknown", connect.CodeOf(err))
+	}
+}
+
+func TestToConnectError_StampsTraceFromContext(t *testing.T) {
+	t.Parallel()
+
+	traceID, _ := trace.TraceIDFromHex("0123456789abcdef0123456789abcdef")
+	spanID, _ := trace.SpanIDFromHex("0123456789abcdef")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	cErr := aeconnect.ToConnectError(ctx, ae.Msg("boom"))
+	back := aeconnect.FromConnectError(cErr)
+
+	if got := ae.TraceId(back); got != traceID.String() {
+		t.Errorf("trace id = %q, want %q", got, traceID.String())
+	}
+	if got := ae.SpanId(back); got != spanID.String() {
+		t.Errorf("span id = %q, want %q", got, spanID.String())
+	}
+}
+
+func TestToConnectError_RedactsSecretAttributes(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Attr("token", ae.Secret("raw-token")).Msg("boom")
+	back := aeconnect.FromConnectError(aeconnect.ToConnectError(context.Background(), err, aeconnect.WithAttributes("token")))
+
+	if got := ae.Attributes(back)["token"]; got != "[REDACTED]" {
+		t.Errorf("attrs[token] = %v, want [REDACTED]", got)
+	}
+}
+
+func TestToConnectError_WithLocale(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().
+		UserMsgLocale("de", "Bitte später erneut versuchen.").
+		UserMsg("boom", "Please try again later.")
+
+	tests := []struct {
+		opts []aeconnect.Option
+		want string
+	}{
+		{nil, "Please try again later."},
+		{[]aeconnect.Option{aeconnect.WithLocale("de-AT")}, "Bitte später erneut versuchen."},
+		{[]aeconnect.Option{aeconnect.WithLocale("fr")}, "Please try again later."},
+	}
+	for _, tt := range tests {
+		if got := aeconnect.ToConnectError(context.Background(), err, tt.opts...).Message(); got != tt.want {
+			t.Errorf("message = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestToConnectError_MapsContextErrors(t *testing.T) {
+	t.Parallel()
+
+	err := ae.Wrap("calling upstream", context.DeadlineExceeded)
+	if got := aeconnect.ToConnectError(context.Background(), err).Code(); got != connect.CodeDeadlineExceeded {
+		t.Errorf("code = %v, want %v", got, connect.CodeDeadlineExceeded)
+	}
+}
+
+func TestConversions_Nil(t *testing.T) {
+	t.Parallel()
+
+	if got := aeconnect.ToConnectError(context.Background(), nil); got != nil {
+		t.Errorf("ToConnectError(nil) = %v, want nil", got)
+	}
+	if got := aeconnect.FromConnectError(nil); got != nil {
+		t.Errorf("FromConnectError(nil) = %v, want nil", got)
+	}
+}
+
+func TestFromConnectError_PassesThroughForeignErrors(t *testing.T) {
+	t.Parallel()
+
+	plain := errors.New("plain")
+	if got := aeconnect.FromConnectError(plain); got != plain {
+		t.Errorf("FromConnectError(plain) = %v, want the same instance", got)
+	}
+}
@@ -0,0 +1,77 @@
+// Package aefs wraps io/fs helpers so the errors they return carry the
+// failing path (see ae.WrapPath).
+package aefs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"go.aledante.io/ae"
+)
+
+// Option configures WalkDir.
+type Option func(c *config)
+
+type config struct {
+	// continueOnError keeps walking after an error and joins all errors.
+	continueOnError bool
+}
+
+// ContinueOnError makes WalkDir keep walking after an error instead of
+// stopping at the first one. All errors are returned joined under a single
+// ae error whose causes are the individual failures.
+func ContinueOnError() Option {
+	return func(c *config) {
+		c.continueOnError = true
+	}
+}
+
+// WalkDir is fs.WalkDir with every error — those passed to fn and those
+// returned by it — wrapped through ae.WrapPath with the op "walk" and the
+// path being visited. fs.SkipDir and fs.SkipAll keep their meaning and are
+// never wrapped.
+//
+// By default the walk stops at the first error, which is returned. With
+// ContinueOnError the walk visits every reachable entry and returns nil
+// when no error occurred, or a joined ae error with one cause per failure.
+func WalkDir(fsys fs.FS, root string, fn fs.WalkDirFunc, opts ...Option) error {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	var errs []error
+	walkErr := fs.WalkDir(fsys, root, func(path string, d fs.DirEntry, err error) error {
+		err = fn(path, d, err)
+		if err == nil || errors.Is(err, fs.SkipDir) || errors.Is(err, fs.SkipAll) {
+			return err
+		}
+
+		err = ae.WrapPath(err, "walk", path)
+		if !c.continueOnError {
+			return err
+		}
+
+		errs = append(errs, err)
+		return nil
+	})
+
+	if !c.continueOnError {
+		return walkErr
+	}
+
+	return ae.WrapMany(fmt.Sprintf("walking %s", root), errs...)
+}
+
+// ReadFile is fs.ReadFile with errors wrapped through ae.WrapPath.
+func ReadFile(fsys fs.FS, name string) ([]byte, error) {
+	data, err := fs.ReadFile(fsys, name)
+	return data, ae.WrapPath(err, "read", name)
+}
+
+// ReadDir is fs.ReadDir with errors wrapped through ae.WrapPath.
+func ReadDir(fsys fs.FS, name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(fsys, name)
+	return entries, ae.WrapPath(err, "readdir", name)
+}
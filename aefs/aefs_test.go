@@ -0,0 +1,159 @@
+package aefs_test
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"go.aledante.io/ae"
+	"go.aledante.io/ae/aefs"
+)
+
+// failingFS serves files from a MapFS but fails to open the listed names.
+// It deliberately implements only fs.FS so fs.ReadDir goes through Open.
+type failingFS struct {
+	files fstest.MapFS
+	fail  map[string]error
+}
+
+func (f failingFS) Open(name string) (fs.File, error) {
+	if err, ok := f.fail[name]; ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return f.files.Open(name)
+}
+
+func testFS() failingFS {
+	return failingFS{
+		files: fstest.MapFS{
+			"a/ok.txt":       {Data: []byte("ok")},
+			"b/secret/x.txt": {Data: []byte("x")},
+			"c/secret/y.txt": {Data: []byte("y")},
+		},
+		fail: map[string]error{
+			"b/secret": fs.ErrPermission,
+			"c/secret": fs.ErrPermission,
+		},
+	}
+}
+
+// visitAll is a WalkDirFunc that propagates the error it is given.
+func visitAll(_ string, _ fs.DirEntry, err error) error {
+	return err
+}
+
+func TestWalkDir_NoErrors(t *testing.T) {
+	t.Parallel()
+
+	var visited []string
+	err := aefs.WalkDir(fstest.MapFS{"a.txt": {}}, ".", func(path string, d fs.DirEntry, err error) error {
+		visited = append(visited, path)
+		return err
+	}, aefs.ContinueOnError())
+
+	if err != nil {
+		t.Fatalf("WalkDir = %v, want nil", err)
+	}
+	if len(visited) != 2 {
+		t.Errorf("visited = %v, want [. a.txt]", visited)
+	}
+}
+
+func TestWalkDir_MissingRoot(t *testing.T) {
+	t.Parallel()
+
+	err := aefs.WalkDir(fstest.MapFS{}, "missing", visitAll)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("errors.Is(err, fs.ErrNotExist) = false for %v", err)
+	}
+	if got := ae.Attributes(err)["fs.path"]; got != "missing" {
+		t.Errorf("fs.path = %v, want missing", got)
+	}
+}
+
+func TestWalkDir_StopsAtFirstErrorByDefault(t *testing.T) {
+	t.Parallel()
+
+	err := aefs.WalkDir(testFS(), ".", visitAll)
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Fatalf("errors.Is(err, fs.ErrPermission) = false for %v", err)
+	}
+	if got := ae.Attributes(err)["fs.path"]; got != "b/secret" {
+		t.Errorf("fs.path = %v, want b/secret", got)
+	}
+	if got := ae.Attributes(err)["fs.op"]; got != "walk" {
+		t.Errorf("fs.op = %v, want walk", got)
+	}
+}
+
+func TestWalkDir_ContinueOnErrorJoinsFailures(t *testing.T) {
+	t.Parallel()
+
+	var visited []string
+	err := aefs.WalkDir(testFS(), ".", func(path string, d fs.DirEntry, err error) error {
+		if err == nil {
+			visited = append(visited, path)
+		}
+		return err
+	}, aefs.ContinueOnError())
+
+	causes := ae.Causes(err)
+	if len(causes) != 2 {
+		t.Fatalf("got %d causes, want 2: %v", len(causes), err)
+	}
+	for i, want := range []string{"b/secret", "c/secret"} {
+		if got := ae.Attributes(causes[i])["fs.path"]; got != want {
+			t.Errorf("causes[%d] fs.path = %v, want %s", i, got, want)
+		}
+	}
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Error("errors.Is(err, fs.ErrPermission) = false for the joined error")
+	}
+	if len(visited) == 0 || visited[len(visited)-1] != "c/secret" {
+		t.Errorf("visited = %v, want the walk to reach c/secret", visited)
+	}
+}
+
+func TestWalkDir_SkipDirPassesThrough(t *testing.T) {
+	t.Parallel()
+
+	err := aefs.WalkDir(testFS(), ".", func(path string, d fs.DirEntry, err error) error {
+		if path == "b" || path == "c" {
+			return fs.SkipDir
+		}
+		return err
+	})
+	if err != nil {
+		t.Errorf("WalkDir = %v, want nil when skipping the failing directories", err)
+	}
+}
+
+func TestReadFile_WrapsPath(t *testing.T) {
+	t.Parallel()
+
+	data, err := aefs.ReadFile(testFS(), "a/ok.txt")
+	if err != nil || string(data) != "ok" {
+		t.Fatalf("ReadFile = %q, %v", data, err)
+	}
+
+	_, err = aefs.ReadFile(testFS(), "a/missing.txt")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("errors.Is(err, fs.ErrNotExist) = false for %v", err)
+	}
+	if got := ae.Attributes(err)["fs.path"]; got != "a/missing.txt" {
+		t.Errorf("fs.path = %v, want a/missing.txt", got)
+	}
+}
+
+func TestReadDir_WrapsPath(t *testing.T) {
+	t.Parallel()
+
+	_, err := aefs.ReadDir(testFS(), "b/secret")
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("errors.Is(err, fs.ErrPermission) = false for %v", err)
+	}
+	if got := ae.Attributes(err)["fs.op"]; got != "readdir" {
+		t.Errorf("fs.op = %v, want readdir", got)
+	}
+}
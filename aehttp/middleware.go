@@ -0,0 +1,156 @@
+package aehttp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	"go.aledante.io/ae"
+)
+
+// Attributes added to the request context by EnrichContext.
+const (
+	AttrMethod    = "http.method"
+	AttrRoute     = "http.route"
+	AttrRequestId = "http.request_id"
+	AttrPeerIP    = "http.peer_ip"
+)
+
+// RequestIdHeader is the header EnrichContext reads the request ID from.
+const RequestIdHeader = "X-Request-ID"
+
+// maxRequestIdLen bounds the request IDs accepted from clients; longer ones
+// are replaced by a generated ID.
+const maxRequestIdLen = 128
+
+// EnrichOption configures the middleware returned by EnrichContext.
+type EnrichOption func(e *enricher)
+
+// OmitAttrs leaves the given attributes, e.g. AttrPeerIP, out of the request
+// context.
+func OmitAttrs(keys ...string) EnrichOption {
+	return func(e *enricher) {
+		e.omit = append(e.omit, keys...)
+	}
+}
+
+// CaptureHeaders adds the given request headers to the request context as
+// "http.header.<name>" attributes, name in lower case. Headers named in
+// RedactHeaders, and Authorization, Cookie and Proxy-Authorization by
+// default, are wrapped with ae.Secret.
+func CaptureHeaders(names ...string) EnrichOption {
+	return func(e *enricher) {
+		e.headers = append(e.headers, names...)
+	}
+}
+
+// RedactHeaders wraps the values of the given captured headers with
+// ae.Secret, in addition to the default ones.
+func RedactHeaders(names ...string) EnrichOption {
+	return func(e *enricher) {
+		for _, name := range names {
+			e.redact = append(e.redact, http.CanonicalHeaderKey(name))
+		}
+	}
+}
+
+type enricher struct {
+	next    http.Handler
+	omit    []string
+	headers []string
+	redact  []string
+}
+
+// EnrichContext wraps next so that every error built from the request
+// context with ae.NewC (or Builder.Context) carries the request's metadata,
+// independently of how the response is written. The context gets the
+// request ID (see ae.WithRequestIdValue) from the X-Request-ID header, or a
+// generated one, the tag "http" and the attributes:
+//
+//   - http.method: the request method.
+//   - http.route: the pattern of the matched route, when routed by an
+//     http.ServeMux before reaching the middleware.
+//   - http.request_id: the request ID.
+//   - http.peer_ip: the IP address of the client connection. Forwarding
+//     headers are not trusted.
+//
+// The context also carries a builder seeded with them (see ae.WithError), so
+// ae.FromContext starts enriched.
+func EnrichContext(next http.Handler, opts ...EnrichOption) http.Handler {
+	e := &enricher{
+		next:   next,
+		redact: []string{"Authorization", "Cookie", "Proxy-Authorization"},
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// ServeHTTP implements http.Handler.
+func (e *enricher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := requestId(r)
+	attrs := map[string]any{
+		AttrMethod:    r.Method,
+		AttrRequestId: id,
+	}
+	if r.Pattern != "" {
+		attrs[AttrRoute] = r.Pattern
+	}
+	if ip := peerIP(r.RemoteAddr); ip != "" {
+		attrs[AttrPeerIP] = ip
+	}
+
+	for _, name := range e.headers {
+		value := r.Header.Get(name)
+		if value == "" {
+			continue
+		}
+
+		key := "http.header." + strings.ToLower(name)
+		if slices.Contains(e.redact, http.CanonicalHeaderKey(name)) {
+			attrs[key] = ae.Secret(value)
+		} else {
+			attrs[key] = value
+		}
+	}
+
+	for _, key := range e.omit {
+		delete(attrs, key)
+	}
+
+	ctx := ae.WithAttributes(r.Context(), attrs)
+	ctx = ae.WithTagsValue(ctx, "http")
+	ctx = ae.WithRequestIdValue(ctx, id)
+	ctx = ae.WithError(ctx, ae.NewC(ctx))
+
+	e.next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// requestId returns the request ID sent by the client, or a new one.
+func requestId(r *http.Request) string {
+	if id := r.Header.Get(RequestIdHeader); id != "" && len(id) <= maxRequestIdLen {
+		return id
+	}
+
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// peerIP returns the IP address of remoteAddr, or "" if it has none.
+func peerIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	if net.ParseIP(host) == nil {
+		return ""
+	}
+
+	return host
+}
@@ -0,0 +1,127 @@
+package aehttp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"go.aledante.io/ae"
+	"go.aledante.io/ae/aehttp"
+)
+
+func loadUser(ctx context.Context) error  { return queryUser(ctx) }
+func queryUser(ctx context.Context) error { return dialDB(ctx) }
+func dialDB(ctx context.Context) error    { return ae.NewC(ctx).Msg("connection refused") }
+
+// serve sends req through EnrichContext and returns the error built three
+// calls below the handler.
+func serve(t *testing.T, req *http.Request, opts ...aehttp.EnrichOption) error {
+	t.Helper()
+
+	var err error
+	mux := http.NewServeMux()
+	mux.Handle("GET /users/{id}", aehttp.EnrichContext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = loadUser(r.Context())
+	}), opts...))
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	if err == nil {
+		t.Fatal("handler not called")
+	}
+	return err
+}
+
+func TestEnrichContext_DeepErrorCarriesRequestAttrs(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	req.RemoteAddr = "203.0.113.7:51234"
+
+	err := serve(t, req)
+
+	attrs := ae.Attributes(err)
+	want := map[string]any{
+		"http.method":     "GET",
+		"http.route":      "GET /users/{id}",
+		"http.request_id": "req-123",
+		"http.peer_ip":    "203.0.113.7",
+	}
+	for k, v := range want {
+		if attrs[k] != v {
+			t.Errorf("%s = %v, want %v", k, attrs[k], v)
+		}
+	}
+	if got := ae.RequestId(err); got != "req-123" {
+		t.Errorf("RequestId = %q, want req-123", got)
+	}
+	if !slices.Contains(ae.Tags(err), "http") {
+		t.Errorf("tags = %v, want http", ae.Tags(err))
+	}
+}
+
+func TestEnrichContext_SeedsFromContext(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+
+	var err error
+	handler := aehttp.EnrichContext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = ae.FromContext(r.Context()).Code("USER_LOOKUP").Msg("lookup failed")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := ae.Attributes(err)["http.request_id"]; got != "req-123" {
+		t.Errorf("http.request_id = %v, want req-123", got)
+	}
+	if ae.RequestId(err) != "req-123" || !slices.Contains(ae.Tags(err), "http") || ae.Code(err) != "USER_LOOKUP" {
+		t.Errorf("error not enriched: request ID %q, tags %v, code %q", ae.RequestId(err), ae.Tags(err), ae.Code(err))
+	}
+}
+
+func TestEnrichContext_GeneratesRequestId(t *testing.T) {
+	t.Parallel()
+
+	first := ae.RequestId(serve(t, httptest.NewRequest(http.MethodGet, "/users/1", nil)))
+	second := ae.RequestId(serve(t, httptest.NewRequest(http.MethodGet, "/users/1", nil)))
+
+	if len(first) != 16 {
+		t.Errorf("generated request ID = %q, want 16 hex digits", first)
+	}
+	if first == second {
+		t.Errorf("two requests share the generated ID %v", first)
+	}
+}
+
+func TestEnrichContext_Options(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("X-Tenant", "acme")
+	req.Header.Set("X-Api-Key", "k3y")
+
+	err := serve(t, req,
+		aehttp.OmitAttrs(aehttp.AttrPeerIP),
+		aehttp.CaptureHeaders("Authorization", "X-Tenant", "X-Api-Key", "X-Missing"),
+		aehttp.RedactHeaders("x-api-key"))
+
+	attrs := ae.Attributes(err)
+	if _, ok := attrs["http.peer_ip"]; ok {
+		t.Error("http.peer_ip captured despite OmitAttrs")
+	}
+	if attrs["http.header.x-tenant"] != "acme" {
+		t.Errorf("x-tenant = %v", attrs["http.header.x-tenant"])
+	}
+	for _, key := range []string{"http.header.authorization", "http.header.x-api-key"} {
+		if v := attrs[key]; v == nil || v == ae.RevealSecret(v) {
+			t.Errorf("%s = %v, want a secret", key, v)
+		}
+	}
+	if _, ok := attrs["http.header.x-missing"]; ok {
+		t.Error("absent header captured")
+	}
+}
@@ -0,0 +1,56 @@
+package aehttp
+
+import (
+	"net/http"
+
+	"go.aledante.io/ae"
+)
+
+// Codes set on errors built from 429 and 503 responses.
+const (
+	CodeRateLimited         = "RATE_LIMITED"
+	CodeUpstreamUnavailable = "UPSTREAM_UNAVAILABLE"
+)
+
+// ErrorFromResponse builds an error describing a response with a non-2xx
+// status, carrying the http.status attribute. 429 and 503 responses are
+// marked recoverable, get the code CodeRateLimited or CodeUpstreamUnavailable
+// and, when the headers advertise one (see ae.RetryAfterFromHeader), the
+// retry delay in the retry_after attribute. Malformed headers are ignored.
+//
+// The response body is neither read nor closed. Returns nil if resp is nil or
+// has a 2xx status.
+func ErrorFromResponse(resp *http.Response) error {
+	if resp == nil || (resp.StatusCode >= 200 && resp.StatusCode < 300) {
+		return nil
+	}
+
+	req := resp.Request
+	if req == nil || req.URL == nil {
+		return withStatus(ae.New().Tag("http-client"), resp).Msg(resp.Status)
+	}
+
+	t := &transport{}
+	return withStatus(t.builder(req), resp).
+		Msgf("%s %s: %s", req.Method, t.url(req.URL), resp.Status)
+}
+
+// withStatus adds the status derived metadata of resp to b.
+func withStatus(b ae.Builder, resp *http.Response) ae.Builder {
+	b = b.Attr("http.status", resp.StatusCode)
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		b = b.Code(CodeRateLimited)
+	case http.StatusServiceUnavailable:
+		b = b.Code(CodeUpstreamUnavailable)
+	default:
+		return b
+	}
+
+	if d, ok := ae.RetryAfterFromHeader(resp.Header); ok {
+		b = b.Attr("retry_after", d)
+	}
+
+	return b.Recoverable(true)
+}
@@ -0,0 +1,87 @@
+package aehttp_test
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"go.aledante.io/ae"
+	"go.aledante.io/ae/aehttp"
+)
+
+func response(status int, header http.Header) *http.Response {
+	return &http.Response{
+		Status:     http.StatusText(status),
+		StatusCode: status,
+		Header:     header,
+		Request: &http.Request{
+			Method: http.MethodPost,
+			URL:    &url.URL{Scheme: "https", User: url.UserPassword("u", "p"), Host: "api.example.com", Path: "/v1/jobs", RawQuery: "key=secret"},
+		},
+	}
+}
+
+func TestErrorFromResponse_RateLimited(t *testing.T) {
+	t.Parallel()
+
+	err := aehttp.ErrorFromResponse(response(http.StatusTooManyRequests, http.Header{"Retry-After": {"3"}}))
+
+	if got := ae.Code(err); got != aehttp.CodeRateLimited {
+		t.Errorf("code = %q, want %q", got, aehttp.CodeRateLimited)
+	}
+	if !ae.IsRecoverable(err) {
+		t.Error("IsRecoverable = false, want true")
+	}
+
+	attrs := ae.Attributes(err)
+	if attrs["retry_after"] != 3*time.Second {
+		t.Errorf("retry_after = %v, want 3s", attrs["retry_after"])
+	}
+	if attrs["http.status"] != http.StatusTooManyRequests {
+		t.Errorf("http.status = %v, want 429", attrs["http.status"])
+	}
+	if attrs["http.url"] != "https://api.example.com/v1/jobs" {
+		t.Errorf("http.url = %v, want user info and query stripped", attrs["http.url"])
+	}
+}
+
+func TestErrorFromResponse_UnavailableWithMalformedHeader(t *testing.T) {
+	t.Parallel()
+
+	err := aehttp.ErrorFromResponse(response(http.StatusServiceUnavailable, http.Header{"Retry-After": {"later"}}))
+
+	if got := ae.Code(err); got != aehttp.CodeUpstreamUnavailable {
+		t.Errorf("code = %q, want %q", got, aehttp.CodeUpstreamUnavailable)
+	}
+	if _, ok := ae.Attributes(err)["retry_after"]; ok {
+		t.Error("retry_after set from a malformed header")
+	}
+	if !ae.IsRecoverable(err) {
+		t.Error("IsRecoverable = false, want true")
+	}
+}
+
+func TestErrorFromResponse_OtherStatuses(t *testing.T) {
+	t.Parallel()
+
+	if err := aehttp.ErrorFromResponse(response(http.StatusNoContent, nil)); err != nil {
+		t.Errorf("ErrorFromResponse(204) = %v, want nil", err)
+	}
+	if err := aehttp.ErrorFromResponse(nil); err != nil {
+		t.Errorf("ErrorFromResponse(nil) = %v, want nil", err)
+	}
+
+	err := aehttp.ErrorFromResponse(&http.Response{Status: "404 Not Found", StatusCode: http.StatusNotFound})
+	var aeErr *ae.Ae
+	if !errors.As(err, &aeErr) {
+		t.Fatalf("ErrorFromResponse(404) = %T, want *ae.Ae", err)
+	}
+	if ae.Code(err) != "" {
+		t.Errorf("code = %q, want none", ae.Code(err))
+	}
+	if got := err.Error(); got != "404 Not Found" {
+		t.Errorf("Error() = %q", got)
+	}
+}
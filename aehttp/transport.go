@@ -0,0 +1,115 @@
+// Package aehttp integrates ae errors with net/http.
+package aehttp
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.aledante.io/ae"
+)
+
+// TransportOption configures the RoundTripper returned by NewTransport.
+type TransportOption func(t *transport)
+
+// KeepQuery keeps the query string in the http.url attribute. By default it
+// is stripped since it frequently carries tokens or personal data.
+func KeepQuery() TransportOption {
+	return func(t *transport) {
+		t.keepQuery = true
+	}
+}
+
+// ErrorOnStatus converts responses with a non-2xx status into errors. The
+// error carries the http.status attribute, up to maxBody bytes of the response
+// body in http.body, and for 429 and 503 responses the same code, retry_after
+// and recoverable metadata as ErrorFromResponse. A non-positive maxBody defaults to 512 bytes.
+func ErrorOnStatus(maxBody int) TransportOption {
+	if maxBody <= 0 {
+		maxBody = 512
+	}
+
+	return func(t *transport) {
+		t.errorOnStatus = true
+		t.maxBody = maxBody
+	}
+}
+
+type transport struct {
+	base          http.RoundTripper
+	keepQuery     bool
+	errorOnStatus bool
+	maxBody       int
+}
+
+// NewTransport wraps base so transport-level failures become ae errors
+// tagged "http-client" with the attributes http.method, http.url and
+// http.duration (the duration of the failed attempt). User info is always
+// removed from http.url. If base is nil, http.DefaultTransport is used.
+//
+// Successful responses are returned untouched. Responses with a non-2xx
+// status are only converted into errors when ErrorOnStatus is given.
+func NewTransport(base http.RoundTripper, opts ...TransportOption) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	t := &transport{base: base}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	duration := time.Since(start)
+
+	if err != nil {
+		return nil, t.builder(req).Attr("http.duration", duration).
+			Cause(err).
+			Msgf("%s %s", req.Method, t.url(req.URL))
+	}
+
+	if !t.errorOnStatus || (resp.StatusCode >= 200 && resp.StatusCode < 300) {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+
+	b := withStatus(t.builder(req).Attr("http.duration", duration), resp)
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, int64(t.maxBody)))
+	if snippet := strings.TrimSpace(string(body)); snippet != "" {
+		b = b.Attr("http.body", snippet)
+	}
+
+	return nil, b.Msgf("%s %s: %s", req.Method, t.url(req.URL), resp.Status)
+}
+
+func (t *transport) builder(req *http.Request) ae.Builder {
+	return ae.NewC(req.Context()).
+		Tag("http-client").
+		Attr("http.method", req.Method).
+		Attr("http.url", t.url(req.URL))
+}
+
+// url renders u without user info and, unless keepQuery is set, without the
+// query string and fragment.
+func (t *transport) url(u *url.URL) string {
+	cpy := *u
+	cpy.User = nil
+	if !t.keepQuery {
+		cpy.RawQuery = ""
+		cpy.ForceQuery = false
+		cpy.Fragment = ""
+		cpy.RawFragment = ""
+	}
+
+	return cpy.String()
+}
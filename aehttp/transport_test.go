@@ -0,0 +1,139 @@
+package aehttp_test
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go.aledante.io/ae"
+	"go.aledante.io/ae/aehttp"
+)
+
+func TestTransport_SuccessResponseUntouched(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Test", "yes")
+		_, _ = io.WriteString(w, "hello")
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: aehttp.NewTransport(nil, aehttp.ErrorOnStatus(0))}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "hello" || resp.Header.Get("X-Test") != "yes" {
+		t.Errorf("response altered: status %d body %q header %q", resp.StatusCode, body, resp.Header.Get("X-Test"))
+	}
+}
+
+func TestTransport_StatusErrorIsOptIn(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: aehttp.NewTransport(nil)}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get without ErrorOnStatus returned %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", resp.StatusCode)
+	}
+}
+
+func TestTransport_StatusErrorWithRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = io.WriteString(w, strings.Repeat("x", 100))
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: aehttp.NewTransport(nil, aehttp.ErrorOnStatus(10))}
+	_, err := client.Get(srv.URL + "/users?token=secret")
+	if err == nil {
+		t.Fatal("expected an error for a 503 response")
+	}
+
+	attrs := ae.Attributes(aeError(t, err))
+	if attrs["http.status"] != http.StatusServiceUnavailable {
+		t.Errorf("http.status = %v, want 503", attrs["http.status"])
+	}
+	if attrs["http.body"] != strings.Repeat("x", 10) {
+		t.Errorf("http.body = %q, want 10 bytes", attrs["http.body"])
+	}
+	if attrs["retry_after"] != 120*time.Second {
+		t.Errorf("retry_after = %v, want 2m0s", attrs["retry_after"])
+	}
+	if attrs["http.url"] != srv.URL+"/users" {
+		t.Errorf("http.url = %v, want the query stripped", attrs["http.url"])
+	}
+	if attrs["http.method"] != http.MethodGet {
+		t.Errorf("http.method = %v, want GET", attrs["http.method"])
+	}
+}
+
+func TestTransport_ClosedPort(t *testing.T) {
+	t.Parallel()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	_ = l.Close()
+
+	client := &http.Client{Transport: aehttp.NewTransport(nil, aehttp.KeepQuery())}
+	_, err = client.Get("http://" + addr + "/health?verbose=1")
+	if err == nil {
+		t.Fatal("expected an error for a closed port")
+	}
+
+	aeErr := aeError(t, err)
+	if !slices.Contains(ae.Tags(aeErr), "http-client") {
+		t.Errorf("tags = %v, want http-client", ae.Tags(aeErr))
+	}
+
+	attrs := ae.Attributes(aeErr)
+	if attrs["http.url"] != "http://"+addr+"/health?verbose=1" {
+		t.Errorf("http.url = %v, want the query kept", attrs["http.url"])
+	}
+	if _, ok := attrs["http.duration"].(time.Duration); !ok {
+		t.Errorf("http.duration = %v, want a time.Duration", attrs["http.duration"])
+	}
+
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		t.Error("the transport error is not reachable through errors.As")
+	}
+}
+
+// aeError extracts the *ae.Ae the transport returned from the *url.Error
+// http.Client wraps it in.
+func aeError(t *testing.T, err error) *ae.Ae {
+	t.Helper()
+
+	var aeErr *ae.Ae
+	if !errors.As(err, &aeErr) {
+		t.Fatalf("no *ae.Ae in %T: %v", err, err)
+	}
+	return aeErr
+}
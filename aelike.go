@@ -0,0 +1,53 @@
+package ae
+
+// AeLike aggregates the ErrorXxx interfaces From reads. Errors implementing
+// all of them, *Ae among them, are handled with a single type assertion
+// instead of one per interface.
+type AeLike interface {
+	error
+	ErrorMessage
+	ErrorUserMessage
+	ErrorUserMessages
+	ErrorHint
+	ErrorTimestamp
+	ErrorCode
+	ErrorExitCode
+	ErrorTraceId
+	ErrorSpanId
+	ErrorRequestId
+	ErrorTags
+	ErrorAttributes
+	ErrorCauses
+	ErrorRelated
+	ErrorStacks
+	ErrorFingerprint
+}
+
+var (
+	_ AeLike = Ae{}
+	_ AeLike = (*Ae)(nil)
+)
+
+// fromAeLike copies the metadata of x into a new Builder, as From does for
+// errors implementing the interfaces one by one.
+func fromAeLike(x AeLike) Builder {
+	b := New()
+	b.msg = x.ErrorMessage()
+	b.userMsg = x.ErrorUserMessage()
+	b.userMsgs = x.ErrorUserMessages()
+	b.traceId = x.ErrorTraceId()
+	b.spanId = x.ErrorSpanId()
+	b.requestId = x.ErrorRequestId()
+	b = b.Tags(x.ErrorTags()...)
+	b.code = x.ErrorCode()
+	b.attributes = x.ErrorAttributes()
+	b.exitCode = x.ErrorExitCode()
+	b.hint = x.ErrorHint()
+	b.fingerprint = x.ErrorFingerprint()
+	b.related = x.ErrorRelated()
+	b.causes = x.ErrorCauses()
+	b.timestamp = x.ErrorTimestamp()
+	b.stacks = x.ErrorStacks()
+
+	return b
+}
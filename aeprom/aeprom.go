@@ -0,0 +1,149 @@
+// Package aeprom counts ae errors with a Prometheus CounterVec.
+package aeprom
+
+import (
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.aledante.io/ae"
+)
+
+// Label names supported by Counter.
+const (
+	// LabelCode is the error code, "unknown" when the error has none.
+	LabelCode = "code"
+	// LabelTag is the first tag in sorted order, "none" when the error has none.
+	LabelTag = "tag"
+	// LabelRecoverable is "true" or "false", see ae.IsRecoverable.
+	LabelRecoverable = "recoverable"
+)
+
+// Values substituted for missing or collapsed label values.
+const (
+	unknownCode = "unknown"
+	otherCode   = "other"
+	noTag       = "none"
+)
+
+// Opts configures a Counter.
+type Opts struct {
+	// Namespace, Subsystem and Name build the metric name.
+	// Name defaults to "errors_total".
+	Namespace string
+	Subsystem string
+	Name      string
+	// Help defaults to a generic description.
+	Help string
+	// Labels is the allowlist of labels to use, any of LabelCode, LabelTag and
+	// LabelRecoverable. Defaults to all of them.
+	Labels []string
+	// MaxCodes caps the number of distinct code label values. Once reached,
+	// further codes are counted as "other". Zero means unlimited.
+	MaxCodes int
+}
+
+// Counter counts errors by code, tag and recoverability.
+// It is safe for concurrent use.
+type Counter struct {
+	vec      *prometheus.CounterVec
+	labels   []string
+	maxCodes int
+
+	mu    sync.RWMutex
+	codes map[string]struct{}
+}
+
+// NewCounter creates a Counter and registers it with reg. Panics if
+// registration fails, like prometheus.MustRegister, or if opts.Labels
+// contains an unsupported label.
+func NewCounter(reg prometheus.Registerer, opts Opts) *Counter {
+	labels := opts.Labels
+	if len(labels) == 0 {
+		labels = []string{LabelCode, LabelTag, LabelRecoverable}
+	}
+	for _, l := range labels {
+		if l != LabelCode && l != LabelTag && l != LabelRecoverable {
+			panic("aeprom: unsupported label " + strconv.Quote(l))
+		}
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = "errors_total"
+	}
+	help := opts.Help
+	if help == "" {
+		help = "Number of errors observed, by code, tag and recoverability."
+	}
+
+	c := &Counter{
+		vec: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Subsystem: opts.Subsystem,
+			Name:      name,
+			Help:      help,
+		}, labels),
+		labels:   labels,
+		maxCodes: opts.MaxCodes,
+		codes:    make(map[string]struct{}),
+	}
+	reg.MustRegister(c.vec)
+
+	return c
+}
+
+// Observe increments the counter for err. Does nothing if err is nil.
+func (c *Counter) Observe(err error) {
+	if err == nil {
+		return
+	}
+
+	values := make([]string, len(c.labels))
+	for i, l := range c.labels {
+		switch l {
+		case LabelCode:
+			values[i] = c.code(ae.Code(err))
+		case LabelTag:
+			values[i] = noTag
+			if tags := ae.Tags(err); len(tags) > 0 {
+				values[i] = slices.Min(tags)
+			}
+		case LabelRecoverable:
+			values[i] = strconv.FormatBool(ae.IsRecoverable(err))
+		}
+	}
+
+	c.vec.WithLabelValues(values...).Inc()
+}
+
+// code returns the label value for code, applying the cardinality cap.
+func (c *Counter) code(code string) string {
+	if code == "" {
+		return unknownCode
+	}
+	if c.maxCodes <= 0 {
+		return code
+	}
+
+	c.mu.RLock()
+	_, seen := c.codes[code]
+	c.mu.RUnlock()
+	if seen {
+		return code
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, seen := c.codes[code]; seen {
+		return code
+	}
+	if len(c.codes) >= c.maxCodes {
+		return otherCode
+	}
+	c.codes[code] = struct{}{}
+
+	return code
+}
@@ -0,0 +1,152 @@
+package aeprom_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.aledante.io/ae"
+	"go.aledante.io/ae/aeprom"
+)
+
+func TestCounter_LabelsErrors(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewPedanticRegistry()
+	c := aeprom.NewCounter(reg, aeprom.Opts{})
+
+	c.Observe(ae.New().Code("DB_TIMEOUT").Tags("net", "db").Msg("x"))
+	c.Observe(ae.New().Code("DB_TIMEOUT").Tags("db", "net").Msg("y"))
+	c.Observe(ae.New().Fatal().Msg("z"))
+	c.Observe(errors.New("plain"))
+
+	// Gathered label pairs are sorted by name: code, recoverable, tag.
+	vec := counterVec(t, reg)
+	cases := []struct {
+		labels []string
+		want   float64
+	}{
+		{[]string{"DB_TIMEOUT", "true", "db"}, 2},
+		{[]string{"unknown", "false", "none"}, 1},
+		{[]string{"unknown", "true", "none"}, 1},
+	}
+	for _, tc := range cases {
+		if got := vec[fmt.Sprint(tc.labels)]; got != tc.want {
+			t.Errorf("count%v = %v, want %v", tc.labels, got, tc.want)
+		}
+	}
+}
+
+func TestCounter_NilIsNoop(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewPedanticRegistry()
+	c := aeprom.NewCounter(reg, aeprom.Opts{})
+	c.Observe(nil)
+
+	if n, err := testutil.GatherAndCount(reg); err != nil || n != 0 {
+		t.Errorf("got %d series (err %v) after observing nil, want 0", n, err)
+	}
+}
+
+func TestCounter_LabelAllowlist(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewPedanticRegistry()
+	c := aeprom.NewCounter(reg, aeprom.Opts{Labels: []string{aeprom.LabelCode}})
+
+	c.Observe(ae.New().Code("A").Tag("t").Msg("x"))
+
+	if got := counterVec(t, reg)["[A]"]; got != 1 {
+		t.Errorf("count[A] = %v, want 1", got)
+	}
+}
+
+func TestCounter_CardinalityCapCollapsesIntoOther(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewPedanticRegistry()
+	c := aeprom.NewCounter(reg, aeprom.Opts{
+		Labels:   []string{aeprom.LabelCode},
+		MaxCodes: 2,
+	})
+
+	for _, code := range []string{"A", "B", "C", "D", "A"} {
+		c.Observe(ae.New().Code(code).Msg("x"))
+	}
+
+	vec := counterVec(t, reg)
+	want := map[string]float64{"[A]": 2, "[B]": 1, "[other]": 2}
+	if len(vec) != len(want) {
+		t.Errorf("series = %v, want %v", vec, want)
+	}
+	for k, v := range want {
+		if vec[k] != v {
+			t.Errorf("count%s = %v, want %v", k, vec[k], v)
+		}
+	}
+}
+
+func TestCounter_ConcurrentObserve(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewPedanticRegistry()
+	c := aeprom.NewCounter(reg, aeprom.Opts{MaxCodes: 5})
+
+	var wg sync.WaitGroup
+	for i := range 32 {
+		wg.Go(func() {
+			for j := range 100 {
+				c.Observe(ae.New().Code(fmt.Sprintf("C%d", (i+j)%10)).Msg("x"))
+			}
+		})
+	}
+	wg.Wait()
+
+	total := 0.0
+	for _, v := range counterVec(t, reg) {
+		total += v
+	}
+	if total != 3200 {
+		t.Errorf("total = %v, want 3200", total)
+	}
+}
+
+func TestNewCounter_PanicsOnUnsupportedLabel(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("NewCounter did not panic on an unsupported label")
+		}
+	}()
+	aeprom.NewCounter(prometheus.NewPedanticRegistry(), aeprom.Opts{Labels: []string{"kind"}})
+}
+
+// counterVec gathers the single counter family from reg and returns its
+// values keyed by fmt.Sprint of the label values, sorted by label name.
+func counterVec(t *testing.T, reg *prometheus.Registry) map[string]float64 {
+	t.Helper()
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	if len(families) != 1 {
+		t.Fatalf("got %d metric families, want 1", len(families))
+	}
+
+	out := make(map[string]float64)
+	for _, m := range families[0].GetMetric() {
+		var values []string
+		for _, lp := range m.GetLabel() {
+			values = append(values, lp.GetValue())
+		}
+		out[fmt.Sprint(values)] = m.GetCounter().GetValue()
+	}
+
+	return out
+}
@@ -0,0 +1,140 @@
+// Package aetest provides test assertions for errors built with ae.
+//
+// Assertions look at the error and every error in its cause tree (see
+// ae.Causes), so a test can check for a code or tag without knowing how many
+// times the error was wrapped. On failure they report the full verbose
+// rendering of the error, so the test output shows what was actually
+// returned. Golden compares the rendering of an error with a golden file.
+package aetest
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+// AssertCode reports a test failure unless err or an error in its cause tree
+// has the given code.
+func AssertCode(t testing.TB, err error, code string) bool {
+	t.Helper()
+
+	if !anyInTree(err, func(e error) bool { return ae.Code(e) == code }) {
+		return fail(t, err, "want an error with code %s", code)
+	}
+
+	return true
+}
+
+// AssertTag reports a test failure unless err or an error in its cause tree
+// is tagged with tag.
+func AssertTag(t testing.TB, err error, tag string) bool {
+	t.Helper()
+
+	if !anyInTree(err, func(e error) bool { return slices.Contains(ae.Tags(e), tag) }) {
+		return fail(t, err, "want an error tagged %q", tag)
+	}
+
+	return true
+}
+
+// AssertAttr reports a test failure unless err or an error in its cause tree
+// has the attribute key set to a value equal to want according to
+// reflect.DeepEqual.
+func AssertAttr(t testing.TB, err error, key string, want any) bool {
+	t.Helper()
+
+	match := func(e error) bool {
+		got, ok := ae.Attributes(e)[key]
+		return ok && reflect.DeepEqual(got, want)
+	}
+	if !anyInTree(err, match) {
+		return fail(t, err, "want an error with attribute %s = %v", key, want)
+	}
+
+	return true
+}
+
+// AssertUserMessage reports a test failure unless err or an error in its
+// cause tree has the given user message.
+func AssertUserMessage(t testing.TB, err error, msg string) bool {
+	t.Helper()
+
+	if !anyInTree(err, func(e error) bool { return ae.UserMessage(e) == msg }) {
+		return fail(t, err, "want an error with user message %q", msg)
+	}
+
+	return true
+}
+
+// AssertCauseCount reports a test failure unless err has exactly n direct
+// causes.
+func AssertCauseCount(t testing.TB, err error, n int) bool {
+	t.Helper()
+
+	if got := len(ae.Causes(err)); got != n {
+		return fail(t, err, "want %d causes, got %d", n, got)
+	}
+
+	return true
+}
+
+// AssertMatches reports a test failure unless match returns true for err or
+// an error in its cause tree.
+func AssertMatches(t testing.TB, err error, match func(err error) bool) bool {
+	t.Helper()
+
+	if !anyInTree(err, match) {
+		return fail(t, err, "want an error matching the predicate")
+	}
+
+	return true
+}
+
+// RequireIs stops the test unless errors.Is(err, target).
+func RequireIs(t testing.TB, err, target error) {
+	t.Helper()
+
+	if !errors.Is(err, target) {
+		t.Fatalf("want an error matching %q\n%s", target, render(err))
+	}
+}
+
+// anyInTree reports whether match returns true for err or any error in its
+// cause tree, searched depth-first.
+func anyInTree(err error, match func(err error) bool) bool {
+	if err == nil {
+		return false
+	}
+	if match(err) {
+		return true
+	}
+
+	for _, cause := range ae.Causes(err) {
+		if anyInTree(cause, match) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fail reports a failure with the rendering of err below the message and
+// returns false.
+func fail(t testing.TB, err error, format string, args ...any) bool {
+	t.Helper()
+
+	t.Errorf(format+"\n%s", append(args, render(err))...)
+	return false
+}
+
+// render returns the verbose, uncolored rendering of err.
+func render(err error) string {
+	if err == nil {
+		return "got: <nil>"
+	}
+
+	return "got: " + ae.NewPrinter(ae.PrintVerbose(), ae.NoPrintColors()).Prints(err)
+}
@@ -0,0 +1,136 @@
+package aetest_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.aledante.io/ae"
+	"go.aledante.io/ae/aetest"
+)
+
+// mockTB records failures instead of failing the enclosing test. Embedding
+// testing.TB satisfies its unexported method; only the methods the helpers
+// use are overridden.
+type mockTB struct {
+	testing.TB
+	helper bool
+	errors []string
+	fatal  bool
+}
+
+func (m *mockTB) Helper() { m.helper = true }
+
+func (m *mockTB) Errorf(format string, args ...any) {
+	m.errors = append(m.errors, fmt.Sprintf(format, args...))
+}
+
+func (m *mockTB) Fatalf(format string, args ...any) {
+	m.Errorf(format, args...)
+	m.fatal = true
+}
+
+var errDial = errors.New("dial tcp: timeout")
+
+func sample() error {
+	inner := ae.New().
+		Code("DB_TIMEOUT").
+		Tag("retry").
+		Attr("attempts", 3).
+		Cause(errDial).
+		UserMsg("timed out", "The database is slow, try again.")
+
+	return ae.New().Code("QUERY_FAILED").Cause(inner, errors.New("rollback failed")).Msg("query failed")
+}
+
+func TestAssertions(t *testing.T) {
+	t.Parallel()
+
+	err := sample()
+	tests := []struct {
+		name   string
+		assert func(t testing.TB) bool
+		pass   bool
+		want   string
+	}{
+		{"code top", func(t testing.TB) bool { return aetest.AssertCode(t, err, "QUERY_FAILED") }, true, ""},
+		{"code nested", func(t testing.TB) bool { return aetest.AssertCode(t, err, "DB_TIMEOUT") }, true, ""},
+		{"code missing", func(t testing.TB) bool { return aetest.AssertCode(t, err, "DB_CONN") }, false, "want an error with code DB_CONN"},
+		{"tag", func(t testing.TB) bool { return aetest.AssertTag(t, err, "retry") }, true, ""},
+		{"tag missing", func(t testing.TB) bool { return aetest.AssertTag(t, err, "fatal") }, false, `want an error tagged "fatal"`},
+		{"attr", func(t testing.TB) bool { return aetest.AssertAttr(t, err, "attempts", 3) }, true, ""},
+		{"attr value", func(t testing.TB) bool { return aetest.AssertAttr(t, err, "attempts", 4) }, false, "want an error with attribute attempts = 4"},
+		{"user message", func(t testing.TB) bool {
+			return aetest.AssertUserMessage(t, err, "The database is slow, try again.")
+		}, true, ""},
+		{"user message missing", func(t testing.TB) bool { return aetest.AssertUserMessage(t, err, "Oops.") }, false, `want an error with user message "Oops."`},
+		{"cause count", func(t testing.TB) bool { return aetest.AssertCauseCount(t, err, 2) }, true, ""},
+		{"cause count mismatch", func(t testing.TB) bool { return aetest.AssertCauseCount(t, err, 1) }, false, "want 1 causes, got 2"},
+		{"matches", func(t testing.TB) bool {
+			return aetest.AssertMatches(t, err, func(e error) bool { return e == errDial })
+		}, true, ""},
+		{"matches none", func(t testing.TB) bool {
+			return aetest.AssertMatches(t, err, func(error) bool { return false })
+		}, false, "want an error matching the predicate"},
+		{"nil error", func(t testing.TB) bool { return aetest.AssertCode(t, nil, "X") }, false, "got: <nil>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m := &mockTB{}
+			if got := tt.assert(m); got != tt.pass {
+				t.Errorf("assertion returned %t, want %t", got, tt.pass)
+			}
+			if !m.helper {
+				t.Error("assertion did not call t.Helper")
+			}
+			if tt.pass {
+				if len(m.errors) > 0 {
+					t.Errorf("unexpected failures: %q", m.errors)
+				}
+				return
+			}
+			if len(m.errors) != 1 || !strings.Contains(m.errors[0], tt.want) {
+				t.Errorf("failures = %q, want one containing %q", m.errors, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssertions_FailureShowsVerboseRendering(t *testing.T) {
+	t.Parallel()
+
+	m := &mockTB{}
+	aetest.AssertCode(m, sample(), "DB_CONN")
+
+	if len(m.errors) != 1 {
+		t.Fatalf("got %d failures, want 1", len(m.errors))
+	}
+	for _, want := range []string{"[ERROR] {QUERY_FAILED} query failed", "DB_TIMEOUT", "rollback failed"} {
+		if !strings.Contains(m.errors[0], want) {
+			t.Errorf("failure output missing %q:\n%s", want, m.errors[0])
+		}
+	}
+}
+
+func TestRequireIs(t *testing.T) {
+	t.Parallel()
+
+	m := &mockTB{}
+	aetest.RequireIs(m, sample(), errDial)
+	if m.fatal || len(m.errors) > 0 {
+		t.Errorf("RequireIs failed on a matching error: %q", m.errors)
+	}
+
+	m = &mockTB{}
+	aetest.RequireIs(m, sample(), errors.New("other"))
+	if !m.fatal {
+		t.Error("RequireIs did not stop the test on a mismatch")
+	}
+	if len(m.errors) != 1 || !strings.Contains(m.errors[0], `want an error matching "other"`) {
+		t.Errorf("failures = %q", m.errors)
+	}
+}
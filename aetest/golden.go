@@ -0,0 +1,188 @@
+package aetest
+
+import (
+	"errors"
+	"flag"
+	"go/build"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+// UpdateEnv is the environment variable that makes Golden rewrite the golden
+// files when set to a true value such as 1, see Golden.
+const UpdateEnv = "AETEST_UPDATE"
+
+// updating reports whether Golden rewrites the golden files: if the test
+// binary defines a boolean -update flag that is set, or UpdateEnv is true.
+// The flag is looked up on every call rather than defined by this package,
+// so test packages can define their own -update flag.
+func updating() bool {
+	if f := flag.Lookup("update"); f != nil {
+		if set, err := strconv.ParseBool(f.Value.String()); err == nil && set {
+			return true
+		}
+	}
+	set, _ := strconv.ParseBool(os.Getenv(UpdateEnv))
+
+	return set
+}
+
+var (
+	scrubbersMu sync.RWMutex
+	scrubbers   []*scrubber
+)
+
+// scrubber is a registered scrubbing function; the pointer identifies it
+// for removal.
+type scrubber struct {
+	fn func(string) string
+}
+
+// Placeholders the default scrubbers of Golden replace run-dependent values
+// with.
+const (
+	TimestampPlaceholder = "<timestamp>"
+	HexIdPlaceholder     = "<hex>"
+	PathPlaceholder      = "<path>"
+)
+
+var (
+	timestampPattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`)
+	goroutinePattern = regexp.MustCompile(`goroutine \d+`)
+	hexIdPattern     = regexp.MustCompile(`\b([0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}|[0-9a-fA-F]{16,})\b`)
+)
+
+// RegisterScrubber adds scrub to the functions Golden rewrites every
+// rendering with, after the default ones, e.g. to redact hostnames. It
+// returns a function that removes scrub again.
+func RegisterScrubber(scrub func(string) string) (remove func()) {
+	s := &scrubber{fn: scrub}
+
+	scrubbersMu.Lock()
+	defer scrubbersMu.Unlock()
+	scrubbers = append(scrubbers, s)
+
+	return func() {
+		scrubbersMu.Lock()
+		defer scrubbersMu.Unlock()
+		for i, registered := range scrubbers {
+			if registered == s {
+				scrubbers = append(scrubbers[:i:i], scrubbers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Golden compares the rendering of err with the golden file at path and
+// reports a test failure with a unified diff if they differ. The file is
+// rewritten instead when the test package defines a boolean -update flag and
+// the test runs with it, or when the environment variable AETEST_UPDATE is
+// set to a true value:
+//
+//	var update = flag.Bool("update", false, "rewrite golden files")
+//
+// aetest doesn't define the flag itself.
+//
+// err is rendered by a printer configured with ae.PrintDeterministic and
+// then opts, so ae.PrintJSON() selects the JSON rendering and other options
+// narrow the printed fields. The rendering is scrubbed of timestamps,
+// goroutine IDs, hex IDs such as trace IDs and UUIDs, and the directories of
+// absolute paths below the working directory, the Go installation, GOPATH or
+// the temporary directory, then by the scrubbers added with
+// RegisterScrubber.
+func Golden(t testing.TB, err error, path string, opts ...ae.PrinterOption) bool {
+	t.Helper()
+
+	opts = append([]ae.PrinterOption{ae.PrintDeterministic()}, opts...)
+	for _, scrub := range goldenScrubbers() {
+		opts = append(opts, ae.PrintScrub(scrub))
+	}
+	got := ae.NewPrinter(opts...).Prints(err) + "\n"
+
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("creating golden file directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("writing golden file: %v", err)
+		}
+		return true
+	}
+
+	data, readErr := os.ReadFile(path)
+	switch {
+	case errors.Is(readErr, fs.ErrNotExist):
+		t.Errorf("golden file %s does not exist; run the test with -update to create it\ngot:\n%s", path, got)
+		return false
+	case readErr != nil:
+		t.Fatalf("reading golden file: %v", readErr)
+	}
+
+	want := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if got != want {
+		t.Errorf("rendering differs from golden file %s; run the test with -update to accept it\n%s",
+			path, unifiedDiff(path, "got", want, got))
+		return false
+	}
+
+	return true
+}
+
+// goldenScrubbers returns the default scrubbers followed by the registered
+// ones.
+func goldenScrubbers() []func(string) string {
+	scrubs := []func(string) string{
+		func(s string) string { return timestampPattern.ReplaceAllString(s, TimestampPlaceholder) },
+		func(s string) string { return goroutinePattern.ReplaceAllString(s, "goroutine 0") },
+		func(s string) string { return hexIdPattern.ReplaceAllString(s, HexIdPlaceholder) },
+	}
+	if paths := absPathPattern(); paths != nil {
+		scrubs = append(scrubs, func(s string) string { return paths.ReplaceAllString(s, PathPlaceholder+"/") })
+	}
+
+	scrubbersMu.RLock()
+	defer scrubbersMu.RUnlock()
+	for _, s := range scrubbers {
+		scrubs = append(scrubs, s.fn)
+	}
+
+	return scrubs
+}
+
+// absPathPattern matches the directories of absolute paths below the roots
+// that vary between machines: the working directory (which
+// ae.PrintDeterministic trims where it can), the Go installation, GOPATH and
+// the temporary directory.
+var absPathPattern = sync.OnceValue(func() *regexp.Regexp {
+	var roots []string
+	if wd, err := os.Getwd(); err == nil {
+		roots = append(roots, wd)
+	}
+	roots = append(roots, build.Default.GOROOT, build.Default.GOPATH, os.TempDir())
+	// the longest root wins where one contains another
+	slices.SortFunc(roots, func(a, b string) int { return len(b) - len(a) })
+
+	var alts []string
+	for _, root := range roots {
+		root = strings.TrimRight(root, `/\`)
+		if root == "" {
+			continue
+		}
+		alts = append(alts, regexp.QuoteMeta(root))
+	}
+	if len(alts) == 0 {
+		return nil
+	}
+
+	return regexp.MustCompile(`(?:` + strings.Join(alts, "|") + `)(?:[/\\][^\s"'/\\:]+)*[/\\]`)
+})
@@ -0,0 +1,155 @@
+package aetest_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.aledante.io/ae"
+	"go.aledante.io/ae/aetest"
+)
+
+// update is defined here as in any package using golden files; aetest
+// reads it without defining it.
+var _ = flag.Bool("update", false, "rewrite golden files")
+
+// setUpdate sets the -update flag for the rest of the test.
+func setUpdate(t *testing.T, update bool) {
+	t.Helper()
+
+	prev := flag.Lookup("update").Value.String()
+	if update {
+		_ = flag.Set("update", "true")
+	} else {
+		_ = flag.Set("update", "false")
+	}
+	t.Cleanup(func() { _ = flag.Set("update", prev) })
+}
+
+func goldenSample(attempts int) error {
+	return ae.New().
+		Code("DB_TIMEOUT").
+		TraceId("4bf92f3577b34da6a3ce929d0e0e4736").
+		Attr("attempts", attempts).
+		Attr("id", "123e4567-e89b-12d3-a456-426614174000").
+		Attr("file", filepath.Join(os.TempDir(), "run-42", "data.db")).
+		Stack().
+		Cause(ae.Msg("at 2024-05-01T12:00:00.123Z: dial tcp: timeout")).
+		Msg("query failed")
+}
+
+func TestGolden_UpdateFlow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "timeout.golden")
+
+	setUpdate(t, true)
+	if !aetest.Golden(t, goldenSample(3), path) {
+		t.Fatal("Golden failed while updating")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("golden file not written: %v", err)
+	}
+	golden := string(data)
+	for _, want := range []string{"{DB_TIMEOUT} query failed", "trace      <hex>", "<hex>", "<path>/data.db", "at <timestamp>: dial tcp", "goroutine 0"} {
+		if !strings.Contains(golden, want) {
+			t.Errorf("golden file missing %q:\n%s", want, golden)
+		}
+	}
+	for _, leak := range []string{"4bf92f35", "123e4567", os.TempDir() + string(filepath.Separator), "2024-05-01"} {
+		if strings.Contains(golden, leak) {
+			t.Errorf("golden file contains run-dependent %q:\n%s", leak, golden)
+		}
+	}
+
+	setUpdate(t, false)
+	if !aetest.Golden(t, goldenSample(3), path) {
+		t.Error("Golden failed on an unchanged error")
+	}
+
+	m := &mockTB{}
+	if aetest.Golden(m, goldenSample(4), path) {
+		t.Error("Golden passed on a changed error")
+	}
+	if len(m.errors) != 1 || !strings.Contains(m.errors[0], "-  attrs      attempts  3\n+  attrs      attempts  4") {
+		t.Errorf("failures = %q, want a diff of the attempts", m.errors)
+	}
+
+	setUpdate(t, true)
+	aetest.Golden(t, goldenSample(4), path)
+	setUpdate(t, false)
+	if !aetest.Golden(t, goldenSample(4), path) {
+		t.Error("Golden failed after updating the changed error")
+	}
+}
+
+func TestGolden_JSON(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "timeout.json.golden")
+	err := ae.New().Code("DB_TIMEOUT").Attr("trace", "4bf92f3577b34da6a3ce929d0e0e4736").Msg("query failed")
+	want := `{
+  "message": "query failed",
+  "code": "DB_TIMEOUT",
+  "exit_code": 1,
+  "attrs": {
+    "trace": "<hex>"
+  }
+}
+`
+	if err := os.WriteFile(path, []byte(want), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &mockTB{}
+	aetest.Golden(m, err, path, ae.PrintJSON(), ae.PrintCompact(), ae.NoPrintUserMessage())
+	if len(m.errors) > 0 {
+		t.Errorf("unexpected failures: %q", m.errors)
+	}
+}
+
+func TestGolden_MissingFile(t *testing.T) {
+	t.Parallel()
+
+	m := &mockTB{}
+	if aetest.Golden(m, goldenSample(1), filepath.Join(t.TempDir(), "missing.golden")) {
+		t.Error("Golden passed without a golden file")
+	}
+	if len(m.errors) != 1 || !strings.Contains(m.errors[0], "run the test with -update to create it") {
+		t.Errorf("failures = %q", m.errors)
+	}
+}
+
+func TestRegisterScrubber(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "host.golden")
+	if err := os.WriteFile(path, []byte("[ERROR] dial <host> failed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	remove := aetest.RegisterScrubber(func(s string) string {
+		return strings.ReplaceAll(s, "db-7.internal", "<host>")
+	})
+	defer remove()
+
+	m := &mockTB{}
+	aetest.Golden(m, ae.Msg("dial db-7.internal failed"), path, ae.PrintCompact())
+	if len(m.errors) > 0 {
+		t.Errorf("unexpected failures: %q", m.errors)
+	}
+}
+
+func TestGolden_UpdateEnv(t *testing.T) {
+	setUpdate(t, false)
+	t.Setenv(aetest.UpdateEnv, "1")
+
+	path := filepath.Join(t.TempDir(), "env.golden")
+	if !aetest.Golden(t, ae.New().Msg("from env"), path) {
+		t.Fatal("Golden with AETEST_UPDATE=1 reported a mismatch")
+	}
+	if data, err := os.ReadFile(path); err != nil || !strings.Contains(string(data), "from env") {
+		t.Errorf("golden file = %q, %v; want it written", data, err)
+	}
+}
@@ -0,0 +1,381 @@
+package ae
+
+import (
+	"cmp"
+	"container/list"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// OverflowFingerprint is the fingerprint of the aggregate that collects the
+// groups evicted by an Aggregator at its group limit.
+const OverflowFingerprint = "overflow"
+
+// defaultAggregatorMaxGroups is the group limit of an Aggregator unless
+// AggregatorMaxGroups sets another.
+const defaultAggregatorMaxGroups = 1000
+
+// Aggregate summarizes the errors an Aggregator observed with one fingerprint
+// during a window.
+type Aggregate struct {
+	// Fingerprint is the Fingerprint shared by the errors, or
+	// OverflowFingerprint.
+	Fingerprint string
+	// Count is the number of errors observed.
+	Count int
+	// FirstSeen and LastSeen are the times of the first and last observation.
+	FirstSeen time.Time
+	LastSeen  time.Time
+	// Sample is the first error observed.
+	Sample error
+	// Codes and Tags are the distinct codes and tags found in the cause
+	// trees of the errors, sorted.
+	Codes []string
+	Tags  []string
+}
+
+// Aggregator groups errors by Fingerprint and reports per window how often
+// each occurred, so batch jobs and long-running workers can report "what
+// failed and how often" instead of every occurrence. It is safe for
+// concurrent use; Close must be called to release its goroutines.
+type Aggregator struct {
+	window    time.Duration
+	onFlush   func([]Aggregate)
+	now       func() time.Time
+	maxGroups int
+
+	mu sync.Mutex
+	// start is the time of the first observation of the current window,
+	// zero while it is empty.
+	start time.Time
+	// groups indexes lru by fingerprint; lru holds the groups of the current
+	// window, the least recently observed first.
+	groups   map[string]*list.Element
+	lru      *list.List
+	overflow *aggregate
+	closed   bool
+
+	// queue holds the flushed windows waiting for onFlush; queued and
+	// delivered count the windows handed to and returned from onFlush.
+	queue     [][]Aggregate
+	queued    int
+	delivered int
+	cond      *sync.Cond
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// AggregatorOption configures an Aggregator.
+type AggregatorOption func(*Aggregator)
+
+// AggregatorClock makes the Aggregator read the time from now instead of
+// time.Now, e.g. to control windows in tests.
+func AggregatorClock(now func() time.Time) AggregatorOption {
+	return func(a *Aggregator) {
+		a.now = now
+	}
+}
+
+// AggregatorMaxGroups limits the number of fingerprints tracked per window
+// to n, 1000 by default. At the limit, the least recently observed group is
+// merged into a single aggregate with the fingerprint OverflowFingerprint to
+// make room. A non-positive n means unlimited.
+func AggregatorMaxGroups(n int) AggregatorOption {
+	return func(a *Aggregator) {
+		a.maxGroups = n
+	}
+}
+
+// NewAggregator returns an Aggregator calling onFlush with the aggregates of
+// each window, the most frequent first and the overflow aggregate last. A
+// window starts with its first observation and is flushed once window has
+// passed, or by Flush and Close; a non-positive window is only flushed
+// explicitly. Empty windows are not reported.
+//
+// onFlush is called from a goroutine of the Aggregator, one window at a
+// time, without holding its lock, so a slow callback never blocks Observe.
+// onFlush may call Observe, but must not call Flush or Close: both wait for
+// the window being delivered and would deadlock. A panic in onFlush is
+// reported to stderr and the window counts as delivered.
+func NewAggregator(window time.Duration, onFlush func([]Aggregate), opts ...AggregatorOption) *Aggregator {
+	a := &Aggregator{
+		window:    window,
+		onFlush:   onFlush,
+		now:       time.Now,
+		maxGroups: defaultAggregatorMaxGroups,
+		groups:    make(map[string]*list.Element),
+		lru:       list.New(),
+		stop:      make(chan struct{}),
+	}
+	a.cond = sync.NewCond(&a.mu)
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	a.wg.Add(1)
+	go a.deliver()
+
+	if window > 0 {
+		a.wg.Add(1)
+		go a.tick()
+	}
+
+	return a
+}
+
+// Observe records err in the current window. Nil errors and errors observed
+// after Close are ignored.
+func (a *Aggregator) Observe(err error) {
+	if err == nil {
+		return
+	}
+
+	fp := Fingerprint(err)
+	codes, tags := treeCodesAndTags(err)
+	now := a.now()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return
+	}
+	if a.expired(now) {
+		a.rotate()
+	}
+	if a.start.IsZero() {
+		a.start = now
+	}
+
+	if el, ok := a.groups[fp]; ok {
+		el.Value.(*aggregate).observe(now, codes, tags)
+		a.lru.MoveToBack(el)
+		return
+	}
+
+	if a.maxGroups > 0 && a.lru.Len() >= a.maxGroups {
+		a.evict()
+	}
+
+	g := &aggregate{
+		Aggregate: Aggregate{Fingerprint: fp, FirstSeen: now, Sample: err},
+		codes:     make(map[string]struct{}),
+		tags:      make(map[string]struct{}),
+	}
+	g.observe(now, codes, tags)
+	a.groups[fp] = a.lru.PushBack(g)
+}
+
+// Flush ends the current window and returns once onFlush has been called
+// for it and every window flushed before. It must not be called from
+// onFlush.
+func (a *Aggregator) Flush() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.rotate()
+	a.waitDelivered()
+}
+
+// Close flushes the current window like Flush and stops the Aggregator.
+// Later observations are ignored. Close is idempotent.
+func (a *Aggregator) Close() {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return
+	}
+	a.closed = true
+	a.rotate()
+	a.waitDelivered()
+	a.cond.Broadcast()
+	a.mu.Unlock()
+
+	close(a.stop)
+	a.wg.Wait()
+}
+
+// expired reports whether the current window has lasted its length at now.
+// a.mu must be held.
+func (a *Aggregator) expired(now time.Time) bool {
+	return a.window > 0 && !a.start.IsZero() && now.Sub(a.start) >= a.window
+}
+
+// rotate queues the aggregates of the current window for onFlush and starts
+// a new one. a.mu must be held.
+func (a *Aggregator) rotate() {
+	aggs := make([]Aggregate, 0, a.lru.Len()+1)
+	for el := a.lru.Front(); el != nil; el = el.Next() {
+		aggs = append(aggs, el.Value.(*aggregate).snapshot())
+	}
+	slices.SortFunc(aggs, func(x, y Aggregate) int {
+		if c := cmp.Compare(y.Count, x.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(x.Fingerprint, y.Fingerprint)
+	})
+	if a.overflow != nil {
+		aggs = append(aggs, a.overflow.snapshot())
+	}
+
+	a.start = time.Time{}
+	clear(a.groups)
+	a.lru.Init()
+	a.overflow = nil
+
+	if len(aggs) == 0 {
+		return
+	}
+
+	a.queue = append(a.queue, aggs)
+	a.queued++
+	a.cond.Broadcast()
+}
+
+// evict merges the least recently observed group into the overflow
+// aggregate. a.mu must be held.
+func (a *Aggregator) evict() {
+	g := a.lru.Remove(a.lru.Front()).(*aggregate)
+	delete(a.groups, g.Fingerprint)
+
+	if a.overflow == nil {
+		a.overflow = &aggregate{
+			Aggregate: Aggregate{
+				Fingerprint: OverflowFingerprint,
+				FirstSeen:   g.FirstSeen,
+				Sample:      g.Sample,
+			},
+			codes: make(map[string]struct{}),
+			tags:  make(map[string]struct{}),
+		}
+	}
+
+	o := a.overflow
+	o.Count += g.Count
+	if g.FirstSeen.Before(o.FirstSeen) {
+		o.FirstSeen = g.FirstSeen
+	}
+	if g.LastSeen.After(o.LastSeen) {
+		o.LastSeen = g.LastSeen
+	}
+	maps.Copy(o.codes, g.codes)
+	maps.Copy(o.tags, g.tags)
+}
+
+// waitDelivered waits until onFlush has returned for every queued window.
+// a.mu must be held.
+func (a *Aggregator) waitDelivered() {
+	for a.delivered < a.queued {
+		a.cond.Wait()
+	}
+}
+
+// deliver calls onFlush for each queued window until the Aggregator is
+// closed and the queue is drained.
+func (a *Aggregator) deliver() {
+	defer a.wg.Done()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for {
+		for len(a.queue) == 0 && !a.closed {
+			a.cond.Wait()
+		}
+		if len(a.queue) == 0 {
+			return
+		}
+
+		aggs := a.queue[0]
+		a.queue = a.queue[1:]
+
+		a.mu.Unlock()
+		a.runOnFlush(aggs)
+		a.mu.Lock()
+
+		a.delivered++
+		a.cond.Broadcast()
+	}
+}
+
+// runOnFlush calls onFlush with aggs. A callback that panics is reported to
+// stderr rather than stopping delivery, so Flush and Close still return.
+func (a *Aggregator) runOnFlush(aggs []Aggregate) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "ae: aggregator flush callback panicked: %v\n", r)
+		}
+	}()
+
+	a.onFlush(aggs)
+}
+
+// tick flushes the current window once it has lasted its length, even if
+// nothing else is observed.
+func (a *Aggregator) tick() {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(a.window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+			a.mu.Lock()
+			if !a.closed && a.expired(a.now()) {
+				a.rotate()
+			}
+			a.mu.Unlock()
+		}
+	}
+}
+
+// aggregate is an Aggregate being built, with its codes and tags as sets.
+type aggregate struct {
+	Aggregate
+	codes map[string]struct{}
+	tags  map[string]struct{}
+}
+
+// observe counts an observation at now of an error with the given codes and
+// tags.
+func (g *aggregate) observe(now time.Time, codes, tags []string) {
+	g.Count++
+	if now.After(g.LastSeen) {
+		g.LastSeen = now
+	}
+	for _, code := range codes {
+		g.codes[code] = struct{}{}
+	}
+	for _, tag := range tags {
+		g.tags[tag] = struct{}{}
+	}
+}
+
+// snapshot returns the Aggregate with its codes and tags sorted.
+func (g *aggregate) snapshot() Aggregate {
+	agg := g.Aggregate
+	agg.Codes = slices.Sorted(maps.Keys(g.codes))
+	agg.Tags = slices.Sorted(maps.Keys(g.tags))
+	return agg
+}
+
+// treeCodesAndTags returns the codes and tags found in err's cause tree.
+func treeCodesAndTags(err error) (codes, tags []string) {
+	walkCauses(err, func(err error) bool {
+		if code := Code(err); code != "" {
+			codes = append(codes, code)
+		}
+		tags = slices.AppendSeq(tags, tagSeq(err))
+		return true
+	})
+
+	return codes, tags
+}
@@ -0,0 +1,303 @@
+package ae_test
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go.aledante.io/ae"
+)
+
+// fakeClock is a clock advanced by hand.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// flushRecorder collects the windows an Aggregator flushes.
+type flushRecorder struct {
+	mu      sync.Mutex
+	windows [][]ae.Aggregate
+}
+
+func (r *flushRecorder) onFlush(aggs []ae.Aggregate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.windows = append(r.windows, aggs)
+}
+
+func (r *flushRecorder) get() [][]ae.Aggregate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.windows)
+}
+
+func TestAggregator_GroupsByFingerprint(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	rec := &flushRecorder{}
+	agg := ae.NewAggregator(time.Minute, rec.onFlush, ae.AggregatorClock(clock.Now))
+	defer agg.Close()
+
+	timeout := func(row int) error {
+		return ae.New().Code("DB_TIMEOUT").Tag("db").Attr("row", row).Msg("query timed out")
+	}
+	first := timeout(1)
+	agg.Observe(first)
+	clock.Advance(time.Second)
+	agg.Observe(ae.Wrap("importing", timeout(2)))
+	agg.Observe(ae.New().Code("NOT_FOUND").Msg("no such row"))
+	agg.Observe(nil)
+	agg.Flush()
+
+	windows := rec.get()
+	if len(windows) != 1 {
+		t.Fatalf("got %d windows, want 1", len(windows))
+	}
+	aggs := windows[0]
+	if len(aggs) != 2 {
+		t.Fatalf("got %d aggregates, want 2: %+v", len(aggs), aggs)
+	}
+
+	got := aggs[0]
+	if got.Fingerprint != ae.Fingerprint(first) || got.Count != 2 {
+		t.Errorf("first aggregate = %s x%d, want the timeouts x2", got.Fingerprint, got.Count)
+	}
+	if got.Sample != first {
+		t.Errorf("Sample = %v, want the first timeout", got.Sample)
+	}
+	if !got.FirstSeen.Equal(clock.Now().Add(-time.Second)) || !got.LastSeen.Equal(clock.Now()) {
+		t.Errorf("seen %v to %v", got.FirstSeen, got.LastSeen)
+	}
+	if !slices.Equal(got.Codes, []string{"DB_TIMEOUT"}) || !slices.Equal(got.Tags, []string{"db"}) {
+		t.Errorf("codes, tags = %v, %v", got.Codes, got.Tags)
+	}
+	if aggs[1].Count != 1 || !slices.Equal(aggs[1].Codes, []string{"NOT_FOUND"}) {
+		t.Errorf("second aggregate = %+v", aggs[1])
+	}
+
+	agg.Flush()
+	if got := len(rec.get()); got != 1 {
+		t.Errorf("an empty window was flushed: %d windows", got)
+	}
+}
+
+func TestAggregator_WindowRollover(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	rec := &flushRecorder{}
+	agg := ae.NewAggregator(time.Minute, rec.onFlush, ae.AggregatorClock(clock.Now))
+
+	errA := errors.New("a")
+	agg.Observe(errA)
+	clock.Advance(59 * time.Second)
+	agg.Observe(errA)
+	clock.Advance(time.Second)
+	agg.Observe(errA)
+	agg.Close()
+
+	windows := rec.get()
+	if len(windows) != 2 {
+		t.Fatalf("got %d windows, want 2", len(windows))
+	}
+	if windows[0][0].Count != 2 || windows[1][0].Count != 1 {
+		t.Errorf("counts = %d, %d, want 2, 1", windows[0][0].Count, windows[1][0].Count)
+	}
+
+	agg.Observe(errA)
+	agg.Close()
+	if got := len(rec.get()); got != 2 {
+		t.Errorf("observed after Close: %d windows", got)
+	}
+}
+
+func TestAggregator_FlushesOnTimer(t *testing.T) {
+	t.Parallel()
+
+	flushed := make(chan []ae.Aggregate, 1)
+	agg := ae.NewAggregator(10*time.Millisecond, func(aggs []ae.Aggregate) { flushed <- aggs })
+	defer agg.Close()
+
+	agg.Observe(errors.New("a"))
+	select {
+	case aggs := <-flushed:
+		if len(aggs) != 1 || aggs[0].Count != 1 {
+			t.Errorf("flushed %+v", aggs)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("window not flushed")
+	}
+}
+
+func TestAggregator_MaxGroupsEvictsLeastRecent(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	rec := &flushRecorder{}
+	agg := ae.NewAggregator(0, rec.onFlush, ae.AggregatorClock(clock.Now), ae.AggregatorMaxGroups(2))
+
+	errA := ae.New().Code("A").Msg("a")
+	errB := ae.New().Code("B").Msg("b")
+	errC := ae.New().Code("C").Msg("c")
+	errD := ae.New().Code("D").Msg("d")
+	for _, err := range []error{errA, errA, errB, errA, errC, errD} {
+		clock.Advance(time.Second)
+		agg.Observe(err)
+	}
+	agg.Close()
+
+	aggs := rec.get()[0]
+	var fps []string
+	for _, a := range aggs {
+		fps = append(fps, a.Fingerprint)
+	}
+	want := []string{ae.Fingerprint(errC), ae.Fingerprint(errD), ae.OverflowFingerprint}
+	slices.Sort(want[:2])
+	if !slices.Equal(fps, want) {
+		t.Fatalf("fingerprints = %v, want C and D then the overflow", fps)
+	}
+
+	overflow := aggs[2]
+	if overflow.Count != 4 {
+		t.Errorf("overflow count = %d, want A x3 and B x1", overflow.Count)
+	}
+	if !slices.Equal(overflow.Codes, []string{"A", "B"}) {
+		t.Errorf("overflow codes = %v", overflow.Codes)
+	}
+	if overflow.Sample != errB {
+		t.Errorf("overflow sample = %v, want the first evicted group's", overflow.Sample)
+	}
+}
+
+func TestAggregator_SlowFlushDoesNotBlockObserve(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	release := make(chan struct{})
+	agg := ae.NewAggregator(time.Minute, func([]ae.Aggregate) { <-release }, ae.AggregatorClock(clock.Now))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 5 {
+			agg.Observe(errors.New("a"))
+			clock.Advance(time.Minute)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Observe blocked on the flush callback")
+	}
+	close(release)
+	agg.Close()
+}
+
+func TestAggregator_ObserveFromFlush(t *testing.T) {
+	t.Parallel()
+
+	rec := &flushRecorder{}
+	var agg *ae.Aggregator
+	agg = ae.NewAggregator(0, func(aggs []ae.Aggregate) {
+		rec.onFlush(aggs)
+		if len(rec.get()) == 1 {
+			agg.Observe(errors.New("reported"))
+		}
+	})
+	defer agg.Close()
+
+	agg.Observe(errors.New("a"))
+	agg.Flush()
+	agg.Flush()
+
+	windows := rec.get()
+	if len(windows) != 2 || windows[1][0].Sample.Error() != "reported" {
+		t.Errorf("windows = %v, want the error observed by onFlush in the second", windows)
+	}
+}
+
+func TestAggregator_PanickingFlushStillDelivers(t *testing.T) {
+	t.Parallel()
+
+	rec := &flushRecorder{}
+	agg := ae.NewAggregator(0, func(aggs []ae.Aggregate) {
+		rec.onFlush(aggs)
+		if len(rec.get()) == 1 {
+			panic("boom")
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		agg.Observe(errors.New("a"))
+		agg.Flush()
+		agg.Observe(errors.New("b"))
+		agg.Close()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Flush or Close blocked after the flush callback panicked")
+	}
+	if windows := rec.get(); len(windows) != 2 {
+		t.Errorf("windows = %v, want both delivered", windows)
+	}
+}
+
+func TestAggregator_ConcurrentObserve(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu    sync.Mutex
+		total int
+	)
+	agg := ae.NewAggregator(time.Millisecond, func(aggs []ae.Aggregate) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, a := range aggs {
+			total += a.Count
+		}
+	}, ae.AggregatorMaxGroups(4))
+
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				agg.Observe(ae.New().Code(string(rune('A' + (g+i)%8))).Msg("x"))
+			}
+		}()
+	}
+	wg.Wait()
+	agg.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if total != 8*200 {
+		t.Errorf("counted %d observations, want %d", total, 8*200)
+	}
+}
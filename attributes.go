@@ -3,6 +3,8 @@ package ae
 import (
 	"context"
 	"maps"
+	"slices"
+	"sync/atomic"
 )
 
 // ErrorAttributes defines an interface for errors that can provide a map of attributes.
@@ -30,6 +32,63 @@ func Attributes(err error) map[string]any {
 	return make(map[string]any)
 }
 
+// attrShallowCopy disables deep copying of attribute values, see
+// SetAttrDeepCopy.
+var attrShallowCopy atomic.Bool
+
+// SetAttrDeepCopy configures whether Ae.Clone, Ae.ErrorAttributes and From
+// deep-copy container attribute values, which is enabled by default. Values
+// of type map[string]any, []any and []string are copied recursively; other
+// values, including pointers, are shared between the copies. Disabling it
+// makes copies cheaper when attribute values are never mutated.
+func SetAttrDeepCopy(enabled bool) {
+	attrShallowCopy.Store(!enabled)
+}
+
+// copyAttributes copies attrs, deep-copying container values unless
+// disabled via SetAttrDeepCopy.
+func copyAttributes(attrs map[string]any) map[string]any {
+	if attrs == nil || attrShallowCopy.Load() {
+		return maps.Clone(attrs)
+	}
+
+	cpy := make(map[string]any, len(attrs))
+	for k, v := range attrs {
+		cpy[k] = deepCopyValue(v)
+	}
+
+	return cpy
+}
+
+// deepCopyValue copies map[string]any, []any and []string values
+// recursively and returns any other value as is.
+func deepCopyValue(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		if x == nil {
+			return x
+		}
+		cpy := make(map[string]any, len(x))
+		for k, v := range x {
+			cpy[k] = deepCopyValue(v)
+		}
+		return cpy
+	case []any:
+		if x == nil {
+			return x
+		}
+		cpy := make([]any, len(x))
+		for i, v := range x {
+			cpy[i] = deepCopyValue(v)
+		}
+		return cpy
+	case []string:
+		return slices.Clone(x)
+	default:
+		return v
+	}
+}
+
 type attributesKey struct{}
 
 // WithAttribute creates a new context with the given attribute added to it.
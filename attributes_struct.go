@@ -0,0 +1,101 @@
+package ae
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// maxStructAttrDepth is the nesting depth up to which AttrsFromStruct
+// flattens nested structs; deeper ones are stored as a single value.
+const maxStructAttrDepth = 4
+
+var (
+	stringerType      = reflect.TypeFor[fmt.Stringer]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// AttrsFromStruct adds the exported fields of the struct v, or of the struct
+// v points to, as attributes. Other values are ignored.
+//
+// A field is named by its `ae:"name"` struct tag, or by its name in lower
+// case, and skipped when tagged `ae:"-"`, or when tagged with the omitempty
+// option (`ae:",omitempty"`) and holding its zero value. Nested structs are
+// flattened with dotted keys ("db.host") up to 4 levels deep; untagged
+// embedded structs are flattened into their parent, as in encoding/json. Structs
+// implementing fmt.Stringer or encoding.TextMarshaler, such as time.Time and
+// values wrapped with Secret, are kept as a single value. Funcs, channels
+// and unsafe pointers are skipped.
+// Attributes are subject to the limits set with SetAttrLimits.
+func (b Builder) AttrsFromStruct(v any) Builder {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return b
+	}
+
+	b.structAttrs(rv, "", 0)
+	return b
+}
+
+// structAttrs sets the attributes for the fields of the struct rv, their
+// keys prefixed with prefix.
+func (b *Builder) structAttrs(rv reflect.Value, prefix string, depth int) {
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		tag := field.Tag.Get("ae")
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		value := rv.Field(i)
+		if opts == "omitempty" && value.IsZero() {
+			continue
+		}
+
+		switch value.Kind() {
+		case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+			continue
+		}
+
+		nested := value
+		for nested.Kind() == reflect.Pointer && !nested.IsNil() {
+			nested = nested.Elem()
+		}
+		flatten := nested.Kind() == reflect.Struct && depth < maxStructAttrDepth && !opaqueStruct(nested.Type())
+
+		if field.Anonymous && name == "" && flatten {
+			b.structAttrs(nested, prefix, depth+1)
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		key := prefix + name
+
+		if flatten {
+			b.structAttrs(nested, key+".", depth+1)
+			continue
+		}
+		if value.Kind() == reflect.Pointer && value.IsNil() {
+			b.setAttr(key, nil)
+			continue
+		}
+		b.setAttr(key, nested.Interface())
+	}
+}
+
+// opaqueStruct reports whether values of the struct type t render
+// themselves and should not be flattened.
+func opaqueStruct(t reflect.Type) bool {
+	pt := reflect.PointerTo(t)
+
+	return t.Implements(stringerType) || t.Implements(textMarshalerType) ||
+		pt.Implements(stringerType) || pt.Implements(textMarshalerType)
+}
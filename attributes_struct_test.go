@@ -0,0 +1,111 @@
+package ae_test
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	"go.aledante.io/ae"
+)
+
+type dbConfig struct {
+	Host     string
+	Port     int    `ae:"port,omitempty"`
+	Password string `ae:"-"`
+}
+
+type Meta struct {
+	Region string
+}
+
+type request struct {
+	Meta
+	UserID   int    `ae:"user_id"`
+	Email    string `ae:",omitempty"`
+	Token    any    `ae:"token"`
+	DB       dbConfig
+	Replica  *dbConfig `ae:"replica,omitempty"`
+	Started  time.Time
+	Callback func()
+	Events   chan int
+	secret   string
+}
+
+type level struct {
+	Name string
+	Next *level
+}
+
+func TestBuilder_AttrsFromStruct(t *testing.T) {
+	t.Parallel()
+
+	started := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	token := ae.Secret("t0k3n")
+
+	tests := []struct {
+		name string
+		v    any
+		want map[string]any
+	}{
+		{
+			name: "full",
+			v: request{
+				Meta:     Meta{Region: "eu"},
+				UserID:   42,
+				Email:    "a@example.com",
+				Token:    token,
+				DB:       dbConfig{Host: "db1", Port: 5432, Password: "hunter2"},
+				Replica:  &dbConfig{Host: "db2"},
+				Started:  started,
+				Callback: func() {},
+				Events:   make(chan int),
+				secret:   "x",
+			},
+			want: map[string]any{
+				"region":       "eu",
+				"user_id":      42,
+				"email":        "a@example.com",
+				"token":        token,
+				"db.host":      "db1",
+				"db.port":      5432,
+				"replica.host": "db2",
+				"started":      started,
+			},
+		},
+		{
+			name: "zero values",
+			v:    &request{},
+			want: map[string]any{
+				"region":  "",
+				"user_id": 0,
+				"token":   nil,
+				"db.host": "",
+				"started": time.Time{},
+			},
+		},
+		{
+			name: "depth limit",
+			v:    level{Name: "0", Next: &level{Name: "1", Next: &level{Name: "2", Next: &level{Name: "3", Next: &level{Name: "4", Next: &level{Name: "5"}}}}}},
+			want: map[string]any{
+				"name":                     "0",
+				"next.name":                "1",
+				"next.next.name":           "2",
+				"next.next.next.name":      "3",
+				"next.next.next.next.name": "4",
+				"next.next.next.next.next": level{Name: "5"},
+			},
+		},
+		{name: "not a struct", v: map[string]any{"a": 1}, want: map[string]any{}},
+		{name: "nil", v: nil, want: map[string]any{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := ae.Attributes(ae.New().AttrsFromStruct(tt.v).Msg("x"))
+			if !maps.EqualFunc(got, tt.want, func(a, b any) bool { return a == b }) {
+				t.Errorf("attrs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
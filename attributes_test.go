@@ -3,6 +3,8 @@ package ae_test
 import (
 	"context"
 	"errors"
+	"maps"
+	"strings"
 	"testing"
 
 	"go.aledante.io/ae"
@@ -110,3 +112,54 @@ func TestBuilder_ContextPullsAttributesIntoError(t *testing.T) {
 		t.Errorf("Attributes after NewC = %v, want request_id=r-7", got)
 	}
 }
+
+func TestAe_AttrSeq(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Attr("a", 1).Attr("b", "two").Msg("boom").(*ae.Ae)
+
+	got := maps.Collect(err.AttrSeq())
+	if want := map[string]any{"a": 1, "b": "two"}; !maps.Equal(got, want) {
+		t.Errorf("AttrSeq = %v, want %v", got, want)
+	}
+
+	n := 0
+	for range err.AttrSeq() {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("AttrSeq did not stop after break, yielded %d", n)
+	}
+
+	if got := maps.Collect(ae.New().Msg("x").(*ae.Ae).AttrSeq()); len(got) != 0 {
+		t.Errorf("AttrSeq without attributes = %v", got)
+	}
+}
+
+func TestAttributes_MutatingCopyDoesNotCorruptError(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().
+		Attr("ids", []any{1, 2}).
+		Attr("meta", map[string]any{"region": "eu"}).
+		Attr("n", 1).
+		Msg("boom")
+
+	attrs := ae.Attributes(err)
+	attrs["n"] = 2
+	attrs["extra"] = true
+	attrs["ids"].([]any)[0] = 99
+	attrs["meta"].(map[string]any)["region"] = "us"
+
+	got := maps.Collect(err.(*ae.Ae).AttrSeq())
+	if got["n"] != 1 || got["extra"] != nil {
+		t.Errorf("attributes changed through the copy: %v", got)
+	}
+	if got["ids"].([]any)[0] != 1 || got["meta"].(map[string]any)["region"] != "eu" {
+		t.Errorf("container values changed through the copy: %v", got)
+	}
+	if out := ae.NewPrinter(ae.NoPrintColors()).Prints(err); !strings.Contains(out, "map[region:eu]") {
+		t.Errorf("printed attributes changed through the copy:\n%s", out)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"maps"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/otel/trace"
@@ -12,11 +13,14 @@ import (
 // Builder is a builder for Ae errors with a fluent interface.
 type Builder Ae
 
+// durationKey is the attribute set by Builder.Since and Builder.Duration.
+const durationKey = "duration"
+
 // New creates and returns a new instance of Builder.
 func New() Builder {
+	// tags and attributes are allocated on first write; most errors never
+	// carry either.
 	return Builder{
-		tags:        make(map[string]struct{}),
-		attributes:  make(map[string]any),
 		recoverable: true,
 	}
 }
@@ -27,7 +31,44 @@ func NewC(ctx context.Context) Builder {
 	return New().Context(ctx)
 }
 
+type builderKey struct{}
+
+// WithError returns a new context carrying b as the builder FromContext
+// starts from, e.g. seeded by a middleware with what every error built while
+// serving a request should carry. b is copied, so later changes to it don't
+// affect the context.
+func WithError(ctx context.Context, b Builder) context.Context {
+	return context.WithValue(ctx, builderKey{}, Builder(*Ae(b).Clone()))
+}
+
+// FromContext returns a builder for an error occurring in ctx: a copy of
+// the one stored with WithError, or New() if there is none, with the values
+// of ctx added as by Builder.Context.
+func FromContext(ctx context.Context) Builder {
+	b, ok := ctx.Value(builderKey{}).(Builder)
+	if !ok {
+		return NewC(ctx)
+	}
+
+	return Builder(*Ae(b).Clone()).Context(ctx)
+}
+
 // From creates and returns a new instance of Builder based on the given error.
+// Metadata exposed through the ErrorXxx interfaces is copied; errors that
+// don't implement ErrorMessage contribute err.Error() as the message.
+//
+// *Ae errors are cloned, less a timestamp taken automatically while
+// packages were initialized (see SetAutoTimestamp), which is taken again
+// when the error is finalized.
+// Other errors whose causes can't be copied (they implement neither
+// ErrorCauses nor WrappedErrors) become the single cause of the builder, so
+// errors.Is and errors.As keep matching the original; while the message is
+// the text of err, Error() and the text printers don't repeat err after it.
+// Stack traces of github.com/pkg/errors style errors (a StackTrace() method)
+// are converted into a Stack when the error does not implement ErrorStacks.
+// Errors that are not *Ae are passed through the classifiers registered
+// with RegisterClassifier. Errors implementing AeLike are read with a single
+// type assertion.
 func From(err error) Builder {
 	if err == nil {
 		return New()
@@ -35,28 +76,45 @@ func From(err error) Builder {
 
 	//goland:noinspection GoTypeAssertionOnErrors
 	if x, ok := err.(*Ae); ok {
-		return (Builder)(x.clone())
+		b := (Builder)(*x.Clone()).limitTree()
+		b.dropInitTimestamp()
+		b.source = err
+		return b
+	}
+
+	if x, ok := err.(AeLike); ok {
+		b := fromAeLike(x).limitTree().classify(err)
+		if a, ok := asAe(err); ok && a.initStamped {
+			b.timestamp = time.Time{}
+		}
+		b.source = err
+		return b
 	}
 
 	b := New()
 
 	if x, ok := err.(ErrorMessage); ok {
 		b.msg = x.ErrorMessage()
+	} else {
+		b.msg = err.Error()
 	}
 	if x, ok := err.(ErrorUserMessage); ok {
 		b.userMsg = x.ErrorUserMessage()
 	}
+	if x, ok := err.(ErrorUserMessages); ok {
+		b.userMsgs = x.ErrorUserMessages()
+	}
 	if x, ok := err.(ErrorTraceId); ok {
 		b.traceId = x.ErrorTraceId()
 	}
 	if x, ok := err.(ErrorSpanId); ok {
 		b.spanId = x.ErrorSpanId()
 	}
+	if x, ok := err.(ErrorRequestId); ok {
+		b.requestId = x.ErrorRequestId()
+	}
 	if x, ok := err.(ErrorTags); ok {
-		b.tags = make(map[string]struct{})
-		for _, tag := range x.ErrorTags() {
-			b.tags[tag] = struct{}{}
-		}
+		b = b.Tags(x.ErrorTags()...)
 	}
 	if x, ok := err.(ErrorCode); ok {
 		b.code = x.ErrorCode()
@@ -66,23 +124,41 @@ func From(err error) Builder {
 	}
 	if x, ok := err.(ErrorExitCode); ok {
 		b.exitCode = x.ErrorExitCode()
+	} else if x, ok := err.(exitCoder); ok && x.ExitCode() > 0 {
+		b.exitCode = x.ExitCode()
 	}
 	if x, ok := err.(ErrorHint); ok {
 		b.hint = x.ErrorHint()
 	}
+	if x, ok := err.(ErrorFingerprint); ok {
+		b.fingerprint = x.ErrorFingerprint()
+	}
 	if x, ok := err.(ErrorRelated); ok {
 		b.related = x.ErrorRelated()
 	}
 	if x, ok := err.(ErrorCauses); ok {
 		b.causes = x.ErrorCauses()
+	} else if x, ok := err.(multiError); ok {
+		b = b.Causes(x.WrappedErrors())
+	} else {
+		b.causes = []error{err}
+		if messageIsText(err) {
+			// Error doesn't repeat err after its own text, unless the
+			// message is replaced
+			b.causesInMsg = []bool{true}
+		}
 	}
 	if x, ok := err.(ErrorTimestamp); ok {
 		b.timestamp = x.ErrorTimestamp()
 	}
 	if x, ok := err.(ErrorStacks); ok {
 		b.stacks = x.ErrorStacks()
+	} else if st := stackFromStackTracer(err); st != nil {
+		b.stacks = []*Stack{st}
 	}
 
+	b = b.limitTree().classify(err)
+	b.source = err
 	return b
 }
 
@@ -96,6 +172,7 @@ func FromC(ctx context.Context, err error) Builder {
 // If recoverable is true, the error is considered recoverable; otherwise, it is not.
 func (b Builder) Recoverable(recoverable bool) Builder {
 	b.recoverable = recoverable
+	b.recoverableSet = true
 	return b
 }
 
@@ -111,21 +188,52 @@ func (b Builder) Hint(hint string) Builder {
 	return b
 }
 
+// UserMsgLocale sets the user message shown to users of the given locale,
+// such as "de" or "de-AT". The message set with UserMsg remains the default
+// for other locales; see UserMessageFor.
+func (b Builder) UserMsgLocale(locale, msg string) Builder {
+	if b.userMsgs == nil {
+		b.userMsgs = make(map[string]string)
+	}
+
+	b.userMsgs[locale] = msg
+	return b
+}
+
 // Timestamp sets the timestamp for when the error occurred.
 func (b Builder) Timestamp(timestamp time.Time) Builder {
 	b.timestamp = timestamp
+	b.autoStamped, b.initStamped = false, false
 	return b
 }
 
-// Now sets the current time as the error timestamp.
+// Now sets the current time, as read from the clock set with SetClock, as
+// the error timestamp.
 func (b Builder) Now() Builder {
-	b.timestamp = time.Now()
+	b.timestamp = now()
+	b.autoStamped, b.initStamped = false, false
+	return b
+}
+
+// Since sets the "duration" attribute to the time elapsed since start, as
+// read from the clock set with SetClock, e.g. the start of the operation
+// that failed.
+func (b Builder) Since(start time.Time) Builder {
+	return b.Duration(now().Sub(start))
+}
+
+// Duration sets the "duration" attribute to d. The value is kept as a
+// time.Duration: the text printer renders it as e.g. "1.284s" and the JSON
+// printer as milliseconds.
+func (b Builder) Duration(d time.Duration) Builder {
+	b.setAttr(durationKey, d)
 	return b
 }
 
 // Code sets an error code string identifier.
+// Codes are validated against the pattern set with SetCodePattern.
 func (b Builder) Code(code string) Builder {
-	b.code = code
+	b.code = b.validCode(code)
 	return b
 }
 
@@ -151,30 +259,45 @@ func (b Builder) SpanId(spanId string) Builder {
 	return b
 }
 
+// RequestId sets the ID of the request being served when the error occurred.
+func (b Builder) RequestId(requestId string) Builder {
+	b.requestId = requestId
+	return b
+}
+
 // Tag adds a single tag to the error.
+// Tags are validated against the pattern set with SetTagPattern and subject
+// to the limits set with SetAttrLimits.
 func (b Builder) Tag(tag string) Builder {
-	b.tags[tag] = struct{}{}
+	b.addTag(b.validTag(tag))
 	return b
 }
 
 // Tags adds multiple tags to the error.
+// Tags are validated against the pattern set with SetTagPattern and subject
+// to the limits set with SetAttrLimits.
 func (b Builder) Tags(tags ...string) Builder {
 	for _, tag := range tags {
-		b.tags[tag] = struct{}{}
+		b.addTag(b.validTag(tag))
 	}
 
 	return b
 }
 
 // Attr adds a single key-value attribute to the error.
+// Attributes are subject to the limits set with SetAttrLimits.
 func (b Builder) Attr(key string, value any) Builder {
-	b.attributes[key] = value
+	b.setAttr(key, value)
 	return b
 }
 
 // Attrs adds multiple attributes to the error by copying from the provided map.
+// Attributes are subject to the limits set with SetAttrLimits.
 func (b Builder) Attrs(attrs map[string]any) Builder {
-	maps.Copy(b.attributes, attrs)
+	if len(attrs) > 0 {
+		b.setAttrs(attrs)
+	}
+
 	return b
 }
 
@@ -186,11 +309,10 @@ func (b Builder) Cause(causes ...error) Builder {
 // Causes adds one or more underlying causes to the error.
 // It filters out any nil errors from the provided list.
 // The causes represent errors that directly led to this error occurring.
+// Causes are subject to the limits set with SetTreeLimits.
 func (b Builder) Causes(causes []error) Builder {
 	for _, cause := range causes {
-		if cause != nil {
-			b.causes = append(b.causes, cause)
-		}
+		b.addCause(cause)
 	}
 
 	return b
@@ -201,17 +323,16 @@ func (b Builder) Causes(causes []error) Builder {
 // If an error implements Unwrap() []error, its unwrapped errors are added individually.
 // Otherwise, the error is added as-is.
 // The causes represent errors that directly led to this error occurring.
+// Causes are subject to the limits set with SetTreeLimits.
 func (b Builder) CauseUnwrap(causes ...error) Builder {
 	for _, cause := range causes {
 		if cause != nil {
 			if x, ok := cause.(interface{ Unwrap() []error }); ok {
 				for _, cause := range x.Unwrap() {
-					if cause != nil {
-						b.causes = append(b.causes, cause)
-					}
+					b.addCause(cause)
 				}
 			} else {
-				b.causes = append(b.causes, cause)
+				b.addCause(cause)
 			}
 		}
 	}
@@ -220,14 +341,14 @@ func (b Builder) CauseUnwrap(causes ...error) Builder {
 }
 
 // Related adds one or more related errors.
-// It filters out any nil errors from the provided list.
+// It filters out any nil errors from the provided list, as well as errors
+// already present among the related errors or the causes.
 // Related errors are those that are connected to this error but are not direct causes.
 // This can include errors that occurred during the handling of the cause(s).
+// Related errors are subject to the limits set with SetTreeLimits.
 func (b Builder) Related(related ...error) Builder {
 	for _, related := range related {
-		if related != nil {
-			b.related = append(b.related, related)
-		}
+		b.addRelated(related)
 	}
 
 	return b
@@ -238,17 +359,16 @@ func (b Builder) Related(related ...error) Builder {
 // If an error implements Unwrap() []error, its unwrapped errors are added individually.
 // Otherwise, the error is added as-is.
 // Related errors are those that are connected to this error but are not direct causes.
+// Related errors are subject to the limits set with SetTreeLimits.
 func (b Builder) RelatedUnwrap(related ...error) Builder {
 	for _, related := range related {
 		if related != nil {
 			if x, ok := related.(interface{ Unwrap() []error }); ok {
 				for _, related := range x.Unwrap() {
-					if related != nil {
-						b.related = append(b.related, related)
-					}
+					b.addRelated(related)
 				}
 			} else {
-				b.related = append(b.related, related)
+				b.addRelated(related)
 			}
 		}
 	}
@@ -256,23 +376,207 @@ func (b Builder) RelatedUnwrap(related ...error) Builder {
 	return b
 }
 
-// Stack captures the current stack trace for the error.
+// addRelated adds related unless it is nil or the same error as one of the
+// related errors or causes, enforcing the active tree limits.
+func (b *Builder) addRelated(related error) {
+	if related == nil || containsError(b.related, related) || containsError(b.causes, related) {
+		return
+	}
+
+	b.related = b.appendLimited(b.related, related, relatedOmittedKey)
+}
+
+// Stack captures the stack trace of the calling goroutine for the error,
+// adding it to the stacks already present (e.g. inherited via From). If one
+// of those was captured on the same goroutine from the same callers, only the
+// more complete one is kept (see SetStackDedupe).
+//
+// Capturing is cheap: only program counters are recorded, and frames are
+// resolved when the stacks are first read (e.g. when printing). In hot paths
+// it can be limited further with SetStackSampling.
 func (b Builder) Stack() Builder {
-	b.stacks = newStack()
+	if !sampleStack() {
+		return b.Attr("stack_sampled", false)
+	}
+
+	b.stacks = attachStacks(b.stacks, newStack(1, int(maxStackFrames.Load())))
+	return b
+}
+
+// StackLimit is like Stack but caps the captured frames at n instead of the
+// limit set via SetMaxStackFrames. n <= 0 captures every frame.
+func (b Builder) StackLimit(n int) Builder {
+	if !sampleStack() {
+		return b.Attr("stack_sampled", false)
+	}
+
+	b.stacks = attachStacks(b.stacks, newStack(1, n))
+	return b
+}
+
+// StackAll captures the stack traces of all goroutines for the error, adding
+// them to the stacks already present like Stack. This stops the world and
+// parses the runtime's textual traceback, so it is much more expensive than
+// Stack; use it for diagnosing deadlocks and the like.
+func (b Builder) StackAll() Builder {
+	b.stacks = attachStacks(b.stacks, newStacksAll()...)
+	return b
+}
+
+// Fingerprint sets the fingerprint returned by Fingerprint for this error and
+// errors wrapping it, overriding the computed one.
+func (b Builder) Fingerprint(fp string) Builder {
+	b.fingerprint = fp
+	return b
+}
+
+// StacksFrom attaches pre-built stacks to the error, e.g. ones obtained from
+// ParseStacks, replacing any captured previously. nil stacks are ignored.
+func (b Builder) StacksFrom(stacks ...*Stack) Builder {
+	b.stacks = make([]*Stack, 0, len(stacks))
+	for _, st := range stacks {
+		if st != nil {
+			b.stacks = append(b.stacks, st)
+		}
+	}
+
 	return b
 }
 
 // Msg sets the error message and returns the final error.
 // This is a terminal operation that completes the builder chain.
+// An empty msg keeps the message the builder already has, such as the one
+// taken over by From. Without one, the message falls back to the user
+// message, the code, the single cause's message, "N errors" for several
+// causes or "(no message)", and the attribute msg_fallback is set.
+// Hooks registered with OnFinalize are called with the final error.
 func (b Builder) Msg(msg string) error {
-	b.msg = msg
+	// the maps may be shared with errors finalized from the same builder
+	// before; the steps below write to them
+	b.tags = maps.Clone(b.tags)
+	b.attributes = maps.Clone(b.attributes)
+	b.userMsgs = maps.Clone(b.userMsgs)
+
+	if msg != "" {
+		b.replaceMsg(msg)
+	} else if b.msg == "" {
+		b.fallbackMsg()
+	}
+	b.applyClassifiers()
+	if b.timestamp.IsZero() && autoTimestamp.Load() {
+		b.timestamp = now()
+		b.autoStamped = true
+		b.initStamped = stampedAtInit()
+	}
+	b.stampServiceInfo()
+	if captureProcessInfo.Load() {
+		b.stampProcessInfo()
+	}
+	b.stampCauseTypes()
+
+	err := b.finalize()
+	runFinalizeHooks(err)
+
+	return err
+}
+
+// finalize returns the final error of b as is, without the stamping,
+// classification and hooks of Msg.
+func (b Builder) finalize() *Ae {
+	b.source = nil
+	b.classifyErrs = nil
+	b.noClassify = false
+
+	b.sortedTags = &tagCache{}
+	b.errorText = &errorCache{}
 	return (*Ae)(&b)
 }
 
 // Msgf sets the error message and returns the final error.
 // This is a terminal operation that completes the builder chain.
+//
+// As with fmt.Errorf, every %w verb whose argument is an error renders the
+// error's text and adds it to the causes, so errors.Is and errors.As see it;
+// other arguments render as "%!w(...)". Error doesn't repeat the text of
+// these causes after the message.
 func (b Builder) Msgf(msg string, args ...any) error {
-	return b.Msg(fmt.Sprintf(msg, args...))
+	if !strings.Contains(msg, "%w") {
+		return b.Msg(fmt.Sprintf(msg, args...))
+	}
+
+	formatted := fmt.Errorf(msg, args...)
+	var wrapped []error
+	switch x := formatted.(type) {
+	case interface{ Unwrap() []error }:
+		wrapped = x.Unwrap()
+	case interface{ Unwrap() error }:
+		wrapped = []error{x.Unwrap()}
+	}
+
+	text := formatted.Error()
+	b.replaceMsg(text)
+	for _, err := range wrapped {
+		b.addMsgCause(err)
+	}
+
+	return b.Msg(text)
+}
+
+// MsgFrom sets the error message to the message of err (see Message), adds
+// err to the causes and returns the final error, so the error reads as err
+// while carrying the builder's metadata and errors.Is and errors.As keep
+// matching err. Error doesn't repeat the text of err after the message.
+// A builder created from err with From already holds err and doesn't add it
+// again. A nil err returns nil, as Wrap does, discarding the builder.
+// This is a terminal operation that completes the builder chain.
+func (b Builder) MsgFrom(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	msg := Message(err)
+	b.replaceMsg(msg)
+	if !containsError([]error{b.source}, err) {
+		b.addMsgCause(err)
+	}
+
+	return b.Msg(msg)
+}
+
+// dropInitTimestamp clears a timestamp taken automatically while packages
+// were initialized, so the error is stamped with the time it is finalized
+// instead. Package-level sentinels are finalized at init, which tells
+// nothing about when the errors derived from them occur.
+func (b *Builder) dropInitTimestamp() {
+	if b.initStamped {
+		b.timestamp = time.Time{}
+		b.autoStamped, b.initStamped = false, false
+	}
+}
+
+// replaceMsg sets the message to msg unless it is empty. If that changes the
+// message, the marks of the causes whose text was part of the previous one
+// are cleared, such as those of the %w causes of Msgf or of the error From
+// took the message from.
+func (b *Builder) replaceMsg(msg string) {
+	if msg != "" && msg != b.msg {
+		b.msg = msg
+		b.causesInMsg = nil
+	}
+}
+
+// addMsgCause adds cause, whose text is part of the message, to the causes
+// and marks it so that Error doesn't repeat it.
+func (b *Builder) addMsgCause(cause error) {
+	inMsg := make([]bool, len(b.causes), len(b.causes)+1)
+	copy(inMsg, b.causesInMsg)
+
+	b.addCause(cause)
+	for _, c := range b.causes[len(inMsg):] {
+		_, omitted := c.(omittedErrors)
+		inMsg = append(inMsg, !omitted)
+	}
+	b.causesInMsg = inMsg
 }
 
 // UserMsg sets the error message and a user message. Then, it returns the final error.
@@ -282,7 +586,7 @@ func (b Builder) UserMsg(msg, userMsg string) error {
 	return b.Msg(msg)
 }
 
-// Context extracts OpenTelemetry trace information, tags and attributes from the given context.
+// Context extracts OpenTelemetry trace information, the request ID, tags and attributes from the given context.
 // Additionally, it adds the provided keys as attributes.
 // It captures span and trace IDs if present, and adds any requested context values as attributes.
 // The keys parameter can be strings, fmt.Stringer implementations, or any other type that can be converted to a string.
@@ -297,6 +601,10 @@ func (b Builder) Context(ctx context.Context, keys ...any) Builder {
 		}
 	}
 
+	if id := RequestIdFromContext(ctx); id != "" {
+		b.requestId = id
+	}
+
 	b = b.Tags(TagsFromContext(ctx)...)
 	b = b.Attrs(AttributesFromContext(ctx))
 
@@ -327,7 +635,7 @@ func (b Builder) Context(ctx context.Context, keys ...any) Builder {
 		}
 
 		if key != "" && value != "" {
-			b.attributes[key] = value
+			b = b.Attr(key, value)
 		}
 	}
 
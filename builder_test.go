@@ -2,7 +2,10 @@ package ae_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"slices"
 	"strings"
 	"testing"
@@ -36,6 +39,33 @@ func TestNewC_ShorthandForContext(t *testing.T) {
 	}
 }
 
+func TestFromContext_StartsFromSeededBuilder(t *testing.T) {
+	t.Parallel()
+
+	seed := ae.New().Code("CHECKOUT").Attr("cart", 7)
+	ctx := ae.WithError(context.Background(), seed)
+	_ = seed.Attr("cart", 8)
+	ctx = ae.WithTagsValue(ctx, "ctx-tag")
+
+	first := ae.FromContext(ctx).Attr("step", "pay").Msg("payment declined")
+	second := ae.FromContext(ctx).Msg("out of stock")
+
+	if ae.Code(first) != "CHECKOUT" || ae.Attributes(first)["cart"] != 7 {
+		t.Errorf("first = %v %v, want the seeded code and attributes", ae.Code(first), ae.Attributes(first))
+	}
+	if !slices.Contains(ae.Tags(second), "ctx-tag") {
+		t.Errorf("Tags = %v, want the context's tags", ae.Tags(second))
+	}
+	if _, ok := ae.Attributes(second)["step"]; ok {
+		t.Error("an attribute of one error leaked into the seeded builder")
+	}
+
+	plain := ae.WithTagsValue(context.Background(), "ctx-tag")
+	if got := ae.Tags(ae.FromContext(plain).Msg("x")); !slices.Equal(got, []string{"ctx-tag"}) {
+		t.Errorf("Tags without a seeded builder = %v, want the context's", got)
+	}
+}
+
 func TestFrom_NilErrorReturnsEmptyBuilder(t *testing.T) {
 	t.Parallel()
 
@@ -138,6 +168,111 @@ func TestFrom_ArbitraryErrorPullsInterfaceValues(t *testing.T) {
 	}
 }
 
+func TestFrom_PlainErrorKeepsMessageAndCause(t *testing.T) {
+	t.Parallel()
+
+	src := errors.New("boom")
+	err := ae.From(src).Attr("k", "v").Msg("")
+
+	if got := err.Error(); got != "boom" {
+		t.Errorf("Error() = %q, want the message taken over and not repeated", got)
+	}
+	if !errors.Is(err, src) {
+		t.Error("errors.Is(err, src) = false")
+	}
+	if causes := ae.Causes(err); len(causes) != 1 || causes[0] != src {
+		t.Errorf("causes = %v, want [src]", causes)
+	}
+}
+
+func TestFrom_FmtWrappedError(t *testing.T) {
+	t.Parallel()
+
+	inner := errors.New("inner")
+	src := fmt.Errorf("ctx: %w", inner)
+
+	if got := ae.From(src).Msg("").Error(); got != "ctx: inner" {
+		t.Errorf("Error() = %q, want the fallback message", got)
+	}
+
+	err := ae.From(src).Code("X").Msg("wrapped")
+	if !errors.Is(err, inner) {
+		t.Error("errors.Is does not reach the error wrapped with %w")
+	}
+	if got := err.Error(); got != "wrapped: ctx: inner" {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestFrom_SourceOnlyCoveredByItsOwnText(t *testing.T) {
+	t.Parallel()
+
+	// a cause with the same text that From didn't add is listed
+	if got := ae.Wrap("boom", errors.New("boom")).Error(); got != "boom: boom" {
+		t.Errorf("Wrap: Error() = %q, want boom: boom", got)
+	}
+
+	src := errors.New("plain")
+	for _, tt := range []struct {
+		name     string
+		err      error
+		text     string
+		causedBy bool
+	}{
+		{"kept message", ae.From(src).Msg(""), "plain", false},
+		{"new message", ae.From(src).Msg("loading"), "loading: plain", true},
+		{"formatted message", ae.From(src).Msgf("loading %s", "users"), "loading users: plain", true},
+	} {
+		if got := tt.err.Error(); got != tt.text {
+			t.Errorf("%s: Error() = %q, want %q", tt.name, got, tt.text)
+		}
+		for _, opt := range []ae.PrinterOption{ae.NoPrintPlain(), ae.PrintPlain()} {
+			out := ae.NewPrinter(ae.NoPrintColors(), opt).Prints(tt.err)
+			if got := strings.Count(out, "plain") > strings.Count(ae.Message(tt.err), "plain"); got != tt.causedBy {
+				t.Errorf("%s: cause printed = %t, want %t:\n%s", tt.name, got, tt.causedBy, out)
+			}
+		}
+	}
+}
+
+func TestFrom_MsgUnmarksCausesOfReplacedMessage(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name string
+		err  error
+		text string
+	}{
+		{"Msgf kept", ae.From(ae.New().Msgf("read: %w", io.EOF)).Msg(""), "read: EOF"},
+		{"Msgf replaced", ae.From(ae.New().Msgf("read: %w", io.EOF)).Msg("other"), "other: EOF"},
+		{"MsgFrom kept", ae.From(ae.New().MsgFrom(io.EOF)).Msg(""), "EOF"},
+		{"MsgFrom replaced", ae.From(ae.New().MsgFrom(io.EOF)).Msg("other"), "other: EOF"},
+	} {
+		if got := tt.err.Error(); got != tt.text {
+			t.Errorf("%s: Error() = %q, want %q", tt.name, got, tt.text)
+		}
+		for _, opt := range []ae.PrinterOption{ae.NoPrintPlain(), ae.PrintPlain()} {
+			if out := ae.NewPrinter(ae.NoPrintColors(), opt).Prints(tt.err); !strings.Contains(out, "EOF") {
+				t.Errorf("%s: EOF not printed:\n%s", tt.name, out)
+			}
+		}
+	}
+}
+
+func TestFrom_AeIsNotItsOwnCause(t *testing.T) {
+	t.Parallel()
+
+	src := ae.New().Code("X").Msg("src")
+	err := ae.From(src).Msg("copy")
+
+	if causes := ae.Causes(err); len(causes) != 0 {
+		t.Errorf("From(*Ae) added causes: %v", causes)
+	}
+	if got := err.Error(); got != "copy" {
+		t.Errorf("Error() = %q, want copy", got)
+	}
+}
+
 func TestFromC_CombinesErrorAndContext(t *testing.T) {
 	t.Parallel()
 
@@ -322,6 +457,114 @@ func TestBuilder_CauseStringIncludedInError(t *testing.T) {
 	}
 }
 
+func TestNew_NoTagsOrAttributes(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Msg("bare")
+	if tags := ae.Tags(err); len(tags) != 0 {
+		t.Errorf("Tags() = %v, want empty", tags)
+	}
+	if attrs := ae.Attributes(err); attrs == nil || len(attrs) != 0 {
+		t.Errorf("Attributes() = %#v, want empty non-nil map", attrs)
+	}
+	if _, err := json.Marshal(err); err != nil {
+		t.Errorf("json.Marshal: %v", err)
+	}
+
+	wrapped := ae.From(err).Tag("later").Attr("k", "v").Msg("wrapped")
+	if !slices.Contains(ae.Tags(wrapped), "later") {
+		t.Error("tag added after From was lost")
+	}
+	if got := ae.Attributes(wrapped)["k"]; got != "v" {
+		t.Errorf("attr k = %v, want v", got)
+	}
+}
+
+func BenchmarkNew_Msg(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = ae.New().Msg("x")
+	}
+}
+
+// fullErr is a foreign error implementing every interface of ae.AeLike.
+type fullErr struct {
+	stubErr
+	fingerprint string
+	requestId   string
+}
+
+func (f fullErr) ErrorUserMessages() map[string]string { return map[string]string{"de": "Fehler"} }
+func (f fullErr) ErrorFingerprint() string             { return f.fingerprint }
+func (f fullErr) ErrorRequestId() string               { return f.requestId }
+
+func newFullErr() fullErr {
+	return fullErr{
+		stubErr: stubErr{
+			msg:       "query failed",
+			userMsg:   "Please retry.",
+			code:      "DB_TIMEOUT",
+			exitCode:  3,
+			hint:      "check the pool",
+			traceId:   "trace-1",
+			spanId:    "span-1",
+			tags:      []string{"db", "retry"},
+			attrs:     map[string]any{"attempts": 3},
+			causes:    []error{errors.New("dial tcp: timeout")},
+			related:   []error{errors.New("rollback failed")},
+			timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+		fingerprint: "fp-1",
+		requestId:   "req-1",
+	}
+}
+
+func TestFrom_AeLike(t *testing.T) {
+	t.Parallel()
+
+	src := newFullErr()
+	var _ ae.AeLike = src
+
+	got := ae.From(src).Msg("")
+	want := ae.New().
+		UserMsgLocale("de", "Fehler").
+		Hint("check the pool").
+		Code("DB_TIMEOUT").
+		ExitCode(3).
+		TraceId("trace-1").
+		SpanId("span-1").
+		RequestId("req-1").
+		Tags("db", "retry").
+		Attr("attempts", 3).
+		Cause(src.causes...).
+		Related(src.related...).
+		Timestamp(src.timestamp).
+		Fingerprint("fp-1").
+		UserMsg("query failed", "Please retry.")
+
+	if d := ae.Diff(want, got); d != "" {
+		t.Errorf("From(AeLike) differs:\n%s", d)
+	}
+}
+
+func BenchmarkFrom(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		err  error
+	}{
+		{"ae", ae.New().Code("DB_TIMEOUT").Tags("db", "retry").Attr("attempts", 3).Cause(errors.New("x")).Msg("query failed")},
+		{"aelike", newFullErr()},
+		{"plain", errors.New("query failed")},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				_ = ae.From(bc.err)
+			}
+		})
+	}
+}
+
 // traceContextWith returns a context carrying a valid OpenTelemetry SpanContext
 // built from the given hex strings. Kept here so tests that need a real span
 // context don't pull in a full tracer.
@@ -345,3 +588,159 @@ func traceContextWith(t *testing.T, traceHex, spanHex string) context.Context {
 	})
 	return trace.ContextWithSpanContext(context.Background(), sc)
 }
+
+func TestBuilder_MsgfWrap(t *testing.T) {
+	t.Parallel()
+
+	errA := errors.New("connection refused")
+	errB := ae.New().Code("NOT_FOUND").Msg("no such user")
+	wrapFormat := "lookup: %w" // not a constant, so vet doesn't reject 42
+
+	tests := []struct {
+		name    string
+		err     error
+		msg     string
+		errText string
+		causes  []error
+	}{
+		{
+			name:    "single",
+			err:     ae.New().Msgf("dialing db: %w", errA),
+			msg:     "dialing db: connection refused",
+			errText: "dialing db: connection refused",
+			causes:  []error{errA},
+		},
+		{
+			name:    "two",
+			err:     ae.New().Msgf("both failed: %w, %w", errA, errB),
+			msg:     "both failed: connection refused, no such user",
+			errText: "both failed: connection refused, no such user",
+			causes:  []error{errA, errB},
+		},
+		{
+			name:    "mixed verbs",
+			err:     ae.New().Msgf("user %s (%d): %w", "bob", 7, errB),
+			msg:     "user bob (7): no such user",
+			errText: "user bob (7): no such user",
+			causes:  []error{errB},
+		},
+		{
+			name:    "nil error",
+			err:     ae.New().Msgf("lookup: %w", nil),
+			msg:     "lookup: %!w(<nil>)",
+			errText: "lookup: %!w(<nil>)",
+		},
+		{
+			name:    "not an error",
+			err:     ae.New().Msgf(wrapFormat, 42),
+			msg:     "lookup: %!w(int=42)",
+			errText: "lookup: %!w(int=42)",
+		},
+		{
+			name:    "other causes",
+			err:     ae.New().Cause(errors.New("disk full")).Msgf("saving: %w", errA),
+			msg:     "saving: connection refused",
+			errText: "saving: connection refused: disk full",
+			causes:  []error{errors.New("disk full"), errA},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ae.Message(tt.err); got != tt.msg {
+				t.Errorf("Message = %q, want %q", got, tt.msg)
+			}
+			if got := tt.err.Error(); got != tt.errText {
+				t.Errorf("Error() = %q, want %q", got, tt.errText)
+			}
+
+			causes := ae.Causes(tt.err)
+			if len(causes) != len(tt.causes) {
+				t.Fatalf("causes = %v, want %v", causes, tt.causes)
+			}
+			for i, want := range tt.causes {
+				if causes[i].Error() != want.Error() {
+					t.Errorf("cause %d = %v, want %v", i, causes[i], want)
+				}
+			}
+		})
+	}
+
+	err := ae.New().Msgf("both failed: %w, %w", errA, errB)
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Error("errors.Is does not find the %w arguments")
+	}
+	if got := ae.WithHint(err, "retry").Error(); got != "both failed: connection refused, no such user" {
+		t.Errorf("Error() after From = %q", got)
+	}
+}
+
+func TestBuilder_MsgFrom(t *testing.T) {
+	t.Parallel()
+
+	t.Run("std error", func(t *testing.T) {
+		t.Parallel()
+
+		err := ae.New().Code("IO").Tag("disk").MsgFrom(io.ErrUnexpectedEOF)
+
+		if got := err.Error(); got != "unexpected EOF" {
+			t.Errorf("Error() = %q, want unexpected EOF", got)
+		}
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Error("errors.Is does not find the original error")
+		}
+		if ae.Code(err) != "IO" || !slices.Contains(ae.Tags(err), "disk") {
+			t.Errorf("metadata lost: code %q, tags %v", ae.Code(err), ae.Tags(err))
+		}
+	})
+
+	t.Run("ae error", func(t *testing.T) {
+		t.Parallel()
+
+		orig := ae.New().Code("NOT_FOUND").Cause(io.EOF).Msg("no such user")
+		err := ae.New().Hint("check the ID").Cause(errors.New("cache miss")).MsgFrom(orig)
+
+		if got := ae.Message(err); got != "no such user" {
+			t.Errorf("Message = %q, want no such user", got)
+		}
+		if got := err.Error(); got != "no such user: cache miss" {
+			t.Errorf("Error() = %q, want no such user: cache miss", got)
+		}
+		causes := ae.Causes(err)
+		if len(causes) != 2 || causes[1] != orig {
+			t.Errorf("causes = %v, want the cache miss and the original error", causes)
+		}
+		if !errors.Is(err, io.EOF) {
+			t.Error("errors.Is does not reach the original error's cause")
+		}
+	})
+
+	t.Run("from round trip", func(t *testing.T) {
+		t.Parallel()
+
+		orig := ae.New().Code("NOT_FOUND").Cause(io.EOF).Msg("no such user")
+		err := ae.From(orig).Tag("retry").MsgFrom(orig)
+
+		if got, want := err.Error(), orig.Error(); got != want {
+			t.Errorf("Error() = %q, want %q", got, want)
+		}
+		causes := ae.Causes(err)
+		if len(causes) != 1 || causes[0] != io.EOF {
+			t.Errorf("causes = %v, want only io.EOF", causes)
+		}
+
+		std := ae.From(io.EOF).Code("IO").MsgFrom(io.EOF)
+		if causes := ae.Causes(std); len(causes) != 1 {
+			t.Errorf("causes = %v, want io.EOF once", causes)
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+
+		if err := ae.New().Code("IO").MsgFrom(nil); err != nil {
+			t.Errorf("MsgFrom(nil) = %v, want nil", err)
+		}
+	})
+}
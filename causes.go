@@ -1,5 +1,10 @@
 package ae
 
+import (
+	"reflect"
+	"sync/atomic"
+)
+
 // ErrorCauses defines an interface for errors that can provide a list of underlying causes.
 type ErrorCauses interface {
 	// ErrorCauses returns a list of errors that caused this error.
@@ -10,6 +15,7 @@ type ErrorCauses interface {
 // Causes extracts the list of underlying causes from an error.
 // If the error implements ErrorCauses, returns its Causes().
 // If the error implements Unwrap() []error, returns its Unwrap().
+// If the error implements WrappedErrors() []error (hashicorp/go-multierror), returns its WrappedErrors().
 // If the error implements Unwrap() error, returns a single-element slice containing its Unwrap().
 // Returns nil if err is nil or if the error does not implement any of these interfaces.
 func Causes(err error) []error {
@@ -22,6 +28,8 @@ func Causes(err error) []error {
 		return x.ErrorCauses()
 	case interface{ Unwrap() []error }:
 		return x.Unwrap()
+	case multiError:
+		return x.WrappedErrors()
 	case interface{ Unwrap() error }:
 		return []error{x.Unwrap()}
 	case interface{ Cause() error }:
@@ -30,3 +38,81 @@ func Causes(err error) []error {
 
 	return nil
 }
+
+// multiError is implemented by *multierror.Error from
+// github.com/hashicorp/go-multierror. Older versions of that package do not
+// implement Unwrap() []error, and newer ones unwrap into a chain, so the
+// members are read through WrappedErrors instead.
+type multiError interface {
+	WrappedErrors() []error
+}
+
+// walkCauses calls visit for err and every error in its cause tree,
+// depth-first, until visit returns false. Errors already visited are
+// skipped, so cyclic trees terminate; errors of non-comparable types can't be
+// recognized and are visited each time they're reached. Reports whether the
+// walk was stopped by visit.
+func walkCauses(err error, visit func(err error) bool) bool {
+	seen := make(map[error]struct{})
+	stack := []error{err}
+	for len(stack) > 0 {
+		err := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if err == nil {
+			continue
+		}
+
+		if reflect.TypeOf(err).Comparable() {
+			if _, ok := seen[err]; ok {
+				continue
+			}
+			seen[err] = struct{}{}
+		}
+
+		if !visit(err) {
+			return true
+		}
+
+		causes := Causes(err)
+		for i := len(causes) - 1; i >= 0; i-- {
+			stack = append(stack, causes[i])
+		}
+	}
+
+	return false
+}
+
+// defaultMaxTraversalDepth is the traversal depth used unless
+// SetMaxTraversalDepth sets another.
+const defaultMaxTraversalDepth = 2048
+
+// truncatedMessage stands in for the errors below the maximum traversal
+// depth.
+const truncatedMessage = "… deeper errors truncated"
+
+// maxTraversalDepth holds the depth set with SetMaxTraversalDepth; zero means
+// the default.
+var maxTraversalDepth atomic.Int64
+
+// SetMaxTraversalDepth sets how many levels of nested causes and related
+// errors Error, LogValue, ExitCode and the printers descend into, so that a
+// pathologically deep chain (e.g. an error wrapped once per iteration of a
+// long loop) is truncated instead of exhausting the stack. Below the limit,
+// Error, the printers and LogValue render "… deeper errors truncated"; ExitCode
+// ignores the errors there. A non-positive n restores the default of 2048.
+//
+// Searches through the cause tree, such as IsRecoverable, IsRelated and
+// Fingerprint, don't recurse and always see the whole tree.
+func SetMaxTraversalDepth(n int) {
+	maxTraversalDepth.Store(int64(max(n, 0)))
+	errorTextGeneration.Add(1)
+}
+
+// traversalDepth returns the depth at which recursive traversals stop.
+func traversalDepth() int {
+	if n := maxTraversalDepth.Load(); n > 0 {
+		return int(n)
+	}
+
+	return defaultMaxTraversalDepth
+}
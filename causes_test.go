@@ -1,7 +1,12 @@
 package ae_test
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
 	"testing"
 
 	"go.aledante.io/ae"
@@ -82,3 +87,137 @@ func TestCauses_ErrorCausesWinsOverUnwrap(t *testing.T) {
 		t.Errorf("Causes precedence: got %v, want [%v]", got, c)
 	}
 }
+
+func TestCauses_MultiErrorWrappedErrors(t *testing.T) {
+	t.Parallel()
+
+	a, b := errors.New("a"), errors.New("b")
+	got := ae.Causes(multiErrorStyleErr{errs: []error{a, b}})
+	if len(got) != 2 || got[0] != a || got[1] != b {
+		t.Errorf("Causes(multierror) = %v, want [a b]", got)
+	}
+}
+
+func TestFrom_MultiErrorMembersBecomeCauses(t *testing.T) {
+	t.Parallel()
+
+	a, b := errors.New("a"), errors.New("b")
+	got := ae.Causes(ae.From(multiErrorStyleErr{errs: []error{a, nil, b}}).Msg("x"))
+	if len(got) != 2 || got[0] != a || got[1] != b {
+		t.Errorf("From(multierror) causes = %v, want [a b]", got)
+	}
+}
+
+func TestPrinter_RendersMultiErrorMembersAsSeparateCauses(t *testing.T) {
+	t.Parallel()
+
+	merr := multiErrorStyleErr{errs: []error{
+		errors.New("shard 1 unreachable"),
+		errors.New("shard 2 unreachable"),
+	}}
+	out := ae.NewPrinter(ae.NoPrintColors()).Prints(ae.Wrap("syncing shards", merr))
+
+	for _, want := range []string{"├─ shard 1 unreachable", "└─ shard 2 unreachable"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+// The deep-chain tests below are serial: some change the traversal depth,
+// and the others measure allocations.
+
+// deepChainLen is deep enough to exhaust the stack of any unbounded
+// recursion through the printers or slog handlers.
+const deepChainLen = 100_000
+
+func setTraversalDepth(t *testing.T, n int) {
+	t.Helper()
+
+	ae.SetMaxTraversalDepth(n)
+	withPackageState(t, func() { ae.SetMaxTraversalDepth(0) })
+}
+
+func TestDeepChain_Truncated(t *testing.T) {
+	err := chain(deepChainLen)
+
+	outputs := map[string]string{
+		"Error": err.Error(),
+		"text":  ae.NewPrinter(ae.NoPrintColors()).Prints(err),
+		"json":  ae.NewPrinter(ae.PrintJSON()).Prints(err),
+	}
+	var logBuf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&logBuf, nil)).Error("failed", "err", err)
+	outputs["slog"] = logBuf.String()
+
+	for name, out := range outputs {
+		if !strings.Contains(out, "… deeper errors truncated") {
+			t.Errorf("%s output has no truncation marker", name)
+		}
+		if strings.Contains(out, fmt.Sprintf("level %d", deepChainLen)) {
+			t.Errorf("%s output contains the innermost error", name)
+		}
+		if len(out) > 64<<20 {
+			t.Errorf("%s output is %d bytes", name, len(out))
+		}
+	}
+
+	if got := ae.ExitCode(err); got != 1 {
+		t.Errorf("ExitCode = %d, want 1", got)
+	}
+	if !ae.IsRecoverable(err) {
+		t.Error("IsRecoverable = false, want true")
+	}
+	if ae.Fingerprint(err) == "" {
+		t.Error("Fingerprint is empty")
+	}
+}
+
+func TestDeepChain_BoundedMemory(t *testing.T) {
+	err := chain(deepChainLen)
+
+	for name, p := range map[string]*ae.Printer{
+		"text": ae.NewPrinter(ae.NoPrintColors()),
+		"json": ae.NewPrinter(ae.PrintJSON()),
+	} {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		p.Prints(err)
+		runtime.ReadMemStats(&after)
+
+		if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 512<<20 {
+			t.Errorf("%s printing allocated %d MiB", name, alloc>>20)
+		}
+	}
+}
+
+func TestSetMaxTraversalDepth(t *testing.T) {
+	setTraversalDepth(t, 2)
+	err := chain(4)
+
+	if got, want := err.Error(), "level 1: level 2: level 3: … deeper errors truncated"; got != want {
+		t.Errorf("Error = %q, want %q", got, want)
+	}
+
+	out := ae.NewPrinter(ae.NoPrintColors()).Prints(err)
+	if !strings.Contains(out, "level 3") || strings.Contains(out, "level 4") {
+		t.Errorf("printed two levels of causes other than expected:\n%s", out)
+	}
+	if !strings.Contains(out, "└─ … deeper errors truncated") {
+		t.Errorf("printed output has no truncation marker:\n%s", out)
+	}
+
+	inner := ae.New().ExitCode(3).Fatal().Msg("innermost")
+	deep := ae.Wrap("level 1", ae.Wrap("level 2", ae.Wrap("level 3", inner)))
+	if got := ae.ExitCode(deep); got != 1 {
+		t.Errorf("ExitCode = %d, want 1: the exit code lies below the limit", got)
+	}
+	if ae.IsRecoverable(deep) {
+		t.Error("IsRecoverable = true, want false: searches see the whole tree")
+	}
+
+	ae.SetMaxTraversalDepth(0)
+	if got := ae.ExitCode(deep); got != 3 {
+		t.Errorf("ExitCode after restoring the default = %d, want 3", got)
+	}
+}
@@ -0,0 +1,186 @@
+package ae
+
+import (
+	"reflect"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// AttrCauseTypes is the attribute SetCaptureCauseTypes stamps errors with.
+const AttrCauseTypes = "cause.types"
+
+// CauseTypesMode selects the causes whose types SetCaptureCauseTypes
+// records.
+type CauseTypesMode int32
+
+const (
+	// CauseTypesOff records no types, the default.
+	CauseTypesOff CauseTypesMode = iota
+	// CauseTypesDirect records the types of the direct causes.
+	CauseTypesDirect
+	// CauseTypesDeep records the types of every error in the cause trees of
+	// the causes.
+	CauseTypesDeep
+)
+
+// causeTypesMode is set with SetCaptureCauseTypes.
+var causeTypesMode atomic.Int32
+
+// SetCaptureCauseTypes makes every error finalized by a Builder with causes
+// carry the attribute cause.types, listing the distinct Go types of its
+// causes, sorted, so the type of a root cause such as *net.OpError survives
+// flattened messages. Type names are qualified by their package path, less
+// the path of the module the package belongs to: *net.OpError, *pq.Error,
+// *internal/db.Error. The types of this package, such as *Ae, are left out,
+// as they tell nothing. An attribute set on the error itself wins.
+func SetCaptureCauseTypes(mode CauseTypesMode) {
+	causeTypesMode.Store(int32(mode))
+}
+
+// stampCauseTypes adds the cause.types attribute under SetCaptureCauseTypes.
+func (b *Builder) stampCauseTypes() {
+	b.deepCauseTypes = false
+	mode := CauseTypesMode(causeTypesMode.Load())
+	if mode == CauseTypesOff || len(b.causes) == 0 {
+		return
+	}
+	if _, ok := b.attributes[AttrCauseTypes]; ok {
+		return
+	}
+
+	var names []string
+	add := func(name string) {
+		if name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	if mode == CauseTypesDeep {
+		deepCauseTypes(b.causes, add)
+		b.deepCauseTypes = true
+	} else {
+		for _, cause := range b.causes {
+			if cause != nil {
+				add(causeTypeName(reflect.TypeOf(cause)))
+			}
+		}
+	}
+
+	if len(names) > 0 {
+		slices.Sort(names)
+		b.setAttr(AttrCauseTypes, names)
+	}
+}
+
+// deepCauseTypes calls add with the type names of the errors in the cause
+// trees of causes. The trees of errors stamped under CauseTypesDeep aren't
+// walked again, their cause.types attribute is used instead, so wrapping
+// stays cheap for long chains.
+func deepCauseTypes(causes []error, add func(name string)) {
+	seen := make(map[error]struct{})
+	stack := slices.Clone(causes)
+	slices.Reverse(stack)
+	for len(stack) > 0 {
+		err := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if err == nil {
+			continue
+		}
+
+		if reflect.TypeOf(err).Comparable() {
+			if _, ok := seen[err]; ok {
+				continue
+			}
+			seen[err] = struct{}{}
+		}
+
+		//goland:noinspection GoTypeAssertionOnErrors
+		if a, ok := err.(*Ae); ok && a.deepCauseTypes {
+			types, _ := a.attributes[AttrCauseTypes].([]string)
+			for _, name := range types {
+				add(name)
+			}
+			continue
+		}
+
+		add(causeTypeName(reflect.TypeOf(err)))
+		causes := Causes(err)
+		for i := len(causes) - 1; i >= 0; i-- {
+			stack = append(stack, causes[i])
+		}
+	}
+}
+
+// ownPkgPath is the path of this package, whose types are not recorded.
+var ownPkgPath = reflect.TypeFor[Ae]().PkgPath()
+
+// causeTypeNames caches causeTypeName by type.
+var causeTypeNames sync.Map
+
+// causeTypeName names t for cause.types, or returns "" for the types of this
+// package.
+func causeTypeName(t reflect.Type) string {
+	if name, ok := causeTypeNames.Load(t); ok {
+		return name.(string)
+	}
+
+	elem, stars := t, ""
+	for elem.Kind() == reflect.Pointer && elem.Name() == "" {
+		elem, stars = elem.Elem(), stars+"*"
+	}
+
+	var name string
+	switch pkg := elem.PkgPath(); {
+	case pkg == ownPkgPath:
+	case pkg == "" || elem.Name() == "":
+		name = t.String()
+	default:
+		name = stars + trimModulePath(pkg, elem.String()) + "." + elem.Name()
+	}
+
+	causeTypeNames.Store(t, name)
+	return name
+}
+
+// trimModulePath returns pkg less the path of the module it belongs to, or
+// the package name from qualified, a type name such as "pq.Error", for the
+// root package of a module. Packages of unknown modules, such as the
+// standard library's, keep their path.
+func trimModulePath(pkg, qualified string) string {
+	// external test packages, e.g. "example.com/mod/db_test"
+	base, test := strings.CutSuffix(pkg, "_test")
+	for _, mod := range modulePaths() {
+		if base == mod {
+			name, _, _ := strings.Cut(qualified, ".")
+			return name
+		}
+		if rel, ok := strings.CutPrefix(base, mod+"/"); ok {
+			if test {
+				rel += "_test"
+			}
+			return rel
+		}
+	}
+
+	return pkg
+}
+
+// modulePaths returns the paths of the main module and its dependencies from
+// the build info, longest first so nested modules win.
+var modulePaths = sync.OnceValue(func() []string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+
+	paths := []string{info.Main.Path}
+	for _, dep := range info.Deps {
+		paths = append(paths, dep.Path)
+	}
+	paths = slices.DeleteFunc(paths, func(path string) bool { return path == "" })
+	slices.SortFunc(paths, func(a, b string) int { return len(b) - len(a) })
+
+	return paths
+})
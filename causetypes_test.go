@@ -0,0 +1,140 @@
+package ae_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"slices"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+// valueErr is an error implemented on a value receiver.
+type valueErr struct{}
+
+func (valueErr) Error() string { return "value" }
+
+func setCaptureCauseTypes(t *testing.T, mode ae.CauseTypesMode) {
+	t.Helper()
+
+	ae.SetCaptureCauseTypes(mode)
+	withPackageState(t, func() { ae.SetCaptureCauseTypes(ae.CauseTypesOff) })
+}
+
+// causeTypes returns the cause.types attribute of err.
+func causeTypes(err error) []string {
+	types, _ := ae.Attributes(err)[ae.AttrCauseTypes].([]string)
+	return types
+}
+
+func TestSetCaptureCauseTypes_DefaultOff(t *testing.T) {
+	if _, ok := ae.Attributes(ae.Wrap("dialing", &net.OpError{Op: "dial"}))[ae.AttrCauseTypes]; ok {
+		t.Error("cause.types stamped by default")
+	}
+}
+
+func TestSetCaptureCauseTypes_Direct(t *testing.T) {
+	setCaptureCauseTypes(t, ae.CauseTypesDirect)
+
+	err := ae.WrapMany("syncing",
+		&net.OpError{Op: "dial", Err: errors.New("refused")},
+		fmt.Errorf("reading: %w", errors.New("eof")),
+		errors.New("plain"),
+		errors.New("another plain"),
+		valueErr{},
+		ae.Msg("ae errors are left out"),
+		&fs.PathError{Op: "open", Path: "/etc/app.yaml", Err: fs.ErrNotExist},
+	)
+
+	want := []string{"*errors.errorString", "*fmt.wrapError", "*io/fs.PathError", "*net.OpError", "ae_test.valueErr"}
+	if got := causeTypes(err); !slices.Equal(got, want) {
+		t.Errorf("cause.types = %v, want %v", got, want)
+	}
+
+	// only the direct causes count
+	if got := causeTypes(ae.Wrap("outer", ae.Wrap("inner", &net.OpError{}))); got != nil {
+		t.Errorf("cause.types of an ae cause = %v, want none", got)
+	}
+}
+
+func TestSetCaptureCauseTypes_Deep(t *testing.T) {
+	setCaptureCauseTypes(t, ae.CauseTypesDeep)
+
+	err := ae.Wrap("outer", ae.Wrap("inner", fmt.Errorf("dialing: %w", &net.OpError{Op: "dial", Err: errors.New("refused")})))
+
+	want := []string{"*errors.errorString", "*fmt.wrapError", "*net.OpError"}
+	if got := causeTypes(err); !slices.Equal(got, want) {
+		t.Errorf("cause.types = %v, want %v", got, want)
+	}
+}
+
+func TestSetCaptureCauseTypes_NilSafe(t *testing.T) {
+	setCaptureCauseTypes(t, ae.CauseTypesDirect)
+
+	if got := causeTypes(ae.New().Cause(nil).Msg("no causes")); got != nil {
+		t.Errorf("cause.types = %v, want none", got)
+	}
+	if got := causeTypes(ae.New().Msg("no causes")); got != nil {
+		t.Errorf("cause.types = %v, want none", got)
+	}
+
+	var typedNil *net.OpError
+	if got := causeTypes(ae.New().Cause(typedNil).Msg("typed nil")); !slices.Equal(got, []string{"*net.OpError"}) {
+		t.Errorf("cause.types = %v, want the type of the typed nil", got)
+	}
+}
+
+func TestSetCaptureCauseTypes_DeepLongChain(t *testing.T) {
+	setCaptureCauseTypes(t, ae.CauseTypesDeep)
+
+	// each wrap reuses the types recorded on its cause instead of walking
+	// the chain again
+	err := ae.Wrap("root", &net.OpError{Op: "dial"})
+	for i := range 20000 {
+		if i%1000 == 0 {
+			err = fmt.Errorf("step %d: %w", i, err)
+		}
+		err = ae.Wrap("retrying", err)
+	}
+
+	want := []string{"*fmt.wrapError", "*net.OpError"}
+	if got := causeTypes(err); !slices.Equal(got, want) {
+		t.Errorf("cause.types = %v, want %v", got, want)
+	}
+}
+
+func TestSetCaptureCauseTypes_DeepAfterOff(t *testing.T) {
+	// stamped while capturing is off, so the tree is walked
+	inner := ae.Wrap("inner", &net.OpError{Op: "dial"})
+
+	setCaptureCauseTypes(t, ae.CauseTypesDeep)
+
+	want := []string{"*net.OpError"}
+	if got := causeTypes(ae.Wrap("outer", inner)); !slices.Equal(got, want) {
+		t.Errorf("cause.types = %v, want %v", got, want)
+	}
+}
+
+func TestSetCaptureCauseTypes_SharedBuilder(t *testing.T) {
+	setCaptureCauseTypes(t, ae.CauseTypesDirect)
+
+	proto := ae.New().Attr("user_id", 42)
+	e0 := proto.Msg("no cause")
+	_ = proto.Cause(io.EOF).Msg("with cause")
+
+	if _, ok := ae.Attributes(e0)[ae.AttrCauseTypes]; ok {
+		t.Error("finalized error gained cause.types from a later one")
+	}
+}
+
+func TestSetCaptureCauseTypes_ErrorAttrWins(t *testing.T) {
+	setCaptureCauseTypes(t, ae.CauseTypesDirect)
+
+	err := ae.New().Attr(ae.AttrCauseTypes, "custom").Cause(errors.New("x")).Msg("y")
+	if got := ae.Attributes(err)[ae.AttrCauseTypes]; got != "custom" {
+		t.Errorf("cause.types = %v, want the error's own", got)
+	}
+}
@@ -0,0 +1,27 @@
+package ae
+
+// BuilderCheckpoint is a snapshot of a Builder, taken with
+// Builder.Checkpoint and returned to with Builder.Restore.
+type BuilderCheckpoint struct {
+	b     Builder
+	taken bool
+}
+
+// Checkpoint returns a snapshot of b, e.g. before enriching it speculatively.
+// Tags, attributes, causes and other changes made to b or to builders
+// derived from it later don't affect the checkpoint.
+func (b Builder) Checkpoint() BuilderCheckpoint {
+	return BuilderCheckpoint{b: Builder(*Ae(b).Clone()), taken: true}
+}
+
+// Restore returns the builder saved in cp, discarding the changes made since
+// then. A checkpoint can be restored any number of times; the restored
+// builders don't share state. Restoring the zero BuilderCheckpoint returns
+// New().
+func (b Builder) Restore(cp BuilderCheckpoint) Builder {
+	if !cp.taken {
+		return New()
+	}
+
+	return Builder(*Ae(cp.b).Clone())
+}
@@ -0,0 +1,82 @@
+package ae_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+func TestBuilder_CheckpointRestore(t *testing.T) {
+	t.Parallel()
+
+	base := ae.New().Code("IMPORT").Tag("batch").Attr("file", "users.csv")
+	cp := base.Checkpoint()
+
+	// Mutating base itself, not a copy, must not reach the checkpoint.
+	base = base.Tag("partial").Attr("row", 42).Cause(errors.New("bad row"))
+
+	err := base.Restore(cp).Msg("import failed")
+
+	if tags := ae.Tags(err); !slices.Equal(tags, []string{"batch"}) {
+		t.Errorf("tags = %v, want [batch]", tags)
+	}
+	attrs := ae.Attributes(err)
+	if _, ok := attrs["row"]; ok || attrs["file"] != "users.csv" {
+		t.Errorf("attrs = %v, want only file", attrs)
+	}
+	if causes := ae.Causes(err); len(causes) != 0 {
+		t.Errorf("causes = %v, want none", causes)
+	}
+	if ae.Code(err) != "IMPORT" {
+		t.Errorf("code = %q, want IMPORT", ae.Code(err))
+	}
+}
+
+func TestBuilder_CheckpointInterleaved(t *testing.T) {
+	t.Parallel()
+
+	b := ae.New().Tag("a")
+	first := b.Checkpoint()
+	b = b.Tag("b").Cause(errors.New("cause-b"))
+	second := b.Checkpoint()
+	b = b.Tag("c").Cause(errors.New("cause-c"))
+
+	errSecond := b.Restore(second).Tag("x").Msg("second")
+	errFirst := b.Restore(first).Cause(errors.New("cause-x")).Msg("first")
+	errSecondAgain := b.Restore(second).Tag("y").Msg("second again")
+	errCurrent := b.Msg("current")
+
+	tests := []struct {
+		err    error
+		tags   []string
+		causes int
+	}{
+		{errSecond, []string{"a", "b", "x"}, 1},
+		{errFirst, []string{"a"}, 1},
+		{errSecondAgain, []string{"a", "b", "y"}, 1},
+		{errCurrent, []string{"a", "b", "c"}, 2},
+	}
+	for _, tt := range tests {
+		if tags := ae.Tags(tt.err); !slices.Equal(tags, tt.tags) {
+			t.Errorf("%v: tags = %v, want %v", tt.err, tags, tt.tags)
+		}
+		if causes := ae.Causes(tt.err); len(causes) != tt.causes {
+			t.Errorf("%v: causes = %v, want %d", tt.err, causes, tt.causes)
+		}
+	}
+}
+
+func TestBuilder_RestoreZeroCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Tag("a").Restore(ae.BuilderCheckpoint{}).Msg("fresh")
+
+	if tags := ae.Tags(err); len(tags) != 0 {
+		t.Errorf("tags = %v, want none", tags)
+	}
+	if !ae.IsRecoverable(err) {
+		t.Error("restored zero checkpoint is not recoverable, want New()")
+	}
+}
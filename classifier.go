@@ -0,0 +1,278 @@
+package ae
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Classifier classifies a foreign error, i.e. an error that is not *Ae. If it
+// recognizes err it returns ok and a function applying the classification to
+// a builder, typically setting a code, tags or recoverability.
+type Classifier func(err error) (apply func(Builder) Builder, ok bool)
+
+// classifier is a registered classifier; the pointer identifies it for
+// removal.
+type classifier struct {
+	name string
+	fn   Classifier
+}
+
+var (
+	classifiersMu sync.RWMutex
+	classifiers   []*classifier
+
+	// classificationOff is set with SetClassification.
+	classificationOff atomic.Bool
+)
+
+func init() {
+	RegisterClassifier("context.canceled", ClassifyContextCanceled)
+	RegisterClassifier("context.deadline", ClassifyContextDeadline)
+	RegisterClassifier("net.timeout", ClassifyNetTimeout)
+}
+
+// RegisterClassifier appends fn to the chain of classifiers under name.
+// From classifies the error it is given, Convert the error it converts, and
+// Wrap, Wrapf, WrapC, WrapCf, WrapMany and ReWrap each foreign cause; the
+// direct causes of these errors are classified as well. For example:
+//
+//	ae.RegisterClassifier("pg.unique_violation", func(err error) (func(ae.Builder) ae.Builder, bool) {
+//		var pgErr *pgconn.PgError
+//		if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+//			return nil, false
+//		}
+//		return func(b ae.Builder) ae.Builder {
+//			return b.Code("DB_UNIQUE_VIOLATION").Tag("db").Fatal()
+//		}, true
+//	})
+//
+// Classifiers run when the error is finalized, in chain order, and every
+// matching classifier is applied. Their results are additive: tags are added,
+// and the code, exit code, hint, user messages, fingerprint, attributes and
+// recoverability they set only fill fields the error doesn't have yet, so
+// fields set explicitly on the builder and classifications earlier in the
+// chain win. Other fields are ignored.
+//
+// The chain starts with the built-in classifiers "context.canceled"
+// (ClassifyContextCanceled), "context.deadline" (ClassifyContextDeadline) and
+// "net.timeout" (ClassifyNetTimeout). Registering a name again replaces the
+// classifier in place. Registration is meant for init time; the returned
+// function unregisters the classifier. Builder.NoClassify and
+// SetClassification turn classification off.
+func RegisterClassifier(name string, fn Classifier) (remove func()) {
+	if fn == nil {
+		return func() {}
+	}
+
+	c := &classifier{name: name, fn: fn}
+
+	classifiersMu.Lock()
+	defer classifiersMu.Unlock()
+
+	// the slice is replaced rather than modified, as classification reads
+	// it after unlocking
+	if i := slices.IndexFunc(classifiers, func(other *classifier) bool { return other.name == name }); i >= 0 {
+		classifiers = slices.Clone(classifiers)
+		classifiers[i] = c
+	} else {
+		classifiers = append(classifiers, c)
+	}
+
+	return func() {
+		classifiersMu.Lock()
+		defer classifiersMu.Unlock()
+
+		for i, other := range classifiers {
+			if other == c {
+				classifiers = append(classifiers[:i:i], classifiers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Classifiers returns the names of the registered classifiers, in chain
+// order.
+func Classifiers() []string {
+	classifiersMu.RLock()
+	defer classifiersMu.RUnlock()
+
+	names := make([]string, len(classifiers))
+	for i, c := range classifiers {
+		names[i] = c.name
+	}
+
+	return names
+}
+
+// SetClassification enables or disables the classifiers registered with
+// RegisterClassifier for every error. Enabled by default.
+func SetClassification(enabled bool) {
+	classificationOff.Store(!enabled)
+}
+
+// NoClassify keeps the classifiers registered with RegisterClassifier from
+// classifying the foreign errors of this error:
+//
+//	ae.From(err).NoClassify().Msg("query users")
+func (b Builder) NoClassify() Builder {
+	b.noClassify = true
+	return b
+}
+
+// ClassifySQLNoRows is a classifier for use with RegisterClassifier that tags
+// errors matching sql.ErrNoRows with "db" and "not-found".
+func ClassifySQLNoRows(err error) (func(Builder) Builder, bool) {
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false
+	}
+
+	return func(b Builder) Builder {
+		return b.Tags("db", "not-found")
+	}, true
+}
+
+// ClassifyContextDeadline is a built-in classifier that tags errors matching
+// context.DeadlineExceeded with "timeout" and marks them recoverable, since
+// the operation may succeed when retried.
+func ClassifyContextDeadline(err error) (func(Builder) Builder, bool) {
+	if !errors.Is(err, context.DeadlineExceeded) {
+		return nil, false
+	}
+
+	return func(b Builder) Builder {
+		return b.Tag("timeout").Recoverable(true)
+	}, true
+}
+
+// ClassifyContextCanceled is a built-in classifier that tags errors matching
+// context.Canceled with "canceled".
+func ClassifyContextCanceled(err error) (func(Builder) Builder, bool) {
+	if !errors.Is(err, context.Canceled) {
+		return nil, false
+	}
+
+	return func(b Builder) Builder {
+		return b.Tag("canceled")
+	}, true
+}
+
+// ClassifyNetTimeout is a built-in classifier that tags errors implementing
+// net.Error and reporting a timeout, such as an exceeded I/O deadline or
+// ETIMEDOUT, with "timeout" and marks them recoverable. Errors matching
+// context.DeadlineExceeded, which implements net.Error as well, are left to
+// ClassifyContextDeadline.
+func ClassifyNetTimeout(err error) (func(Builder) Builder, bool) {
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() || errors.Is(err, context.DeadlineExceeded) {
+		return nil, false
+	}
+
+	return func(b Builder) Builder {
+		return b.Tag("timeout").Recoverable(true)
+	}, true
+}
+
+// classify marks the foreign errors in errs for classification when b is
+// finalized.
+func (b Builder) classify(errs ...error) Builder {
+	for _, err := range errs {
+		//goland:noinspection GoTypeAssertionOnErrors
+		if _, ok := err.(*Ae); ok || err == nil {
+			continue
+		}
+		b.classifyErrs = append(b.classifyErrs[:len(b.classifyErrs):len(b.classifyErrs)], err)
+	}
+
+	return b
+}
+
+// applyClassifiers applies the registered classifiers to the errors marked
+// by classify and their direct causes.
+func (b *Builder) applyClassifiers() {
+	errs := b.classifyErrs
+	b.classifyErrs = nil
+	if len(errs) == 0 || b.noClassify || classificationOff.Load() {
+		return
+	}
+
+	classifiersMu.RLock()
+	registered := classifiers
+	classifiersMu.RUnlock()
+	if len(registered) == 0 {
+		return
+	}
+
+	for _, err := range errs {
+		for _, target := range append([]error{err}, directCauses(err)...) {
+			//goland:noinspection GoTypeAssertionOnErrors
+			if _, ok := target.(*Ae); ok || target == nil {
+				continue
+			}
+			for _, c := range registered {
+				if apply, ok := c.fn(target); ok && apply != nil {
+					b.mergeClassified(apply(New()))
+				}
+			}
+		}
+	}
+}
+
+// directCauses returns the errors err wraps.
+func directCauses(err error) []error {
+	switch x := err.(type) {
+	case interface{ Unwrap() []error }:
+		return x.Unwrap()
+	case interface{ Unwrap() error }:
+		if cause := x.Unwrap(); cause != nil {
+			return []error{cause}
+		}
+	}
+
+	return nil
+}
+
+// mergeClassified adds the fields a classifier set on c, a new builder, to
+// the fields of b that are unset.
+func (b *Builder) mergeClassified(c Builder) {
+	if b.code == "" {
+		b.code = c.code
+	}
+	if b.exitCode == 0 {
+		b.exitCode = c.exitCode
+	}
+	if b.hint == "" {
+		b.hint = c.hint
+	}
+	if b.userMsg == "" {
+		b.userMsg = c.userMsg
+	}
+	if b.fingerprint == "" {
+		b.fingerprint = c.fingerprint
+	}
+	for locale, msg := range c.userMsgs {
+		if _, ok := b.userMsgs[locale]; !ok {
+			if b.userMsgs == nil {
+				b.userMsgs = make(map[string]string)
+			}
+			b.userMsgs[locale] = msg
+		}
+	}
+	for tag := range c.tags {
+		b.addTag(tag)
+	}
+	for key, value := range c.attributes {
+		if _, ok := b.attributes[key]; !ok {
+			b.setAttr(key, value)
+		}
+	}
+	if !b.recoverableSet && c.recoverableSet {
+		b.recoverable = c.recoverable
+		b.recoverableSet = true
+	}
+}
@@ -0,0 +1,291 @@
+package ae_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+// uniqueViolation mimics a driver-specific error carrying a vendor code.
+type uniqueViolation struct{ constraint string }
+
+func (u uniqueViolation) Error() string {
+	return fmt.Sprintf("duplicate key value violates unique constraint %q", u.constraint)
+}
+
+func classifyUniqueViolation(err error) (func(ae.Builder) ae.Builder, bool) {
+	var uv uniqueViolation
+	if !errors.As(err, &uv) {
+		return nil, false
+	}
+
+	return func(b ae.Builder) ae.Builder {
+		return b.Code("DB_UNIQUE_VIOLATION").Tag("db").Fatal()
+	}, true
+}
+
+func TestRegisterClassifier_AppliesToWrapAndFrom(t *testing.T) {
+	t.Parallel()
+
+	remove := ae.RegisterClassifier(t.Name(), classifyUniqueViolation)
+	defer remove()
+
+	cause := uniqueViolation{constraint: "users_email_key"}
+	for name, err := range map[string]error{
+		"Wrap":     ae.Wrap("query users", cause),
+		"Wrapf":    ae.Wrapf("query %s", cause, "users"),
+		"WrapC":    ae.WrapC(context.Background(), "query users", cause),
+		"WrapMany": ae.WrapMany("query users", nil, fmt.Errorf("exec: %w", cause)),
+		"From":     ae.From(cause).Msg("query users"),
+	} {
+		if got := ae.Code(err); got != "DB_UNIQUE_VIOLATION" {
+			t.Errorf("%s: code = %q, want DB_UNIQUE_VIOLATION", name, got)
+		}
+		if tags := ae.Tags(err); !slices.Contains(tags, "db") {
+			t.Errorf("%s: tags = %v, want to contain db", name, tags)
+		}
+		if ae.IsRecoverable(err) {
+			t.Errorf("%s: error is recoverable, want fatal", name)
+		}
+	}
+}
+
+func TestRegisterClassifier_NonMatchingErrorUntouched(t *testing.T) {
+	t.Parallel()
+
+	remove := ae.RegisterClassifier(t.Name(), classifyUniqueViolation)
+	defer remove()
+
+	err := ae.Wrap("query users", errors.New("connection reset"))
+	if got := ae.Code(err); got != "" {
+		t.Errorf("code = %q, want empty", got)
+	}
+	if tags := ae.Tags(err); len(tags) != 0 {
+		t.Errorf("tags = %v, want none", tags)
+	}
+}
+
+func TestRegisterClassifier_SkipsAeCauses(t *testing.T) {
+	t.Parallel()
+
+	remove := ae.RegisterClassifier(t.Name(), classifyUniqueViolation)
+	defer remove()
+
+	// An *Ae wrapping a matching error is not foreign; its metadata is its own.
+	inner := ae.Wrap("insert", uniqueViolation{constraint: "k"})
+	outer := ae.Wrap("create user", inner)
+	if got := ae.Code(outer); got != "" {
+		t.Errorf("outer code = %q, want empty (only the foreign cause is classified)", got)
+	}
+}
+
+// Other tests register the same classifier in parallel, so this one does not.
+func TestRegisterClassifier_RemoveStopsClassification(t *testing.T) {
+	remove := ae.RegisterClassifier(t.Name(), classifyUniqueViolation)
+	remove()
+
+	if got := ae.Code(ae.Wrap("x", uniqueViolation{constraint: "k"})); got != "" {
+		t.Errorf("code = %q after remove, want empty", got)
+	}
+}
+
+func TestClassifySQLNoRows(t *testing.T) {
+	remove := ae.RegisterClassifier(t.Name(), ae.ClassifySQLNoRows)
+	defer remove()
+
+	err := ae.Wrap("load user", fmt.Errorf("scan: %w", sql.ErrNoRows))
+	tags := ae.Tags(err)
+	if !slices.Contains(tags, "db") || !slices.Contains(tags, "not-found") {
+		t.Errorf("tags = %v, want db and not-found", tags)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Error("errors.Is(err, sql.ErrNoRows) = false")
+	}
+}
+
+func TestClassifyContextDeadline(t *testing.T) {
+	remove := ae.RegisterClassifier(t.Name(), ae.ClassifyContextDeadline)
+	defer remove()
+
+	err := ae.Wrap("call upstream", context.DeadlineExceeded)
+	if tags := ae.Tags(err); !slices.Contains(tags, "timeout") {
+		t.Errorf("tags = %v, want timeout", tags)
+	}
+	if !ae.IsRecoverable(err) {
+		t.Error("deadline error is not recoverable")
+	}
+
+	if _, matched := ae.ClassifyContextDeadline(context.Canceled); matched {
+		t.Error("ClassifyContextDeadline matched context.Canceled")
+	}
+}
+
+// classifyCode returns a classifier setting code on errors of type T.
+func classifyCode[T error](code string) ae.Classifier {
+	return func(err error) (func(ae.Builder) ae.Builder, bool) {
+		if _, ok := err.(T); !ok {
+			return nil, false
+		}
+		return func(b ae.Builder) ae.Builder {
+			return b.Code(code).Tag(strings.ToLower(code))
+		}, true
+	}
+}
+
+// chainErr is matched by the classifiers of the chain tests.
+type chainErr struct{}
+
+func (chainErr) Error() string { return "chain" }
+
+func TestRegisterClassifier_ChainOrder(t *testing.T) {
+	withPackageState(t, ae.RegisterClassifier("chain.first", classifyCode[chainErr]("FIRST")))
+	withPackageState(t, ae.RegisterClassifier("chain.second", classifyCode[chainErr]("SECOND")))
+
+	names := ae.Classifiers()
+	want := []string{"context.canceled", "context.deadline", "net.timeout", "chain.first", "chain.second"}
+	if !slices.Equal(names, want) {
+		t.Errorf("Classifiers() = %v, want %v", names, want)
+	}
+
+	// every match is applied, but the first code wins
+	err := ae.Wrap("x", chainErr{})
+	if got := ae.Code(err); got != "FIRST" {
+		t.Errorf("code = %q, want FIRST", got)
+	}
+	if tags := ae.Tags(err); !slices.Equal(tags, []string{"first", "second"}) {
+		t.Errorf("tags = %v, want [first second]", tags)
+	}
+
+	// registering a name again replaces it in place
+	withPackageState(t, ae.RegisterClassifier("chain.first", classifyCode[chainErr]("THIRD")))
+	if names := ae.Classifiers(); !slices.Equal(names, want) {
+		t.Errorf("Classifiers() after replacing = %v, want %v", names, want)
+	}
+	if got := ae.Code(ae.Wrap("x", chainErr{})); got != "THIRD" {
+		t.Errorf("code after replacing = %q, want THIRD", got)
+	}
+}
+
+func TestRegisterClassifier_ExplicitFieldsWin(t *testing.T) {
+	withPackageState(t, ae.RegisterClassifier(t.Name(), classifyUniqueViolation))
+
+	cause := uniqueViolation{constraint: "k"}
+	err := ae.From(cause).Code("USER_EXISTS").Recoverable(true).Msg("create user")
+	if got := ae.Code(err); got != "USER_EXISTS" {
+		t.Errorf("code = %q, want the explicit USER_EXISTS", got)
+	}
+	if !ae.IsRecoverable(err) {
+		t.Error("explicitly recoverable error marked fatal by the classifier")
+	}
+	if tags := ae.Tags(err); !slices.Contains(tags, "db") {
+		t.Errorf("tags = %v, want the classifier's db added", tags)
+	}
+}
+
+// codedFailure is a foreign error carrying its own code and recoverability.
+type codedFailure struct{}
+
+func (codedFailure) Error() string            { return "upstream failed" }
+func (codedFailure) ErrorCode() string        { return "UPSTREAM_FAILED" }
+func (codedFailure) ErrorIsRecoverable() bool { return false }
+
+func TestRegisterClassifier_WrapKeepKeptFieldsWin(t *testing.T) {
+	withPackageState(t, ae.RegisterClassifier(t.Name(), func(err error) (func(ae.Builder) ae.Builder, bool) {
+		if !errors.As(err, new(codedFailure)) {
+			return nil, false
+		}
+		return func(b ae.Builder) ae.Builder {
+			return b.Code("CLASSIFIED").Tag("classified").Recoverable(true)
+		}, true
+	}))
+
+	err := ae.WrapKeep("calling upstream", codedFailure{})
+	if got := ae.Code(err); got != "UPSTREAM_FAILED" {
+		t.Errorf("code = %q, want the kept UPSTREAM_FAILED", got)
+	}
+	if err.(ae.ErrorRecoverable).ErrorIsRecoverable() {
+		t.Error("wrapper marked recoverable by the classifier, want the kept fatal")
+	}
+	if tags := ae.Tags(err); !slices.Contains(tags, "classified") {
+		t.Errorf("tags = %v, want the classifier's classified added", tags)
+	}
+}
+
+func TestRegisterClassifier_DirectCauses(t *testing.T) {
+	withPackageState(t, ae.RegisterClassifier(t.Name(), classifyCode[chainErr]("DIRECT")))
+
+	if got := ae.Code(ae.Wrap("x", fmt.Errorf("exec: %w", chainErr{}))); got != "DIRECT" {
+		t.Errorf("code = %q for a direct cause, want DIRECT", got)
+	}
+	if got := ae.Code(ae.Wrap("x", errors.Join(errors.New("a"), chainErr{}))); got != "DIRECT" {
+		t.Errorf("code = %q for a joined cause, want DIRECT", got)
+	}
+	if got := ae.Code(ae.Wrap("x", fmt.Errorf("a: %w", fmt.Errorf("b: %w", chainErr{})))); got != "" {
+		t.Errorf("code = %q for an indirect cause, want empty", got)
+	}
+}
+
+func TestNoClassify(t *testing.T) {
+	withPackageState(t, ae.RegisterClassifier(t.Name(), classifyUniqueViolation))
+
+	cause := uniqueViolation{constraint: "k"}
+	if got := ae.Code(ae.From(cause).NoClassify().Msg("x")); got != "" {
+		t.Errorf("code = %q with NoClassify, want empty", got)
+	}
+	if got := ae.Code(ae.From(cause).Msg("x")); got != "DB_UNIQUE_VIOLATION" {
+		t.Errorf("code = %q without NoClassify, want DB_UNIQUE_VIOLATION", got)
+	}
+}
+
+func TestSetClassification(t *testing.T) {
+	withPackageState(t, ae.RegisterClassifier(t.Name(), classifyUniqueViolation))
+
+	ae.SetClassification(false)
+	withPackageState(t, func() { ae.SetClassification(true) })
+
+	if got := ae.Code(ae.Wrap("x", uniqueViolation{constraint: "k"})); got != "" {
+		t.Errorf("code = %q with classification disabled, want empty", got)
+	}
+	if tags := ae.Tags(ae.Wrap("x", context.Canceled)); len(tags) != 0 {
+		t.Errorf("tags = %v with classification disabled, want none", tags)
+	}
+}
+
+func TestBuiltinClassifiers(t *testing.T) {
+	netTimeout := &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}
+
+	for _, tc := range []struct {
+		name        string
+		err         error
+		tags        []string
+		recoverable bool
+	}{
+		{"canceled", fmt.Errorf("query: %w", context.Canceled), []string{"canceled"}, true},
+		{"deadline", context.DeadlineExceeded, []string{"timeout"}, true},
+		{"net timeout", netTimeout, []string{"timeout"}, true},
+		{"net error", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("refused")}, nil, true},
+	} {
+		err := ae.Wrap("call upstream", tc.err)
+		if tags := ae.Tags(err); !slices.Equal(tags, tc.tags) {
+			t.Errorf("%s: tags = %v, want %v", tc.name, tags, tc.tags)
+		}
+		if got := ae.IsRecoverable(err); got != tc.recoverable {
+			t.Errorf("%s: recoverable = %t, want %t", tc.name, got, tc.recoverable)
+		}
+	}
+
+	if _, ok := ae.ClassifyNetTimeout(context.DeadlineExceeded); ok {
+		t.Error("ClassifyNetTimeout matched context.DeadlineExceeded")
+	}
+	if _, ok := ae.ClassifyContextCanceled(context.DeadlineExceeded); ok {
+		t.Error("ClassifyContextCanceled matched context.DeadlineExceeded")
+	}
+}
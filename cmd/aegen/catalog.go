@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.aledante.io/ae"
+	"gopkg.in/yaml.v3"
+)
+
+// catalog is a parsed error catalog.
+type catalog struct {
+	// Package is the package of the generated file, unless overridden on the
+	// command line.
+	Package string `yaml:"package"`
+	// Errors holds the entries in the order of the file until sorted by
+	// parseCatalog.
+	Errors []*entry `yaml:"-"`
+}
+
+// entry is an error of the catalog.
+type entry struct {
+	Code        string `yaml:"code"`
+	Message     string `yaml:"message"`
+	Doc         string `yaml:"doc"`
+	Hint        string `yaml:"hint"`
+	UserMessage string `yaml:"user_message"`
+	ExitCode    *int   `yaml:"exit_code"`
+	Kind        string `yaml:"kind"`
+
+	// line is the line of the entry in the catalog file.
+	line int
+}
+
+// name returns the Go identifier derived from the code of e, e.g.
+// "UserNotFound" for USER_NOT_FOUND.
+func (e *entry) name() string {
+	var sb strings.Builder
+	for _, part := range strings.Split(e.Code, "_") {
+		if part == "" {
+			continue
+		}
+		sb.WriteString(part[:1])
+		sb.WriteString(strings.ToLower(part[1:]))
+	}
+
+	return sb.String()
+}
+
+// message returns the message of e, derived from its code if the catalog
+// has none, e.g. "user not found" for USER_NOT_FOUND.
+func (e *entry) message() string {
+	if e.Message != "" {
+		return e.Message
+	}
+
+	return strings.ToLower(strings.ReplaceAll(e.Code, "_", " "))
+}
+
+// maxExitCode is the highest exit status a process can report.
+const maxExitCode = 255
+
+// parseCatalog parses the YAML catalog data read from file and validates its
+// entries, which it returns sorted by code. All problems found are reported
+// at once, each prefixed with the file and line.
+func parseCatalog(file string, data []byte) (*catalog, error) {
+	var raw struct {
+		Package string      `yaml:"package"`
+		Errors  []yaml.Node `yaml:"errors"`
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	c := &catalog{Package: raw.Package}
+	var problems []error
+	for _, node := range raw.Errors {
+		e := &entry{line: node.Line}
+		if err := node.Decode(e); err != nil {
+			problems = append(problems, fmt.Errorf("%s:%d: %w", file, node.Line, err))
+			continue
+		}
+		c.Errors = append(c.Errors, e)
+	}
+	problems = append(problems, c.validate(file)...)
+	if len(problems) > 0 {
+		return nil, errors.Join(problems...)
+	}
+
+	slices.SortFunc(c.Errors, func(a, b *entry) int { return strings.Compare(a.Code, b.Code) })
+
+	return c, nil
+}
+
+// validate checks the entries of c, returning an error for every problem.
+func (c *catalog) validate(file string) []error {
+	var problems []error
+	report := func(e *entry, format string, args ...any) {
+		problems = append(problems, fmt.Errorf("%s:%d: %s", file, e.line, fmt.Sprintf(format, args...)))
+	}
+
+	codes := make(map[string]*entry)
+	names := make(map[string]*entry)
+	for _, e := range c.Errors {
+		switch {
+		case e.Code == "":
+			report(e, "missing code")
+			continue
+		case !ae.DefaultCodePattern.MatchString(e.Code):
+			report(e, "code %q doesn't match %s", e.Code, ae.DefaultCodePattern)
+			continue
+		}
+
+		if first, ok := codes[e.Code]; ok {
+			report(e, "duplicate code %s, first defined on line %d", e.Code, first.line)
+			continue
+		}
+		codes[e.Code] = e
+
+		if first, ok := names[e.name()]; ok {
+			report(e, "code %s maps to the same name Err%s as %s on line %d", e.Code, e.name(), first.Code, first.line)
+		} else {
+			names[e.name()] = e
+		}
+
+		if e.ExitCode != nil && (*e.ExitCode <= 0 || *e.ExitCode > maxExitCode) {
+			report(e, "exit code %d of %s is not in 1..%d", *e.ExitCode, e.Code, maxExitCode)
+		}
+		if e.Kind != "" && !ae.DefaultTagPattern.MatchString(e.Kind) {
+			report(e, "kind %q of %s doesn't match %s", e.Kind, e.Code, ae.DefaultTagPattern)
+		}
+	}
+
+	return problems
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseCatalog_Sorted(t *testing.T) {
+	t.Parallel()
+
+	c := parseFile(t, "testdata/catalog.yaml")
+
+	var codes []string
+	for _, e := range c.Errors {
+		codes = append(codes, e.Code)
+	}
+	if got := strings.Join(codes, " "); got != "CONFIG_INVALID RATE_LIMITED USER_NOT_FOUND" {
+		t.Errorf("codes = %s", got)
+	}
+	if c.Package != "apperr" {
+		t.Errorf("package = %q", c.Package)
+	}
+}
+
+func TestParseCatalog_Invalid(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile("testdata/invalid.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parseCatalog("invalid.yaml", data)
+	if err == nil {
+		t.Fatal("parseCatalog accepted an invalid catalog")
+	}
+
+	problems := strings.Split(err.Error(), "\n")
+	want := []string{
+		"invalid.yaml:15: yaml: unmarshal errors:",
+		"  line 16: cannot unmarshal !!str `four` into int",
+		"invalid.yaml:4: exit code 256 of CONFIG_INVALID is not in 1..255",
+		"invalid.yaml:6: duplicate code USER_NOT_FOUND, first defined on line 2",
+		"invalid.yaml:7: exit code 0 of SHUTDOWN is not in 1..255",
+		`invalid.yaml:9: code "lower_case" doesn't match ^[A-Z][A-Z0-9_]*$`,
+		"invalid.yaml:10: exit code -1 of RATE__LIMITED is not in 1..255",
+		"invalid.yaml:12: code RATE_LIMITED maps to the same name ErrRateLimited as RATE__LIMITED on line 10",
+		`invalid.yaml:12: kind "Not Found" of RATE_LIMITED doesn't match ` + `^[a-z0-9][a-z0-9_.-]*(/[a-z0-9][a-z0-9_.-]*)*$`,
+		"invalid.yaml:14: missing code",
+	}
+	if strings.Join(problems, "\n") != strings.Join(want, "\n") {
+		t.Errorf("problems:\n%s\nwant:\n%s", err, strings.Join(want, "\n"))
+	}
+}
+
+func TestParseCatalog_Malformed(t *testing.T) {
+	t.Parallel()
+
+	_, err := parseCatalog("broken.yaml", []byte("errors: [code: X"))
+	if err == nil || !strings.HasPrefix(err.Error(), "broken.yaml: yaml:") {
+		t.Errorf("err = %v", err)
+	}
+}
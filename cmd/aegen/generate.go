@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// options configures the generated file.
+type options struct {
+	// Package is the package of the file.
+	Package string
+	// Source is the name of the catalog, as mentioned in the header.
+	Source string
+	// Type is the name of the generated code type.
+	Type string
+}
+
+var fileTemplate = template.Must(template.New("file").Funcs(template.FuncMap{
+	"quote":   strconv.Quote,
+	"comment": comment,
+}).Parse(`// Code generated by aegen from {{.Source}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import "go.aledante.io/ae"
+
+// {{.Type}} is an error code of the catalog {{.Source}}. The errors declared
+// with a code match every error with the same code under errors.Is.
+type {{.Type}} string
+
+// String returns the code as set on the errors with it.
+func (c {{.Type}}) String() string {
+	return string(c)
+}
+
+// Codes of the catalog.
+const (
+{{- range .Errors}}
+	{{$.Type}}{{.Name}} {{$.Type}} = {{quote .Code}}
+{{- end}}
+)
+
+{{- range .Errors}}
+
+// Err{{.Name}} is the {{.Code}} error: {{.Message}}.
+{{- if .Doc}}
+//
+{{comment .Doc}}
+{{- end}}
+{{- if .ExitCode}}
+//
+// Programs exit with {{.ExitCode}} for it, see ae.ExitCode.
+{{- end}}
+var Err{{.Name}} = ae.New().
+	Code({{$.Type}}{{.Name}}.String()).
+{{- if .Kind}}
+	Tag({{quote .Kind}}).
+{{- end}}
+{{- if .Hint}}
+	Hint({{quote .Hint}}).
+{{- end}}
+{{- if .UserMessage}}
+	UserMsg({{quote .Message}}, {{quote .UserMessage}})
+{{- else}}
+	Msg({{quote .Message}})
+{{- end}}
+{{- end}}
+
+
+func init() {
+{{- range .Errors}}
+	ae.RegisterCode(Err{{.Name}})
+{{- end}}
+{{- range .ExitCodes}}
+	ae.RegisterExitCodeForCode({{$.Type}}{{.Name}}.String(), {{.ExitCode}})
+{{- end}}
+}
+`))
+
+// templateEntry is an entry as seen by fileTemplate.
+type templateEntry struct {
+	Name        string
+	Code        string
+	Message     string
+	Doc         string
+	Hint        string
+	UserMessage string
+	Kind        string
+	ExitCode    int
+}
+
+// generate renders the Go file declaring the errors of c, which parseCatalog
+// has sorted, so the same catalog always yields the same file.
+func generate(c *catalog, opts options) ([]byte, error) {
+	data := struct {
+		options
+		Errors, ExitCodes []templateEntry
+	}{options: opts}
+
+	for _, e := range c.Errors {
+		te := templateEntry{
+			Name:        e.name(),
+			Code:        e.Code,
+			Message:     e.message(),
+			Doc:         strings.TrimSpace(e.Doc),
+			Hint:        e.Hint,
+			UserMessage: e.UserMessage,
+			Kind:        e.Kind,
+		}
+		if e.ExitCode != nil {
+			te.ExitCode = *e.ExitCode
+			data.ExitCodes = append(data.ExitCodes, te)
+		}
+		data.Errors = append(data.Errors, te)
+	}
+
+	var buf bytes.Buffer
+	if err := fileTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+
+	return src, nil
+}
+
+// comment renders text as the lines of a doc comment.
+func comment(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("// "+strings.TrimSpace(line), " ")
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// update rewrites testdata/catalog_gen.go.golden instead of comparing with it.
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+// parseFile parses the catalog at path, failing t on errors.
+func parseFile(t *testing.T, path string) *catalog {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := parseCatalog(path, data)
+	if err != nil {
+		t.Fatalf("parseCatalog: %v", err)
+	}
+
+	return c
+}
+
+func TestGenerate_Golden(t *testing.T) {
+	t.Parallel()
+
+	got, err := generate(parseFile(t, "testdata/catalog.yaml"), options{Package: "apperr", Source: "catalog.yaml", Type: "Code"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := "testdata/catalog_gen.go.golden"
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("generated file differs from %s; run the test with -update to accept it\ngot:\n%s", golden, got)
+	}
+}
+
+func TestGenerate_Deterministic(t *testing.T) {
+	t.Parallel()
+
+	sorted := "errors:\n  - code: A_FIRST\n    exit_code: 3\n  - code: B_SECOND\n    exit_code: 2\n  - code: C_THIRD\n"
+	shuffled := "errors:\n  - code: C_THIRD\n  - code: A_FIRST\n    exit_code: 3\n  - code: B_SECOND\n    exit_code: 2\n"
+
+	var outputs [][]byte
+	for _, catalogData := range []string{sorted, shuffled} {
+		c, err := parseCatalog("catalog.yaml", []byte(catalogData))
+		if err != nil {
+			t.Fatal(err)
+		}
+		src, err := generate(c, options{Package: "apperr", Source: "catalog.yaml", Type: "Code"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs = append(outputs, src)
+	}
+
+	if !bytes.Equal(outputs[0], outputs[1]) {
+		t.Errorf("output depends on the order of the catalog:\n%s\n---\n%s", outputs[0], outputs[1])
+	}
+	if a, b := bytes.Index(outputs[0], []byte("var ErrAFirst =")), bytes.Index(outputs[0], []byte("var ErrCThird =")); a < 0 || b < a {
+		t.Errorf("errors are not sorted by code:\n%s", outputs[0])
+	}
+}
+
+func TestGenerate_Minimal(t *testing.T) {
+	t.Parallel()
+
+	c, err := parseCatalog("catalog.yaml", []byte("errors:\n  - code: DISK_FULL\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate(c, options{Package: "apperr", Source: "catalog.yaml", Type: "Kind"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := string(src)
+	for _, want := range []string{
+		`KindDiskFull Kind = "DISK_FULL"`,
+		"ae.RegisterCode(ErrDiskFull)",
+		"var ErrDiskFull = ae.New().\n\tCode(KindDiskFull.String()).\n\tMsg(\"disk full\")",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("generated file lacks %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "RegisterExitCodeForCode") {
+		t.Errorf("generated file registers exit codes without any:\n%s", got)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	catalogPath := filepath.Join(dir, "errors.yaml")
+	if err := os.WriteFile(catalogPath, []byte("errors:\n  - code: DISK_FULL\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := run([]string{"-catalog", catalogPath}); err == nil || !strings.Contains(err.Error(), "no package given") {
+		t.Errorf("run without a package: err = %v", err)
+	}
+
+	t.Setenv("GOPACKAGE", "storage")
+	if err := run([]string{"-catalog", catalogPath}); err != nil {
+		t.Fatal(err)
+	}
+	src, err := os.ReadFile(filepath.Join(dir, "errors_gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "package storage\n") {
+		t.Errorf("generated file:\n%s", src)
+	}
+}
@@ -0,0 +1,101 @@
+// Command aegen generates Go declarations for a catalog of ae errors kept in
+// a YAML file, so the codes, hints, user messages and exit codes documented
+// in one place are the ones the program uses. It is meant for go:generate:
+//
+//	//go:generate go run go.aledante.io/ae/cmd/aegen -catalog errors.yaml
+//
+// The catalog lists the errors with their code and optional message, doc,
+// hint, user message, exit code and kind:
+//
+//	package: apperr
+//	errors:
+//	  - code: USER_NOT_FOUND
+//	    message: user not found
+//	    doc: ErrUserNotFound is returned when no user has the given ID.
+//	    hint: check the user ID
+//	    user_message: The user does not exist.
+//	    exit_code: 4
+//	    kind: not-found
+//
+// For every entry the generated file declares a constant of the code type
+// (CodeUserNotFound) and a sentinel error (ErrUserNotFound) with the code,
+// the kind as tag, the hint and the messages; the message defaults to the
+// code in lower case. An *ae.Ae matches every error with its code under
+// errors.Is, so errors derived with ae.From(ErrUserNotFound) or built with
+// the code match the sentinel. An init function registers the errors with
+// ae.RegisterCode, so ae.CodesReport lists them, and the exit codes with
+// ae.RegisterExitCodeForCode.
+//
+// Entries are sorted by code, so reordering the catalog doesn't change the
+// output. Duplicate codes, codes or kinds not matching ae.DefaultCodePattern
+// or ae.DefaultTagPattern, and exit codes outside 1..255 are rejected.
+//
+// Usage:
+//
+//	aegen -catalog errors.yaml [-o errors_gen.go] [-package name] [-type Code]
+//
+// The output defaults to the catalog name with the suffix _gen.go, the
+// package to the one in the catalog, then to $GOPACKAGE as set by go
+// generate.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "aegen:", err)
+		os.Exit(1)
+	}
+}
+
+// run generates the file described by args.
+func run(args []string) error {
+	fs := flag.NewFlagSet("aegen", flag.ContinueOnError)
+	catalogPath := fs.String("catalog", "", "the YAML `file` holding the error catalog")
+	output := fs.String("o", "", "the generated `file` (default: the catalog name with the suffix _gen.go)")
+	pkg := fs.String("package", "", "the package of the generated file (default: the catalog's, then $GOPACKAGE)")
+	typeName := fs.String("type", "Code", "the `name` of the generated code type")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *catalogPath == "" {
+		return fmt.Errorf("no catalog given; use -catalog")
+	}
+
+	data, err := os.ReadFile(*catalogPath)
+	if err != nil {
+		return err
+	}
+	c, err := parseCatalog(*catalogPath, data)
+	if err != nil {
+		return err
+	}
+
+	opts := options{Package: *pkg, Source: filepath.Base(*catalogPath), Type: *typeName}
+	if opts.Package == "" {
+		opts.Package = c.Package
+	}
+	if opts.Package == "" {
+		opts.Package = os.Getenv("GOPACKAGE")
+	}
+	if opts.Package == "" {
+		return fmt.Errorf("no package given; use -package or set package in %s", *catalogPath)
+	}
+
+	src, err := generate(c, opts)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		*output = strings.TrimSuffix(*catalogPath, filepath.Ext(*catalogPath)) + "_gen.go"
+	}
+
+	return os.WriteFile(*output, src, 0o644)
+}
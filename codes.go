@@ -0,0 +1,175 @@
+package ae
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CodeInfo describes an error code the program can emit, as listed by
+// CodesReport.
+type CodeInfo struct {
+	Code string `json:"code"`
+	// Tags are the tags of the registered template, e.g. the kind of a
+	// cmd/aegen catalog entry.
+	Tags []string `json:"tags,omitempty"`
+	// ExitCode is the exit code set on the template or registered for its
+	// code or tags with RegisterExitCodeForCode or RegisterExitCode, 0 if
+	// there is none.
+	ExitCode    int    `json:"exit_code,omitempty"`
+	Message     string `json:"message,omitempty"`
+	Hint        string `json:"hint,omitempty"`
+	UserMessage string `json:"user_message,omitempty"`
+	// File and Line locate the call registering the code.
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// registeredCode is a template registered with RegisterCode; the pointer
+// identifies it for removal.
+type registeredCode struct {
+	template error
+	file     string
+	line     int
+}
+
+var (
+	codesMu sync.RWMutex
+	codes   []*registeredCode
+)
+
+// RegisterCode registers template, an error with a code, as the error
+// CodesReport documents for its code, recording the file and line of the
+// call. Sentinel errors are natural templates:
+//
+//	var ErrUserNotFound = ae.New().Code("USER_NOT_FOUND").Hint("check the user ID").Msg("user not found")
+//
+//	func init() { ae.RegisterCode(ErrUserNotFound) }
+//
+// The errors generated by cmd/aegen are registered this way. Registering a
+// code again replaces the earlier template in the report. Registration is
+// meant for init time; the returned function unregisters the template. A
+// template without a code is ignored.
+func RegisterCode(template error) (remove func()) {
+	if Code(template) == "" {
+		return func() {}
+	}
+
+	r := &registeredCode{template: template}
+	_, r.file, r.line, _ = runtime.Caller(1)
+
+	codesMu.Lock()
+	defer codesMu.Unlock()
+	codes = append(codes, r)
+
+	return func() {
+		codesMu.Lock()
+		defer codesMu.Unlock()
+
+		for i, other := range codes {
+			if other == r {
+				codes = append(codes[:i:i], codes[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// CodesReport lists the error codes registered with RegisterCode or
+// RegisterExitCodeForCode, sorted by code, e.g. to document them for
+// support. Codes registered with both are described by their template and
+// located at the RegisterCode call.
+func CodesReport() []CodeInfo {
+	codesMu.RLock()
+	templates := codes
+	codesMu.RUnlock()
+
+	exitCodeRulesMu.RLock()
+	rules := exitCodeRules
+	exitCodeRulesMu.RUnlock()
+
+	byCode := make(map[string]CodeInfo)
+	for _, r := range templates {
+		code := Code(r.template)
+		byCode[code] = CodeInfo{
+			Code:        code,
+			Tags:        Tags(r.template),
+			ExitCode:    max(explicitExitCode(r.template, nil, 0), registeredExitCode(r.template)),
+			Message:     Message(r.template),
+			Hint:        Hint(r.template),
+			UserMessage: UserMessage(r.template),
+			File:        r.file,
+			Line:        r.line,
+		}
+	}
+
+	ruleCodes := make(map[string]CodeInfo)
+	for _, r := range rules {
+		if r.code == "" {
+			continue
+		}
+		if _, ok := byCode[r.code]; ok {
+			continue
+		}
+		if info, ok := ruleCodes[r.code]; ok && info.ExitCode >= r.exitCode {
+			continue
+		}
+		ruleCodes[r.code] = CodeInfo{Code: r.code, ExitCode: r.exitCode, File: r.file, Line: r.line}
+	}
+
+	infos := make([]CodeInfo, 0, len(byCode)+len(ruleCodes))
+	for _, m := range []map[string]CodeInfo{byCode, ruleCodes} {
+		for _, info := range m {
+			infos = append(infos, info)
+		}
+	}
+	slices.SortFunc(infos, func(a, b CodeInfo) int { return strings.Compare(a.Code, b.Code) })
+
+	return infos
+}
+
+// WriteCodesMarkdown writes CodesReport to w as a Markdown table of the code,
+// tags, exit code, hint, user message and registration of every code.
+func WriteCodesMarkdown(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("| Code | Tags | Exit code | Hint | User message | Registered at |\n")
+	sb.WriteString("|------|------|-----------|------|--------------|---------------|\n")
+
+	for _, info := range CodesReport() {
+		exitCode := ""
+		if info.ExitCode > 0 {
+			exitCode = strconv.Itoa(info.ExitCode)
+		}
+
+		cells := []string{
+			"`" + info.Code + "`",
+			markdownCell(strings.Join(info.Tags, ", ")),
+			exitCode,
+			markdownCell(info.Hint),
+			markdownCell(info.UserMessage),
+			markdownCell(fmt.Sprintf("%s:%d", info.File, info.Line)),
+		}
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteCodesJSON writes CodesReport to w as an indented JSON array.
+func WriteCodesJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(CodesReport())
+}
+
+// markdownCell escapes s for a cell of a Markdown table.
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>").Replace(s)
+}
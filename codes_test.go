@@ -0,0 +1,134 @@
+package ae_test
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+// callerLine returns the line of the statement calling it.
+func callerLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+// registerCodes registers three codes, returning the file and lines of the
+// registrations in code order.
+func registerCodes(t *testing.T) (file string, lines [3]int) {
+	t.Helper()
+
+	_, file, _, _ = runtime.Caller(0)
+
+	userNotFound := ae.New().
+		Code("USER_NOT_FOUND").
+		Tag("not-found").
+		Hint("check the user ID").
+		UserMsg("user not found", "The user | account does not exist.")
+	var remove func()
+	remove, lines[2] = ae.RegisterCode(userNotFound), callerLine()
+	withPackageState(t, remove)
+
+	remove, lines[0] = ae.RegisterCode(ae.New().Code("CONFIG_INVALID").Tag("config").Hint("run with --check-config").Msg("invalid configuration")), callerLine()
+	withPackageState(t, remove)
+	withPackageState(t, ae.RegisterExitCodeForCode("CONFIG_INVALID", 12))
+
+	remove, lines[1] = ae.RegisterExitCodeForCode("RATE_LIMITED", 75), callerLine()
+	withPackageState(t, remove)
+
+	return file, lines
+}
+
+func TestWriteCodesMarkdown(t *testing.T) {
+	file, lines := registerCodes(t)
+
+	var sb strings.Builder
+	if err := ae.WriteCodesMarkdown(&sb); err != nil {
+		t.Fatal(err)
+	}
+
+	want := fmt.Sprintf(`| Code | Tags | Exit code | Hint | User message | Registered at |
+|------|------|-----------|------|--------------|---------------|
+| `+"`CONFIG_INVALID`"+` | config | 12 | run with --check-config |  | %[1]s:%[2]d |
+| `+"`RATE_LIMITED`"+` |  | 75 |  |  | %[1]s:%[3]d |
+| `+"`USER_NOT_FOUND`"+` | not-found |  | check the user ID | The user \| account does not exist. | %[1]s:%[4]d |
+`, file, lines[0], lines[1], lines[2])
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteCodesJSON(t *testing.T) {
+	file, lines := registerCodes(t)
+
+	var sb strings.Builder
+	if err := ae.WriteCodesJSON(&sb); err != nil {
+		t.Fatal(err)
+	}
+
+	want := fmt.Sprintf(`[
+  {
+    "code": "CONFIG_INVALID",
+    "tags": [
+      "config"
+    ],
+    "exit_code": 12,
+    "message": "invalid configuration",
+    "hint": "run with --check-config",
+    "file": %[1]q,
+    "line": %[2]d
+  },
+  {
+    "code": "RATE_LIMITED",
+    "exit_code": 75,
+    "file": %[1]q,
+    "line": %[3]d
+  },
+  {
+    "code": "USER_NOT_FOUND",
+    "tags": [
+      "not-found"
+    ],
+    "message": "user not found",
+    "hint": "check the user ID",
+    "user_message": "The user | account does not exist.",
+    "file": %[1]q,
+    "line": %[4]d
+  }
+]
+`, file, lines[0], lines[1], lines[2])
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCodesReport_Empty(t *testing.T) {
+	var sb strings.Builder
+	if err := ae.WriteCodesJSON(&sb); err != nil || sb.String() != "[]\n" {
+		t.Errorf("WriteCodesJSON = %q, %v", sb.String(), err)
+	}
+}
+
+func TestRegisterCode_ReplaceAndRemove(t *testing.T) {
+	ae.RegisterCode(ae.Msg("no code"))()
+
+	removeFirst := ae.RegisterCode(ae.New().Code("DISK_FULL").Msg("first"))
+	removeSecond := ae.RegisterCode(ae.New().Code("DISK_FULL").ExitCode(3).Msg("second"))
+
+	report := ae.CodesReport()
+	if len(report) != 1 || report[0].Message != "second" || report[0].ExitCode != 3 {
+		t.Errorf("report = %+v, want the second template", report)
+	}
+
+	removeSecond()
+	if report = ae.CodesReport(); len(report) != 1 || report[0].Message != "first" {
+		t.Errorf("report = %+v, want the first template", report)
+	}
+
+	removeFirst()
+	if report = ae.CodesReport(); len(report) != 0 {
+		t.Errorf("report = %+v, want none", report)
+	}
+}
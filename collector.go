@@ -0,0 +1,58 @@
+package ae
+
+// Collector accumulates the errors of a sequence of steps so that all
+// failures can be reported together:
+//
+//	var c ae.Collector
+//	c.Wrap("validating", validate(cfg))
+//	c.Wrap("writing", write(cfg))
+//	return c.Err("saving config")
+//
+// The zero value is ready to use. A Collector is not safe for concurrent
+// use; see Group for collecting the errors of goroutines.
+type Collector struct {
+	errs  []error
+	limit int
+}
+
+// Add adds err to the collector. A nil err is ignored.
+func (c *Collector) Add(err error) {
+	if err != nil {
+		c.errs = append(c.errs, err)
+	}
+}
+
+// Wrap adds err wrapped with msg (see Wrap). A nil err is ignored.
+func (c *Collector) Wrap(msg string, err error) {
+	c.Add(Wrap(msg, err))
+}
+
+// Len returns the number of errors added.
+func (c *Collector) Len() int {
+	return len(c.errs)
+}
+
+// Limit makes Err include only the first n errors, followed by an error
+// reading "and K more" for the rest. n <= 0 removes the limit.
+func (c *Collector) Limit(n int) {
+	c.limit = n
+}
+
+// Err returns nil if no error was added, the error itself if exactly one
+// was, and otherwise an error with msg as its message and the added errors,
+// in order, as its causes.
+func (c *Collector) Err(msg string) error {
+	switch len(c.errs) {
+	case 0:
+		return nil
+	case 1:
+		return c.errs[0]
+	}
+
+	errs := c.errs
+	if c.limit > 0 && len(errs) > c.limit {
+		errs = append(errs[:c.limit:c.limit], Msgf("and %d more", len(errs)-c.limit))
+	}
+
+	return WrapMany(msg, errs...)
+}
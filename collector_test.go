@@ -0,0 +1,84 @@
+package ae_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+func TestCollector_Empty(t *testing.T) {
+	t.Parallel()
+
+	var c ae.Collector
+	c.Add(nil)
+	c.Wrap("step", nil)
+
+	if c.Len() != 0 {
+		t.Errorf("Len = %d, want 0 after nil adds", c.Len())
+	}
+	if err := c.Err("saving"); err != nil {
+		t.Errorf("Err = %v, want nil", err)
+	}
+}
+
+func TestCollector_Single(t *testing.T) {
+	t.Parallel()
+
+	want := errors.New("boom")
+	var c ae.Collector
+	c.Add(nil)
+	c.Add(want)
+
+	if got := c.Err("saving"); got != want {
+		t.Errorf("Err = %v, want the single error itself", got)
+	}
+}
+
+func TestCollector_Many(t *testing.T) {
+	t.Parallel()
+
+	errValidate := errors.New("invalid port")
+	errWrite := errors.New("disk full")
+
+	var c ae.Collector
+	c.Wrap("validating", errValidate)
+	c.Add(nil)
+	c.Wrap("writing", errWrite)
+
+	if c.Len() != 2 {
+		t.Errorf("Len = %d, want 2", c.Len())
+	}
+
+	err := c.Err("saving config")
+	if got := err.Error(); got != "saving config: [validating: invalid port; writing: disk full]" {
+		t.Errorf("Error() = %q", got)
+	}
+	if !errors.Is(err, errValidate) || !errors.Is(err, errWrite) {
+		t.Error("errors.Is does not find the collected errors")
+	}
+}
+
+func TestCollector_Limit(t *testing.T) {
+	t.Parallel()
+
+	var c ae.Collector
+	c.Limit(2)
+	for _, msg := range []string{"a", "b", "c", "d", "e"} {
+		c.Add(errors.New(msg))
+	}
+
+	err := c.Err("checks failed")
+	if got := err.Error(); got != "checks failed: [a; b; and 3 more]" {
+		t.Errorf("Error() = %q", got)
+	}
+	if c.Len() != 5 {
+		t.Errorf("Len = %d, want 5", c.Len())
+	}
+
+	// under the limit nothing is elided
+	c.Limit(10)
+	if got := len(ae.Causes(c.Err("checks failed"))); got != 5 {
+		t.Errorf("got %d causes, want 5", got)
+	}
+}
@@ -0,0 +1,45 @@
+//go:build !ae_minimal && !js && !tinygo
+
+package ae
+
+import "github.com/fatih/color"
+
+// textColor is a color role of the text output.
+type textColor = color.Color
+
+// Color roles for text-mode rendering. Each call through Printer.fmt becomes a
+// no-op when Printer.colors is false — the formatted string is returned verbatim.
+// EnableColor is called on every instance so fatih/color does not second-guess
+// our decision based on its own TTY detection: the Printer.colors flag is the
+// single source of truth.
+var (
+	colBadge    = forceColor(color.New(color.FgRed, color.Bold))
+	colMsg      = forceColor(color.New(color.FgRed, color.Bold))
+	colCode     = forceColor(color.New(color.FgHiYellow))
+	colBrace    = forceColor(color.New(color.FgYellow))
+	colTag      = forceColor(color.New(color.FgHiMagenta))
+	colBracket  = forceColor(color.New(color.FgMagenta))
+	colLabel    = forceColor(color.New(color.FgCyan))
+	colHint     = forceColor(color.New(color.FgHiCyan))
+	colShown    = forceColor(color.New(color.FgWhite, color.Bold))
+	colDim      = forceColor(color.New(color.FgHiBlack))
+	colAttrKey  = forceColor(color.New(color.FgHiBlue))
+	colAttrVal  = forceColor(color.New(color.FgHiGreen))
+	colStackFn  = forceColor(color.New(color.FgHiYellow))
+	colStackLoc = forceColor(color.New(color.FgHiBlack))
+	colStackLn  = forceColor(color.New(color.FgYellow))
+)
+
+// forceColor returns c after calling EnableColor so fatih/color will emit ANSI
+// regardless of the package-level NoColor/TTY detection. The Printer.colors
+// flag still gates whether these instances get called at all.
+func forceColor(c *color.Color) *color.Color {
+	c.EnableColor()
+	return c
+}
+
+// terminalColors reports whether stdout is a terminal that colors should be
+// enabled for by default.
+func terminalColors() bool {
+	return !color.NoColor
+}
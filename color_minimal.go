@@ -0,0 +1,40 @@
+//go:build ae_minimal || js || tinygo
+
+package ae
+
+import "fmt"
+
+// textColor is a color role of the text output. Builds tagged ae_minimal, and
+// js and TinyGo builds, leave out fatih/color: the roles render text
+// unchanged, so colors are always off.
+type textColor struct{}
+
+// Sprint formats a as fmt.Sprint does.
+func (*textColor) Sprint(a ...any) string {
+	return fmt.Sprint(a...)
+}
+
+// Color roles for text-mode rendering, all plain.
+var (
+	colBadge    = &textColor{}
+	colMsg      = &textColor{}
+	colCode     = &textColor{}
+	colBrace    = &textColor{}
+	colTag      = &textColor{}
+	colBracket  = &textColor{}
+	colLabel    = &textColor{}
+	colHint     = &textColor{}
+	colShown    = &textColor{}
+	colDim      = &textColor{}
+	colAttrKey  = &textColor{}
+	colAttrVal  = &textColor{}
+	colStackFn  = &textColor{}
+	colStackLoc = &textColor{}
+	colStackLn  = &textColor{}
+)
+
+// terminalColors reports whether colors should be enabled by default, never
+// in minimal builds.
+func terminalColors() bool {
+	return false
+}
@@ -0,0 +1,29 @@
+//go:build ae_minimal || js || tinygo
+
+package ae_test
+
+import (
+	"strings"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+// colorsBuild reports whether the build renders colors; minimal builds
+// leave fatih/color out.
+const colorsBuild = false
+
+func TestPrintColors_MinimalBuildIsPlain(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Code("E").Tag("t").Attr("k", "v").Stack().Msg("boom")
+
+	plain := ae.NewPrinter(ae.NoPrintColors()).Prints(err)
+	colored := ae.NewPrinter(ae.PrintColors()).Prints(err)
+	if strings.Contains(colored, "\x1b[") {
+		t.Errorf("minimal build emitted ANSI sequences:\n%s", colored)
+	}
+	if colored != plain {
+		t.Errorf("PrintColors output differs from plain:\n%s\nwant:\n%s", colored, plain)
+	}
+}
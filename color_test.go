@@ -0,0 +1,7 @@
+//go:build !ae_minimal && !js && !tinygo
+
+package ae_test
+
+// colorsBuild reports whether the build renders colors; minimal builds
+// leave fatih/color out.
+const colorsBuild = true
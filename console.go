@@ -0,0 +1,34 @@
+package ae
+
+import "io"
+
+// VirtualTerminal is implemented by writers that interpret ANSI escape
+// sequences only once enabled, such as Windows consoles. Before writing
+// colored output to such a writer, Printer.Fprint calls
+// EnableVirtualTerminal, and writes without colors if it fails. Windows
+// console handles (an *os.File for the console) are handled without
+// implementing the interface.
+type VirtualTerminal interface {
+	EnableVirtualTerminal() error
+}
+
+// forWriter returns p, or a copy of p without colors if w is a console that
+// can't render them.
+func (p *Printer) forWriter(w io.Writer) *Printer {
+	if !p.colors || p.json || enableVirtualTerminal(w) == nil {
+		return p
+	}
+
+	cpy := *p
+	cpy.colors = false
+	return &cpy
+}
+
+// enableVirtualTerminal prepares w for ANSI escape sequences.
+func enableVirtualTerminal(w io.Writer) error {
+	if vt, ok := w.(VirtualTerminal); ok {
+		return vt.EnableVirtualTerminal()
+	}
+
+	return enableConsoleVT(w)
+}
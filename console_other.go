@@ -0,0 +1,11 @@
+//go:build !windows
+
+package ae
+
+import "io"
+
+// enableConsoleVT is a no-op: terminals outside of Windows interpret ANSI
+// escape sequences as they are.
+func enableConsoleVT(io.Writer) error {
+	return nil
+}
@@ -0,0 +1,67 @@
+package ae_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+// fakeConsole is a console whose virtual terminal processing fails to
+// enable when err is set.
+type fakeConsole struct {
+	bytes.Buffer
+	err     error
+	enabled int
+}
+
+func (c *fakeConsole) EnableVirtualTerminal() error {
+	c.enabled++
+	return c.err
+}
+
+func TestFprint_VirtualTerminal(t *testing.T) {
+	t.Parallel()
+	if !colorsBuild {
+		t.Skip("colors are always off in minimal builds")
+	}
+
+	err := ae.New().Code("E").Msg("boom")
+	p := ae.NewPrinter(ae.PrintColors())
+
+	ok := &fakeConsole{}
+	p.Fprint(ok, err)
+	if ok.enabled != 1 {
+		t.Errorf("EnableVirtualTerminal called %d times, want 1", ok.enabled)
+	}
+	if !strings.Contains(ok.String(), "\x1b[") {
+		t.Errorf("output has no ANSI sequences:\n%s", ok.String())
+	}
+
+	failing := &fakeConsole{err: errors.New("not supported")}
+	p.Fprint(failing, err)
+	if strings.Contains(failing.String(), "\x1b[") {
+		t.Errorf("output has ANSI sequences despite the failing console:\n%s", failing.String())
+	}
+	if want := ae.NewPrinter(ae.NoPrintColors()).Prints(err) + "\n"; failing.String() != want {
+		t.Errorf("fallback output = %q, want %q", failing.String(), want)
+	}
+}
+
+func TestFprint_VirtualTerminalNotNeeded(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Msg("boom")
+	for name, p := range map[string]*ae.Printer{
+		"no colors": ae.NewPrinter(ae.NoPrintColors()),
+		"json":      ae.NewPrinter(ae.PrintColors(), ae.PrintJSON()),
+	} {
+		c := &fakeConsole{}
+		p.Fprint(c, err)
+		if c.enabled != 0 {
+			t.Errorf("%s: EnableVirtualTerminal called for uncolored output", name)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+//go:build windows
+
+package ae
+
+import (
+	"io"
+
+	"golang.org/x/sys/windows"
+)
+
+// enableConsoleVT enables virtual terminal processing on w if it is a
+// console handle, so that Windows 10 and later interpret ANSI escape
+// sequences. Writers that are not consoles, such as pipes and files, are
+// left alone.
+func enableConsoleVT(w io.Writer) error {
+	f, ok := w.(interface{ Fd() uintptr })
+	if !ok {
+		return nil
+	}
+
+	h := windows.Handle(f.Fd())
+	var mode uint32
+	if windows.GetConsoleMode(h, &mode) != nil {
+		return nil
+	}
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return nil
+	}
+
+	return windows.SetConsoleMode(h, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+}
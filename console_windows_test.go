@@ -0,0 +1,45 @@
+//go:build windows
+
+package ae_test
+
+import (
+	"os"
+	"testing"
+
+	"golang.org/x/sys/windows"
+
+	"go.aledante.io/ae"
+)
+
+// TestFprint_EnablesConsoleVirtualTerminal changes the mode of the process's
+// console; it does not run in parallel.
+func TestFprint_EnablesConsoleVirtualTerminal(t *testing.T) {
+	if !colorsBuild {
+		t.Skip("colors are always off in minimal builds")
+	}
+
+	console, err := os.OpenFile("CONOUT$", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("no console attached: %v", err)
+	}
+	defer console.Close()
+
+	h := windows.Handle(console.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(h, &mode); err != nil {
+		t.Skipf("GetConsoleMode: %v", err)
+	}
+	defer windows.SetConsoleMode(h, mode)
+	if err := windows.SetConsoleMode(h, mode&^windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING); err != nil {
+		t.Skipf("SetConsoleMode: %v", err)
+	}
+
+	ae.NewPrinter(ae.PrintColors()).Fprint(console, ae.New().Msg("boom"))
+
+	if err := windows.GetConsoleMode(h, &mode); err != nil {
+		t.Fatalf("GetConsoleMode: %v", err)
+	}
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING == 0 {
+		t.Error("ENABLE_VIRTUAL_TERMINAL_PROCESSING not set after Fprint")
+	}
+}
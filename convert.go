@@ -0,0 +1,40 @@
+package ae
+
+import "context"
+
+// Convert returns err as an *Ae, for normalizing errors once at an API
+// boundary. It returns nil for a nil err and err itself when it already is an
+// *Ae, so converting twice returns the same instance. Other errors are read
+// with FromOS, which passes them through the classifiers registered with
+// RegisterClassifier, and finalized with Builder.MsgFrom: the result has
+// err's message and keeps err as its cause, so errors.Is and errors.As keep
+// matching it. Errors from encoding/json need their input for their details
+// and are better converted with FromJSONError beforehand.
+func Convert(err error) *Ae {
+	if err == nil {
+		return nil
+	}
+
+	//goland:noinspection GoTypeAssertionOnErrors
+	if x, ok := err.(*Ae); ok {
+		return x
+	}
+
+	return FromOS(err).MsgFrom(err).(*Ae)
+}
+
+// ConvertC is Convert, with errors that are not *Ae yet also enriched from
+// ctx (see Builder.Context). An *Ae is returned as is: it got its context
+// when it was built.
+func ConvertC(ctx context.Context, err error) *Ae {
+	if err == nil {
+		return nil
+	}
+
+	//goland:noinspection GoTypeAssertionOnErrors
+	if x, ok := err.(*Ae); ok {
+		return x
+	}
+
+	return FromOS(err).Context(ctx).MsgFrom(err).(*Ae)
+}
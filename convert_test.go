@@ -0,0 +1,114 @@
+package ae_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+func TestConvert_Nil(t *testing.T) {
+	t.Parallel()
+
+	if err := ae.Convert(nil); err != nil {
+		t.Errorf("Convert(nil) = %v, want nil", err)
+	}
+	if err := ae.ConvertC(context.Background(), nil); err != nil {
+		t.Errorf("ConvertC(nil) = %v, want nil", err)
+	}
+}
+
+func TestConvert_KeepsAeInstance(t *testing.T) {
+	t.Parallel()
+
+	orig := ae.New().Code("NOT_FOUND").Msg("no such user")
+
+	if got := ae.Convert(orig); got != orig {
+		t.Errorf("Convert(*Ae) = %p, want the same instance %p", got, orig)
+	}
+	ctx := ae.WithRequestIdValue(context.Background(), "req-1")
+	if got := ae.ConvertC(ctx, orig); got != orig {
+		t.Errorf("ConvertC(*Ae) = %p, want the same instance %p", got, orig)
+	}
+}
+
+func TestConvert_Idempotent(t *testing.T) {
+	t.Parallel()
+
+	for name, err := range map[string]error{
+		"std":     errors.New("boom"),
+		"wrapped": fmt.Errorf("reading: %w", fs.ErrClosed),
+		"ae":      ae.Msg("boom"),
+	} {
+		once := ae.Convert(err)
+		if twice := ae.Convert(once); twice != once {
+			t.Errorf("%s: Convert(Convert(err)) = %p, want %p", name, twice, once)
+		}
+	}
+}
+
+func TestConvert_StdError(t *testing.T) {
+	t.Parallel()
+
+	orig := fmt.Errorf("reading: %w", fs.ErrClosed)
+	err := ae.Convert(orig)
+
+	if got := err.Error(); got != orig.Error() {
+		t.Errorf("Error() = %q, want %q", got, orig.Error())
+	}
+	if causes := ae.Causes(err); len(causes) != 1 || causes[0] != orig {
+		t.Errorf("causes = %v, want the original error", causes)
+	}
+	if !errors.Is(err, fs.ErrClosed) {
+		t.Error("errors.Is does not reach the original error's chain")
+	}
+}
+
+func TestConvert_Classifies(t *testing.T) {
+	t.Parallel()
+
+	remove := ae.RegisterClassifier(t.Name(), classifyUniqueViolation)
+	defer remove()
+
+	err := ae.Convert(fmt.Errorf("insert: %w", uniqueViolation{constraint: "users_email_key"}))
+	if got := ae.Code(err); got != "DB_UNIQUE_VIOLATION" {
+		t.Errorf("code = %q, want DB_UNIQUE_VIOLATION", got)
+	}
+
+	_, openErr := os.Open(filepath.Join(t.TempDir(), "missing.yaml"))
+	err = ae.Convert(openErr)
+	if attrs := ae.Attributes(err); attrs["os.op"] != "open" {
+		t.Errorf("os.op = %v, want open", attrs["os.op"])
+	}
+	if !slices.Contains(ae.Tags(err), "not-found") {
+		t.Errorf("tags = %v, want not-found", ae.Tags(err))
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Error("errors.Is(err, fs.ErrNotExist) = false through Convert")
+	}
+}
+
+func TestConvertC_EnrichesFromContext(t *testing.T) {
+	t.Parallel()
+
+	ctx := ae.WithRequestIdValue(context.Background(), "req-1")
+	ctx = ae.WithAttribute(ctx, "tenant", "acme")
+
+	err := ae.ConvertC(ctx, errors.New("boom"))
+
+	if got := ae.RequestId(err); got != "req-1" {
+		t.Errorf("RequestId = %q, want req-1", got)
+	}
+	if got := ae.Attributes(err)["tenant"]; got != "acme" {
+		t.Errorf("tenant = %v, want acme", got)
+	}
+	if got := err.Error(); got != "boom" {
+		t.Errorf("Error() = %q, want boom", got)
+	}
+}
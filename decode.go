@@ -0,0 +1,342 @@
+package ae
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+)
+
+// DecodeLimitCode is the code of the error DecodeJSON returns for input
+// exceeding its DecodeJSONLimits.
+const DecodeLimitCode = "DECODE_LIMIT_EXCEEDED"
+
+// DecodeJSONLimits bounds the errors DecodeJSON and Ae.UnmarshalJSON accept,
+// so a malicious or runaway payload can't exhaust the memory of the
+// consumer. A zero field takes the default of DefaultDecodeJSONLimits; a
+// negative one means unlimited.
+type DecodeJSONLimits struct {
+	// MaxDepth is the number of levels causes and related errors may nest
+	// below the top-level error.
+	MaxDepth int
+	// MaxCauses is the number of errors the tree may hold below the
+	// top-level error, causes and related errors alike.
+	MaxCauses int
+	// MaxBytes is the size of the input. Larger input is always rejected.
+	MaxBytes int
+	// MaxAttrValueLen is the size of an attribute value: the length of a
+	// string, or of the JSON encoding of other values.
+	MaxAttrValueLen int
+	// MaxStacks is the number of stacks an error may carry.
+	MaxStacks int
+	// MaxFrames is the number of frames a stack may hold.
+	MaxFrames int
+	// Truncate makes errors beyond MaxDepth and MaxCauses dropped instead
+	// of rejected, replaced by a summary error "… and N more errors
+	// omitted" and counted in the "causes_omitted" or "related_omitted"
+	// attribute of the error that lost them, attribute values beyond
+	// MaxAttrValueLen cut as SetAttrLimits does, stacks beyond MaxStacks
+	// dropped and frames beyond MaxFrames elided, counted in
+	// Stack.ElidedCount.
+	Truncate bool
+}
+
+// DefaultDecodeJSONLimits returns the limits used for fields left zero: 32
+// levels, 1000 errors, 1 MiB of input, 64 KiB per attribute value, 32
+// stacks per error and 1024 frames per stack, rejecting input beyond them.
+func DefaultDecodeJSONLimits() DecodeJSONLimits {
+	return DecodeJSONLimits{
+		MaxDepth:        32,
+		MaxCauses:       1000,
+		MaxBytes:        1 << 20,
+		MaxAttrValueLen: 64 << 10,
+		MaxStacks:       32,
+		MaxFrames:       1024,
+	}
+}
+
+// withDefaults returns l with zero fields set to their default.
+func (l DecodeJSONLimits) withDefaults() DecodeJSONLimits {
+	d := DefaultDecodeJSONLimits()
+	for _, f := range [...]struct{ v, d *int }{
+		{&l.MaxDepth, &d.MaxDepth},
+		{&l.MaxCauses, &d.MaxCauses},
+		{&l.MaxBytes, &d.MaxBytes},
+		{&l.MaxAttrValueLen, &d.MaxAttrValueLen},
+		{&l.MaxStacks, &d.MaxStacks},
+		{&l.MaxFrames, &d.MaxFrames},
+	} {
+		if *f.v == 0 {
+			*f.v = *f.d
+		}
+	}
+
+	return l
+}
+
+// decodeLimits holds the limits set with SetDecodeJSONLimits.
+var decodeLimits atomic.Pointer[DecodeJSONLimits]
+
+func init() {
+	decodeLimits.Store(&DecodeJSONLimits{})
+}
+
+// SetDecodeJSONLimits sets the limits Ae.UnmarshalJSON enforces, the
+// defaults of DefaultDecodeJSONLimits unless changed.
+func SetDecodeJSONLimits(l DecodeJSONLimits) {
+	decodeLimits.Store(&l)
+}
+
+// jsonInput is an error as rendered by the JSON printer, its nested errors
+// and attribute values kept raw until they are checked against the limits.
+type jsonInput struct {
+	Message      string                     `json:"message"`
+	UserMessage  string                     `json:"user_message"`
+	UserMessages map[string]string          `json:"user_messages"`
+	Hint         string                     `json:"hint"`
+	Code         string                     `json:"code"`
+	ExitCode     int                        `json:"exit_code"`
+	Recoverable  *bool                      `json:"recoverable"`
+	TraceId      string                     `json:"trace_id"`
+	SpanId       string                     `json:"span_id"`
+	RequestId    string                     `json:"request_id"`
+	Tags         []string                   `json:"tags"`
+	Attrs        map[string]json.RawMessage `json:"attrs"`
+	Causes       []json.RawMessage          `json:"causes"`
+	Related      []json.RawMessage          `json:"related"`
+	Stacks       []json.RawMessage          `json:"stacks"`
+}
+
+// DecodeJSON decodes an error rendered by the JSON printer (PrintJSON, with
+// the default SnakeCase keys), enforcing limits. The decoded error and every
+// error in its tree is an *Ae with the rendered message, user messages,
+// hint, code, exit code, recoverability, IDs, tags, attributes and stacks;
+// numbers in attributes decode as float64. Decoded errors are not stamped,
+// classified or passed to OnFinalize hooks as the errors built here are.
+//
+// Input exceeding the limits is rejected with an error tagged "decode" and
+// coded DecodeLimitCode, or truncated, see DecodeJSONLimits.Truncate.
+// Malformed input is rejected with an error tagged "decode" whose cause is
+// described by FromJSONError. Returns nil, nil for the JSON null.
+func DecodeJSON(data []byte, limits DecodeJSONLimits) (*Ae, error) {
+	d := &jsonDecoder{limits: limits.withDefaults()}
+
+	if n := d.limits.MaxBytes; n >= 0 && len(data) > n {
+		return nil, d.limitErr("MaxBytes", n, fmt.Sprintf("input of %d bytes exceeds %d bytes", len(data), n))
+	}
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil, nil
+	}
+
+	return d.decode(data, 0)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding data as DecodeJSON
+// does with the limits set with SetDecodeJSONLimits. The JSON null leaves a
+// unchanged.
+func (a *Ae) UnmarshalJSON(data []byte) error {
+	decoded, err := DecodeJSON(data, *decodeLimits.Load())
+	if err != nil || decoded == nil {
+		return err
+	}
+
+	*a = *decoded
+	return nil
+}
+
+// jsonDecoder decodes one error tree, counting its errors.
+type jsonDecoder struct {
+	limits DecodeJSONLimits
+	errors int
+}
+
+// decode decodes the error data found at depth.
+func (d *jsonDecoder) decode(data []byte, depth int) (*Ae, error) {
+	var in jsonInput
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, New().Tag("decode").Cause(FromJSONError(err, data)).Msg("decoding error JSON")
+	}
+
+	b := New()
+	b.userMsg = in.UserMessage
+	b.userMsgs = in.UserMessages
+	b.hint = in.Hint
+	b.code = in.Code
+	if in.ExitCode > 1 {
+		b.exitCode = in.ExitCode
+	}
+	if in.Recoverable != nil {
+		b.recoverable = *in.Recoverable
+		b.recoverableSet = true
+	}
+	b.traceId = in.TraceId
+	b.spanId = in.SpanId
+	b.requestId = in.RequestId
+	for _, tag := range in.Tags {
+		b.addTag(tag)
+	}
+	var err error
+	if b.stacks, err = d.decodeStacks(in.Stacks); err != nil {
+		return nil, err
+	}
+
+	for key, raw := range in.Attrs {
+		if err := d.decodeAttr(&b, key, raw); err != nil {
+			return nil, err
+		}
+	}
+
+	if b.causes, err = d.decodeList(&b, in.Causes, depth, causesOmittedKey); err != nil {
+		return nil, err
+	}
+	if b.related, err = d.decodeList(&b, in.Related, depth, relatedOmittedKey); err != nil {
+		return nil, err
+	}
+
+	// the error happened elsewhere: it is taken as rendered, without the
+	// stamping, classification and hooks of a local one
+	b.msg = in.Message
+	return b.finalize(), nil
+}
+
+// decodeList decodes raw, the causes or related errors of b found at depth.
+// Errors dropped under DecodeJSONLimits.Truncate are counted under key.
+func (d *jsonDecoder) decodeList(b *Builder, raw []json.RawMessage, depth int, key string) ([]error, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var errs []error
+	for i, data := range raw {
+		exceeded := ""
+		switch {
+		case d.limits.MaxDepth >= 0 && depth >= d.limits.MaxDepth:
+			exceeded = "MaxDepth"
+		case d.limits.MaxCauses >= 0 && d.errors >= d.limits.MaxCauses:
+			exceeded = "MaxCauses"
+		}
+		if exceeded != "" {
+			if !d.limits.Truncate {
+				return nil, d.treeLimitErr(exceeded)
+			}
+			return b.omit(errs, key, len(raw)-i), nil
+		}
+
+		d.errors++
+		err, decErr := d.decode(data, depth+1)
+		if decErr != nil {
+			return nil, decErr
+		}
+		errs = append(errs, err)
+	}
+
+	return errs, nil
+}
+
+// decodeStacks decodes raw, the stacks of an error. The stacks they were
+// created by, linked as Stack.Ancestor, count towards MaxStacks as well.
+func (d *jsonDecoder) decodeStacks(raw []json.RawMessage) ([]*Stack, error) {
+	count := 0
+	var stacks []*Stack
+	for _, data := range raw {
+		var st *Stack
+		if err := json.Unmarshal(data, &st); err != nil {
+			return nil, New().Tag("decode").Cause(FromJSONError(err, data)).Msg("decoding stack")
+		}
+
+		for s, prev := st, (*Stack)(nil); s != nil; prev, s = s, s.Ancestor {
+			if n := d.limits.MaxStacks; n >= 0 && count >= n {
+				if !d.limits.Truncate {
+					return nil, d.limitErr("MaxStacks", n, fmt.Sprintf("error carries more than %d stacks", n))
+				}
+				if prev == nil {
+					return stacks, nil
+				}
+				prev.Ancestor = nil
+				break
+			}
+			count++
+
+			if err := d.limitFrames(s); err != nil {
+				return nil, err
+			}
+		}
+		if st != nil {
+			stacks = append(stacks, st)
+		}
+	}
+
+	return stacks, nil
+}
+
+// limitFrames enforces MaxFrames on the frames of st.
+func (d *jsonDecoder) limitFrames(st *Stack) error {
+	n := d.limits.MaxFrames
+	if n < 0 || len(st.Frames) <= n {
+		return nil
+	}
+	if !d.limits.Truncate {
+		return d.limitErr("MaxFrames", n, fmt.Sprintf("stack of %d frames exceeds %d frames", len(st.Frames), n))
+	}
+
+	st.ElidedCount += len(st.Frames) - n
+	st.FramesElided = true
+	st.Frames = st.Frames[:n:n]
+	return nil
+}
+
+// decodeAttr sets the attribute key of b to the decoded raw value. Values
+// other than strings are measured before they are decoded, so an oversized
+// one is never expanded in memory.
+func (d *jsonDecoder) decodeAttr(b *Builder, key string, raw json.RawMessage) error {
+	n := d.limits.MaxAttrValueLen
+	isString := len(raw) > 0 && raw[0] == '"'
+
+	var value any
+	size := len(raw)
+	if isString || n < 0 || size <= n {
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return New().Tag("decode").Cause(FromJSONError(err, raw)).Msgf("decoding attribute %q", key)
+		}
+		if s, ok := value.(string); ok {
+			size = len(s)
+		}
+	}
+
+	if n >= 0 && size > n {
+		if !d.limits.Truncate {
+			return d.limitErr("MaxAttrValueLen", n, fmt.Sprintf("attribute %q of %d bytes exceeds %d bytes", key, size, n))
+		}
+
+		s, ok := value.(string)
+		if !ok {
+			s = string(raw)
+		}
+		value = truncatedValue(s, n)
+		b.setAttr(key+originalBytesSuffix, size)
+	}
+
+	b.setAttr(key, value)
+	return nil
+}
+
+// treeLimitErr returns the error for a tree exceeding the limit named
+// limit.
+func (d *jsonDecoder) treeLimitErr(limit string) error {
+	if limit == "MaxDepth" {
+		return d.limitErr(limit, d.limits.MaxDepth, fmt.Sprintf("errors nest deeper than %d levels", d.limits.MaxDepth))
+	}
+
+	return d.limitErr(limit, d.limits.MaxCauses, fmt.Sprintf("tree holds more than %d errors", d.limits.MaxCauses))
+}
+
+// limitErr returns the error for input exceeding the limit named limit,
+// set to n, as described by detail.
+func (d *jsonDecoder) limitErr(limit string, n int, detail string) error {
+	return New().
+		Code(DecodeLimitCode).
+		Tag("decode").
+		Attr("limit", limit).
+		Attr("max", n).
+		Msgf("decoding error JSON: %s", detail)
+}
@@ -0,0 +1,361 @@
+package ae_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+// chainJSON returns the JSON of an error with n nested causes below it.
+func chainJSON(n int) string {
+	s := `{"message":"level 0"}`
+	for i := 1; i <= n; i++ {
+		s = fmt.Sprintf(`{"message":"level %d","causes":[%s]}`, i, s)
+	}
+
+	return s
+}
+
+// flatJSON returns the JSON of an error with n direct causes.
+func flatJSON(n int) string {
+	causes := make([]string, n)
+	for i := range causes {
+		causes[i] = fmt.Sprintf(`{"message":"cause %d"}`, i)
+	}
+
+	return `{"message":"top","causes":[` + strings.Join(causes, ",") + `]}`
+}
+
+// depthOf returns the number of levels of causes below err.
+func depthOf(err error) int {
+	depth := 0
+	for _, cause := range ae.Causes(err) {
+		depth = max(depth, depthOf(cause)+1)
+	}
+
+	return depth
+}
+
+// countErrors returns the number of errors in the tree of err, causes and
+// related errors alike.
+func countErrors(err error) int {
+	n := 1
+	for _, e := range append(ae.Causes(err), ae.Related(err)...) {
+		n += countErrors(e)
+	}
+
+	return n
+}
+
+// assertLimitErr fails t unless err is a decode error exceeding limit.
+func assertLimitErr(t *testing.T, err error, limit string) {
+	t.Helper()
+
+	if err == nil {
+		t.Fatalf("no error, want %s exceeded", limit)
+	}
+	if ae.Code(err) != ae.DecodeLimitCode || !slices.Contains(ae.Tags(err), "decode") {
+		t.Errorf("err = %v (code %q, tags %v), want a decode limit error", err, ae.Code(err), ae.Tags(err))
+	}
+	if got := ae.Attributes(err)["limit"]; got != limit {
+		t.Errorf("limit = %v, want %s", got, limit)
+	}
+}
+
+func TestDecodeJSON_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	src := ae.New().
+		Code("AUTH_FAILED").
+		ExitCode(77).
+		TraceId("trace-abc123").
+		RequestId("req-1").
+		Tags("auth", "security").
+		Attr("user_id", 42).
+		Hint("rotate the token").
+		Cause(ae.New().Code("DB_TIMEOUT").Fatal().Msg("database timed out")).
+		Related(ae.Msg("audit write failed")).
+		UserMsg("authentication failed", "Please sign in again.")
+
+	data := ae.NewPrinter(ae.PrintJSON(), ae.NoPrintStacks()).Prints(src)
+	got, err := ae.DecodeJSON([]byte(data), ae.DecodeJSONLimits{})
+	if err != nil {
+		t.Fatalf("DecodeJSON: %v", err)
+	}
+
+	if got.Error() != src.Error() {
+		t.Errorf("Error() = %q, want %q", got.Error(), src.Error())
+	}
+	if ae.Code(got) != "AUTH_FAILED" || ae.ExitCode(got) != 77 || ae.TraceId(got) != "trace-abc123" || ae.RequestId(got) != "req-1" {
+		t.Errorf("metadata lost: %s", ae.NewPrinter(ae.PrintJSON()).Prints(got))
+	}
+	if ae.UserMessage(got) != "Please sign in again." || ae.Hint(got) != "rotate the token" {
+		t.Errorf("user message %q, hint %q", ae.UserMessage(got), ae.Hint(got))
+	}
+	if !slices.Equal(ae.Tags(got), []string{"auth", "security"}) || ae.Attributes(got)["user_id"] != 42.0 {
+		t.Errorf("tags %v, attrs %v", ae.Tags(got), ae.Attributes(got))
+	}
+	if len(ae.Related(got)) != 1 || ae.Code(ae.Causes(got)[0]) != "DB_TIMEOUT" {
+		t.Errorf("causes %v, related %v", ae.Causes(got), ae.Related(got))
+	}
+	if ae.IsRecoverable(got) || !ae.IsRecoverable(ae.Related(got)[0]) {
+		t.Errorf("recoverable = %v, related recoverable = %v, want false and true", ae.IsRecoverable(got), ae.IsRecoverable(ae.Related(got)[0]))
+	}
+}
+
+func TestDecodeJSON_Null(t *testing.T) {
+	t.Parallel()
+
+	got, err := ae.DecodeJSON([]byte(" null "), ae.DecodeJSONLimits{})
+	if got != nil || err != nil {
+		t.Errorf("DecodeJSON(null) = %v, %v, want nil, nil", got, err)
+	}
+}
+
+func TestDecodeJSON_Malformed(t *testing.T) {
+	t.Parallel()
+
+	for _, in := range []string{`{"message":`, `"text"`, `{"causes":{}}`, `{"attrs":{"a":[1,}}`} {
+		_, err := ae.DecodeJSON([]byte(in), ae.DecodeJSONLimits{})
+		if err == nil || !slices.Contains(ae.Tags(err), "decode") {
+			t.Errorf("DecodeJSON(%s) = %v, want a decode error", in, err)
+		}
+	}
+}
+
+func TestDecodeJSON_MaxBytes(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`{"message":"boom"}`)
+	if _, err := ae.DecodeJSON(data, ae.DecodeJSONLimits{MaxBytes: len(data)}); err != nil {
+		t.Errorf("at the limit: %v", err)
+	}
+
+	// MaxBytes rejects even when truncating
+	_, err := ae.DecodeJSON(data, ae.DecodeJSONLimits{MaxBytes: len(data) - 1, Truncate: true})
+	assertLimitErr(t, err, "MaxBytes")
+
+	big := []byte(`{"message":"` + strings.Repeat("x", 1<<20) + `"}`)
+	_, err = ae.DecodeJSON(big, ae.DecodeJSONLimits{})
+	assertLimitErr(t, err, "MaxBytes")
+	if _, err := ae.DecodeJSON(big, ae.DecodeJSONLimits{MaxBytes: -1}); err != nil {
+		t.Errorf("unlimited: %v", err)
+	}
+}
+
+func TestDecodeJSON_MaxDepth(t *testing.T) {
+	t.Parallel()
+
+	limits := ae.DecodeJSONLimits{MaxDepth: 5}
+	got, err := ae.DecodeJSON([]byte(chainJSON(5)), limits)
+	if err != nil || depthOf(got) != 5 {
+		t.Fatalf("at the limit: depth %d, %v", depthOf(got), err)
+	}
+
+	_, err = ae.DecodeJSON([]byte(chainJSON(6)), limits)
+	assertLimitErr(t, err, "MaxDepth")
+
+	limits.Truncate = true
+	got, err = ae.DecodeJSON([]byte(chainJSON(6)), limits)
+	if err != nil {
+		t.Fatalf("truncating: %v", err)
+	}
+	// level 1 lost level 0, replaced by the summary
+	cut := got
+	for range 5 {
+		cut = ae.Causes(cut)[0].(*ae.Ae)
+	}
+	if ae.Message(cut) != "level 1" || ae.Attributes(cut)["causes_omitted"] != 1 {
+		t.Errorf("deepest kept error = %q, attrs %v", ae.Message(cut), ae.Attributes(cut))
+	}
+	if causes := ae.Causes(cut); len(causes) != 1 || causes[0].Error() != "… and 1 more error omitted" {
+		t.Errorf("causes of the cut error = %v", causes)
+	}
+}
+
+func TestDecodeJSON_MaxCauses(t *testing.T) {
+	t.Parallel()
+
+	limits := ae.DecodeJSONLimits{MaxCauses: 10}
+	got, err := ae.DecodeJSON([]byte(flatJSON(10)), limits)
+	if err != nil || len(ae.Causes(got)) != 10 {
+		t.Fatalf("at the limit: %d causes, %v", len(ae.Causes(got)), err)
+	}
+
+	_, err = ae.DecodeJSON([]byte(flatJSON(11)), limits)
+	assertLimitErr(t, err, "MaxCauses")
+
+	// nested errors count as well
+	_, err = ae.DecodeJSON([]byte(chainJSON(11)), limits)
+	assertLimitErr(t, err, "MaxCauses")
+
+	limits.Truncate = true
+	got, err = ae.DecodeJSON([]byte(flatJSON(1000)), limits)
+	if err != nil {
+		t.Fatalf("truncating: %v", err)
+	}
+	if causes := ae.Causes(got); len(causes) != 11 || causes[10].Error() != "… and 990 more errors omitted" {
+		t.Errorf("got %d causes, last %v", len(causes), causes[len(causes)-1])
+	}
+	if n := ae.Attributes(got)["causes_omitted"]; n != 990 {
+		t.Errorf("causes_omitted = %v, want 990", n)
+	}
+}
+
+func TestDecodeJSON_MaxAttrValueLen(t *testing.T) {
+	t.Parallel()
+
+	data := func(value string) []byte { return []byte(`{"message":"m","attrs":{"body":` + value + `}}`) }
+	limits := ae.DecodeJSONLimits{MaxAttrValueLen: 8}
+
+	if _, err := ae.DecodeJSON(data(`"12345678"`), limits); err != nil {
+		t.Errorf("string at the limit: %v", err)
+	}
+	if _, err := ae.DecodeJSON(data(`[12,3,4]`), limits); err != nil {
+		t.Errorf("array at the limit: %v", err)
+	}
+	_, err := ae.DecodeJSON(data(`"123456789"`), limits)
+	assertLimitErr(t, err, "MaxAttrValueLen")
+	_, err = ae.DecodeJSON(data(`[1,2,3,45]`), limits)
+	assertLimitErr(t, err, "MaxAttrValueLen")
+
+	limits.Truncate = true
+	got, err := ae.DecodeJSON(data(`"123456789"`), limits)
+	if err != nil {
+		t.Fatalf("truncating: %v", err)
+	}
+	attrs := ae.Attributes(got)
+	if attrs["body"] != "12345678…(truncated, 9 bytes)" || attrs["body.original_bytes"] != 9 {
+		t.Errorf("attrs = %v", attrs)
+	}
+
+	got, err = ae.DecodeJSON(data(`[1,2,3,45]`), limits)
+	if err != nil || ae.Attributes(got)["body"] != "[1,2,3,4…(truncated, 10 bytes)" {
+		t.Errorf("truncated array = %v, %v", ae.Attributes(got)["body"], err)
+	}
+}
+
+func TestDecodeJSON_MaxStacksAndFrames(t *testing.T) {
+	t.Parallel()
+
+	frames := func(n int) string {
+		return "[" + strings.TrimSuffix(strings.Repeat(`{"func":"main.f","file":"main.go","line":1},`, n), ",") + "]"
+	}
+	data := []byte(`{"message":"m","stacks":[` +
+		`{"id":1,"frames":` + frames(3) + `,"ancestor":{"id":2,"frames":` + frames(1) + `}},` +
+		`{"id":3,"frames":` + frames(1) + `}]}`)
+
+	if _, err := ae.DecodeJSON(data, ae.DecodeJSONLimits{MaxStacks: 3, MaxFrames: 3}); err != nil {
+		t.Errorf("stacks at the limits: %v", err)
+	}
+	_, err := ae.DecodeJSON(data, ae.DecodeJSONLimits{MaxStacks: 2})
+	assertLimitErr(t, err, "MaxStacks")
+	_, err = ae.DecodeJSON(data, ae.DecodeJSONLimits{MaxFrames: 2})
+	assertLimitErr(t, err, "MaxFrames")
+
+	got, err := ae.DecodeJSON(data, ae.DecodeJSONLimits{MaxStacks: 2, MaxFrames: 2, Truncate: true})
+	if err != nil {
+		t.Fatalf("truncating: %v", err)
+	}
+	stacks := ae.Stacks(got)
+	if len(stacks) != 1 || stacks[0].Ancestor == nil || stacks[0].Ancestor.Ancestor != nil {
+		t.Fatalf("stacks = %v, want the first with its ancestor", stacks)
+	}
+	if st := stacks[0]; len(st.Frames) != 2 || !st.FramesElided || st.ElidedCount != 1 {
+		t.Errorf("frames = %d, elided %v (%d), want 2 and 1 elided", len(st.Frames), st.FramesElided, st.ElidedCount)
+	}
+}
+
+func TestUnmarshalJSON_UsesPackageLimits(t *testing.T) {
+	withPackageState(t, func() { ae.SetDecodeJSONLimits(ae.DecodeJSONLimits{}) })
+
+	var got ae.Ae
+	if err := json.Unmarshal([]byte(chainJSON(3)), &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if got.Error() != "level 3: level 2: level 1: level 0" {
+		t.Errorf("Error() = %q", got.Error())
+	}
+
+	ae.SetDecodeJSONLimits(ae.DecodeJSONLimits{MaxDepth: 2})
+	var limited *ae.Ae
+	err := json.Unmarshal([]byte(chainJSON(3)), &limited)
+	var aeErr *ae.Ae
+	if !errors.As(err, &aeErr) || ae.Code(aeErr) != ae.DecodeLimitCode {
+		t.Errorf("Unmarshal = %v, want the MaxDepth error", err)
+	}
+}
+
+func FuzzDecodeJSON(f *testing.F) {
+	f.Add([]byte(ae.NewPrinter(ae.PrintJSON()).Prints(ae.New().
+		Tags("a", "b").
+		Attr("k", map[string]any{"n": []int{1, 2}}).
+		Cause(ae.New().Code("X").Cause(errors.New("leaf")).Msg("mid")).
+		Related(ae.Msg("rel")).
+		Msg("top"))))
+	f.Add([]byte(chainJSON(8)))
+	f.Add([]byte(flatJSON(20)))
+	f.Add([]byte(`{"attrs":{"s":"` + strings.Repeat("x", 100) + `"}}`))
+	f.Add([]byte(`null`))
+
+	limits := ae.DecodeJSONLimits{MaxDepth: 4, MaxCauses: 16, MaxBytes: 4096, MaxAttrValueLen: 32}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, truncate := range []bool{false, true} {
+			limits.Truncate = truncate
+			got, err := ae.DecodeJSON(data, limits)
+			if err != nil {
+				if got != nil {
+					t.Fatalf("got an error and a result: %v", err)
+				}
+				if !slices.Contains(ae.Tags(err), "decode") {
+					t.Fatalf("error not tagged decode: %v", err)
+				}
+				continue
+			}
+			if got == nil {
+				continue
+			}
+
+			// the summary errors of truncated lists sit one level below
+			if d := depthOf(got); d > limits.MaxDepth+1 {
+				t.Fatalf("depth %d exceeds the limit", d)
+			}
+			if n := countErrors(got); n > 2*(limits.MaxCauses+1) {
+				t.Fatalf("%d errors exceed the limit", n)
+			}
+			_ = ae.NewPrinter(ae.PrintJSON()).Prints(got)
+		}
+	})
+}
+
+func TestDecodeJSON_NotStampedLocally(t *testing.T) {
+	ae.SetServiceInfo("local", "1.0.0", "test")
+	withPackageState(t, func() { ae.SetServiceInfo("", "", "") })
+
+	hooked := 0
+	remove := ae.OnFinalize(func(error) { hooked++ })
+	withPackageState(t, remove)
+
+	got, err := ae.DecodeJSON([]byte(`{"message":"","code":"REMOTE","causes":[{"message":"context canceled"}]}`), ae.DecodeJSONLimits{})
+	if err != nil {
+		t.Fatalf("DecodeJSON: %v", err)
+	}
+
+	if attrs := ae.Attributes(got); len(attrs) != 0 {
+		t.Errorf("attributes = %v, want none", attrs)
+	}
+	if ts := ae.Timestamp(got); !ts.IsZero() {
+		t.Errorf("timestamp = %v, want none", ts)
+	}
+	if hooked != 0 {
+		t.Errorf("finalize hooks called %d times, want none", hooked)
+	}
+	if ae.Message(got) != "" {
+		t.Errorf("message = %q, want the rendered empty one", ae.Message(got))
+	}
+}
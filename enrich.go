@@ -0,0 +1,72 @@
+package ae
+
+// The helpers below annotate an error on its way up the call stack without
+// changing its message. Each returns nil for a nil err and never modifies
+// err itself: *Ae errors are cloned (see From), and other errors become the
+// cause of the returned error, so errors.Is and errors.As keep matching them.
+
+// WithHint returns err with its hint set to hint.
+func WithHint(err error, hint string) error {
+	if err == nil {
+		return nil
+	}
+
+	return From(err).Hint(hint).MsgFrom(err)
+}
+
+// WithCode returns err with its code set to code.
+func WithCode(err error, code string) error {
+	if err == nil {
+		return nil
+	}
+
+	return From(err).Code(code).MsgFrom(err)
+}
+
+// WithTags returns err with tags added to its tags.
+func WithTags(err error, tags ...string) error {
+	if err == nil {
+		return nil
+	}
+
+	return From(err).Tags(tags...).MsgFrom(err)
+}
+
+// WithAttr returns err with the attribute key set to value.
+func WithAttr(err error, key string, value any) error {
+	if err == nil {
+		return nil
+	}
+
+	return From(err).Attr(key, value).MsgFrom(err)
+}
+
+// WithUserMsg returns err with its user message set to msg.
+func WithUserMsg(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+
+	b := From(err)
+	b.userMsg = msg
+
+	return b.MsgFrom(err)
+}
+
+// AddRelated returns err with related added to its related errors, e.g.
+// a rollback that failed while handling err. Nil related errors are ignored,
+// as are err itself and errors already among its related errors or causes.
+func AddRelated(err error, related ...error) error {
+	if err == nil {
+		return nil
+	}
+
+	b := From(err)
+	for _, r := range related {
+		if !containsError([]error{err}, r) {
+			b = b.Related(r)
+		}
+	}
+
+	return b.MsgFrom(err)
+}
@@ -0,0 +1,184 @@
+package ae_test
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+func TestWith_NilReturnsNil(t *testing.T) {
+	t.Parallel()
+
+	for name, err := range map[string]error{
+		"WithHint":    ae.WithHint(nil, "h"),
+		"WithCode":    ae.WithCode(nil, "C"),
+		"WithTags":    ae.WithTags(nil, "t"),
+		"WithAttr":    ae.WithAttr(nil, "k", "v"),
+		"WithUserMsg": ae.WithUserMsg(nil, "u"),
+		"AddRelated":  ae.AddRelated(nil, errors.New("r")),
+	} {
+		if err != nil {
+			t.Errorf("%s(nil) = %v, want nil", name, err)
+		}
+	}
+}
+
+func TestWith_SetsFieldAndKeepsMessage(t *testing.T) {
+	t.Parallel()
+
+	base := ae.New().Code("BASE").Tag("base").Attr("a", 1).Msg("loading config")
+	related := errors.New("cleanup failed")
+
+	tests := []struct {
+		name  string
+		err   error
+		check func(err error) bool
+	}{
+		{"WithHint", ae.WithHint(base, "check the path"), func(err error) bool { return ae.Hint(err) == "check the path" }},
+		{"WithCode", ae.WithCode(base, "CONFIG"), func(err error) bool { return ae.Code(err) == "CONFIG" }},
+		{"WithTags", ae.WithTags(base, "config", "io"), func(err error) bool {
+			return slices.Equal(ae.Tags(err), []string{"base", "config", "io"})
+		}},
+		{"WithAttr", ae.WithAttr(base, "path", "/etc/app.yaml"), func(err error) bool {
+			attrs := ae.Attributes(err)
+			return attrs["path"] == "/etc/app.yaml" && attrs["a"] == 1
+		}},
+		{"WithUserMsg", ae.WithUserMsg(base, "Configuration is invalid."), func(err error) bool {
+			return ae.UserMessage(err) == "Configuration is invalid."
+		}},
+		{"AddRelated", ae.AddRelated(base, related, nil), func(err error) bool {
+			r := ae.Related(err)
+			return len(r) == 1 && r[0] == related
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if !tt.check(tt.err) {
+				t.Errorf("field not set: %s", ae.NewPrinter(ae.NoPrintColors()).Prints(tt.err))
+			}
+			if got := ae.Message(tt.err); got != "loading config" {
+				t.Errorf("Message = %q, want the original message", got)
+			}
+			if got, want := ae.Timestamp(tt.err), ae.Timestamp(base); !got.Equal(want) {
+				t.Errorf("Timestamp = %v, want the original %v", got, want)
+			}
+		})
+	}
+}
+
+func TestWith_DoesNotMutateSource(t *testing.T) {
+	t.Parallel()
+
+	base := ae.New().Code("BASE").Hint("h").Tag("base").Attr("a", 1).UserMsg("m", "u")
+	before := base.(*ae.Ae).Clone()
+
+	_ = ae.WithHint(base, "other")
+	_ = ae.WithCode(base, "OTHER")
+	_ = ae.WithTags(base, "extra")
+	_ = ae.WithAttr(base, "a", 2)
+	_ = ae.WithAttr(base, "b", 3)
+	_ = ae.WithUserMsg(base, "other")
+	_ = ae.AddRelated(base, errors.New("r"))
+
+	if diff := ae.Diff(before, base); diff != "" {
+		t.Errorf("source error changed:\n%s", diff)
+	}
+}
+
+func TestWith_Stacking(t *testing.T) {
+	t.Parallel()
+
+	base := ae.Msg("disk full")
+	err := ae.WithUserMsg(
+		ae.WithAttr(
+			ae.WithTags(
+				ae.WithCode(ae.WithHint(base, "free some space"), "NO_SPACE"),
+				"io"),
+			"free_bytes", 0),
+		"Not enough disk space.")
+
+	want := ae.New().
+		Hint("free some space").
+		Code("NO_SPACE").
+		Tag("io").
+		Attr("free_bytes", 0).
+		UserMsg("disk full", "Not enough disk space.")
+	if diff := ae.Diff(want, err); diff != "" {
+		t.Errorf("stacked With* calls:\n%s", diff)
+	}
+	if got, want := ae.Timestamp(err), ae.Timestamp(base); !got.Equal(want) {
+		t.Errorf("Timestamp = %v, want the original %v", got, want)
+	}
+}
+
+func TestWith_NonAeInputStaysReachable(t *testing.T) {
+	t.Parallel()
+
+	err := ae.WithCode(ae.WithTags(fs.ErrNotExist, "config"), "CONFIG_MISSING")
+
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Error("errors.Is(err, fs.ErrNotExist) = false")
+	}
+	if got := ae.Message(err); got != fs.ErrNotExist.Error() {
+		t.Errorf("Message = %q, want %q", got, fs.ErrNotExist.Error())
+	}
+	if got := err.Error(); got != "file does not exist" {
+		t.Errorf("Error() = %q, want the original text without repetition", got)
+	}
+}
+
+func TestAddRelated_SkipsSelf(t *testing.T) {
+	t.Parallel()
+
+	for _, err := range []error{ae.Msg("boom"), errors.New("boom")} {
+		got := ae.AddRelated(err, err)
+		if related := ae.Related(got); len(related) != 0 {
+			t.Errorf("AddRelated(%T, itself): related = %v, want none", err, related)
+		}
+	}
+}
+
+func TestAddRelated_SkipsDuplicates(t *testing.T) {
+	t.Parallel()
+
+	rollback := errors.New("rollback failed")
+	err := ae.AddRelated(ae.Msg("commit failed"), rollback, rollback)
+	err = ae.AddRelated(err, rollback)
+
+	if related := ae.Related(err); len(related) != 1 || related[0] != rollback {
+		t.Errorf("related = %v, want rollback once", related)
+	}
+}
+
+func TestAddRelated_SkipsCauses(t *testing.T) {
+	t.Parallel()
+
+	cause := errors.New("dial tcp: timeout")
+	cleanup := errors.New("cleanup failed")
+	err := ae.New().Cause(cause).Related(cause, cleanup).Msg("query failed")
+	err = ae.AddRelated(err, cause)
+
+	if related := ae.Related(err); len(related) != 1 || related[0] != cleanup {
+		t.Errorf("related = %v, want only the cleanup error", related)
+	}
+	if causes := ae.Causes(err); len(causes) != 1 || causes[0] != cause {
+		t.Errorf("causes = %v, want the cause unchanged", causes)
+	}
+}
+
+func TestRelatedUnwrap_SkipsDuplicates(t *testing.T) {
+	t.Parallel()
+
+	a, b := errors.New("a"), errors.New("b")
+	err := ae.New().Related(a).RelatedUnwrap(errors.Join(a, b, b)).Msg("x")
+
+	if related := ae.Related(err); len(related) != 2 || related[0] != a || related[1] != b {
+		t.Errorf("related = %v, want [a b]", related)
+	}
+}
@@ -0,0 +1,364 @@
+package ae
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// EqualOption configures the comparison performed by Equal and Diff.
+type EqualOption func(*equalOptions)
+
+type equalOptions struct {
+	ignoreTimestamps bool
+	ignoreStacks     bool
+	ignoreTraceIds   bool
+	ignoreAttrs      map[string]struct{}
+}
+
+// IgnoreTimestamps makes Equal ignore the errors' timestamps.
+func IgnoreTimestamps() EqualOption {
+	return func(o *equalOptions) {
+		o.ignoreTimestamps = true
+	}
+}
+
+// IgnoreStacks makes Equal ignore the errors' stack traces.
+func IgnoreStacks() EqualOption {
+	return func(o *equalOptions) {
+		o.ignoreStacks = true
+	}
+}
+
+// IgnoreTraceIds makes Equal ignore the errors' trace, span and request IDs.
+func IgnoreTraceIds() EqualOption {
+	return func(o *equalOptions) {
+		o.ignoreTraceIds = true
+	}
+}
+
+// IgnoreAttrs makes Equal ignore the attributes with the given keys.
+// May be passed multiple times.
+func IgnoreAttrs(keys ...string) EqualOption {
+	return func(o *equalOptions) {
+		if o.ignoreAttrs == nil {
+			o.ignoreAttrs = make(map[string]struct{}, len(keys))
+		}
+		for _, key := range keys {
+			o.ignoreAttrs[key] = struct{}{}
+		}
+	}
+}
+
+// Equal reports whether a and b are structurally equal: same message, user
+// message, hint, recoverability, code, exit code, trace and span IDs,
+// timestamp, tags (as a set), attributes (compared with reflect.DeepEqual),
+// fingerprint override and stack frames, with causes and related errors
+// compared recursively in order. Timestamps are not compared when both
+// were taken automatically as the errors were built (see SetAutoTimestamp),
+// so errors built the same way at different times are equal.
+//
+// Errors that are not an Ae (or *Ae) are compared by their Error() string,
+// and an Ae never equals a non-Ae error. Cycles in the cause tree are
+// tolerated. Two nil errors are equal.
+func Equal(a, b error, opts ...EqualOption) bool {
+	c := newComparer(opts)
+	c.stopEarly = true
+	c.compare("", a, b)
+
+	return len(c.diffs) == 0
+}
+
+// Diff returns a report of the differences between want and got, one per
+// line in the form "path: description", or an empty string if they are
+// Equal under the same options. Paths lead into the cause tree, e.g.
+//
+//	code: want DB_TIMEOUT, got DB_CONN
+//	causes[1].tags: extra {retry}
+//	causes[2]: missing "connection reset"
+func Diff(want, got error, opts ...EqualOption) string {
+	c := newComparer(opts)
+	c.compare("", want, got)
+	if len(c.diffs) == 0 {
+		return ""
+	}
+
+	return strings.Join(c.diffs, "\n") + "\n"
+}
+
+// comparer walks two error trees side by side and records differences as
+// "path: description" lines.
+type comparer struct {
+	opts      equalOptions
+	stopEarly bool
+	diffs     []string
+	// seen holds the pairs of *Ae currently being compared, so that cyclic
+	// trees terminate.
+	seen map[[2]*Ae]struct{}
+}
+
+func newComparer(opts []EqualOption) *comparer {
+	c := &comparer{}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+
+	return c
+}
+
+func (c *comparer) done() bool {
+	return c.stopEarly && len(c.diffs) > 0
+}
+
+func (c *comparer) report(path, field, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if p := joinPath(path, field); p != "" {
+		msg = p + ": " + msg
+	}
+
+	c.diffs = append(c.diffs, msg)
+}
+
+func joinPath(path, field string) string {
+	switch {
+	case path == "":
+		return field
+	case field == "" || strings.HasPrefix(field, "["):
+		return path + field
+	default:
+		return path + "." + field
+	}
+}
+
+func (c *comparer) compare(path string, want, got error) {
+	if c.done() {
+		return
+	}
+
+	if want == nil || got == nil {
+		if want != nil {
+			c.report(path, "", "want %q, got nil", want.Error())
+		} else if got != nil {
+			c.report(path, "", "want nil, got %q", got.Error())
+		}
+		return
+	}
+
+	wa, wok := asAe(want)
+	ga, gok := asAe(got)
+	switch {
+	case !wok && !gok:
+		if want.Error() != got.Error() {
+			c.report(path, "", "want %q, got %q", want.Error(), got.Error())
+		}
+		return
+	case !wok:
+		c.report(path, "", "want %T %q, got ae error %q", want, want.Error(), got.Error())
+		return
+	case !gok:
+		c.report(path, "", "want ae error %q, got %T %q", want.Error(), got, got.Error())
+		return
+	}
+
+	pair := [2]*Ae{wa, ga}
+	if _, ok := c.seen[pair]; ok {
+		return
+	}
+	if c.seen == nil {
+		c.seen = make(map[[2]*Ae]struct{})
+	}
+	c.seen[pair] = struct{}{}
+	defer delete(c.seen, pair)
+
+	c.compareAe(path, wa, ga)
+}
+
+func (c *comparer) compareAe(path string, want, got *Ae) {
+	c.compareString(path, "msg", want.msg, got.msg, true)
+	c.compareString(path, "user_msg", want.userMsg, got.userMsg, true)
+	c.compareUserMessages(path, want.userMsgs, got.userMsgs)
+	c.compareString(path, "hint", want.hint, got.hint, true)
+	if want.recoverable != got.recoverable {
+		c.report(path, "recoverable", "want %t, got %t", want.recoverable, got.recoverable)
+	}
+	c.compareString(path, "code", want.code, got.code, false)
+	if want.exitCode != got.exitCode {
+		c.report(path, "exit_code", "want %d, got %d", want.exitCode, got.exitCode)
+	}
+	c.compareString(path, "fingerprint", want.fingerprint, got.fingerprint, false)
+	if !c.opts.ignoreTraceIds {
+		c.compareString(path, "trace_id", want.traceId, got.traceId, false)
+		c.compareString(path, "span_id", want.spanId, got.spanId, false)
+		c.compareString(path, "request_id", want.requestId, got.requestId, false)
+	}
+	if !c.opts.ignoreTimestamps && !(want.autoStamped && got.autoStamped) && !want.timestamp.Equal(got.timestamp) {
+		c.report(path, "timestamp", "want %s, got %s", want.timestamp, got.timestamp)
+	}
+
+	c.compareTags(path, want, got)
+	c.compareAttributes(path, want, got)
+	if !c.opts.ignoreStacks {
+		c.compareStacks(path, want.stacks, got.stacks)
+	}
+
+	c.compareErrors(path, "causes", want.causes, got.causes)
+	c.compareErrors(path, "related", want.related, got.related)
+}
+
+func (c *comparer) compareString(path, field, want, got string, quote bool) {
+	if c.done() || want == got {
+		return
+	}
+
+	if quote {
+		c.report(path, field, "want %q, got %q", want, got)
+	} else {
+		c.report(path, field, "want %s, got %s", orNone(want), orNone(got))
+	}
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+
+	return s
+}
+
+func (c *comparer) compareUserMessages(path string, want, got map[string]string) {
+	for _, locale := range slices.Sorted(maps.Keys(want)) {
+		if c.done() {
+			return
+		}
+		if g, ok := got[locale]; !ok {
+			c.report(path, "user_messages["+locale+"]", "missing")
+		} else if g != want[locale] {
+			c.report(path, "user_messages["+locale+"]", "want %q, got %q", want[locale], g)
+		}
+	}
+	for _, locale := range slices.Sorted(maps.Keys(got)) {
+		if c.done() {
+			return
+		}
+		if _, ok := want[locale]; !ok {
+			c.report(path, "user_messages["+locale+"]", "extra %q", got[locale])
+		}
+	}
+}
+
+func (c *comparer) compareTags(path string, want, got *Ae) {
+	if c.done() {
+		return
+	}
+
+	var missing, extra []string
+	for tag := range want.tags {
+		if _, ok := got.tags[tag]; !ok {
+			missing = append(missing, tag)
+		}
+	}
+	for tag := range got.tags {
+		if _, ok := want.tags[tag]; !ok {
+			extra = append(extra, tag)
+		}
+	}
+
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		c.report(path, "tags", "missing {%s}", strings.Join(missing, ", "))
+	}
+	if len(extra) > 0 {
+		slices.Sort(extra)
+		c.report(path, "tags", "extra {%s}", strings.Join(extra, ", "))
+	}
+}
+
+func (c *comparer) compareAttributes(path string, want, got *Ae) {
+	if c.done() {
+		return
+	}
+
+	keys := make(map[string]struct{}, len(want.attributes)+len(got.attributes))
+	for key := range want.attributes {
+		keys[key] = struct{}{}
+	}
+	for key := range got.attributes {
+		keys[key] = struct{}{}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(keys)) {
+		if _, ok := c.opts.ignoreAttrs[key]; ok {
+			continue
+		}
+
+		field := "attributes[" + key + "]"
+		wv, wok := want.attributes[key]
+		gv, gok := got.attributes[key]
+		switch {
+		case !gok:
+			c.report(path, field, "missing (want %v)", wv)
+		case !wok:
+			c.report(path, field, "extra (got %v)", gv)
+		case !reflect.DeepEqual(wv, gv):
+			c.report(path, field, "want %v, got %v", wv, gv)
+		}
+	}
+}
+
+func (c *comparer) compareStacks(path string, want, got []*Stack) {
+	if c.done() {
+		return
+	}
+
+	if len(want) != len(got) {
+		c.report(path, "stacks", "want %d, got %d", len(want), len(got))
+		return
+	}
+
+	for i := range want {
+		want[i].resolve()
+		got[i].resolve()
+
+		field := fmt.Sprintf("stacks[%d]", i)
+		wf, gf := want[i].Frames, got[i].Frames
+		if len(wf) != len(gf) {
+			c.report(path, field, "want %d frames, got %d", len(wf), len(gf))
+			continue
+		}
+		for j := range wf {
+			if wf[j].Func != gf[j].Func || wf[j].File != gf[j].File || wf[j].Line != gf[j].Line {
+				c.report(path, fmt.Sprintf("%s.frames[%d]", field, j), "want %s (%s:%d), got %s (%s:%d)",
+					wf[j].Func, wf[j].File, wf[j].Line, gf[j].Func, gf[j].File, gf[j].Line)
+				break
+			}
+		}
+	}
+}
+
+func (c *comparer) compareErrors(path, field string, want, got []error) {
+	for i := range max(len(want), len(got)) {
+		if c.done() {
+			return
+		}
+
+		elem := joinPath(path, fmt.Sprintf("%s[%d]", field, i))
+		switch {
+		case i >= len(got):
+			c.report(elem, "", "missing %q", errorString(want[i]))
+		case i >= len(want):
+			c.report(elem, "", "extra %q", errorString(got[i]))
+		default:
+			c.compare(elem, want[i], got[i])
+		}
+	}
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return "<nil>"
+	}
+
+	return err.Error()
+}
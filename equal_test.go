@@ -0,0 +1,279 @@
+package ae_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.aledante.io/ae"
+)
+
+var equalTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// equalBase returns a builder with every comparable field set, so each
+// near-miss case below differs from it in exactly one field.
+func equalBase() ae.Builder {
+	return ae.New().
+		Timestamp(equalTime).
+		Hint("try again").
+		Code("DB_TIMEOUT").
+		ExitCode(3).
+		TraceId("0af7651916cd43dd8448eb211c80319c").
+		SpanId("b7ad6b7169203331").
+		Tags("db", "retry").
+		Attr("table", "users").
+		Attr("ids", []any{1, 2}).
+		Cause(errors.New("dial tcp: timeout")).
+		Related(errors.New("rollback failed"))
+}
+
+func TestEqual(t *testing.T) {
+	t.Parallel()
+
+	want := equalBase().UserMsg("query failed", "Please retry.")
+
+	tests := []struct {
+		name string
+		got  error
+		opts []ae.EqualOption
+		want bool
+	}{
+		{"identical", equalBase().UserMsg("query failed", "Please retry."), nil, true},
+		{"same value and pointer", *want.(*ae.Ae), nil, true},
+		{"tag order", equalBase().Tags("retry", "db").UserMsg("query failed", "Please retry."), nil, true},
+		{"message", equalBase().UserMsg("query failed!", "Please retry."), nil, false},
+		{"user message", equalBase().UserMsg("query failed", "Retry."), nil, false},
+		{"hint", equalBase().Hint("give up").UserMsg("query failed", "Please retry."), nil, false},
+		{"recoverable", equalBase().Fatal().UserMsg("query failed", "Please retry."), nil, false},
+		{"code", equalBase().Code("DB_CONN").UserMsg("query failed", "Please retry."), nil, false},
+		{"exit code", equalBase().ExitCode(4).UserMsg("query failed", "Please retry."), nil, false},
+		{"fingerprint", equalBase().Fingerprint("fp").UserMsg("query failed", "Please retry."), nil, false},
+		{"extra tag", equalBase().Tag("extra").UserMsg("query failed", "Please retry."), nil, false},
+		{"attr value", equalBase().Attr("table", "orders").UserMsg("query failed", "Please retry."), nil, false},
+		{"nested attr value", equalBase().Attr("ids", []any{1, 3}).UserMsg("query failed", "Please retry."), nil, false},
+		{"extra attr", equalBase().Attr("rows", 0).UserMsg("query failed", "Please retry."), nil, false},
+		{"extra attr ignored", equalBase().Attr("rows", 0).UserMsg("query failed", "Please retry."), []ae.EqualOption{ae.IgnoreAttrs("rows")}, true},
+		{"timestamp", equalBase().Timestamp(equalTime.Add(time.Second)).UserMsg("query failed", "Please retry."), nil, false},
+		{"timestamp ignored", equalBase().Timestamp(equalTime.Add(time.Second)).UserMsg("query failed", "Please retry."), []ae.EqualOption{ae.IgnoreTimestamps()}, true},
+		{"trace id", equalBase().TraceId("x").UserMsg("query failed", "Please retry."), nil, false},
+		{"span id ignored", equalBase().SpanId("x").UserMsg("query failed", "Please retry."), []ae.EqualOption{ae.IgnoreTraceIds()}, true},
+		{"stack", equalBase().Stack().UserMsg("query failed", "Please retry."), nil, false},
+		{"stack ignored", equalBase().Stack().UserMsg("query failed", "Please retry."), []ae.EqualOption{ae.IgnoreStacks()}, true},
+		{"cause message", equalBase().Causes([]error{errors.New("dial tcp: refused")}).UserMsg("query failed", "Please retry."), nil, false},
+		{"extra cause", equalBase().Cause(errors.New("again")).UserMsg("query failed", "Please retry."), nil, false},
+		{"extra related", equalBase().Related(errors.New("again")).UserMsg("query failed", "Please retry."), nil, false},
+		{"std error", errors.New("query failed: dial tcp: timeout"), nil, false},
+		{"nil", nil, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ae.Equal(want, tt.got, tt.opts...); got != tt.want {
+				t.Errorf("Equal(want, got) = %t, want %t", got, tt.want)
+			}
+			if got := ae.Equal(tt.got, want, tt.opts...); got != tt.want {
+				t.Errorf("Equal(got, want) = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEqual_AutoTimestamps(t *testing.T) {
+	t.Parallel()
+
+	a, b := ae.New().Code("X").Msg("x"), ae.New().Code("X").Msg("x")
+	if !ae.Equal(a, b) {
+		t.Errorf("errors built the same way differ:\n%s", ae.Diff(a, b))
+	}
+
+	// a timestamp that was set is still compared
+	explicit := ae.New().Code("X").Timestamp(ae.Timestamp(a).Add(-time.Hour)).Msg("x")
+	if ae.Equal(a, explicit) {
+		t.Error("an automatic timestamp equals a different explicit one")
+	}
+}
+
+func TestEqual_StdErrorsByString(t *testing.T) {
+	t.Parallel()
+
+	if !ae.Equal(errors.New("boom"), fmt.Errorf("boom")) {
+		t.Error("std errors with the same text should be equal")
+	}
+	if ae.Equal(errors.New("boom"), errors.New("bang")) {
+		t.Error("std errors with different text should not be equal")
+	}
+	if !ae.Equal(nil, nil) {
+		t.Error("nil errors should be equal")
+	}
+}
+
+func TestEqual_NestedCauses(t *testing.T) {
+	t.Parallel()
+
+	build := func(innerCode string) error {
+		inner := ae.New().Code(innerCode).Msg("inner")
+		mid := fmt.Errorf("mid: %w", errors.New("leaf"))
+		return ae.New().Cause(mid, ae.New().Cause(inner).Msg("wrapper")).Msg("outer")
+	}
+
+	if !ae.Equal(build("A"), build("A"), ae.IgnoreTimestamps()) {
+		t.Error("identical mixed trees should be equal")
+	}
+	if ae.Equal(build("A"), build("B"), ae.IgnoreTimestamps()) {
+		t.Error("trees differing in a nested code should not be equal")
+	}
+}
+
+func TestEqual_Cycle(t *testing.T) {
+	t.Parallel()
+
+	cycle := func() error {
+		a := ae.New().Msg("a").(*ae.Ae)
+		b := ae.New().Cause(a).Msg("b")
+		*a = *ae.New().Cause(b).Msg("a").(*ae.Ae)
+		return a
+	}
+
+	x, y := cycle(), cycle()
+	if !ae.Equal(x, x) {
+		t.Error("a cyclic error should equal itself")
+	}
+	if !ae.Equal(x, y, ae.IgnoreTimestamps()) {
+		t.Error("two identical cyclic errors should be equal")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	want := ae.New().
+		Timestamp(equalTime).
+		Code("DB_TIMEOUT").
+		Tag("db").
+		Cause(
+			errors.New("dial tcp: timeout"),
+			ae.New().Tag("pool").Attr("size", 10).Msg("pool exhausted"),
+			errors.New("connection reset"),
+		).
+		Msg("query failed")
+
+	tests := []struct {
+		name string
+		got  error
+		opts []ae.EqualOption
+		want string
+	}{
+		{
+			name: "equal",
+			got:  want,
+			want: "",
+		},
+		{
+			name: "top-level fields",
+			got: ae.New().
+				Timestamp(equalTime).
+				Code("DB_CONN").
+				Tags("db", "net").
+				Attr("host", "db1").
+				Cause(
+					errors.New("dial tcp: timeout"),
+					ae.New().Tag("pool").Attr("size", 10).Msg("pool exhausted"),
+					errors.New("connection reset"),
+				).
+				Msg("query failed!"),
+			want: `msg: want "query failed", got "query failed!"
+code: want DB_TIMEOUT, got DB_CONN
+tags: extra {net}
+attributes[host]: extra (got db1)
+`,
+		},
+		{
+			name: "nested cause fields",
+			got: ae.New().
+				Timestamp(equalTime).
+				Code("DB_TIMEOUT").
+				Tag("db").
+				Cause(
+					errors.New("dial tcp: timeout"),
+					ae.New().Tags("pool", "retry").Attr("size", 20).Msg("pool exhausted"),
+					errors.New("connection reset"),
+				).
+				Msg("query failed"),
+			opts: []ae.EqualOption{ae.IgnoreTimestamps()},
+			want: `causes[1].tags: extra {retry}
+causes[1].attributes[size]: want 10, got 20
+`,
+		},
+		{
+			name: "missing cause and kind mismatch",
+			got: ae.New().
+				Timestamp(equalTime).
+				Code("DB_TIMEOUT").
+				Tag("db").
+				Cause(
+					ae.New().Msg("dial tcp: timeout"),
+					errors.New("pool exhausted"),
+				).
+				Msg("query failed"),
+			opts: []ae.EqualOption{ae.IgnoreTimestamps()},
+			want: `causes[0]: want *errors.errorString "dial tcp: timeout", got ae error "dial tcp: timeout"
+causes[1]: want ae error "pool exhausted", got *errors.errorString "pool exhausted"
+causes[2]: missing "connection reset"
+`,
+		},
+		{
+			name: "extra cause, ignored attrs",
+			got: ae.New().
+				Timestamp(equalTime).
+				Code("DB_TIMEOUT").
+				Tag("db").
+				Cause(
+					errors.New("dial tcp: timeout"),
+					ae.New().Tag("pool").Attr("size", 99).Msg("pool exhausted"),
+					errors.New("connection reset"),
+					errors.New("broken pipe"),
+				).
+				Msg("query failed"),
+			opts: []ae.EqualOption{ae.IgnoreAttrs("size")},
+			want: `causes[3]: extra "broken pipe"
+`,
+		},
+		{
+			name: "nil",
+			got:  nil,
+			want: `want "query failed: [dial tcp: timeout; pool exhausted; connection reset]", got nil
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ae.Diff(want, tt.got, tt.opts...); got != tt.want {
+				t.Errorf("Diff() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if equal := ae.Equal(want, tt.got, tt.opts...); equal != (tt.want == "") {
+				t.Errorf("Equal() = %t, inconsistent with Diff", equal)
+			}
+		})
+	}
+}
+
+func TestDiff_UserMessages(t *testing.T) {
+	t.Parallel()
+
+	want := ae.New().UserMsgLocale("de", "Fehler").UserMsgLocale("fr", "Erreur").Msg("boom")
+	got := ae.New().UserMsgLocale("de", "Störung").UserMsgLocale("it", "Errore").Msg("boom")
+
+	const diff = `user_messages[de]: want "Fehler", got "Störung"
+user_messages[fr]: missing
+user_messages[it]: extra "Errore"
+`
+	if d := ae.Diff(want, got); d != diff {
+		t.Errorf("Diff =\n%s\nwant\n%s", d, diff)
+	}
+}
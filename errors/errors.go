@@ -2,7 +2,6 @@ package errors
 
 import (
 	stdErrors "errors"
-	"strings"
 
 	"go.aledante.io/ae"
 )
@@ -17,9 +16,10 @@ func New(msg string) error {
 // Nil entries are filtered before the combination is decided:
 //   - If all inputs are nil (or the list is empty), returns nil.
 //   - If exactly one non-nil error is supplied, returns it directly.
-//   - Otherwise, creates an ae error whose message joins every sub-message
-//     with semicolons inside square brackets and whose causes are the
-//     surviving non-nil errors.
+//   - Otherwise, creates an ae error whose causes are the surviving non-nil
+//     errors and whose message falls back to "N errors". Its Error text lists
+//     the causes with semicolons inside square brackets, cut at the limits
+//     set with ae.SetErrorTextLimits.
 func Join(errs ...error) error {
 	var filtered []error
 	for _, err := range errs {
@@ -34,21 +34,9 @@ func Join(errs ...error) error {
 	case 1:
 		return filtered[0]
 	default:
-		var sb strings.Builder
-		sb.WriteRune('[')
-
-		for i, err := range filtered {
-			if i > 0 {
-				sb.WriteString("; ")
-			}
-
-			sb.WriteString(err.Error())
-		}
-
-		sb.WriteRune(']')
 		return ae.New().
 			Causes(filtered).
-			Msg(sb.String())
+			Msg("")
 	}
 }
 
@@ -2,9 +2,11 @@ package errors_test
 
 import (
 	stdErrors "errors"
+	"fmt"
 	"strings"
 	"testing"
 
+	"go.aledante.io/ae"
 	aeerrors "go.aledante.io/ae/errors"
 )
 
@@ -87,6 +89,43 @@ func TestJoin_MultipleErrorsBracketedWithCauses(t *testing.T) {
 	}
 }
 
+// TestJoin_FallbackMessage asserts that a join carries no message of its own
+// and falls back to "N errors", as documented on ae.Builder.Msg.
+func TestJoin_FallbackMessage(t *testing.T) {
+	t.Parallel()
+
+	got := aeerrors.Join(stdErrors.New("one"), nil, stdErrors.New("two"))
+	if msg := ae.Message(got); msg != "2 errors" {
+		t.Errorf("Message(joined) = %q, want %q", msg, "2 errors")
+	}
+	if ae.Attributes(got)["msg_fallback"] != true {
+		t.Errorf("msg_fallback not set on the joined error")
+	}
+	if want := "2 errors: [one; two]"; got.Error() != want {
+		t.Errorf("Error() = %q, want %q", got.Error(), want)
+	}
+}
+
+// TestJoin_ErrorTextCapped asserts that the text of a large join stays within
+// the default limits of ae.SetErrorTextLimits instead of repeating every
+// member's text in the message.
+func TestJoin_ErrorTextCapped(t *testing.T) {
+	t.Parallel()
+
+	errs := make([]error, 1000)
+	for i := range errs {
+		errs[i] = fmt.Errorf("member %d failed", i)
+	}
+
+	got := aeerrors.Join(errs...).Error()
+	if len(got) > 4096 {
+		t.Errorf("len(Error()) = %d, want at most 4096", len(got))
+	}
+	if !strings.Contains(got, "… and 992 more") {
+		t.Errorf("Error() = %q, want the omitted members summarized", got)
+	}
+}
+
 func TestIs_ProxiesToStdErrors(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,23 @@
+module go.aledante.io/ae/examples/catalog
+
+go 1.26.0
+
+require go.aledante.io/ae v0.0.0-00010101000000-000000000000
+
+require (
+	github.com/DataDog/gostackparse v0.7.0 // indirect
+	github.com/fatih/color v1.18.0 // indirect
+	github.com/mattn/go-colorable v0.1.14 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	go.aledante.io/ae/cmd/aegen v0.0.0-00010101000000-000000000000 // indirect
+	go.opentelemetry.io/otel v1.38.0 // indirect
+	go.opentelemetry.io/otel/trace v1.38.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace go.aledante.io/ae => ../..
+
+tool go.aledante.io/ae/cmd/aegen
+
+replace go.aledante.io/ae/cmd/aegen => ../../cmd/aegen
@@ -0,0 +1,26 @@
+package main
+
+//go:generate go run go.aledante.io/ae/cmd/aegen -catalog errors.yaml
+
+import (
+	"errors"
+	"fmt"
+
+	"go.aledante.io/ae"
+)
+
+func findUser(id string) error {
+	return ae.From(ErrUserNotFound).
+		Attr("user_id", id).
+		Msgf("user %s not found", id)
+}
+
+func main() {
+	err := findUser("42")
+
+	// the error derived from the sentinel matches it, and exits with the
+	// exit code registered for its code in the catalog
+	fmt.Println(errors.Is(err, ErrUserNotFound), ae.ExitCode(err))
+
+	ae.PrintExitCompact(err)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+
+	"go.aledante.io/ae"
+)
+
+var (
+	ErrUserNotFound = ae.New().
+			Code("USER_NOT_FOUND").
+			Tag("not-found").
+			Hint("check the user ID").
+			UserMsg("user not found", "The user does not exist.")
+
+	ErrConfigInvalid = ae.New().
+				Code("CONFIG_INVALID").
+				Tag("config").
+				Hint("run with --check-config to list the problems").
+				Msg("invalid configuration")
+)
+
+func init() {
+	ae.RegisterCode(ErrUserNotFound)
+	ae.RegisterCode(ErrConfigInvalid)
+	ae.RegisterExitCodeForCode("CONFIG_INVALID", 12)
+	ae.RegisterExitCodeForCode("RATE_LIMITED", 75)
+}
+
+func main() {
+	// a Markdown table of every code the program can emit, for a support
+	// page; ae.WriteCodesJSON(os.Stdout) renders the same report as JSON
+	if err := ae.WriteCodesMarkdown(os.Stdout); err != nil {
+		ae.PrintExit(err)
+	}
+}
@@ -1,15 +1,17 @@
 package main
 
 import (
-	"os"
-
 	"go.aledante.io/ae"
 )
 
 func main() {
 	err := ae.New().
 		Cause(ae.New().ExitCode(200).Msg("cause")).
-		Msg("should exit with 200")
+		Hint("run with --verbose for the full error").
+		Stack().
+		UserMsg("should exit with 200", "The operation failed.")
 
-	os.Exit(ae.ExitCode(err))
+	// Prints the user message and hint to stderr, without the stack, then
+	// exits with 200. ae.PrintExit(err) would print every field instead.
+	ae.PrintExitCompact(err)
 }
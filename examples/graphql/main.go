@@ -0,0 +1,51 @@
+// Example graphql shows how to wire ae.GraphQLExtensions and ae.GraphQLMessage
+// into a gqlgen error presenter. gqlError mirrors the shape of
+// gqlerror.Error so the example runs without pulling in gqlgen; with gqlgen
+// the presenter is installed via:
+//
+//	srv.SetErrorPresenter(func(ctx context.Context, err error) *gqlerror.Error {
+//		gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+//		gqlErr.Message = ae.GraphQLMessage(err)
+//		gqlErr.Extensions = ae.GraphQLExtensions(err, "user_id")
+//		return gqlErr
+//	})
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go.aledante.io/ae"
+)
+
+// gqlError mirrors the fields of gqlerror.Error the presenter fills in.
+type gqlError struct {
+	Message    string         `json:"message"`
+	Path       []string       `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// presenter converts a resolver error into a GraphQL error. Only the user
+// message and the whitelisted "user_id" attribute reach the client.
+func presenter(_ context.Context, err error) *gqlError {
+	return &gqlError{
+		Message:    ae.GraphQLMessage(err),
+		Extensions: ae.GraphQLExtensions(err, "user_id"),
+	}
+}
+
+func main() {
+	resolverErr := ae.New().
+		Code("USER_NOT_FOUND").
+		Tag("users").
+		Attr("user_id", 42).
+		Attr("query", "SELECT * FROM users WHERE id = 42").
+		UserMsg("no rows for user 42", "User not found.")
+
+	for _, err := range []error{resolverErr, errors.New("pq: connection refused")} {
+		out, _ := json.MarshalIndent(presenter(context.Background(), err), "", "  ")
+		fmt.Println(string(out))
+	}
+}
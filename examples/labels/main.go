@@ -0,0 +1,57 @@
+// Example labels prints errors with the text printer's labels translated to
+// German via ae.PrintLabels.
+package main
+
+import (
+	"errors"
+
+	"go.aledante.io/ae"
+)
+
+// LabelsDE are German labels for the text printer.
+var LabelsDE = ae.Labels{
+	Error:     "[FEHLER]",
+	NoMessage: "(keine Meldung)",
+	Exit:      "Exit-Code",
+	Truncated: "… tiefere Fehler abgeschnitten",
+
+	InheritedAttrs: "(%d geerbte Attribute)",
+
+	Hint:     "Hinweis",
+	Shown:    "Angezeigt",
+	Time:     "Zeit",
+	Trace:    "Trace",
+	Span:     "Span",
+	Request:  "Anfrage",
+	Attrs:    "Attribute",
+	CausedBy: "Ursache",
+	Related:  "Verwandt",
+	Stack:    "Stack",
+
+	Errors:      "Fehler",
+	Codes:       "Codes",
+	Tags:        "Tags",
+	First:       "Erster",
+	Last:        "Letzter",
+	Examples:    "Beispiele",
+	ErrorCounts: "%d im Baum, %d Blätter",
+	NoCode:      "(kein Code)",
+}
+
+func main() {
+	err := ae.New().
+		Code("CONFIG_INVALID").
+		Hint("Prüfen Sie die Konfigurationsdatei").
+		Tag("config").
+		Attr("path", "/etc/app/config.yaml").
+		Cause(errors.New("unexpected end of file")).
+		Related(ae.Msg("backup configuration missing")).
+		UserMsg("loading configuration", "Die Konfiguration konnte nicht geladen werden.")
+
+	ae.Print(err, ae.PrintLabels(LabelsDE))
+
+	ae.Print(ae.WrapMany("checking services",
+		ae.New().Code("TIMEOUT").Msg("database"),
+		ae.New().Code("TIMEOUT").Msg("cache"),
+	), ae.PrintLabels(LabelsDE), ae.PrintSummary())
+}
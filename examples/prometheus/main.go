@@ -0,0 +1,34 @@
+// Example prometheus counts every ae error created by the process with an
+// aeprom.Counter installed through ae.OnFinalize, then prints the metrics.
+package main
+
+import (
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"go.aledante.io/ae"
+	"go.aledante.io/ae/aeprom"
+)
+
+func main() {
+	reg := prometheus.NewRegistry()
+	counter := aeprom.NewCounter(reg, aeprom.Opts{
+		Namespace: "app",
+		MaxCodes:  50,
+	})
+
+	// Count every finalized error.
+	remove := ae.OnFinalize(counter.Observe)
+	defer remove()
+
+	_ = ae.New().Code("DB_TIMEOUT").Tag("db").Msg("query timed out")
+	_ = ae.New().Code("DB_TIMEOUT").Tag("db").Msg("query timed out again")
+	_ = ae.New().Fatal().Msg("config invalid")
+
+	families, _ := reg.Gather()
+	enc := expfmt.NewEncoder(os.Stdout, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, mf := range families {
+		_ = enc.Encode(mf)
+	}
+}
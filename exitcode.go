@@ -1,5 +1,14 @@
 package ae
 
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+)
+
 // ErrorExitCode defines an interface for errors that can provide an exit code.
 type ErrorExitCode interface {
 	// ErrorExitCode returns the exit code associated with the error.
@@ -12,23 +21,241 @@ type ErrorExitCode interface {
 //   - Returns 0 when err is nil.
 //   - If the error implements ErrorExitCode and that method returns a
 //     positive value, returns that value.
+//   - If the error has an ExitCode() int method (e.g. *exec.ExitError) that
+//     returns a positive value, returns that value.
 //   - Otherwise recurses through causes and returns the highest exit code
-//     found, defaulting to 1 when no cause provides one.
+//     found. Causes below the maximum traversal depth (see
+//     SetMaxTraversalDepth) are not considered.
+//   - If no error sets one, returns the highest exit code registered with
+//     RegisterExitCode or RegisterExitCodeForCode for a tag or code of any
+//     error in the cause tree, defaulting to 1.
 func ExitCode(err error) int {
+	return exitCode(err, nil)
+}
+
+// exitCode is ExitCode, remembering the exit codes set by comparable errors
+// in memo if it is non-nil, so repeated calls for the errors of one tree (as
+// made by the printer) don't walk the same subtrees again.
+func exitCode(err error, memo map[error]int) int {
 	if err == nil {
 		return 0
 	}
 
-	if ae, ok := err.(ErrorExitCode); ok && ae.ErrorExitCode() > 0 {
-		return ae.ErrorExitCode()
+	if code := explicitExitCode(err, memo, 0); code > 0 {
+		return code
+	}
+	if code := registeredExitCode(err); code > 0 {
+		return code
+	}
+
+	return 1
+}
+
+// explicitExitCode returns the highest exit code set by err or its causes, or
+// 0 if none sets one, for an error depth levels below the one ExitCode was
+// called on.
+func explicitExitCode(err error, memo map[error]int, depth int) int {
+	if err == nil {
+		return 0
+	}
+
+	cacheable := memo != nil && reflect.TypeOf(err).Comparable()
+	if cacheable {
+		if code, ok := memo[err]; ok {
+			return code
+		}
+	}
+
+	code := 0
+	var causes []error
+	if a, ok := asAe(err); ok {
+		// Ae.ErrorExitCode would walk the causes itself; walking them here
+		// uses the memo.
+		code, causes = a.exitCode, a.causes
+	} else {
+		if x, ok := err.(ErrorExitCode); ok {
+			code = x.ErrorExitCode()
+		}
+		if x, ok := err.(exitCoder); ok && code <= 0 {
+			code = x.ExitCode()
+		}
+		if code <= 0 {
+			causes = Causes(err)
+		}
+	}
+
+	if depth >= traversalDepth() {
+		causes = nil
+	}
+
+	if code <= 0 {
+		code = 0
+		for _, cause := range causes {
+			code = max(code, explicitExitCode(cause, memo, depth+1))
+		}
+	}
+
+	if cacheable {
+		memo[err] = code
+	}
+
+	return code
+}
+
+// exitCodeRule maps a tag or an error code to an exit code, see
+// RegisterExitCode.
+type exitCodeRule struct {
+	tag      string
+	code     string
+	exitCode int
+	// file and line locate the registration of a code, see CodesReport.
+	file string
+	line int
+}
+
+var (
+	exitCodeRulesMu sync.RWMutex
+	exitCodeRules   []*exitCodeRule
+)
+
+// RegisterExitCode makes ExitCode return exitCode for errors tagged with tag,
+// anywhere in their cause tree, unless an error in the tree sets an exit code
+// itself. This keeps a documented exit-code contract ("network failures exit
+// 11") in one place instead of at every call site:
+//
+//	ae.RegisterExitCode("network", 11)
+//	ae.RegisterExitCode("config", 12)
+//
+// When several registered tags or codes match, the highest exit code wins.
+// Registration is meant for init time; the returned function unregisters the
+// mapping. A non-positive exitCode is ignored.
+func RegisterExitCode(tag string, exitCode int) (remove func()) {
+	return registerExitCodeRule(&exitCodeRule{tag: tag, exitCode: exitCode})
+}
+
+// RegisterExitCodeForCode is RegisterExitCode for errors with the error code
+// code (see Builder.Code) rather than a tag. The code is listed by
+// CodesReport.
+func RegisterExitCodeForCode(code string, exitCode int) (remove func()) {
+	r := &exitCodeRule{code: code, exitCode: exitCode}
+	_, r.file, r.line, _ = runtime.Caller(1)
+
+	return registerExitCodeRule(r)
+}
+
+func registerExitCodeRule(r *exitCodeRule) (remove func()) {
+	if r.exitCode <= 0 {
+		return func() {}
+	}
+
+	exitCodeRulesMu.Lock()
+	defer exitCodeRulesMu.Unlock()
+	exitCodeRules = append(exitCodeRules, r)
+
+	return func() {
+		exitCodeRulesMu.Lock()
+		defer exitCodeRulesMu.Unlock()
+
+		for i, other := range exitCodeRules {
+			if other == r {
+				exitCodeRules = append(exitCodeRules[:i:i], exitCodeRules[i+1:]...)
+				return
+			}
+		}
 	}
+}
 
-	exitCode := 1
-	for _, cause := range Causes(err) {
-		if ec := ExitCode(cause); ec > exitCode {
-			exitCode = ec
+// registeredExitCode returns the highest exit code registered for a tag or
+// code of err or any error in its cause tree, or 0 if none is.
+func registeredExitCode(err error) int {
+	exitCodeRulesMu.RLock()
+	rules := exitCodeRules
+	exitCodeRulesMu.RUnlock()
+
+	if len(rules) == 0 {
+		return 0
+	}
+
+	tags := make(map[string]struct{})
+	codes := make(map[string]struct{})
+	walkCauses(err, func(err error) bool {
+		for tag := range tagSeq(err) {
+			tags[tag] = struct{}{}
+		}
+		if code := Code(err); code != "" {
+			codes[code] = struct{}{}
+		}
+		return true
+	})
+
+	exitCode := 0
+	for _, r := range rules {
+		_, tagged := tags[r.tag]
+		_, coded := codes[r.code]
+		if (r.tag != "" && tagged) || (r.code != "" && coded) {
+			exitCode = max(exitCode, r.exitCode)
 		}
 	}
 
 	return exitCode
 }
+
+// exitCoder is implemented by errors carrying a process exit code without
+// implementing ErrorExitCode, such as *exec.ExitError.
+type exitCoder interface {
+	ExitCode() int
+}
+
+// cliError adapts an error to the ExitCoder interface checked by CLI
+// frameworks such as urfave/cli.
+type cliError struct {
+	err error
+}
+
+// Error returns the wrapped error's message.
+func (c cliError) Error() string {
+	return c.err.Error()
+}
+
+// ExitCode returns the exit code of the wrapped error, as computed by ExitCode.
+func (c cliError) ExitCode() int {
+	return ExitCode(c.err)
+}
+
+// Unwrap returns the wrapped error.
+func (c cliError) Unwrap() error {
+	return c.err
+}
+
+// CliError wraps err so it implements the ExitCoder interface
+// (ExitCode() int) used by CLI frameworks such as urfave/cli to choose the
+// process exit code. The wrapper delegates Error to err, ExitCode to
+// ExitCode(err) and unwraps to err, so errors.Is and errors.As keep working.
+// Returns nil if err is nil.
+func CliError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return cliError{err: err}
+}
+
+// FromExec creates a Builder from an error returned by running an external
+// command, like From. In addition, when an *exec.ExitError is found in err's
+// chain, its captured Stderr (see exec.Cmd.Output) is attached as the
+// "exec.stderr" attribute. The child's exit code is picked up by From.
+func FromExec(err error) Builder {
+	b := From(err)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if stderr := strings.TrimSpace(string(exitErr.Stderr)); stderr != "" {
+			b = b.Attr("exec.stderr", stderr)
+		}
+		if b.exitCode == 0 && exitErr.ExitCode() > 0 {
+			b.exitCode = exitErr.ExitCode()
+		}
+	}
+
+	return b
+}
@@ -2,7 +2,12 @@ package ae_test
 
 import (
 	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"go.aledante.io/ae"
 )
@@ -91,3 +96,221 @@ func TestAe_ErrorExitCodeInterfaceRecurses(t *testing.T) {
 		t.Errorf("Ae.ErrorExitCode() = %d, want 11", ec)
 	}
 }
+
+func TestCliError_Nil(t *testing.T) {
+	t.Parallel()
+
+	if got := ae.CliError(nil); got != nil {
+		t.Errorf("CliError(nil) = %v, want nil", got)
+	}
+}
+
+func TestCliError_DelegatesToWrappedError(t *testing.T) {
+	t.Parallel()
+
+	sentinel := errors.New("disk full")
+	err := ae.CliError(ae.New().ExitCode(3).Cause(sentinel).Msg("write failed"))
+
+	if got := err.Error(); got != "write failed: disk full" {
+		t.Errorf("Error() = %q, want %q", got, "write failed: disk full")
+	}
+	if !errors.Is(err, sentinel) {
+		t.Error("errors.Is does not reach the wrapped cause")
+	}
+
+	// the interface CLI frameworks such as urfave/cli check
+	var coder interface{ ExitCode() int }
+	if !errors.As(err, &coder) {
+		t.Fatal("CliError does not implement ExitCode() int")
+	}
+	if got := coder.ExitCode(); got != 3 {
+		t.Errorf("ExitCode() = %d, want 3", got)
+	}
+}
+
+// fakeExitCoder mimics *exec.ExitError: it exposes ExitCode() int but not
+// ErrorExitCode.
+type fakeExitCoder struct{ code int }
+
+func (f fakeExitCoder) Error() string { return fmt.Sprintf("exit status %d", f.code) }
+func (f fakeExitCoder) ExitCode() int { return f.code }
+
+func TestExitCode_UsesExitCoderMethod(t *testing.T) {
+	t.Parallel()
+
+	if got := ae.ExitCode(fakeExitCoder{code: 3}); got != 3 {
+		t.Errorf("ExitCode(fakeExitCoder{3}) = %d, want 3", got)
+	}
+}
+
+func TestExitCode_IgnoresNonPositiveExitCoder(t *testing.T) {
+	t.Parallel()
+
+	// *exec.ExitError reports -1 for a child killed by a signal.
+	if got := ae.ExitCode(fakeExitCoder{code: -1}); got != 1 {
+		t.Errorf("ExitCode(fakeExitCoder{-1}) = %d, want default 1", got)
+	}
+}
+
+func TestExitCode_ExitCoderThroughWrap(t *testing.T) {
+	t.Parallel()
+
+	err := ae.Wrap("running migration", fakeExitCoder{code: 5})
+	if got := ae.ExitCode(err); got != 5 {
+		t.Errorf("ExitCode(wrapped) = %d, want 5", got)
+	}
+}
+
+func TestFrom_PicksUpExitCoder(t *testing.T) {
+	t.Parallel()
+
+	err := ae.From(fakeExitCoder{code: 6}).Msg("x")
+	if got := ae.ExitCode(err); got != 6 {
+		t.Errorf("ExitCode(From(fakeExitCoder{6})) = %d, want 6", got)
+	}
+}
+
+// runShell runs a shell command and returns its error. Skips on platforms
+// without a POSIX shell.
+func runShell(t *testing.T, script string) error {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("no POSIX shell on windows")
+	}
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+
+	_, err = exec.Command(sh, "-c", script).Output()
+	return err
+}
+
+func TestExitCode_ExecExitErrorThroughWrap(t *testing.T) {
+	t.Parallel()
+
+	err := ae.Wrap("running migration", runShell(t, "exit 3"))
+	if got := ae.ExitCode(err); got != 3 {
+		t.Errorf("ExitCode = %d, want 3", got)
+	}
+}
+
+func TestFromExec_AttachesStderrAndExitCode(t *testing.T) {
+	t.Parallel()
+
+	runErr := runShell(t, "echo 'relation users does not exist' >&2; exit 4")
+	err := ae.FromExec(fmt.Errorf("migrate: %w", runErr)).Msg("running migration")
+
+	if got := ae.ExitCode(err); got != 4 {
+		t.Errorf("ExitCode = %d, want 4", got)
+	}
+	if got := ae.Attributes(err)["exec.stderr"]; got != "relation users does not exist" {
+		t.Errorf("exec.stderr = %q, want the child's stderr", got)
+	}
+}
+
+func TestFromExec_NonExecError(t *testing.T) {
+	t.Parallel()
+
+	err := ae.FromExec(errors.New("plain")).Msg("x")
+	if _, ok := ae.Attributes(err)["exec.stderr"]; ok {
+		t.Error("exec.stderr attached for a non-exec error")
+	}
+}
+
+func TestExitCode_DeepChainIsLinear(t *testing.T) {
+	t.Parallel()
+
+	// ExitCode used to call ErrorExitCode twice per level, doubling the
+	// work with every level of wrapping.
+	err := ae.New().ExitCode(7).Msg("root")
+	for i := range 200 {
+		err = ae.New().Cause(err).Msgf("level %d", i)
+	}
+
+	done := make(chan int, 1)
+	go func() { done <- ae.ExitCode(err) }()
+	select {
+	case got := <-done:
+		if got != 7 {
+			t.Errorf("ExitCode = %d, want 7", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ExitCode on a 200-level chain did not finish")
+	}
+}
+
+// The registry tests change package-level state and don't run in parallel.
+
+func registerExitCode(t *testing.T, tag string, code int) {
+	t.Helper()
+	withPackageState(t, ae.RegisterExitCode(tag, code))
+}
+
+func TestRegisterExitCode_Precedence(t *testing.T) {
+	registerExitCode(t, "network", 11)
+	withPackageState(t, ae.RegisterExitCodeForCode("CONFIG_INVALID", 12))
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"default", ae.New().Tag("other").Msg("x"), 1},
+		{"tag", ae.New().Tag("network").Msg("x"), 11},
+		{"code", ae.New().Code("CONFIG_INVALID").Msg("x"), 12},
+		{"deep tag", ae.Wrap("outer", ae.New().Tag("network").Msg("x")), 11},
+		{"highest match", ae.WrapMany("both",
+			ae.New().Tag("network").Msg("a"),
+			ae.New().Code("CONFIG_INVALID").Msg("b")), 12},
+		{"inherited explicit", ae.Wrap("outer", ae.New().Tag("network").Cause(
+			ae.New().ExitCode(3).Msg("inner")).Msg("x")), 3},
+		{"explicit", ae.New().ExitCode(2).Tag("network").Cause(
+			ae.New().ExitCode(3).Msg("inner")).Msg("x"), 2},
+	}
+	for _, tt := range tests {
+		if got := ae.ExitCode(tt.err); got != tt.want {
+			t.Errorf("%s: ExitCode = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterExitCode_Remove(t *testing.T) {
+	remove := ae.RegisterExitCode("network", 11)
+	err := ae.New().Tag("network").Msg("x")
+	if got := ae.ExitCode(err); got != 11 {
+		t.Fatalf("ExitCode = %d, want 11", got)
+	}
+
+	remove()
+	if got := ae.ExitCode(err); got != 1 {
+		t.Errorf("ExitCode after remove = %d, want 1", got)
+	}
+}
+
+func TestRegisterExitCode_Printer(t *testing.T) {
+	registerExitCode(t, "network", 11)
+
+	out := ae.NewPrinter(ae.NoPrintColors()).Prints(ae.New().Tag("network").Msg("dial failed"))
+	if !strings.HasPrefix(out, "[ERROR] {exit 11} dial failed") {
+		t.Errorf("got:\n%s", out)
+	}
+}
+
+func TestRegisterExitCode_WrapKeep(t *testing.T) {
+	registerExitCode(t, "net", 11)
+
+	inner := ae.Wrap("dial", ae.New().Tag("net").Msg("refused"))
+	if got := ae.ExitCode(inner); got != 11 {
+		t.Fatalf("ExitCode(inner) = %d, want 11", got)
+	}
+	if got := ae.ExitCode(ae.WrapKeep("outer", inner)); got != 11 {
+		t.Errorf("ExitCode(WrapKeep) = %d, want the registered 11", got)
+	}
+
+	explicit := ae.New().Tag("net").ExitCode(3).Cause(inner).Msg("explicit")
+	if got := ae.ExitCode(ae.WrapKeep("outer", explicit)); got != 3 {
+		t.Errorf("ExitCode(WrapKeep) = %d, want the explicit 3", got)
+	}
+}
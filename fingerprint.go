@@ -0,0 +1,111 @@
+package ae
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// ErrorFingerprint defines an interface for errors that provide their own
+// fingerprint, overriding the one computed by Fingerprint.
+type ErrorFingerprint interface {
+	// ErrorFingerprint returns the fingerprint of the error.
+	// Returns an empty string if none is set.
+	ErrorFingerprint() string
+}
+
+// Fingerprint returns a stable identifier for "the same error", for grouping
+// and deduplicating occurrences. If an error in err's chain implements
+// ErrorFingerprint with a non-empty value (see Builder.Fingerprint), the
+// first one found is returned as is.
+//
+// Otherwise it is a hash over the first non-empty code in the chain, the
+// message of the root cause (found by following the first cause of each
+// error) and the function names of the first stack in the chain, if any.
+// Timestamps, trace and span IDs and attribute values never contribute, so
+// two occurrences of the same failure path share a fingerprint. Variable
+// parts of messages are not normalized. Returns "" if err is nil.
+func Fingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	if fp := findFingerprint(err); fp != "" {
+		return fp
+	}
+
+	h := sha256.New()
+	write := func(s string) {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+
+	write(deepCode(err))
+	write(rootMessage(err))
+	if st := firstStack(err); st != nil {
+		for _, frame := range st.Frames {
+			write(frame.Func)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// findFingerprint returns the first non-empty ErrorFingerprint in err's
+// cause tree, searched depth-first.
+func findFingerprint(err error) string {
+	var fp string
+	walkCauses(err, func(err error) bool {
+		if x, ok := err.(ErrorFingerprint); ok {
+			fp = x.ErrorFingerprint()
+		}
+		return fp == ""
+	})
+
+	return fp
+}
+
+// deepCode returns the first non-empty code in err's cause tree, searched
+// depth-first.
+func deepCode(err error) string {
+	var code string
+	walkCauses(err, func(err error) bool {
+		code = Code(err)
+		return code == ""
+	})
+
+	return code
+}
+
+// rootMessage returns the message of the root cause, found by following the
+// first non-nil cause of each error.
+func rootMessage(err error) string {
+	for {
+		var next error
+		for _, cause := range Causes(err) {
+			if cause != nil {
+				next = cause
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		err = next
+	}
+
+	return Message(err)
+}
+
+// firstStack returns the first stack in err's cause tree, searched
+// depth-first.
+func firstStack(err error) *Stack {
+	var st *Stack
+	walkCauses(err, func(err error) bool {
+		if stacks := Stacks(err); len(stacks) > 0 {
+			st = stacks[0]
+		}
+		return st == nil
+	})
+
+	return st
+}
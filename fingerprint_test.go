@@ -0,0 +1,74 @@
+package ae_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+// failingQuery is a failure path producing a fresh error on every call, with
+// varying timestamps, trace IDs and attribute values.
+func failingQuery(id int, code, rootMsg string) error {
+	root := ae.New().
+		Code(code).
+		Attr("id", id).
+		TraceId("trace-" + string(rune('a'+id))).
+		Stack().
+		Msg(rootMsg)
+
+	return ae.New().Attr("attempt", id).Cause(root).Msg("loading user")
+}
+
+func TestFingerprint_SameFailurePathMatches(t *testing.T) {
+	t.Parallel()
+
+	var fps []string
+	for i := range 2 {
+		fps = append(fps, ae.Fingerprint(failingQuery(i, "DB_ERROR", "no rows")))
+	}
+
+	if fps[0] == "" || fps[0] != fps[1] {
+		t.Errorf("fingerprints differ for the same failure path: %q vs %q", fps[0], fps[1])
+	}
+}
+
+func TestFingerprint_CodeAndRootMessageMatter(t *testing.T) {
+	t.Parallel()
+
+	base := ae.Fingerprint(failingQuery(0, "DB_ERROR", "no rows"))
+
+	if fp := ae.Fingerprint(failingQuery(0, "TIMEOUT", "no rows")); fp == base {
+		t.Error("changing the code did not change the fingerprint")
+	}
+	if fp := ae.Fingerprint(failingQuery(0, "DB_ERROR", "connection reset")); fp == base {
+		t.Error("changing the root message did not change the fingerprint")
+	}
+}
+
+func TestFingerprint_PlainErrors(t *testing.T) {
+	t.Parallel()
+
+	a := ae.Wrap("outer", errors.New("boom"))
+	b := ae.Wrap("outer", errors.New("boom"))
+	if ae.Fingerprint(a) != ae.Fingerprint(b) {
+		t.Error("fingerprints differ for identical plain errors")
+	}
+	if ae.Fingerprint(nil) != "" {
+		t.Error("Fingerprint(nil) is not empty")
+	}
+}
+
+func TestFingerprint_Override(t *testing.T) {
+	t.Parallel()
+
+	inner := ae.New().Fingerprint("user-lookup").Msg("no rows")
+	err := ae.Wrap("outer", inner)
+
+	if got := ae.Fingerprint(err); got != "user-lookup" {
+		t.Errorf("Fingerprint = %q, want the override from the cause", got)
+	}
+	if got := ae.Fingerprint(ae.From(inner).Msg("copied")); got != "user-lookup" {
+		t.Errorf("Fingerprint after From = %q, want the override kept", got)
+	}
+}
@@ -1,6 +1,8 @@
 package ae_test
 
 import (
+	"fmt"
+	"testing"
 	"time"
 
 	"go.aledante.io/ae"
@@ -74,3 +76,32 @@ func (p pkgErrorsStyleErr) Cause() error  { return p.inner }
 type plainErr struct{ msg string }
 
 func (p plainErr) Error() string { return p.msg }
+
+// multiErrorStyleErr mimics *multierror.Error from hashicorp/go-multierror:
+// it exposes WrappedErrors() []error and a single-error Unwrap chain.
+type multiErrorStyleErr struct{ errs []error }
+
+func (m multiErrorStyleErr) Error() string {
+	return fmt.Sprintf("%d errors occurred", len(m.errs))
+}
+func (m multiErrorStyleErr) WrappedErrors() []error { return m.errs }
+func (m multiErrorStyleErr) Unwrap() error {
+	if len(m.errs) == 0 {
+		return nil
+	}
+	return m.errs[0]
+}
+
+// withPackageState registers restore to undo, once t finishes, a change t
+// made to package-level state: a Set* option, or a registration such as a
+// classifier, hook or reporter, which restore removes.
+//
+// Tests changing package-level state don't call t.Parallel. The testing
+// package runs such tests one at a time and holds the parallel tests back
+// until all of them are done, so no other test observes the change; restore
+// keeps it from leaking into the tests that run after t.
+func withPackageState(t *testing.T, restore func()) {
+	t.Helper()
+
+	t.Cleanup(restore)
+}
@@ -0,0 +1,167 @@
+package ae
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Patterns suggested for SetCodePattern and SetTagPattern: upper-case codes
+// such as "DB_TIMEOUT", and lower-case tags optionally namespaced with "/",
+// such as "db/timeout".
+var (
+	DefaultCodePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+	DefaultTagPattern  = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*(/[a-z0-9][a-z0-9_.-]*)*$`)
+)
+
+// InvalidFormatCode is the code of the related error recorded for a code or
+// tag that doesn't match the active pattern.
+const InvalidFormatCode = "INVALID_FORMAT"
+
+// formatRules holds the validation set up with SetCodePattern, SetTagPattern,
+// SetFormatNormalization and StrictForTesting.
+type formatRules struct {
+	code, tag  *regexp.Regexp
+	keepAsIs   bool
+	strictTest testingT
+}
+
+// testingT is the part of testing.TB violations are reported to.
+type testingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+var (
+	// formatsMu serializes updates; builders load formats lock-free.
+	formatsMu sync.Mutex
+	formats   atomic.Pointer[formatRules]
+)
+
+func init() {
+	formats.Store(&formatRules{})
+}
+
+// updateFormats applies fn to a copy of the active rules and stores it.
+func updateFormats(fn func(r *formatRules)) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	r := *formats.Load()
+	fn(&r)
+	formats.Store(&r)
+}
+
+// SetCodePattern makes Builder.Code validate codes against re, e.g.
+// DefaultCodePattern. A code that doesn't match is normalized (see
+// SetFormatNormalization); if it still doesn't match, it is kept as given
+// and a related error with the code INVALID_FORMAT is recorded on the built
+// error, so the typo shows up without failing the caller. A nil re, the
+// default, disables the validation.
+func SetCodePattern(re *regexp.Regexp) {
+	updateFormats(func(r *formatRules) { r.code = re })
+}
+
+// SetTagPattern makes Builder.Tag and Builder.Tags validate tags against re,
+// e.g. DefaultTagPattern, as SetCodePattern does for codes. A nil re, the
+// default, disables the validation.
+func SetTagPattern(re *regexp.Regexp) {
+	updateFormats(func(r *formatRules) { r.tag = re })
+}
+
+// SetFormatNormalization sets whether codes and tags that don't match their
+// pattern are normalized before being checked again: surrounding spaces are
+// trimmed, codes are upper-cased and tags lower-cased. Normalization is
+// enabled by default.
+func SetFormatNormalization(enabled bool) {
+	updateFormats(func(r *formatRules) { r.keepAsIs = !enabled })
+}
+
+// StrictForTesting makes codes and tags that don't match their pattern fail
+// t instead of being normalized or recorded, until the end of t. Patterns
+// not set with SetCodePattern or SetTagPattern default to
+// DefaultCodePattern and DefaultTagPattern meanwhile. The validation is
+// package-wide, so t must not run in parallel with other tests building
+// errors.
+func StrictForTesting(t interface {
+	Helper()
+	Errorf(format string, args ...any)
+	Cleanup(func())
+}) {
+	t.Helper()
+
+	formatsMu.Lock()
+	prev := formats.Load()
+	r := *prev
+	if r.code == nil {
+		r.code = DefaultCodePattern
+	}
+	if r.tag == nil {
+		r.tag = DefaultTagPattern
+	}
+	r.strictTest = t
+	formats.Store(&r)
+	formatsMu.Unlock()
+
+	t.Cleanup(func() {
+		formatsMu.Lock()
+		defer formatsMu.Unlock()
+		formats.Store(prev)
+	})
+}
+
+// validCode returns code, normalized if needed, validated against the
+// active code pattern.
+func (b *Builder) validCode(code string) string {
+	r := formats.Load()
+	if r.code == nil {
+		return code
+	}
+
+	return b.validFormat(r, r.code, "code", code, strings.ToUpper)
+}
+
+// validTag returns tag, normalized if needed, validated against the active
+// tag pattern.
+func (b *Builder) validTag(tag string) string {
+	r := formats.Load()
+	if r.tag == nil {
+		return tag
+	}
+
+	return b.validFormat(r, r.tag, "tag", tag, strings.ToLower)
+}
+
+func (b *Builder) validFormat(r *formatRules, re *regexp.Regexp, kind, value string, fold func(string) string) string {
+	if re.MatchString(value) {
+		return value
+	}
+
+	if r.strictTest != nil {
+		r.strictTest.Helper()
+		r.strictTest.Errorf("ae: invalid %s %q: does not match %s", kind, value, re)
+		return value
+	}
+
+	if !r.keepAsIs {
+		if normalized := fold(strings.TrimSpace(value)); re.MatchString(normalized) {
+			return normalized
+		}
+	}
+
+	b.addRelated(invalidFormatError(kind, value, re))
+	return value
+}
+
+// invalidFormatError describes a code or tag that doesn't match re. It is
+// built without Builder.Code and Builder.Tag, which would validate again.
+func invalidFormatError(kind, value string, re *regexp.Regexp) error {
+	b := New()
+	b.code = InvalidFormatCode
+	b.setAttr(kind, value)
+	b.setAttr("pattern", re.String())
+
+	return b.Msg(fmt.Sprintf("invalid %s %q: does not match %s", kind, value, re))
+}
@@ -0,0 +1,118 @@
+package ae_test
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+func setPatterns(t *testing.T, code, tag *regexp.Regexp) {
+	t.Helper()
+
+	ae.SetCodePattern(code)
+	ae.SetTagPattern(tag)
+	withPackageState(t, func() {
+		ae.SetCodePattern(nil)
+		ae.SetTagPattern(nil)
+		ae.SetFormatNormalization(true)
+	})
+}
+
+func TestFormatValidation_Normalizes(t *testing.T) {
+	setPatterns(t, ae.DefaultCodePattern, ae.DefaultTagPattern)
+
+	tests := []struct {
+		name string
+		err  error
+		code string
+		tags []string
+	}{
+		{"valid", ae.New().Code("DB_TIMEOUT").Tag("db/timeout").Msg("x"), "DB_TIMEOUT", []string{"db/timeout"}},
+		{"trailing space", ae.New().Code("DB_TIMEOUT ").Msg("x"), "DB_TIMEOUT", nil},
+		{"lower case", ae.New().Code("db_timeout").Msg("x"), "DB_TIMEOUT", nil},
+		{"tags", ae.New().Tags(" Retry", "DB").Msg("x"), "", []string{"db", "retry"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ae.Code(tt.err); got != tt.code {
+				t.Errorf("Code = %q, want %q", got, tt.code)
+			}
+			if got := ae.Tags(tt.err); !slices.Equal(got, tt.tags) {
+				t.Errorf("Tags = %q, want %q", got, tt.tags)
+			}
+			if related := ae.Related(tt.err); len(related) != 0 {
+				t.Errorf("normalized value recorded as invalid: %v", related)
+			}
+		})
+	}
+}
+
+func TestFormatValidation_RecordsRelatedError(t *testing.T) {
+	setPatterns(t, ae.DefaultCodePattern, ae.DefaultTagPattern)
+
+	err := ae.New().Code("db timeout").Tag("no spaces").Msg("query failed")
+
+	if got := ae.Code(err); got != "db timeout" {
+		t.Errorf("Code = %q, want the invalid code kept as given", got)
+	}
+	related := ae.Related(err)
+	if len(related) != 2 {
+		t.Fatalf("got %d related errors, want 2: %v", len(related), related)
+	}
+	for i, want := range []string{`invalid code "db timeout"`, `invalid tag "no spaces"`} {
+		if ae.Code(related[i]) != ae.InvalidFormatCode || !strings.Contains(related[i].Error(), want) {
+			t.Errorf("related[%d] = %v (%s), want %s", i, related[i], ae.Code(related[i]), want)
+		}
+	}
+
+	ae.SetFormatNormalization(false)
+	if related := ae.Related(ae.New().Code("db_timeout").Msg("x")); len(related) != 1 {
+		t.Errorf("lower-case code accepted without normalization: %v", related)
+	}
+}
+
+func TestFormatValidation_Disabled(t *testing.T) {
+	err := ae.New().Code("whatever you like ").Tag("Any Tag").Msg("x")
+	if ae.Code(err) != "whatever you like " || len(ae.Related(err)) != 0 {
+		t.Errorf("validated without patterns: %q %v", ae.Code(err), ae.Related(err))
+	}
+}
+
+// recordingT records the failures reported by StrictForTesting.
+type recordingT struct {
+	failures []string
+	cleanups []func()
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingT) Cleanup(fn func()) { r.cleanups = append(r.cleanups, fn) }
+
+func TestStrictForTesting(t *testing.T) {
+	rec := &recordingT{}
+	ae.StrictForTesting(rec)
+
+	err := ae.New().Code("DB_TIMEOUT ").Tag("db").Msg("x")
+	if len(rec.failures) != 1 || !strings.Contains(rec.failures[0], `invalid code "DB_TIMEOUT "`) {
+		t.Errorf("failures = %q, want the invalid code", rec.failures)
+	}
+	if len(ae.Related(err)) != 0 {
+		t.Errorf("strict mode recorded a related error: %v", ae.Related(err))
+	}
+
+	for _, fn := range rec.cleanups {
+		fn()
+	}
+	ae.New().Code("db timeout").Msg("x")
+	if len(rec.failures) != 1 {
+		t.Errorf("strict mode still active after cleanup: %q", rec.failures)
+	}
+}
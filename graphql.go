@@ -0,0 +1,81 @@
+package ae
+
+import (
+	"errors"
+	"slices"
+)
+
+const (
+	// graphQLGenericCode is the extension code reported for errors that are not ae errors.
+	graphQLGenericCode = "INTERNAL_SERVER_ERROR"
+	// graphQLGenericMessage is the message reported when an error has no user message.
+	graphQLGenericMessage = "internal server error"
+)
+
+// GraphQLExtensions returns the map to attach as the "extensions" member of a
+// GraphQL error. It contains the following stable keys:
+//
+//   - "code": the error code, or INTERNAL_SERVER_ERROR when none is set.
+//   - "tags": the sorted tags, if any.
+//   - "retryable": whether the error is recoverable (see IsRecoverable).
+//   - "trace_id": the distributed tracing ID, if any.
+//   - "attributes": the attributes whose key is listed in attrs, if any.
+//
+// Attributes are never included unless whitelisted through attrs.
+// Returns nil if err is nil. Errors without an *Ae in their chain return a
+// map holding only the generic code.
+func GraphQLExtensions(err error, attrs ...string) map[string]any {
+	if err == nil {
+		return nil
+	}
+
+	var a *Ae
+	if !errors.As(err, &a) {
+		return map[string]any{"code": graphQLGenericCode}
+	}
+
+	ext := map[string]any{
+		"code":      graphQLGenericCode,
+		"retryable": IsRecoverable(a),
+	}
+	if code := a.ErrorCode(); code != "" {
+		ext["code"] = code
+	}
+	if tags := a.ErrorTags(); len(tags) > 0 {
+		slices.Sort(tags)
+		ext["tags"] = tags
+	}
+	if traceId := a.ErrorTraceId(); traceId != "" {
+		ext["trace_id"] = traceId
+	}
+
+	whitelisted := make(map[string]any)
+	for k, v := range a.attributes {
+		if slices.Contains(attrs, k) {
+			whitelisted[k] = v
+		}
+	}
+	if len(whitelisted) > 0 {
+		ext["attributes"] = whitelisted
+	}
+
+	return ext
+}
+
+// GraphQLMessage returns the message to report to GraphQL clients. It prefers
+// the user message and falls back to a generic message; the internal message
+// is never returned. Returns an empty string if err is nil.
+func GraphQLMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var a *Ae
+	if errors.As(err, &a) {
+		if msg := a.ErrorUserMessage(); msg != "" {
+			return msg
+		}
+	}
+
+	return graphQLGenericMessage
+}
@@ -0,0 +1,95 @@
+package ae_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+func TestGraphQLExtensions_NilError(t *testing.T) {
+	t.Parallel()
+
+	if got := ae.GraphQLExtensions(nil); got != nil {
+		t.Errorf("GraphQLExtensions(nil) = %v, want nil", got)
+	}
+	if got := ae.GraphQLMessage(nil); got != "" {
+		t.Errorf("GraphQLMessage(nil) = %q, want empty string", got)
+	}
+}
+
+func TestGraphQLExtensions_NonAeErrorIsMinimal(t *testing.T) {
+	t.Parallel()
+
+	got := ae.GraphQLExtensions(errors.New("plain"), "user_id")
+	want := map[string]any{"code": "INTERNAL_SERVER_ERROR"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GraphQLExtensions(plain) = %v, want %v", got, want)
+	}
+}
+
+func TestGraphQLExtensions_StableKeys(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().
+		Code("E_AUTH").
+		Tags("b", "a").
+		TraceId("trace-1").
+		Fatal().
+		Msg("x")
+
+	got := ae.GraphQLExtensions(err)
+	want := map[string]any{
+		"code":      "E_AUTH",
+		"tags":      []string{"a", "b"},
+		"retryable": false,
+		"trace_id":  "trace-1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GraphQLExtensions = %v, want %v", got, want)
+	}
+}
+
+func TestGraphQLExtensions_WhitelistFiltersAttributes(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().
+		Attr("user_id", 42).
+		Attr("password", "hunter2").
+		Msg("x")
+
+	got := ae.GraphQLExtensions(err, "user_id", "missing")
+	want := map[string]any{"user_id": 42}
+	if !reflect.DeepEqual(got["attributes"], want) {
+		t.Errorf("attributes = %v, want %v", got["attributes"], want)
+	}
+
+	if _, ok := ae.GraphQLExtensions(err)["attributes"]; ok {
+		t.Error("attributes present without a whitelist")
+	}
+}
+
+func TestGraphQLExtensions_FindsAeThroughFmtWrap(t *testing.T) {
+	t.Parallel()
+
+	err := fmt.Errorf("resolver: %w", ae.New().Code("E_X").Msg("x"))
+	if got := ae.GraphQLExtensions(err)["code"]; got != "E_X" {
+		t.Errorf("code = %v, want E_X", got)
+	}
+}
+
+func TestGraphQLMessage_NeverLeaksInternalMessage(t *testing.T) {
+	t.Parallel()
+
+	if got := ae.GraphQLMessage(ae.New().UserMsg("internal detail", "Please retry.")); got != "Please retry." {
+		t.Errorf("GraphQLMessage = %q, want the user message", got)
+	}
+	if got := ae.GraphQLMessage(ae.Msg("internal detail")); got != "internal server error" {
+		t.Errorf("GraphQLMessage = %q, want the generic message", got)
+	}
+	if got := ae.GraphQLMessage(errors.New("internal detail")); got != "internal server error" {
+		t.Errorf("GraphQLMessage(plain) = %q, want the generic message", got)
+	}
+}
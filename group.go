@@ -0,0 +1,146 @@
+package ae
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Group runs functions in goroutines and collects all of their errors,
+// unlike golang.org/x/sync/errgroup which keeps only the first. Panics are
+// recovered per goroutine, as by Catch. The zero value is ready to use and
+// has no limit on active goroutines; a Group must not be copied after first
+// use.
+type Group struct {
+	wg  sync.WaitGroup
+	sem chan struct{}
+
+	// ctx and cancel are set by GroupWithContext.
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	mu      sync.Mutex
+	started int
+	failed  bool
+	errs    []groupError
+}
+
+// groupError is an error with the index of the Go call that produced it, so
+// Wait reports errors in call order rather than completion order.
+type groupError struct {
+	index int
+	err   error
+}
+
+// GroupWithContext returns a Group and a context derived from ctx that is
+// canceled, with the error as its cause, when the first function fails, or
+// when Wait returns. Errors that siblings return after observing that
+// cancellation (matching context.Canceled) are not reported; every other
+// error is.
+func GroupWithContext(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group{ctx: ctx, cancel: cancel}, ctx
+}
+
+// SetLimit limits the number of active goroutines to n; Go blocks until a
+// slot is free. A negative n removes the limit. SetLimit panics if called
+// while goroutines are active.
+func (g *Group) SetLimit(n int) {
+	if g.sem != nil && len(g.sem) != 0 {
+		panic(fmt.Errorf("ae: modify limit while %d goroutines in the group are still active", len(g.sem)))
+	}
+
+	if n < 0 {
+		g.sem = nil
+		return
+	}
+	g.sem = make(chan struct{}, n)
+}
+
+// Go runs fn in a new goroutine, blocking first while the limit set with
+// SetLimit is reached. A non-nil error returned by fn, or recovered from its
+// panic, is reported by Wait.
+func (g *Group) Go(fn func() error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+
+	g.mu.Lock()
+	index := g.started
+	g.started++
+	g.mu.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.done()
+
+		if err := Catch(fn); err != nil {
+			g.record(index, err)
+		}
+	}()
+}
+
+func (g *Group) done() {
+	if g.sem != nil {
+		<-g.sem
+	}
+	g.wg.Done()
+}
+
+func (g *Group) record(index int, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.failed && errors.Is(err, context.Canceled) && g.ctx.Err() != nil {
+		// a sibling reacting to the cancellation caused by an earlier failure
+		return
+	}
+
+	g.errs = append(g.errs, groupError{index, err})
+	if g.cancel != nil && !g.failed {
+		g.failed = true
+		g.cancel(err)
+	}
+}
+
+// Wait blocks until all functions started with Go have returned and returns
+// their errors joined as the causes of a single error, in the order of the
+// Go calls, or nil if none failed. The message reports how many failed; use
+// WaitMsg to set it.
+func (g *Group) Wait() error {
+	errs, started := g.wait()
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return WrapMany(fmt.Sprintf("%d of %d goroutines failed", len(errs), started), errs...)
+}
+
+// WaitMsg is Wait with msg as the message of the returned error.
+func (g *Group) WaitMsg(msg string) error {
+	errs, _ := g.wait()
+	return WrapMany(msg, errs...)
+}
+
+// wait waits for all goroutines and returns their errors in call order and
+// the number of goroutines started.
+func (g *Group) wait() ([]error, int) {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel(nil)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	slices.SortFunc(g.errs, func(a, b groupError) int { return cmp.Compare(a.index, b.index) })
+	errs := make([]error, len(g.errs))
+	for i, e := range g.errs {
+		errs[i] = e.err
+	}
+
+	return errs, g.started
+}
@@ -0,0 +1,169 @@
+package ae_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.aledante.io/ae"
+)
+
+func TestGroup_NoErrors(t *testing.T) {
+	t.Parallel()
+
+	var g ae.Group
+	var ran atomic.Int32
+	for range 5 {
+		g.Go(func() error {
+			ran.Add(1)
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		t.Errorf("Wait = %v, want nil", err)
+	}
+	if got := ran.Load(); got != 5 {
+		t.Errorf("ran %d functions, want 5", got)
+	}
+}
+
+func TestGroup_CollectsEveryFailureInCallOrder(t *testing.T) {
+	t.Parallel()
+
+	errA := errors.New("shard a")
+	errC := errors.New("shard c")
+
+	var g ae.Group
+	g.Go(func() error {
+		time.Sleep(10 * time.Millisecond)
+		return errA
+	})
+	g.Go(func() error { return nil })
+	g.Go(func() error { return errC })
+	g.Go(func() error { panic("shard d") })
+
+	err := g.Wait()
+	if got := ae.Message(err); got != "3 of 4 goroutines failed" {
+		t.Errorf("Message = %q", got)
+	}
+
+	causes := ae.Causes(err)
+	if len(causes) != 3 {
+		t.Fatalf("got %d causes, want 3: %v", len(causes), causes)
+	}
+	if causes[0] != errA || causes[1] != errC {
+		t.Errorf("causes = %v, want shard a and shard c first, in call order", causes)
+	}
+	if !slices.Contains(ae.Tags(causes[2]), "panic") {
+		t.Errorf("third cause = %v, want the recovered panic", causes[2])
+	}
+	if !errors.Is(err, errA) || !errors.Is(err, errC) {
+		t.Error("errors.Is does not find the individual failures")
+	}
+}
+
+func TestGroup_WaitMsg(t *testing.T) {
+	t.Parallel()
+
+	var g ae.Group
+	g.Go(func() error { return errors.New("boom") })
+
+	if got := ae.Message(g.WaitMsg("syncing shards")); got != "syncing shards" {
+		t.Errorf("Message = %q, want %q", got, "syncing shards")
+	}
+
+	var empty ae.Group
+	if err := empty.WaitMsg("syncing shards"); err != nil {
+		t.Errorf("WaitMsg on an empty group = %v, want nil", err)
+	}
+}
+
+func TestGroup_SetLimit(t *testing.T) {
+	t.Parallel()
+
+	var g ae.Group
+	g.SetLimit(2)
+
+	var active, peak atomic.Int32
+	for i := range 8 {
+		g.Go(func() error {
+			n := active.Add(1)
+			defer active.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(2 * time.Millisecond)
+			if i%3 == 0 {
+				return fmt.Errorf("worker %d", i)
+			}
+			return nil
+		})
+	}
+
+	err := g.Wait()
+	if got := peak.Load(); got > 2 {
+		t.Errorf("peak concurrency = %d, want <= 2", got)
+	}
+	if got := len(ae.Causes(err)); got != 3 {
+		t.Errorf("got %d causes, want 3", got)
+	}
+}
+
+func TestGroupWithContext_CancelsSiblingsAndKeepsEarlierFailures(t *testing.T) {
+	t.Parallel()
+
+	g, ctx := ae.GroupWithContext(context.Background())
+	errFirst := errors.New("first")
+	errSecond := errors.New("second")
+	started := make(chan struct{})
+
+	g.Go(func() error {
+		<-started
+		return errFirst
+	})
+	g.Go(func() error {
+		// fails independently before observing the cancellation
+		<-started
+		return errSecond
+	})
+	g.Go(func() error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	err := g.Wait()
+	if ctx.Err() == nil {
+		t.Error("context not canceled")
+	}
+	if cause := context.Cause(ctx); cause != errFirst && cause != errSecond {
+		t.Errorf("context cause = %v, want the first failure", cause)
+	}
+
+	causes := ae.Causes(err)
+	if len(causes) != 2 || causes[0] != errFirst || causes[1] != errSecond {
+		t.Errorf("causes = %v, want both failures and not the cancellation", causes)
+	}
+}
+
+func TestGroupWithContext_CanceledByWait(t *testing.T) {
+	t.Parallel()
+
+	g, ctx := ae.GroupWithContext(context.Background())
+	g.Go(func() error { return nil })
+
+	if err := g.Wait(); err != nil {
+		t.Errorf("Wait = %v, want nil", err)
+	}
+	if ctx.Err() == nil {
+		t.Error("context not canceled after Wait")
+	}
+}
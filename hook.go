@@ -0,0 +1,173 @@
+package ae
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// finalizeHook wraps a hook so it can be identified for removal.
+type finalizeHook struct {
+	fn func(err error)
+}
+
+var (
+	// finalizeHooksMu serializes registration; readers load finalizeHooks lock-free.
+	finalizeHooksMu sync.Mutex
+	finalizeHooks   atomic.Pointer[[]*finalizeHook]
+)
+
+// OnFinalize registers fn to be called with every error finalized by a Builder
+// terminal method (Msg, Msgf, UserMsg), and therefore by every top-level
+// convenience function built on them. Hooks are meant for cross-cutting
+// concerns such as metrics; they run synchronously on the goroutine creating
+// the error, in registration order, and must be cheap and safe for concurrent
+// use. A hook that panics is reported to stderr and skipped.
+//
+// The returned function unregisters the hook.
+func OnFinalize(fn func(err error)) (remove func()) {
+	hook := &finalizeHook{fn: fn}
+
+	finalizeHooksMu.Lock()
+	defer finalizeHooksMu.Unlock()
+
+	var hooks []*finalizeHook
+	if cur := finalizeHooks.Load(); cur != nil {
+		hooks = append(hooks, *cur...)
+	}
+	hooks = append(hooks, hook)
+	finalizeHooks.Store(&hooks)
+
+	return func() {
+		finalizeHooksMu.Lock()
+		defer finalizeHooksMu.Unlock()
+
+		var kept []*finalizeHook
+		if cur := finalizeHooks.Load(); cur != nil {
+			for _, h := range *cur {
+				if h != hook {
+					kept = append(kept, h)
+				}
+			}
+		}
+		finalizeHooks.Store(&kept)
+	}
+}
+
+// runFinalizeHooks calls every registered finalize hook with err.
+func runFinalizeHooks(err error) {
+	hooks := finalizeHooks.Load()
+	if hooks == nil {
+		return
+	}
+
+	for _, h := range *hooks {
+		runFinalizeHook(h, err)
+	}
+}
+
+// runFinalizeHook calls hook with err. A hook that panics is reported to
+// stderr rather than failing the Msg call finalizing err.
+func runFinalizeHook(hook *finalizeHook, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "ae: finalize hook panicked: %v\n", r)
+		}
+	}()
+
+	hook.fn(err)
+}
+
+// exitHook wraps a hook so it can be identified for removal.
+type exitHook struct {
+	fn func(err error)
+}
+
+var (
+	exitHooksMu sync.Mutex
+	exitHooks   []*exitHook
+
+	// exitHookTimeout bounds each exit hook, in nanoseconds.
+	exitHookTimeout atomic.Int64
+)
+
+func init() {
+	exitHookTimeout.Store(int64(5 * time.Second))
+}
+
+// OnExit registers fn to be called with the error passed to Exit (and
+// therefore PrintExit and Main) before the process exits, e.g. to flush
+// traces or sync loggers. Hooks run in reverse registration order, like
+// deferred calls. A hook that panics is reported to stderr and skipped; a
+// hook still running after the timeout set with SetExitHookTimeout is
+// abandoned with a warning to stderr.
+//
+// The returned function unregisters the hook.
+func OnExit(fn func(err error)) (remove func()) {
+	hook := &exitHook{fn: fn}
+
+	exitHooksMu.Lock()
+	defer exitHooksMu.Unlock()
+	exitHooks = append(exitHooks, hook)
+
+	return func() {
+		exitHooksMu.Lock()
+		defer exitHooksMu.Unlock()
+
+		for i, h := range exitHooks {
+			if h == hook {
+				exitHooks = append(exitHooks[:i:i], exitHooks[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// SetExitHookTimeout sets how long Exit waits for each exit hook before
+// abandoning it. The default is 5 seconds; d <= 0 waits indefinitely.
+func SetExitHookTimeout(d time.Duration) {
+	exitHookTimeout.Store(int64(d))
+}
+
+// runExitHooks calls every registered exit hook with err, last registered
+// first.
+func runExitHooks(err error) {
+	exitHooksMu.Lock()
+	hooks := append([]*exitHook(nil), exitHooks...)
+	exitHooksMu.Unlock()
+
+	timeout := time.Duration(exitHookTimeout.Load())
+	for i := len(hooks) - 1; i >= 0; i-- {
+		runExitHook(hooks[i], err, timeout)
+	}
+}
+
+func runExitHook(hook *exitHook, err error, timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Fprintf(os.Stderr, "ae: exit hook panicked: %v\n", r)
+			}
+		}()
+
+		hook.fn(err)
+	}()
+
+	if timeout <= 0 {
+		<-done
+		return
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+		fmt.Fprintf(os.Stderr, "ae: exit hook did not finish within %s, abandoning it\n", timeout)
+	}
+}
@@ -0,0 +1,179 @@
+package ae_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.aledante.io/ae"
+)
+
+func TestOnFinalize_CalledForEveryTerminalMethod(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu   sync.Mutex
+		seen []string
+	)
+	remove := ae.OnFinalize(func(err error) {
+		// Other tests run in parallel; only record this test's errors.
+		if ae.Code(err) != "HOOK_FINALIZE" {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		seen = append(seen, ae.Message(err))
+	})
+	defer remove()
+
+	_ = ae.New().Code("HOOK_FINALIZE").Msg("msg")
+	_ = ae.New().Code("HOOK_FINALIZE").Msgf("msgf %d", 1)
+	_ = ae.New().Code("HOOK_FINALIZE").UserMsg("usermsg", "shown")
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"msg", "msgf 1", "usermsg"}
+	if len(seen) != len(want) {
+		t.Fatalf("hook saw %v, want %v", seen, want)
+	}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Errorf("hook call %d = %q, want %q", i, seen[i], want[i])
+		}
+	}
+}
+
+func TestOnFinalize_RemoveStopsCalls(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	var mu sync.Mutex
+	remove := ae.OnFinalize(func(err error) {
+		if ae.Code(err) != "HOOK_REMOVE" {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+	})
+
+	_ = ae.New().Code("HOOK_REMOVE").Msg("counted")
+	remove()
+	_ = ae.New().Code("HOOK_REMOVE").Msg("not counted")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 1 {
+		t.Errorf("hook called %d times, want 1", calls)
+	}
+}
+
+func TestOnExit_HooksRunLIFOBeforeExiter(t *testing.T) {
+	code := captureExit(t)
+
+	var order []string
+	exitErr := ae.New().ExitCode(3).Msg("shutting down")
+	for _, name := range []string{"first", "second", "third"} {
+		remove := ae.OnExit(func(err error) {
+			if err != exitErr {
+				t.Errorf("hook %s got %v, want the exit error", name, err)
+			}
+			if *code != -1 {
+				t.Errorf("hook %s ran after the exiter", name)
+			}
+			order = append(order, name)
+		})
+		withPackageState(t, remove)
+	}
+
+	ae.Exit(exitErr)
+
+	if want := []string{"third", "second", "first"}; strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Errorf("hook order = %v, want %v", order, want)
+	}
+	if *code != 3 {
+		t.Errorf("exit code = %d, want 3", *code)
+	}
+}
+
+func TestOnExit_RemovedHookDoesNotRun(t *testing.T) {
+	captureExit(t)
+
+	ran := false
+	remove := ae.OnExit(func(error) { ran = true })
+	remove()
+
+	ae.Exit(ae.Msg("boom"))
+	if ran {
+		t.Error("removed hook ran")
+	}
+}
+
+func TestOnExit_PanickingHookIsSkipped(t *testing.T) {
+	code := captureExit(t)
+
+	ran := false
+	withPackageState(t, ae.OnExit(func(error) { ran = true }))
+	withPackageState(t, ae.OnExit(func(error) { panic("flush failed") }))
+
+	out := captureStderr(t, func() {
+		ae.Exit(ae.Msg("boom"))
+	})
+
+	if !strings.Contains(out, "exit hook panicked: flush failed") {
+		t.Errorf("stderr = %q, want a panic warning", out)
+	}
+	if !ran {
+		t.Error("hook registered before the panicking one did not run")
+	}
+	if *code != 1 {
+		t.Errorf("exit code = %d, want 1", *code)
+	}
+}
+
+func TestOnExit_SlowHookIsAbandoned(t *testing.T) {
+	code := captureExit(t)
+	ae.SetExitHookTimeout(20 * time.Millisecond)
+	withPackageState(t, func() { ae.SetExitHookTimeout(5 * time.Second) })
+
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	withPackageState(t, ae.OnExit(func(error) { <-release }))
+
+	start := time.Now()
+	out := captureStderr(t, func() {
+		ae.Exit(ae.Msg("boom"))
+	})
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Exit took %s, want the slow hook abandoned", elapsed)
+	}
+	if !strings.Contains(out, "exit hook did not finish within 20ms") {
+		t.Errorf("stderr = %q, want a timeout warning", out)
+	}
+	if *code != 1 {
+		t.Errorf("exit code = %d, want 1", *code)
+	}
+}
+
+func TestOnFinalize_PanickingHook(t *testing.T) {
+	var calls int
+	removePanic := ae.OnFinalize(func(error) { panic("boom") })
+	defer removePanic()
+	removeCount := ae.OnFinalize(func(error) { calls++ })
+	defer removeCount()
+
+	out := captureStderr(t, func() {
+		if err := ae.New().Msg("x"); err == nil {
+			t.Error("Msg returned nil")
+		}
+	})
+
+	if calls != 1 {
+		t.Errorf("hook after the panicking one called %d times, want 1", calls)
+	}
+	if !strings.Contains(out, "ae: finalize hook panicked: boom") {
+		t.Errorf("stderr = %q, want the panic reported", out)
+	}
+}
@@ -0,0 +1,3 @@
+// Package urfavetest tests ae.CliError with urfave/cli. It is a module of its
+// own, so the core module doesn't depend on urfave/cli.
+package urfavetest
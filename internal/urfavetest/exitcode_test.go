@@ -0,0 +1,50 @@
+package urfavetest_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+	"go.aledante.io/ae"
+)
+
+// TestCliError_PropagatesExitCodeThroughApp runs a minimal urfave/cli app and
+// captures the exit code through cli.OsExiter. It mutates package globals of
+// urfave/cli and therefore does not run in parallel.
+func TestCliError_PropagatesExitCodeThroughApp(t *testing.T) {
+	exitCode := -1
+	origExiter, origWriter := cli.OsExiter, cli.ErrWriter
+	cli.OsExiter = func(code int) { exitCode = code }
+	cli.ErrWriter = io.Discard
+	t.Cleanup(func() {
+		cli.OsExiter, cli.ErrWriter = origExiter, origWriter
+	})
+
+	app := &cli.App{
+		Name:   "migrate",
+		Writer: io.Discard,
+		Action: func(*cli.Context) error {
+			return ae.CliError(ae.Wrap("running migration",
+				ae.New().ExitCode(12).Msg("config missing")))
+		},
+	}
+
+	if err := app.Run([]string{"migrate"}); err == nil {
+		t.Fatal("app.Run returned nil")
+	}
+	if exitCode != 12 {
+		t.Errorf("exit code = %d, want 12", exitCode)
+	}
+}
+
+func TestCliError_ImplementsExitCoder(t *testing.T) {
+	t.Parallel()
+
+	coder, ok := ae.CliError(ae.New().ExitCode(3).Msg("write failed")).(cli.ExitCoder)
+	if !ok {
+		t.Fatal("CliError does not implement cli.ExitCoder")
+	}
+	if got := coder.ExitCode(); got != 3 {
+		t.Errorf("ExitCode() = %d, want 3", got)
+	}
+}
@@ -0,0 +1,23 @@
+module go.aledante.io/ae/internal/urfavetest
+
+go 1.26.0
+
+require (
+	github.com/urfave/cli/v2 v2.27.7
+	go.aledante.io/ae v0.0.0-00010101000000-000000000000
+)
+
+require (
+	github.com/DataDog/gostackparse v0.7.0 // indirect
+	github.com/cpuguy83/go-md2man/v2 v2.0.7 // indirect
+	github.com/fatih/color v1.18.0 // indirect
+	github.com/mattn/go-colorable v0.1.14 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/russross/blackfriday/v2 v2.1.0 // indirect
+	github.com/xrash/smetrics v0.0.0-20240521201337-686a1a2994c1 // indirect
+	go.opentelemetry.io/otel v1.38.0 // indirect
+	go.opentelemetry.io/otel/trace v1.38.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+)
+
+replace go.aledante.io/ae => ../..
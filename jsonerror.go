@@ -0,0 +1,91 @@
+package ae
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// jsonSnippetRadius is the number of input bytes shown on each side of the
+// offending offset in the "json.snippet" attribute.
+const jsonSnippetRadius = 24
+
+// FromJSONError wraps an error returned by encoding/json, keeping the details
+// that are otherwise lost once the error is wrapped. data is the input that
+// failed to decode; it is used to attach a short snippet around the offset
+// and may be nil.
+//
+// For *json.SyntaxError and *json.UnmarshalTypeError the attributes
+// "json.offset", "json.snippet" and, for type errors, "json.field",
+// "json.value_type" and "json.target_type" are attached, and the error is
+// tagged "json" and "invalid". Other errors are wrapped without extra
+// metadata. err stays the cause in both cases. Returns nil if err is nil.
+func FromJSONError(err error, data []byte) error {
+	if err == nil {
+		return nil
+	}
+
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+
+	b := New().Cause(err)
+	switch {
+	case errors.As(err, &syntaxErr):
+		b = b.Tags("json", "invalid").
+			Attr("json.offset", syntaxErr.Offset).
+			Attr("json.snippet", jsonSnippet(data, syntaxErr.Offset))
+	case errors.As(err, &typeErr):
+		b = b.Tags("json", "invalid").
+			Attr("json.offset", typeErr.Offset).
+			Attr("json.snippet", jsonSnippet(data, typeErr.Offset)).
+			Attr("json.value_type", typeErr.Value)
+		if typeErr.Field != "" {
+			b = b.Attr("json.field", typeErr.Field)
+		}
+		if typeErr.Type != nil {
+			b = b.Attr("json.target_type", typeErr.Type.String())
+		}
+	default:
+		return b.classify(err).Msg("decoding JSON")
+	}
+
+	return b.Msg("decoding JSON")
+}
+
+// jsonSnippet returns the input around offset with control characters
+// escaped, marking truncated ends with "…".
+func jsonSnippet(data []byte, offset int64) string {
+	if len(data) == 0 {
+		return ""
+	}
+
+	at := int(min(max(offset, 0), int64(len(data))))
+	start, end := max(at-jsonSnippetRadius, 0), min(at+jsonSnippetRadius, len(data))
+
+	var sb strings.Builder
+	if start > 0 {
+		sb.WriteString("…")
+	}
+	for _, c := range data[start:end] {
+		switch {
+		case c == '\n':
+			sb.WriteString(`\n`)
+		case c == '\r':
+			sb.WriteString(`\r`)
+		case c == '\t':
+			sb.WriteString(`\t`)
+		case c < 0x20 || c == 0x7f:
+			_, _ = fmt.Fprintf(&sb, `\x%02x`, c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	if end < len(data) {
+		sb.WriteString("…")
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,111 @@
+package ae_test
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+func TestFromJSONError_Nil(t *testing.T) {
+	t.Parallel()
+
+	if got := ae.FromJSONError(nil, nil); got != nil {
+		t.Errorf("FromJSONError(nil) = %v, want nil", got)
+	}
+}
+
+func TestFromJSONError_SyntaxError(t *testing.T) {
+	t.Parallel()
+
+	data := []byte("{\n\t\"name\": \"gopher\",\n\t\"age\": 12,,\n}")
+	var v map[string]any
+	jsonErr := json.Unmarshal(data, &v)
+
+	err := ae.FromJSONError(jsonErr, data)
+
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatal("*json.SyntaxError is not reachable through the wrapped error")
+	}
+
+	attrs := ae.Attributes(err)
+	if got := attrs["json.offset"]; got != syntaxErr.Offset {
+		t.Errorf("json.offset = %v, want %d", got, syntaxErr.Offset)
+	}
+	snippet, _ := attrs["json.snippet"].(string)
+	if !strings.Contains(snippet, `12,,\n}`) {
+		t.Errorf("json.snippet = %q, want the input around the offset with escaped newlines", snippet)
+	}
+	if strings.ContainsAny(snippet, "\n\t") {
+		t.Errorf("json.snippet = %q contains raw control characters", snippet)
+	}
+	if tags := ae.Tags(err); len(tags) != 2 || !slices.Contains(tags, "json") || !slices.Contains(tags, "invalid") {
+		t.Errorf("tags = %v, want json and invalid", tags)
+	}
+}
+
+func TestFromJSONError_TypeError(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`{"user": {"age": "twelve"}}`)
+	var v struct {
+		User struct {
+			Age int `json:"age"`
+		} `json:"user"`
+	}
+	err := ae.FromJSONError(json.Unmarshal(data, &v), data)
+
+	attrs := ae.Attributes(err)
+	for key, want := range map[string]any{
+		"json.field":       "user.age",
+		"json.value_type":  "string",
+		"json.target_type": "int",
+	} {
+		if got := attrs[key]; got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+	if _, ok := attrs["json.offset"].(int64); !ok {
+		t.Errorf("json.offset = %v, want an int64", attrs["json.offset"])
+	}
+}
+
+func TestFromJSONError_LongInputSnippetIsCapped(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`{"a": "` + strings.Repeat("x", 500) + `" "b": 1}`)
+	var v map[string]any
+	err := ae.FromJSONError(json.Unmarshal(data, &v), data)
+
+	snippet, _ := ae.Attributes(err)["json.snippet"].(string)
+	if len(snippet) > 64 {
+		t.Errorf("json.snippet is %d bytes long, want it capped", len(snippet))
+	}
+	if !strings.HasPrefix(snippet, "…") {
+		t.Errorf("json.snippet = %q, want a leading ellipsis", snippet)
+	}
+}
+
+func TestFromJSONError_GenericError(t *testing.T) {
+	t.Parallel()
+
+	cause := errors.New("unexpected EOF")
+	err := ae.FromJSONError(cause, []byte("{"))
+
+	if !errors.Is(err, cause) {
+		t.Error("errors.Is(err, cause) = false")
+	}
+	if got := err.Error(); got != "decoding JSON: unexpected EOF" {
+		t.Errorf("Error() = %q", got)
+	}
+	if attrs := ae.Attributes(err); len(attrs) != 0 {
+		t.Errorf("attrs = %v, want none", attrs)
+	}
+	if tags := ae.Tags(err); len(tags) != 0 {
+		t.Errorf("tags = %v, want none", tags)
+	}
+}
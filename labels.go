@@ -0,0 +1,109 @@
+package ae
+
+import (
+	"reflect"
+	"unicode/utf8"
+)
+
+// Labels holds the literals the text printer emits, for translating its
+// output with PrintLabels. Fields left empty keep their default, see
+// DefaultLabels. Goroutine stacks keep Go's traceback notation.
+type Labels struct {
+	// Error is the badge starting the first line, "[ERROR]".
+	Error string
+	// NoMessage stands in for an empty message, "(no message)".
+	NoMessage string
+	// Exit precedes an exit code shown without a code, "exit".
+	Exit string
+	// Truncated replaces errors nested deeper than the traversal limit,
+	// "… deeper errors truncated".
+	Truncated string
+	// InheritedAttrs formats the note counting the attributes of a nested
+	// error left out under PrintElideInheritedAttrs, "(%d inherited
+	// attributes)".
+	InheritedAttrs string
+
+	// Row labels of the error sections.
+	Hint     string
+	Shown    string
+	Time     string
+	Trace    string
+	Span     string
+	Request  string
+	Attrs    string
+	CausedBy string
+	Related  string
+	Stack    string
+
+	// Row labels of the summary, see PrintSummary.
+	Errors   string
+	Codes    string
+	Tags     string
+	First    string
+	Last     string
+	Examples string
+	// ErrorCounts formats the errors row from the number of errors in the
+	// tree and of leaves, "%d in tree, %d leaves".
+	ErrorCounts string
+	// NoCode lists the examples of leaves without a code, "(no code)".
+	NoCode string
+}
+
+// DefaultLabels returns the labels the text printer uses by default.
+func DefaultLabels() Labels {
+	return Labels{
+		Error:     "[ERROR]",
+		NoMessage: "(no message)",
+		Exit:      "exit",
+		Truncated: truncatedMessage,
+
+		InheritedAttrs: "(%d inherited attributes)",
+
+		Hint:     "hint",
+		Shown:    "shown",
+		Time:     "time",
+		Trace:    "trace",
+		Span:     "span",
+		Request:  "request",
+		Attrs:    "attrs",
+		CausedBy: "caused by",
+		Related:  "related",
+		Stack:    "stack",
+
+		Errors:      "errors",
+		Codes:       "codes",
+		Tags:        "tags",
+		First:       "first",
+		Last:        "last",
+		Examples:    "examples",
+		ErrorCounts: "%d in tree, %d leaves",
+		NoCode:      "(no code)",
+	}
+}
+
+// withDefaults returns l with its empty fields set to the defaults.
+func (l Labels) withDefaults() Labels {
+	v := reflect.ValueOf(&l).Elem()
+	defaults := reflect.ValueOf(DefaultLabels())
+	for i := range v.NumField() {
+		if v.Field(i).String() == "" {
+			v.Field(i).Set(defaults.Field(i))
+		}
+	}
+
+	return l
+}
+
+// rowWidth returns the width of the label column: the length, in runes, of
+// the longest row label.
+func (l Labels) rowWidth() int {
+	width := 0
+	for _, label := range []string{
+		l.Hint, l.Shown, l.Time, l.Trace, l.Request, l.Attrs, l.CausedBy, l.Related, l.Stack,
+		l.Errors, l.Codes, l.Tags, l.First, l.Last, l.Examples,
+	} {
+		width = max(width, utf8.RuneCountInString(label))
+	}
+
+	return width
+}
@@ -0,0 +1,94 @@
+package ae_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.aledante.io/ae"
+)
+
+// emptyMsgErr is an error with an empty message.
+type emptyMsgErr struct{}
+
+func (emptyMsgErr) Error() string { return "" }
+
+// customLabels returns labels with every field set to "L_<field>".
+func customLabels() ae.Labels {
+	var labels ae.Labels
+	v := reflect.ValueOf(&labels).Elem()
+	for i := range v.NumField() {
+		v.Field(i).SetString("L_" + v.Type().Field(i).Name)
+	}
+	labels.ErrorCounts = "%d L_ErrorCounts %d"
+	labels.InheritedAttrs = "%d L_InheritedAttrs"
+
+	return labels
+}
+
+func TestPrintLabels_NoDefaultsLeak(t *testing.T) {
+	setTraversalDepth(t, 3)
+
+	deep := ae.New().Cause(ae.New().Cause(ae.New().Cause(ae.Msg("d4")).Msg("d3")).Msg("d2")).Msg("d1")
+	err := ae.New().
+		ExitCode(3).
+		Hint("h").
+		TraceId("0af7651916cd43dd8448eb211c80319c").
+		SpanId("b7ad6b7169203331").
+		RequestId("r-1").
+		Timestamp(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)).
+		Attr("k", "v").
+		Cause(emptyMsgErr{}, deep, ae.New().Attr("k", "v").Msg("inheriting")).
+		Related(ae.Msg("r")).
+		Stack().
+		UserMsg("m", "u")
+
+	opts := []ae.PrinterOption{ae.NoPrintColors(), ae.PrintElideInheritedAttrs(), ae.PrintLabels(customLabels())}
+	outputs := []string{
+		ae.NewPrinter(opts...).Prints(err),
+		ae.NewPrinter(append(opts, ae.PrintSummary())...).Prints(ae.WrapMany("w", ae.New().Code("C").Tag("t").Msg("a"), err)),
+	}
+
+	defaults := reflect.ValueOf(ae.DefaultLabels())
+	for _, out := range outputs {
+		for i := range defaults.NumField() {
+			name := defaults.Type().Field(i).Name
+			if name == "ErrorCounts" || name == "InheritedAttrs" {
+				if strings.Contains(out, " in tree, ") || strings.Contains(out, " inherited attributes") {
+					t.Errorf("default %s in output:\n%s", name, out)
+				}
+				continue
+			}
+			if def := defaults.Field(i).String(); strings.Contains(out, def) {
+				t.Errorf("default %s label %q in output:\n%s", name, def, out)
+			}
+		}
+	}
+
+	for _, want := range []string{"L_Error", "L_NoMessage", "L_Exit", "L_Truncated", "L_Hint", "L_Shown",
+		"L_Time", "L_Trace", "L_Span", "L_Request", "L_Attrs", "L_CausedBy", "L_Related", "L_Stack", "L_InheritedAttrs"} {
+		if !strings.Contains(outputs[0], want) {
+			t.Errorf("%s missing from output:\n%s", want, outputs[0])
+		}
+	}
+	for _, want := range []string{"L_Errors", "L_ErrorCounts", "L_Codes", "L_Tags", "L_Examples", "L_NoCode", "L_First", "L_Last"} {
+		if !strings.Contains(outputs[1], want) {
+			t.Errorf("%s missing from summary:\n%s", want, outputs[1])
+		}
+	}
+}
+
+func TestPrintLabels_PartialAndWidth(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Hint("h").Cause(ae.Msg("c")).Msg("m")
+	out := ae.NewPrinter(ae.NoPrintColors(), ae.NoPrintTimestamp(), ae.PrintLabels(ae.Labels{CausedBy: "verursacht durch"})).Prints(err)
+
+	want := "[ERROR] m\n" +
+		"  hint              h\n" +
+		"  verursacht durch  c"
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+}
@@ -0,0 +1,429 @@
+package ae
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// Limits bounds the tags and attributes a Builder accepts, to keep errors
+// from growing without bound (e.g. an entire request body attached as an
+// attribute). A zero field means unlimited. See SetAttrLimits.
+type Limits struct {
+	// MaxValueBytes is the maximum size of an attribute value. Longer
+	// strings and byte slices are truncated and marked with a
+	// "…(truncated, N bytes)" suffix; other values whose %v rendering is
+	// longer are replaced by their type name. In both cases the attribute
+	// "<key>.original_bytes" records the original size.
+	MaxValueBytes int
+	// MaxAttrs is the maximum number of attributes. Further attributes are
+	// dropped and counted in the "attrs_dropped" attribute.
+	MaxAttrs int
+	// MaxTagLen is the maximum length of a tag in bytes; longer tags are
+	// truncated.
+	MaxTagLen int
+	// MaxTags is the maximum number of tags. Further tags are dropped and
+	// counted in the "tags_dropped" attribute.
+	MaxTags int
+}
+
+const (
+	// attrsDroppedKey counts attributes dropped by Limits.MaxAttrs.
+	attrsDroppedKey = "attrs_dropped"
+	// tagsDroppedKey counts tags dropped by Limits.MaxTags.
+	tagsDroppedKey = "tags_dropped"
+	// originalBytesSuffix is appended to the key of a truncated attribute to
+	// record its original size.
+	originalBytesSuffix = ".original_bytes"
+)
+
+// limits holds the active Limits; nil means unlimited.
+var limits atomic.Pointer[Limits]
+
+// SetAttrLimits sets the limits enforced when tags and attributes are added
+// through a Builder. The zero Limits, the default, enforces none. Errors
+// copied with From keep their tags and attributes as they are.
+func SetAttrLimits(l Limits) {
+	if l == (Limits{}) {
+		limits.Store(nil)
+		return
+	}
+
+	limits.Store(&l)
+}
+
+// addTag adds tag, enforcing the active limits.
+func (b *Builder) addTag(tag string) {
+	l := limits.Load()
+	if l != nil && l.MaxTagLen > 0 && len(tag) > l.MaxTagLen {
+		tag = truncateUTF8(tag, l.MaxTagLen)
+	}
+
+	if b.tags == nil {
+		b.tags = make(map[string]struct{})
+	}
+	if _, ok := b.tags[tag]; !ok && l != nil && l.MaxTags > 0 && len(b.tags) >= l.MaxTags {
+		b.countDropped(tagsDroppedKey)
+		return
+	}
+
+	b.tags[tag] = struct{}{}
+}
+
+// setAttr sets the attribute key, enforcing the active limits.
+func (b *Builder) setAttr(key string, value any) {
+	if b.attributes == nil {
+		b.attributes = make(map[string]any)
+	}
+
+	l := limits.Load()
+	if l == nil {
+		b.attributes[key] = value
+		return
+	}
+
+	if _, ok := b.attributes[key]; !ok && l.MaxAttrs > 0 && b.attrCount() >= l.MaxAttrs {
+		b.countDropped(attrsDroppedKey)
+		return
+	}
+
+	if l.MaxValueBytes > 0 {
+		if limited, size, ok := limitValue(value, l.MaxValueBytes); ok {
+			value = limited
+			b.attributes[key+originalBytesSuffix] = size
+		}
+	}
+
+	b.attributes[key] = value
+}
+
+// setAttrs sets every attribute of attrs, in key order when limits are
+// active so that the same attributes are dropped every time.
+func (b *Builder) setAttrs(attrs map[string]any) {
+	if limits.Load() == nil {
+		if b.attributes == nil {
+			b.attributes = make(map[string]any, len(attrs))
+		}
+		maps.Copy(b.attributes, attrs)
+		return
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(attrs)) {
+		b.setAttr(key, attrs[key])
+	}
+}
+
+// attrCount returns the number of attributes, not counting those recording
+// the effect of limits.
+func (b *Builder) attrCount() int {
+	n := 0
+	for key := range b.attributes {
+		switch {
+		case key == attrsDroppedKey, key == tagsDroppedKey, key == causesOmittedKey, key == relatedOmittedKey:
+		case strings.HasSuffix(key, originalBytesSuffix):
+		default:
+			n++
+		}
+	}
+
+	return n
+}
+
+// countDropped increments the counter attribute key.
+func (b *Builder) countDropped(key string) {
+	if b.attributes == nil {
+		b.attributes = make(map[string]any)
+	}
+
+	n, _ := b.attributes[key].(int)
+	b.attributes[key] = n + 1
+}
+
+// limitValue returns value limited to maxBytes and its original size, or
+// ok == false if it is within the limit.
+func limitValue(value any, maxBytes int) (limited any, size int, ok bool) {
+	switch v := value.(type) {
+	case string:
+		if len(v) <= maxBytes {
+			return nil, 0, false
+		}
+		return truncatedValue(v, maxBytes), len(v), true
+	case []byte:
+		if len(v) <= maxBytes {
+			return nil, 0, false
+		}
+		return truncatedValue(string(v), maxBytes), len(v), true
+	case secret:
+		// never rendered in full, so never too large
+		return nil, 0, false
+	}
+
+	if s := fmt.Sprintf("%v", value); len(s) > maxBytes {
+		return fmt.Sprintf("%T", value), len(s), true
+	}
+
+	return nil, 0, false
+}
+
+func truncatedValue(s string, maxBytes int) string {
+	return fmt.Sprintf("%s…(truncated, %d bytes)", truncateUTF8(s, maxBytes), len(s))
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n]
+}
+
+// treeLimits bounds the size of error trees, see SetTreeLimits.
+type treeLimits struct {
+	maxCauses int
+	maxDepth  int
+}
+
+const (
+	// causesOmittedKey counts the causes dropped by the tree limits.
+	causesOmittedKey = "causes_omitted"
+	// relatedOmittedKey counts the related errors dropped by the tree limits.
+	relatedOmittedKey = "related_omitted"
+)
+
+// activeTreeLimits holds the active tree limits; nil means unlimited.
+var activeTreeLimits atomic.Pointer[treeLimits]
+
+// SetTreeLimits bounds the error trees built through a Builder or From, to
+// keep runaway retry or aggregation loops from producing errors too large to
+// print or log. maxCauses limits the causes, and separately the related
+// errors, of each error; maxDepth limits how deeply errors nest below the
+// error being built, cutting off the causes and related errors of *Ae errors
+// at that depth. The *Ae errors on the way to a cut are copied, leaving the
+// originals unchanged; errors of other types are kept as they are.
+//
+// Dropped errors are replaced by a single summary error "… and N more errors
+// omitted" at the end of the list, and counted in the "causes_omitted" or
+// "related_omitted" attribute of the error that lost them. Zero, the
+// default, means unlimited.
+func SetTreeLimits(maxCauses, maxDepth int) {
+	if maxCauses <= 0 && maxDepth <= 0 {
+		activeTreeLimits.Store(nil)
+		return
+	}
+
+	activeTreeLimits.Store(&treeLimits{maxCauses: max(maxCauses, 0), maxDepth: max(maxDepth, 0)})
+}
+
+// errorTextLimits bounds the causes listed by Error, see
+// SetErrorTextLimits.
+type errorTextLimits struct {
+	maxCauses int
+	maxBytes  int
+}
+
+// activeErrorTextLimits holds the limits set with SetErrorTextLimits.
+var activeErrorTextLimits atomic.Pointer[errorTextLimits]
+
+// errorTextGeneration counts the changes to the settings rendering Error
+// texts, invalidating the texts cached before.
+var errorTextGeneration atomic.Uint64
+
+func init() {
+	activeErrorTextLimits.Store(&errorTextLimits{maxCauses: 8, maxBytes: 4096})
+}
+
+// SetErrorTextLimits bounds the text returned by Error for errors with
+// several causes, which lists them in brackets: after maxCauses causes, or
+// once the list is maxBytes long, the remaining ones are summarized as
+// "; … and N more". The limits apply to every list in the text, including
+// those of nested errors. The defaults are 8 causes and 4096 bytes; zero
+// means unlimited. The causes themselves are kept: Unwrap and the printers
+// still see all of them.
+func SetErrorTextLimits(maxCauses, maxBytes int) {
+	activeErrorTextLimits.Store(&errorTextLimits{maxCauses: max(maxCauses, 0), maxBytes: max(maxBytes, 0)})
+	errorTextGeneration.Add(1)
+}
+
+// exceeded reports whether a list of causes that has n causes and size
+// bytes so far must be cut.
+func (l *errorTextLimits) exceeded(n, size int) bool {
+	return (l.maxCauses > 0 && n >= l.maxCauses) || (l.maxBytes > 0 && size >= l.maxBytes)
+}
+
+// omittedErrors is the summary error that replaces errors dropped by the tree
+// limits.
+type omittedErrors int
+
+func (n omittedErrors) Error() string {
+	if n == 1 {
+		return "… and 1 more error omitted"
+	}
+
+	return fmt.Sprintf("… and %d more errors omitted", int(n))
+}
+
+// addCause adds cause unless it is nil, enforcing the active tree limits.
+func (b *Builder) addCause(cause error) {
+	if cause == nil {
+		return
+	}
+
+	b.causes = b.appendLimited(b.causes, cause, causesOmittedKey)
+}
+
+// appendLimited appends err to errs, the causes or related errors of b, and
+// returns the result. With tree limits active, err is cut to the maximum
+// depth, or dropped and counted under key if errs is full.
+func (b *Builder) appendLimited(errs []error, err error, key string) []error {
+	l := activeTreeLimits.Load()
+	if l == nil {
+		return append(errs, err)
+	}
+
+	if n, ok := err.(omittedErrors); ok {
+		return b.omit(errs, key, int(n))
+	}
+
+	kept := len(errs)
+	if kept > 0 {
+		if _, ok := errs[kept-1].(omittedErrors); ok {
+			kept--
+		}
+	}
+	if l.maxCauses > 0 && kept >= l.maxCauses {
+		return b.omit(errs, key, 1)
+	}
+
+	if l.maxDepth > 0 {
+		err = limitDepth(err, l.maxDepth-1)
+	}
+	if kept < len(errs) {
+		// keep the summary last
+		return append(slices.Clone(errs[:kept]), err, errs[kept])
+	}
+
+	return append(errs, err)
+}
+
+// omit records n more errors dropped from errs, updating the summary error at
+// the end of errs and the counter attribute key, and returns the result.
+func (b *Builder) omit(errs []error, key string, n int) []error {
+	if len(errs) > 0 {
+		if prev, ok := errs[len(errs)-1].(omittedErrors); ok {
+			n += int(prev)
+			errs = slices.Clone(errs[:len(errs)-1])
+		}
+	}
+
+	if b.attributes == nil {
+		b.attributes = make(map[string]any)
+	}
+	b.attributes[key] = n
+
+	return append(errs, omittedErrors(n))
+}
+
+// limitTree re-applies the active tree limits to the causes and related errors
+// of b, as copied by From.
+func (b Builder) limitTree() Builder {
+	if activeTreeLimits.Load() == nil {
+		return b
+	}
+
+	causes, related := b.causes, b.related
+	b.causes, b.related = nil, nil
+	for _, cause := range causes {
+		b.addCause(cause)
+	}
+	for _, r := range related {
+		b.addRelated(r)
+	}
+	if n := len(b.causes); n > 0 {
+		if _, ok := b.causes[n-1].(omittedErrors); ok && len(b.causesInMsg) >= n {
+			// the summary took the place of a dropped cause
+			b.causesInMsg = b.causesInMsg[:n-1]
+		}
+	}
+
+	return b
+}
+
+// limitDepth returns err with the causes and related errors of the *Ae errors
+// more than depth levels below it cut off. err is returned as is if nothing
+// is cut; otherwise the affected *Ae errors are cloned.
+func limitDepth(err error, depth int) error {
+	//goland:noinspection GoTypeAssertionOnErrors
+	a, ok := err.(*Ae)
+	if !ok || (len(a.causes) == 0 && len(a.related) == 0) {
+		return err
+	}
+
+	if depth == 0 {
+		b := Builder(*a.Clone())
+		b.causes = cutOff(&b, b.causes, causesOmittedKey)
+		b.related = cutOff(&b, b.related, relatedOmittedKey)
+		// the summary is not part of the message, unlike the causes it
+		// replaces may have been
+		b.causesInMsg = nil
+		b.errorText = &errorCache{}
+		return (*Ae)(&b)
+	}
+
+	causes, causesCut := limitDepthAll(a.causes, depth-1)
+	related, relatedCut := limitDepthAll(a.related, depth-1)
+	if !causesCut && !relatedCut {
+		return err
+	}
+
+	cpy := a.Clone()
+	cpy.causes, cpy.related = causes, related
+	cpy.errorText = &errorCache{}
+	return cpy
+}
+
+// limitDepthAll applies limitDepth to each of errs and reports whether any
+// was cut.
+func limitDepthAll(errs []error, depth int) ([]error, bool) {
+	var limited []error
+	for i, err := range errs {
+		if l := limitDepth(err, depth); l != err {
+			if limited == nil {
+				limited = slices.Clone(errs)
+			}
+			limited[i] = l
+		}
+	}
+
+	if limited == nil {
+		return errs, false
+	}
+
+	return limited, true
+}
+
+// cutOff returns the summary replacing all of errs, counted under key, or nil
+// if errs is empty.
+func cutOff(b *Builder, errs []error, key string) []error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	n := 0
+	for _, err := range errs {
+		if prev, ok := err.(omittedErrors); ok {
+			n += int(prev)
+		} else {
+			n++
+		}
+	}
+
+	return b.omit(nil, key, n)
+}
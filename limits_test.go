@@ -0,0 +1,345 @@
+package ae_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+func setLimits(t *testing.T, l ae.Limits) {
+	t.Helper()
+
+	ae.SetAttrLimits(l)
+	withPackageState(t, func() { ae.SetAttrLimits(ae.Limits{}) })
+}
+
+func TestAttrLimits_ZeroIsUnlimited(t *testing.T) {
+	setLimits(t, ae.Limits{})
+
+	body := strings.Repeat("x", 1<<20)
+	err := ae.New().Attr("body", body).Msg("boom")
+
+	if got := ae.Attributes(err)["body"]; got != body {
+		t.Error("value changed without limits")
+	}
+}
+
+func TestAttrLimits_TruncatesStrings(t *testing.T) {
+	setLimits(t, ae.Limits{MaxValueBytes: 8})
+
+	err := ae.New().
+		Attr("body", "0123456789abcdef").
+		Attr("raw", []byte("0123456789")).
+		Attr("short", "ok").
+		Attr("utf8", "ééééé"). // 10 bytes; byte 8 is mid-rune
+		Msg("boom")
+	attrs := ae.Attributes(err)
+
+	if got := attrs["body"]; got != "01234567…(truncated, 16 bytes)" {
+		t.Errorf("body = %q", got)
+	}
+	if got := attrs["body.original_bytes"]; got != 16 {
+		t.Errorf("body.original_bytes = %v, want 16", got)
+	}
+	if got := attrs["raw"]; got != "01234567…(truncated, 10 bytes)" {
+		t.Errorf("raw = %q", got)
+	}
+	if got := attrs["short"]; got != "ok" {
+		t.Errorf("short = %q, want unchanged", got)
+	}
+	if _, ok := attrs["short.original_bytes"]; ok {
+		t.Error("size recorded for a value within the limit")
+	}
+	if got := attrs["utf8"]; got != "éééé…(truncated, 10 bytes)" {
+		t.Errorf("utf8 = %q, want cut on a rune boundary", got)
+	}
+}
+
+func TestAttrLimits_ReplacesLargeNonStrings(t *testing.T) {
+	setLimits(t, ae.Limits{MaxValueBytes: 10})
+
+	err := ae.New().
+		Attr("ids", []int{1, 2, 3, 4, 5, 6, 7, 8}).
+		Attr("n", 42).
+		Msg("boom")
+	attrs := ae.Attributes(err)
+
+	if got := attrs["ids"]; got != "[]int" {
+		t.Errorf("ids = %v, want the type name", got)
+	}
+	if got := attrs["ids.original_bytes"]; got != len("[1 2 3 4 5 6 7 8]") {
+		t.Errorf("ids.original_bytes = %v", got)
+	}
+	if got := attrs["n"]; got != 42 {
+		t.Errorf("n = %v, want unchanged", got)
+	}
+}
+
+func TestAttrLimits_MaxAttrs(t *testing.T) {
+	setLimits(t, ae.Limits{MaxAttrs: 2, MaxValueBytes: 4})
+
+	err := ae.New().
+		Attr("a", "long value").
+		Attr("b", 2).
+		Attr("a", "x"). // overwriting an existing key is allowed
+		Attrs(map[string]any{"c": 3, "d": 4}).
+		Msg("boom")
+	attrs := ae.Attributes(err)
+
+	if _, ok := attrs["c"]; ok {
+		t.Error("attribute beyond the limit was kept")
+	}
+	if got := attrs["a"]; got != "x" {
+		t.Errorf("a = %v, want overwritten", got)
+	}
+	if got := attrs["attrs_dropped"]; got != 2 {
+		t.Errorf("attrs_dropped = %v, want 2", got)
+	}
+}
+
+func TestAttrLimits_Tags(t *testing.T) {
+	setLimits(t, ae.Limits{MaxTagLen: 5, MaxTags: 2})
+
+	err := ae.New().Tags("network", "db", "db", "cache", "retry").Msg("boom")
+
+	tags := ae.Tags(err)
+	if strings.Join(tags, ",") != "db,netwo" {
+		t.Errorf("tags = %v, want [db netwo]", tags)
+	}
+	if got := ae.Attributes(err)["tags_dropped"]; got != 2 {
+		t.Errorf("tags_dropped = %v, want 2", got)
+	}
+}
+
+func setTreeLimits(t *testing.T, maxCauses, maxDepth int) {
+	t.Helper()
+
+	ae.SetTreeLimits(maxCauses, maxDepth)
+	withPackageState(t, func() { ae.SetTreeLimits(0, 0) })
+}
+
+func numbered(n int) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = fmt.Errorf("attempt %d", i)
+	}
+
+	return errs
+}
+
+// chain returns n nested *Ae errors, the outermost first.
+func chain(n int) error {
+	var err error
+	for i := n; i > 0; i-- {
+		err = ae.New().Cause(err).Msgf("level %d", i)
+	}
+
+	return err
+}
+
+func TestTreeLimits_ZeroIsUnlimited(t *testing.T) {
+	setTreeLimits(t, 0, 0)
+
+	err := ae.New().Causes(numbered(1000)).Cause(chain(50)).Msg("boom")
+	if got := len(ae.Causes(err)); got != 1001 {
+		t.Errorf("got %d causes, want 1001", got)
+	}
+}
+
+func TestTreeLimits_MaxCauses(t *testing.T) {
+	setTreeLimits(t, 3, 0)
+
+	err := ae.New().Causes(numbered(10)).Msg("retries exhausted")
+	causes := ae.Causes(err)
+	if len(causes) != 4 {
+		t.Fatalf("got %d causes, want 3 and the summary: %v", len(causes), causes)
+	}
+	if got := causes[2].Error(); got != "attempt 2" {
+		t.Errorf("causes[2] = %q, want the third cause", got)
+	}
+	if got := causes[3].Error(); got != "… and 7 more errors omitted" {
+		t.Errorf("summary = %q", got)
+	}
+	if got := ae.Attributes(err)["causes_omitted"]; got != 7 {
+		t.Errorf("causes_omitted = %v, want 7", got)
+	}
+
+	// adding to a full error updates the summary in place
+	err = ae.From(err).Cause(errors.New("attempt 10")).Msg("")
+	causes = ae.Causes(err)
+	if len(causes) != 4 || causes[3].Error() != "… and 8 more errors omitted" {
+		t.Errorf("causes = %v, want one summary counting 8", causes)
+	}
+	if got := ae.Attributes(err)["causes_omitted"]; got != 8 {
+		t.Errorf("causes_omitted = %v, want 8", got)
+	}
+}
+
+func TestTreeLimits_MaxCausesAppliesToRelated(t *testing.T) {
+	setTreeLimits(t, 2, 0)
+
+	err := ae.New().Related(numbered(3)...).Msg("cleanup")
+	related := ae.Related(err)
+	if len(related) != 3 || related[2].Error() != "… and 1 more error omitted" {
+		t.Errorf("related = %v, want two and the summary", related)
+	}
+	if got := ae.Attributes(err)["related_omitted"]; got != 1 {
+		t.Errorf("related_omitted = %v, want 1", got)
+	}
+}
+
+func TestTreeLimits_MaxDepth(t *testing.T) {
+	inner := chain(6)
+	setTreeLimits(t, 0, 3)
+
+	err := ae.New().Cause(inner).Msg("top")
+
+	var levels []string
+	for e := err; e != nil; {
+		levels = append(levels, e.Error())
+		causes := ae.Causes(e)
+		if len(causes) == 0 {
+			break
+		}
+		e = causes[0]
+	}
+	if len(levels) != 5 {
+		t.Fatalf("got %d levels, want the error, 3 below it and the summary: %q", len(levels), levels)
+	}
+	if got := levels[4]; got != "… and 1 more error omitted" {
+		t.Errorf("deepest = %q, want the summary", got)
+	}
+
+	cut := ae.Causes(ae.Causes(ae.Causes(err)[0])[0])[0]
+	if got := ae.Message(cut); got != "level 3" {
+		t.Errorf("cut error = %q, want level 3", got)
+	}
+	if got := ae.Attributes(cut)["causes_omitted"]; got != 1 {
+		t.Errorf("causes_omitted on the cut error = %v, want 1", got)
+	}
+
+	if got := len(strings.Split(inner.Error(), ": ")); got != 6 {
+		t.Errorf("the original chain was modified: %q", inner.Error())
+	}
+}
+
+func TestTreeLimits_MaxDepthCutsMessageCause(t *testing.T) {
+	setTreeLimits(t, 0, 2)
+
+	// the text of the plain error is the message of the error From creates,
+	// so Error doesn't repeat it
+	wrapped := ae.From(errors.New("disk full")).Msg("")
+	err := ae.Wrap("saving", ae.Wrap("writing", wrapped))
+
+	want := "saving: writing: disk full: … and 1 more error omitted"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestTreeLimits_From(t *testing.T) {
+	ae.SetTreeLimits(0, 0)
+	err := ae.New().Causes(numbered(10)).Msg("boom")
+
+	setTreeLimits(t, 4, 0)
+	limited := ae.From(err).Msg("")
+	if got := len(ae.Causes(limited)); got != 5 {
+		t.Errorf("got %d causes, want 4 and the summary", got)
+	}
+	if got := ae.Attributes(limited)["causes_omitted"]; got != 6 {
+		t.Errorf("causes_omitted = %v, want 6", got)
+	}
+
+	foreign := ae.From(stubErr{msg: "boom", causes: numbered(10)}).Msg("")
+	if got := len(ae.Causes(foreign)); got != 5 {
+		t.Errorf("From(ErrorCauses): got %d causes, want 4 and the summary", got)
+	}
+}
+
+func TestTreeLimits_PrintersRenderSummary(t *testing.T) {
+	setTreeLimits(t, 2, 0)
+
+	err := ae.New().Causes(numbered(5)).Msg("retries exhausted")
+
+	if out := ae.NewPrinter(ae.NoPrintColors()).Prints(err); !strings.Contains(out, "… and 3 more errors omitted") {
+		t.Errorf("text output has no summary:\n%s", out)
+	}
+	if out := ae.NewPrinter(ae.PrintJSON()).Prints(err); !strings.Contains(out, `"message": "… and 3 more errors omitted"`) {
+		t.Errorf("JSON output has no summary:\n%s", out)
+	}
+}
+
+func setErrorTextLimits(t *testing.T, maxCauses, maxBytes int) {
+	t.Helper()
+
+	ae.SetErrorTextLimits(maxCauses, maxBytes)
+	withPackageState(t, func() { ae.SetErrorTextLimits(8, 4096) })
+}
+
+func TestErrorTextLimits_Default(t *testing.T) {
+	eight := ae.New().Causes(numbered(8)).Msg("boom")
+	if got, want := eight.Error(), "boom: [attempt 0; attempt 1; attempt 2; attempt 3; attempt 4; attempt 5; attempt 6; attempt 7]"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	nine := ae.New().Causes(numbered(9)).Msg("boom")
+	if got := nine.Error(); !strings.HasSuffix(got, "attempt 7; … and 1 more]") {
+		t.Errorf("Error() = %q, want the 9th cause summarized", got)
+	}
+
+	many := ae.New().Causes(numbered(1000)).Msg("boom")
+	if got := many.Error(); !strings.HasSuffix(got, "attempt 7; … and 992 more]") || len(got) > 200 {
+		t.Errorf("Error() = %q, want 8 causes and 992 summarized", got)
+	}
+
+	if got := len(many.(interface{ Unwrap() []error }).Unwrap()); got != 1000 {
+		t.Errorf("Unwrap returned %d causes, want 1000", got)
+	}
+	if out := ae.NewPrinter(ae.NoPrintColors()).Prints(many); !strings.Contains(out, "attempt 999") {
+		t.Error("printer does not show every cause")
+	}
+}
+
+func TestErrorTextLimits_Nested(t *testing.T) {
+	inner := ae.New().Causes(numbered(20)).Msg("inner")
+	err := ae.New().Causes(append(numbered(20), inner)).Msg("outer")
+
+	setErrorTextLimits(t, 2, 0)
+	if got, want := err.Error(), "outer: [attempt 0; attempt 1; … and 19 more]"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	err = ae.New().Cause(errors.New("first"), inner).Msg("outer")
+	if got, want := err.Error(), "outer: [first; inner: [attempt 0; attempt 1; … and 18 more]]"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestErrorTextLimits_Bytes(t *testing.T) {
+	setErrorTextLimits(t, 0, 30)
+
+	err := ae.New().Causes(numbered(100)).Msg("boom")
+	if got, want := err.Error(), "boom: [attempt 0; attempt 1; attempt 2; … and 97 more]"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	setErrorTextLimits(t, 0, 0)
+	if got := ae.New().Causes(numbered(100)).Msg("boom").Error(); !strings.HasSuffix(got, "attempt 99]") {
+		t.Errorf("unlimited Error() = %q", got)
+	}
+}
+
+func TestErrorTextLimits_AppliesToCachedText(t *testing.T) {
+	err := ae.New().Causes(numbered(10)).Msg("boom")
+	if got := err.Error(); !strings.HasSuffix(got, "attempt 7; … and 2 more]") {
+		t.Fatalf("Error() = %q, want the default limits", got)
+	}
+
+	setErrorTextLimits(t, 2, 0)
+	if got, want := err.Error(), "boom: [attempt 0; attempt 1; … and 8 more]"; got != want {
+		t.Errorf("Error() after SetErrorTextLimits = %q, want %q", got, want)
+	}
+}
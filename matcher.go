@@ -0,0 +1,158 @@
+package ae
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// isMatcher is a matcher registered with RegisterIsMatcher; the pointer
+// identifies it for removal.
+type isMatcher struct {
+	target error
+	match  func(err error) bool
+}
+
+var (
+	// isMatchersMu serializes registrations; isMatchers holds the
+	// registered matchers, replaced rather than modified so Is reads them
+	// without locking.
+	isMatchersMu sync.Mutex
+	isMatchers   atomic.Pointer[[]*isMatcher]
+)
+
+// RegisterIsMatcher makes errors.Is(err, target) succeed when match reports
+// true for an error in the tree of err, so errors of third-party libraries
+// count as target without sharing its type or code:
+//
+//	ae.RegisterIsMatcher(ErrDeadlock, func(err error) bool {
+//		return strings.Contains(err.Error(), "deadlock detected")
+//	})
+//
+// errors.Is finds the match through the Is method of an *Ae in the tree,
+// which consults the matchers for itself and the non-ae errors below it;
+// use IsMatched for trees without *Ae errors. The code-based match of Is is
+// tried first. target must be comparable, as the targets of errors.Is are
+// compared with ==; other targets are ignored.
+//
+// Registration is meant for init time; the returned function unregisters the
+// matcher, and ClearIsMatchers unregisters all of them.
+func RegisterIsMatcher(target error, match func(err error) bool) (remove func()) {
+	if target == nil || match == nil || !reflect.TypeOf(target).Comparable() {
+		return func() {}
+	}
+
+	m := &isMatcher{target: target, match: match}
+	updateIsMatchers(func(ms []*isMatcher) []*isMatcher {
+		return append(slices.Clip(ms), m)
+	})
+
+	return func() {
+		updateIsMatchers(func(ms []*isMatcher) []*isMatcher {
+			return slices.DeleteFunc(slices.Clone(ms), func(other *isMatcher) bool { return other == m })
+		})
+	}
+}
+
+// ClearIsMatchers unregisters every matcher registered with
+// RegisterIsMatcher. It is meant for tests.
+func ClearIsMatchers() {
+	updateIsMatchers(func([]*isMatcher) []*isMatcher { return nil })
+}
+
+func updateIsMatchers(update func(ms []*isMatcher) []*isMatcher) {
+	isMatchersMu.Lock()
+	defer isMatchersMu.Unlock()
+
+	var ms []*isMatcher
+	if p := isMatchers.Load(); p != nil {
+		ms = *p
+	}
+	ms = update(ms)
+	isMatchers.Store(&ms)
+}
+
+// matchersFor returns the match functions registered for target.
+func matchersFor(target error) []func(err error) bool {
+	p := isMatchers.Load()
+	if p == nil || len(*p) == 0 || target == nil || !reflect.TypeOf(target).Comparable() {
+		return nil
+	}
+
+	var fns []func(err error) bool
+	for _, m := range *p {
+		if sameTarget(m.target, target) {
+			fns = append(fns, m.match)
+		}
+	}
+
+	return fns
+}
+
+// sameTarget reports whether a == b, treating a comparable type holding an
+// incomparable value as unequal instead of panicking.
+func sameTarget(a, b error) (same bool) {
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+
+	return a == b
+}
+
+// IsMatched reports whether errors.Is(err, target) holds or a matcher
+// registered for target with RegisterIsMatcher reports true for any error in
+// the tree of err, *Ae or not.
+func IsMatched(err error, target error) bool {
+	if errors.Is(err, target) {
+		return true
+	}
+
+	fns := matchersFor(target)
+	if len(fns) == 0 {
+		return false
+	}
+
+	return walkCauses(err, func(err error) bool {
+		return !anyMatch(fns, err)
+	})
+}
+
+// isMatchedBelow reports whether a matcher in fns reports true for a or one
+// of the non-ae errors below it. *Ae errors are skipped, as errors.Is calls
+// their Is method itself.
+func isMatchedBelow(a *Ae, fns []func(err error) bool) bool {
+	if anyMatch(fns, a) {
+		return true
+	}
+
+	for _, cause := range a.causes {
+		if _, ok := asAe(cause); ok {
+			continue
+		}
+		matched := walkCauses(cause, func(err error) bool {
+			if _, ok := asAe(err); ok {
+				return true
+			}
+			return !anyMatch(fns, err)
+		})
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func anyMatch(fns []func(err error) bool, err error) bool {
+	for _, fn := range fns {
+		if fn(err) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,129 @@
+package ae_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+// driverErr is a third-party error known only by its message.
+type driverErr struct{ msg string }
+
+func (e *driverErr) Error() string { return e.msg }
+
+// incomparableErr can't be the target of a matcher.
+type incomparableErr []string
+
+func (incomparableErr) Error() string { return "incomparable" }
+
+var errDeadlock = ae.New().Code("DEADLOCK").Msg("deadlock")
+
+// registerDeadlockMatcher matches driver errors reporting a deadlock and
+// returns the number of calls of the matcher.
+func registerDeadlockMatcher(t *testing.T) *int {
+	t.Helper()
+
+	calls := new(int)
+	withPackageState(t, ae.RegisterIsMatcher(errDeadlock, func(err error) bool {
+		*calls++
+		var d *driverErr
+		return errors.As(err, &d) && strings.Contains(d.msg, "deadlock detected")
+	}))
+
+	return calls
+}
+
+func TestRegisterIsMatcher_HitAtDepth(t *testing.T) {
+	registerDeadlockMatcher(t)
+
+	err := ae.Wrap("committing", ae.Wrap("querying",
+		fmt.Errorf("exec: %w", &driverErr{"ERROR: deadlock detected (SQLSTATE 40P01)"})))
+	if !errors.Is(err, errDeadlock) {
+		t.Error("errors.Is = false, want the matcher to fire")
+	}
+
+	// next to other causes
+	err = ae.WrapMany("batch", errors.New("other"), &driverErr{"deadlock detected"})
+	if !errors.Is(err, errDeadlock) {
+		t.Error("errors.Is = false for a second cause")
+	}
+}
+
+func TestRegisterIsMatcher_Miss(t *testing.T) {
+	registerDeadlockMatcher(t)
+
+	err := ae.Wrap("querying", &driverErr{"ERROR: syntax error"})
+	if errors.Is(err, errDeadlock) {
+		t.Error("errors.Is = true for another driver error")
+	}
+
+	// matchers only apply to their target
+	other := ae.New().Code("TIMEOUT").Msg("timeout")
+	if errors.Is(ae.Wrap("querying", &driverErr{"deadlock detected"}), other) {
+		t.Error("errors.Is = true for a target without matchers")
+	}
+}
+
+func TestRegisterIsMatcher_CodePrecedence(t *testing.T) {
+	calls := registerDeadlockMatcher(t)
+
+	if !errors.Is(ae.New().Code("DEADLOCK").Msg("from our code"), errDeadlock) {
+		t.Error("errors.Is = false for the code")
+	}
+	if *calls != 0 {
+		t.Errorf("matcher called %d times despite the code matching", *calls)
+	}
+
+	// another code doesn't prevent the matcher from firing
+	err := ae.New().Code("DB_ERROR").Cause(&driverErr{"deadlock detected"}).Msg("querying")
+	if !errors.Is(err, errDeadlock) {
+		t.Error("errors.Is = false for another code")
+	}
+}
+
+func TestIsMatched(t *testing.T) {
+	registerDeadlockMatcher(t)
+
+	// no *Ae in the tree: errors.Is can't consult the matchers
+	err := fmt.Errorf("exec: %w", &driverErr{"deadlock detected"})
+	if errors.Is(err, errDeadlock) {
+		t.Fatal("errors.Is consulted the matchers without an *Ae")
+	}
+	if !ae.IsMatched(err, errDeadlock) {
+		t.Error("IsMatched = false, want the matcher to fire")
+	}
+	if ae.IsMatched(errors.New("plain"), errDeadlock) {
+		t.Error("IsMatched = true for an unrelated error")
+	}
+	if !ae.IsMatched(ae.New().Code("DEADLOCK").Msg("x"), errDeadlock) {
+		t.Error("IsMatched = false for the code")
+	}
+}
+
+func TestRegisterIsMatcher_RemoveAndClear(t *testing.T) {
+	err := ae.Wrap("querying", &driverErr{"deadlock detected"})
+
+	remove := ae.RegisterIsMatcher(errDeadlock, func(error) bool { return true })
+	remove()
+	if errors.Is(err, errDeadlock) {
+		t.Error("errors.Is = true after removing the matcher")
+	}
+
+	ae.RegisterIsMatcher(errDeadlock, func(error) bool { return true })
+	ae.RegisterIsMatcher(errDeadlock, func(error) bool { return true })
+	ae.ClearIsMatchers()
+	if errors.Is(err, errDeadlock) {
+		t.Error("errors.Is = true after ClearIsMatchers")
+	}
+}
+
+func TestRegisterIsMatcher_IncomparableTarget(t *testing.T) {
+	withPackageState(t, ae.RegisterIsMatcher(incomparableErr{"x"}, func(error) bool { return true }))
+
+	if ae.IsMatched(ae.Msg("x"), incomparableErr{"x"}) {
+		t.Error("matcher registered for an incomparable target")
+	}
+}
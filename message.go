@@ -1,5 +1,7 @@
 package ae
 
+import "fmt"
+
 // ErrorMessage defines an interface for errors that can provide a message.
 type ErrorMessage interface {
 	// ErrorMessage returns the error message.
@@ -21,3 +23,45 @@ func Message(err error) string {
 
 	return err.Error()
 }
+
+const (
+	// noMessage is the message of errors built without one and nothing to
+	// fall back to.
+	noMessage = "(no message)"
+	// msgFallbackKey marks errors whose message is a fallback.
+	msgFallbackKey = "msg_fallback"
+)
+
+// fallbackMsg sets a message for an error finalized without one: its user
+// message, its code, the message of its single cause, "N errors" for
+// several causes, or "(no message)", in that order. The attribute
+// msg_fallback records that the message was not given.
+func (b *Builder) fallbackMsg() {
+	switch {
+	case b.userMsg != "":
+		b.msg = b.userMsg
+	case b.code != "":
+		b.msg = b.code
+	case len(b.causes) == 1 && Message(b.causes[0]) != "":
+		b.msg = Message(b.causes[0])
+		if messageIsText(b.causes[0]) {
+			b.causesInMsg = []bool{true}
+		}
+	case len(b.causes) > 1:
+		b.msg = fmt.Sprintf("%d errors", len(b.causes))
+	default:
+		b.msg = noMessage
+	}
+
+	b.setAttr(msgFallbackKey, true)
+}
+
+// messageIsText reports whether the message of err, as returned by Message,
+// is its whole text, so a message taken from err covers err.
+func messageIsText(err error) bool {
+	if a, ok := asAe(err); ok {
+		return len(a.textCauses()) == 0
+	}
+	_, ok := err.(ErrorMessage)
+	return !ok
+}
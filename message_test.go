@@ -2,6 +2,7 @@ package ae_test
 
 import (
 	"errors"
+	"strings"
 	"testing"
 
 	"go.aledante.io/ae"
@@ -43,3 +44,53 @@ func TestMessage_AeBuilderReturnsInternalMessage(t *testing.T) {
 		t.Errorf("Message on builder = %q, want %q", got, "hello")
 	}
 }
+
+func TestMsg_EmptyFallback(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		err     error
+		msg     string
+		errText string
+	}{
+		{"user message", ae.New().UserMsg("", "Please retry"), "Please retry", "Please retry"},
+		{"code", ae.New().Code("DB_TIMEOUT").Cause(errors.New("i/o timeout")).Msg(""), "DB_TIMEOUT", "DB_TIMEOUT: i/o timeout"},
+		{"single cause", ae.New().Cause(errors.New("i/o timeout")).Msg(""), "i/o timeout", "i/o timeout"},
+		{"joined", ae.New().Cause(errors.New("a"), errors.New("b"), errors.New("c")).Msg(""), "3 errors", "3 errors: [a; b; c]"},
+		{"nothing", ae.New().Msg(""), "(no message)", "(no message)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ae.Message(tt.err); got != tt.msg {
+				t.Errorf("Message = %q, want %q", got, tt.msg)
+			}
+			if got := tt.err.Error(); got != tt.errText {
+				t.Errorf("Error() = %q, want %q", got, tt.errText)
+			}
+			if got := ae.Attributes(tt.err)["msg_fallback"]; got != true {
+				t.Errorf("msg_fallback = %v, want true", got)
+			}
+
+			header, _, _ := strings.Cut(ae.NewPrinter(ae.NoPrintColors(), ae.NoPrintCode()).Prints(tt.err), "\n")
+			if header != "[ERROR] "+tt.msg {
+				t.Errorf("printed header = %q, want the fallback %q", header, tt.msg)
+			}
+		})
+	}
+}
+
+func TestMsg_NoFallbackWithMessage(t *testing.T) {
+	t.Parallel()
+
+	for _, err := range []error{
+		ae.New().Msg("given"),
+		ae.From(errors.New("taken over")).Msg(""),
+	} {
+		if _, ok := ae.Attributes(err)["msg_fallback"]; ok {
+			t.Errorf("%q: msg_fallback set for an error with a message", err)
+		}
+	}
+}
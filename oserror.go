@@ -0,0 +1,100 @@
+package ae
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// errnoNames maps common errno values onto their symbolic names.
+var errnoNames = map[syscall.Errno]string{
+	syscall.EACCES:       "EACCES",
+	syscall.EAGAIN:       "EAGAIN",
+	syscall.EBUSY:        "EBUSY",
+	syscall.ECONNREFUSED: "ECONNREFUSED",
+	syscall.ECONNRESET:   "ECONNRESET",
+	syscall.EEXIST:       "EEXIST",
+	syscall.EINVAL:       "EINVAL",
+	syscall.EISDIR:       "EISDIR",
+	syscall.EMFILE:       "EMFILE",
+	syscall.ENOENT:       "ENOENT",
+	syscall.ENOSPC:       "ENOSPC",
+	syscall.ENOTDIR:      "ENOTDIR",
+	syscall.ENOTEMPTY:    "ENOTEMPTY",
+	syscall.EPERM:        "EPERM",
+	syscall.EPIPE:        "EPIPE",
+	syscall.EROFS:        "EROFS",
+	syscall.ETIMEDOUT:    "ETIMEDOUT",
+}
+
+// FromOS creates a Builder from a filesystem or syscall error, like From,
+// keeping err as a cause so errors.Is(…, fs.ErrNotExist) and friends keep
+// working. The structured parts found in err's chain are attached:
+//
+//   - *fs.PathError: attributes "os.op" and "os.path".
+//   - *os.LinkError: attributes "os.op", "os.path" and "os.new_path".
+//   - *os.SyscallError: attribute "os.op" (the syscall name).
+//   - syscall.Errno: attributes "os.errno" (the number) and, for common
+//     values, "os.errno_name" (e.g. "ENOENT").
+//
+// Additionally the tags "not-found" (fs.ErrNotExist), "permission"
+// (fs.ErrPermission) and "timeout" (ETIMEDOUT, os.ErrDeadlineExceeded) are
+// added when the chain matches.
+func FromOS(err error) Builder {
+	b := From(err)
+	if err == nil {
+		return b
+	}
+
+	var (
+		pathErr    *fs.PathError
+		linkErr    *os.LinkError
+		syscallErr *os.SyscallError
+		errno      syscall.Errno
+	)
+	switch {
+	case errors.As(err, &pathErr):
+		b = b.Attr("os.op", pathErr.Op).Attr("os.path", pathErr.Path)
+	case errors.As(err, &linkErr):
+		b = b.Attr("os.op", linkErr.Op).Attr("os.path", linkErr.Old).Attr("os.new_path", linkErr.New)
+	case errors.As(err, &syscallErr):
+		b = b.Attr("os.op", syscallErr.Syscall)
+	}
+	if errors.As(err, &errno) {
+		b = b.Attr("os.errno", int(errno))
+		if name, ok := errnoNames[errno]; ok {
+			b = b.Attr("os.errno_name", name)
+		}
+	}
+
+	if errors.Is(err, fs.ErrNotExist) {
+		b = b.Tag("not-found")
+	}
+	if errors.Is(err, fs.ErrPermission) {
+		b = b.Tag("permission")
+	}
+	if errors.Is(err, syscall.ETIMEDOUT) || errors.Is(err, os.ErrDeadlineExceeded) {
+		b = b.Tag("timeout")
+	}
+
+	return b
+}
+
+// WrapPath wraps err with the message "<op> <path>", the attributes "fs.op"
+// and "fs.path", and the tag "fs", so the failing path survives further
+// wrapping. err stays the cause, keeping errors.Is(…, fs.ErrNotExist) and
+// friends working. Returns nil if err is nil.
+func WrapPath(err error, op, path string) error {
+	if err == nil {
+		return nil
+	}
+
+	return New().
+		Cause(err).
+		classify(err).
+		Tag("fs").
+		Attr("fs.op", op).
+		Attr("fs.path", path).
+		Msgf("%s %s", op, path)
+}
@@ -0,0 +1,154 @@
+package ae_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+func TestFromOS_MissingFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "missing.yaml")
+	_, openErr := os.Open(path)
+
+	err := ae.FromOS(openErr).Msg("loading config")
+
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Error("errors.Is(err, fs.ErrNotExist) = false through FromOS")
+	}
+
+	attrs := ae.Attributes(err)
+	if attrs["os.op"] != "open" {
+		t.Errorf("os.op = %v, want open", attrs["os.op"])
+	}
+	if attrs["os.path"] != path {
+		t.Errorf("os.path = %v, want %s", attrs["os.path"], path)
+	}
+	if attrs["os.errno"] != int(syscall.ENOENT) {
+		t.Errorf("os.errno = %v, want %d", attrs["os.errno"], int(syscall.ENOENT))
+	}
+	if attrs["os.errno_name"] != "ENOENT" {
+		t.Errorf("os.errno_name = %v, want ENOENT", attrs["os.errno_name"])
+	}
+
+	if tags := ae.Tags(err); len(tags) != 1 || tags[0] != "not-found" {
+		t.Errorf("tags = %v, want [not-found]", tags)
+	}
+}
+
+func TestFromOS_PermissionDenied(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes do not restrict reads on windows")
+	}
+	if os.Geteuid() == 0 {
+		t.Skip("root bypasses file permissions")
+	}
+
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte("x"), 0o000); err != nil {
+		t.Fatal(err)
+	}
+	_, openErr := os.Open(path)
+
+	err := ae.FromOS(openErr).Msg("reading secret")
+
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Error("errors.Is(err, fs.ErrPermission) = false through FromOS")
+	}
+	if got := ae.Attributes(err)["os.errno_name"]; got != "EACCES" {
+		t.Errorf("os.errno_name = %v, want EACCES", got)
+	}
+	if tags := ae.Tags(err); len(tags) != 1 || tags[0] != "permission" {
+		t.Errorf("tags = %v, want [permission]", tags)
+	}
+}
+
+func TestFromOS_PermissionErrno(t *testing.T) {
+	t.Parallel()
+
+	openErr := &fs.PathError{Op: "open", Path: "/etc/shadow", Err: syscall.EACCES}
+	err := ae.FromOS(openErr).Msg("reading shadow")
+
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Error("errors.Is(err, fs.ErrPermission) = false through FromOS")
+	}
+	if tags := ae.Tags(err); len(tags) != 1 || tags[0] != "permission" {
+		t.Errorf("tags = %v, want [permission]", tags)
+	}
+}
+
+func TestFromOS_LinkError(t *testing.T) {
+	t.Parallel()
+
+	linkErr := &os.LinkError{Op: "rename", Old: "/a", New: "/b", Err: syscall.ETIMEDOUT}
+	err := ae.FromOS(linkErr).Msg("moving file")
+
+	attrs := ae.Attributes(err)
+	if attrs["os.op"] != "rename" || attrs["os.path"] != "/a" || attrs["os.new_path"] != "/b" {
+		t.Errorf("attrs = %v, want rename /a -> /b", attrs)
+	}
+	if tags := ae.Tags(err); len(tags) != 1 || tags[0] != "timeout" {
+		t.Errorf("tags = %v, want [timeout]", tags)
+	}
+}
+
+func TestFromOS_PlainError(t *testing.T) {
+	t.Parallel()
+
+	plain := errors.New("plain")
+	err := ae.FromOS(plain).Msg("x")
+
+	if !errors.Is(err, plain) {
+		t.Error("errors.Is(err, plain) = false through FromOS")
+	}
+	if len(ae.Attributes(err)) != 0 || len(ae.Tags(err)) != 0 {
+		t.Errorf("unexpected enrichment for a plain error: %v %v", ae.Attributes(err), ae.Tags(err))
+	}
+}
+
+func TestFromOS_Nil(t *testing.T) {
+	t.Parallel()
+
+	err := ae.FromOS(nil).Msg("x")
+	if len(ae.Causes(err)) != 0 {
+		t.Errorf("FromOS(nil) added causes: %v", ae.Causes(err))
+	}
+}
+
+func TestWrapPath_Nil(t *testing.T) {
+	t.Parallel()
+
+	if got := ae.WrapPath(nil, "read", "/x"); got != nil {
+		t.Errorf("WrapPath(nil) = %v, want nil", got)
+	}
+}
+
+func TestWrapPath_AttachesPathAndKeepsCause(t *testing.T) {
+	t.Parallel()
+
+	err := ae.Wrap("loading config", ae.WrapPath(fs.ErrNotExist, "read", "conf/app.yaml"))
+
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Error("errors.Is(err, fs.ErrNotExist) = false through WrapPath")
+	}
+	if got := err.Error(); got != "loading config: read conf/app.yaml: file does not exist" {
+		t.Errorf("Error() = %q", got)
+	}
+
+	inner := ae.Causes(err)[0]
+	if attrs := ae.Attributes(inner); attrs["fs.op"] != "read" || attrs["fs.path"] != "conf/app.yaml" {
+		t.Errorf("attrs = %v, want fs.op=read fs.path=conf/app.yaml", attrs)
+	}
+	if tags := ae.Tags(inner); len(tags) != 1 || tags[0] != "fs" {
+		t.Errorf("tags = %v, want [fs]", tags)
+	}
+}
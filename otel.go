@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
 )
 
 // ErrorSpanId defines an interface for errors that can provide a span ID for distributed tracing.
@@ -67,3 +68,40 @@ func WithOtelAttributes(ctx context.Context, attrs []attribute.KeyValue) context
 func WithOtelAttributeSet(ctx context.Context, attrs attribute.Set) context.Context {
 	return WithOtelAttributes(ctx, attrs.ToSlice())
 }
+
+// OtelResourceAttributes returns the attributes of err that describe the
+// producing service and process, as stamped by SetServiceInfo,
+// SetCaptureProcessInfo and Builder.ProcessInfo, converted to the
+// OpenTelemetry semantic-convention resource attributes service.name,
+// service.version, deployment.environment.name, host.name, process.pid and
+// process.creation.time. Missing attributes are left out; goroutine.id
+// describes no resource and is never included.
+func OtelResourceAttributes(err error) []attribute.KeyValue {
+	attrs := Attributes(err)
+
+	var kvs []attribute.KeyValue
+	for _, m := range [...]struct {
+		key     string
+		convert func(string) attribute.KeyValue
+	}{
+		{AttrServiceName, semconv.ServiceName},
+		{AttrServiceVersion, semconv.ServiceVersion},
+		{AttrServiceEnv, semconv.DeploymentEnvironmentName},
+		{AttrHostName, semconv.HostName},
+		{AttrProcessStartTime, semconv.ProcessCreationTime},
+	} {
+		if s, ok := attrs[m.key].(string); ok && s != "" {
+			kvs = append(kvs, m.convert(s))
+		}
+	}
+
+	// decoded errors hold the PID as float64
+	switch pid := attrs[AttrProcessPID].(type) {
+	case int:
+		kvs = append(kvs, semconv.ProcessPID(pid))
+	case float64:
+		kvs = append(kvs, semconv.ProcessPID(int(pid)))
+	}
+
+	return kvs
+}
@@ -96,3 +96,42 @@ func TestWithOtelAttributeSet_Roundtrip(t *testing.T) {
 		t.Errorf("AttributesFromContext after WithOtelAttributeSet = %v, want k and n present", got)
 	}
 }
+
+func TestOtelResourceAttributes(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().
+		Attr(ae.AttrServiceName, "billing").
+		Attr(ae.AttrServiceEnv, "prod").
+		Attr(ae.AttrHostName, "node-1").
+		Attr(ae.AttrProcessPID, 4242).
+		Attr(ae.AttrProcessStartTime, "2026-10-16T08:00:00Z").
+		Attr(ae.AttrGoroutineID, 7).
+		Attr("user_id", 42).
+		Msg("x")
+
+	got := attribute.NewSet(ae.OtelResourceAttributes(err)...)
+	want := attribute.NewSet(
+		attribute.String("service.name", "billing"),
+		attribute.String("deployment.environment.name", "prod"),
+		attribute.String("host.name", "node-1"),
+		attribute.Int("process.pid", 4242),
+		attribute.String("process.creation.time", "2026-10-16T08:00:00Z"),
+	)
+	if !got.Equals(&want) {
+		t.Errorf("got %v, want %v", got.ToSlice(), want.ToSlice())
+	}
+
+	// PIDs decoded from JSON are float64
+	decoded, dErr := ae.DecodeJSON([]byte(`{"message":"x","attrs":{"process.pid":4242}}`), ae.DecodeJSONLimits{})
+	if dErr != nil {
+		t.Fatal(dErr)
+	}
+	if kvs := ae.OtelResourceAttributes(decoded); len(kvs) != 1 || kvs[0] != attribute.Int("process.pid", 4242) {
+		t.Errorf("decoded: got %v", kvs)
+	}
+
+	if kvs := ae.OtelResourceAttributes(errors.New("plain")); len(kvs) != 0 {
+		t.Errorf("plain error: got %v", kvs)
+	}
+}
@@ -0,0 +1,147 @@
+package ae
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FromPanic builds an error from a value returned by recover(). Error values
+// become the cause, strings the message, and anything else is formatted with
+// %v. The error is tagged "panic", marked unrecoverable and carries the stack
+// of the panicking goroutine, trimmed so its top frame is the panic site
+// rather than the deferred recovery code. Returns nil if recovered is nil.
+//
+//	defer func() {
+//		if r := recover(); r != nil {
+//			log.Println(ae.FromPanic(r))
+//		}
+//	}()
+func FromPanic(recovered any) error {
+	if recovered == nil {
+		return nil
+	}
+
+	return fromPanic(recovered)
+}
+
+// Recover converts a panic into an error written through errp. It must be
+// deferred directly:
+//
+//	func handle() (err error) {
+//		defer ae.Recover(&err)
+//		...
+//	}
+//
+// Nothing happens when no panic occurred. If *errp already holds an error, the
+// panic error is joined with it instead of replacing it. If errp is nil the
+// panic is resumed.
+func Recover(errp *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if errp == nil {
+		panic(r)
+	}
+
+	err := fromPanic(r)
+	if *errp != nil {
+		err = errors.Join(*errp, err)
+	}
+	*errp = err
+}
+
+// Catch runs fn and returns its error unchanged. If fn panics, the panic is
+// converted into an error as by Recover: tagged "panic", unrecoverable, with
+// the stack of the panic site and, for runtime errors such as nil map writes
+// or out-of-range indexes, the runtime error as its cause.
+//
+//	err := ae.Catch(func() error {
+//		return process(input)
+//	})
+func Catch(fn func() error) (err error) {
+	defer Recover(&err)
+	return fn()
+}
+
+// CatchValue is Catch for functions returning a value. On panic, the zero
+// value of T is returned with the panic error.
+func CatchValue[T any](fn func() (T, error)) (v T, err error) {
+	defer Recover(&err)
+	return fn()
+}
+
+// Go runs fn in a new goroutine and passes its error to onErr, if non-nil.
+// A panic in fn is recovered as by Catch, so onErr receives an error
+// carrying the stack of fn's goroutine instead of the process crashing.
+// onErr is called from the new goroutine; a nil onErr discards errors.
+func Go(fn func() error, onErr func(error)) {
+	go func() {
+		if err := Catch(fn); err != nil && onErr != nil {
+			onErr(err)
+		}
+	}()
+}
+
+// GoCollect runs fn in a new goroutine like Go and returns a channel
+// receiving fn's error, or the error recovered from its panic. The channel
+// is closed once fn is done, so a receive yields nil when fn succeeded.
+func GoCollect(fn func() error) <-chan error {
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		if err := Catch(fn); err != nil {
+			errc <- err
+		}
+	}()
+
+	return errc
+}
+
+func fromPanic(recovered any) error {
+	b := New().
+		Tag("panic").
+		Recoverable(false)
+	b.stacks = []*Stack{panicStack()}
+
+	switch v := recovered.(type) {
+	case error:
+		return b.Cause(v).Msg("panic")
+	case string:
+		return b.Msgf("panic: %s", v)
+	default:
+		return b.Attr("panic.type", fmt.Sprintf("%T", v)).Msgf("panic: %v", v)
+	}
+}
+
+// panicStack captures the stack of the current goroutine and, when called
+// while panicking, drops every frame above the panic site: the recovery code,
+// runtime.gopanic and runtime helpers raising runtime errors.
+func panicStack() *Stack {
+	st := newStack(1, int(maxStackFrames.Load()))
+	st.resolve()
+
+	for i, frame := range st.Frames {
+		if frame.Func != "runtime.gopanic" {
+			continue
+		}
+
+		i++
+		for i < len(st.Frames) && strings.HasPrefix(st.Frames[i].Func, "runtime.") {
+			i++
+		}
+		st.Frames = st.Frames[i:]
+		if st.elidedAt > 0 {
+			// the elision point moves up with the frames; frames elided
+			// above the panic site are dropped along with them
+			st.elidedAt -= i
+			if st.elidedAt <= 0 {
+				st.FramesElided, st.ElidedCount, st.elidedAt = false, 0, 0
+			}
+		}
+		break
+	}
+
+	return st
+}
@@ -0,0 +1,323 @@
+package ae_test
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go.aledante.io/ae"
+)
+
+type panicPayload struct {
+	ID int
+}
+
+// recovered runs fn and returns the error ae.Recover produced from its panic.
+func recovered(fn func()) (err error) {
+	defer ae.Recover(&err)
+	fn()
+	return nil
+}
+
+func TestFromPanic_Nil(t *testing.T) {
+	t.Parallel()
+
+	if got := ae.FromPanic(nil); got != nil {
+		t.Errorf("FromPanic(nil) = %v, want nil", got)
+	}
+}
+
+func TestRecover_Error(t *testing.T) {
+	t.Parallel()
+
+	cause := errors.New("boom")
+	err := recovered(func() { panic(cause) })
+
+	if !errors.Is(err, cause) {
+		t.Error("errors.Is(err, cause) = false")
+	}
+	if got := err.Error(); got != "panic: boom" {
+		t.Errorf("Error() = %q", got)
+	}
+	if !slices.Contains(ae.Tags(err), "panic") {
+		t.Errorf("tags = %v, want panic", ae.Tags(err))
+	}
+	if ae.IsRecoverable(err) {
+		t.Error("IsRecoverable = true, want false")
+	}
+}
+
+func TestRecover_String(t *testing.T) {
+	t.Parallel()
+
+	err := recovered(func() { panic("invariant violated") })
+	if got := err.Error(); got != "panic: invariant violated" {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestRecover_Struct(t *testing.T) {
+	t.Parallel()
+
+	err := recovered(func() { panic(panicPayload{ID: 7}) })
+	if got := err.Error(); got != "panic: {7}" {
+		t.Errorf("Error() = %q", got)
+	}
+	if got := ae.Attributes(err)["panic.type"]; got != "ae_test.panicPayload" {
+		t.Errorf("panic.type = %v, want ae_test.panicPayload", got)
+	}
+}
+
+func TestRecover_NoPanic(t *testing.T) {
+	t.Parallel()
+
+	if err := recovered(func() {}); err != nil {
+		t.Errorf("Recover set %v without a panic", err)
+	}
+}
+
+func TestRecover_JoinsExistingError(t *testing.T) {
+	t.Parallel()
+
+	existing := errors.New("existing")
+	err := func() (err error) {
+		defer ae.Recover(&err)
+		defer func() { err = existing }()
+		panic("late")
+	}()
+
+	if !errors.Is(err, existing) {
+		t.Error("the existing error was clobbered")
+	}
+	if !strings.Contains(err.Error(), "panic: late") {
+		t.Errorf("Error() = %q, want the panic joined in", err.Error())
+	}
+}
+
+func TestRecover_StackStartsAtPanicSite(t *testing.T) {
+	t.Parallel()
+
+	var line int
+	err := recovered(func() {
+		_, _, line, _ = runtime.Caller(0)
+		var s []int
+		_ = s[3]
+	})
+
+	stacks := ae.Stacks(err)
+	if len(stacks) != 1 || len(stacks[0].Frames) == 0 {
+		t.Fatalf("stacks = %v, want one non-empty stack", stacks)
+	}
+	top := stacks[0].Frames[0]
+	if !strings.Contains(top.Func, "TestRecover_StackStartsAtPanicSite.func") || top.Line != line+2 {
+		t.Errorf("top frame = %s:%d, want the panicking closure at line %d", top.Func, top.Line, line+2)
+	}
+}
+
+func TestCatch_NormalReturn(t *testing.T) {
+	t.Parallel()
+
+	if err := ae.Catch(func() error { return nil }); err != nil {
+		t.Errorf("Catch = %v, want nil", err)
+	}
+}
+
+func TestCatch_ReturnedErrorPassesThrough(t *testing.T) {
+	t.Parallel()
+
+	want := errors.New("boom")
+	if got := ae.Catch(func() error { return want }); got != want {
+		t.Errorf("Catch = %v, want the same error instance", got)
+	}
+}
+
+func TestCatch_StringPanic(t *testing.T) {
+	t.Parallel()
+
+	err := ae.Catch(func() error { panic("kaboom") })
+
+	if got := err.Error(); got != "panic: kaboom" {
+		t.Errorf("Error() = %q", got)
+	}
+	if !slices.Contains(ae.Tags(err), "panic") || ae.IsRecoverable(err) {
+		t.Errorf("want an unrecoverable error tagged panic, got tags %v", ae.Tags(err))
+	}
+	if len(ae.Stacks(err)) != 1 {
+		t.Errorf("got %d stacks, want 1", len(ae.Stacks(err)))
+	}
+}
+
+func TestCatch_RuntimePanics(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		fn   func() error
+	}{
+		{"nil map write", func() error {
+			var m map[string]int
+			m["x"] = 1
+			return nil
+		}},
+		{"index out of range", func() error {
+			s := []int{}
+			_ = s[len(s)+1]
+			return nil
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ae.Catch(tt.fn)
+
+			var rerr runtime.Error
+			if !errors.As(err, &rerr) {
+				t.Fatalf("cause is not a runtime.Error: %v", err)
+			}
+			if causes := ae.Causes(err); len(causes) != 1 || causes[0] != rerr {
+				t.Errorf("causes = %v, want the runtime error", causes)
+			}
+		})
+	}
+}
+
+func TestCatchValue(t *testing.T) {
+	t.Parallel()
+
+	v, err := ae.CatchValue(func() (int, error) { return 7, nil })
+	if v != 7 || err != nil {
+		t.Errorf("CatchValue = %d, %v, want 7, nil", v, err)
+	}
+
+	v, err = ae.CatchValue(func() (int, error) { panic("kaboom") })
+	if v != 0 || err == nil || !slices.Contains(ae.Tags(err), "panic") {
+		t.Errorf("CatchValue = %d, %v, want 0 and a panic error", v, err)
+	}
+}
+
+func TestGo_ReportsOnlyFailures(t *testing.T) {
+	t.Parallel()
+
+	errReturned := errors.New("returned")
+	fns := map[string]func() error{
+		"ok":       func() error { return nil },
+		"returned": func() error { return errReturned },
+		"panicked": func() error { panicInGoroutine(); return nil },
+	}
+
+	type report struct {
+		name string
+		err  error
+	}
+	reports := make(chan report, len(fns))
+	for name, fn := range fns {
+		ae.Go(fn, func(err error) {
+			reports <- report{name, err}
+		})
+	}
+
+	reported := map[string]error{}
+	for range 2 {
+		select {
+		case r := <-reports:
+			reported[r.name] = r.err
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for reports, got %v", reported)
+		}
+	}
+
+	if _, ok := reported["ok"]; ok {
+		t.Error("successful goroutine was reported")
+	}
+	if reported["returned"] != errReturned {
+		t.Errorf("returned error = %v, want the same instance", reported["returned"])
+	}
+	assertPanicStackInFn(t, reported["panicked"])
+}
+
+func TestGoCollect(t *testing.T) {
+	t.Parallel()
+
+	if err := <-ae.GoCollect(func() error { return nil }); err != nil {
+		t.Errorf("success: got %v, want nil", err)
+	}
+
+	want := errors.New("returned")
+	if err := <-ae.GoCollect(func() error { return want }); err != want {
+		t.Errorf("returned: got %v, want the same instance", err)
+	}
+
+	err := <-ae.GoCollect(func() error { panicInGoroutine(); return nil })
+	assertPanicStackInFn(t, err)
+}
+
+//go:noinline
+func panicInGoroutine() {
+	panic("worker failed")
+}
+
+// assertPanicStackInFn checks that err was recovered from a panic and that
+// its stack starts in the spawned function, not in the spawning test.
+func assertPanicStackInFn(t *testing.T, err error) {
+	t.Helper()
+
+	if !slices.Contains(ae.Tags(err), "panic") {
+		t.Fatalf("want a panic error, got %v", err)
+	}
+	stacks := ae.Stacks(err)
+	if len(stacks) != 1 || len(stacks[0].Frames) == 0 {
+		t.Fatalf("stacks = %v, want one non-empty stack", stacks)
+	}
+	if top := stacks[0].Frames[0].Func; !strings.HasSuffix(top, ".panicInGoroutine") {
+		t.Errorf("top frame = %s, want panicInGoroutine", top)
+	}
+	for _, frame := range stacks[0].Frames {
+		if strings.Contains(frame.Func, "testing.tRunner") {
+			t.Errorf("stack contains the spawner's frame %s", frame.Func)
+		}
+	}
+}
+
+func TestRecover_DeepStackElisionMarker(t *testing.T) {
+	t.Parallel()
+
+	err := recovered(func() {
+		_ = recurse(100, func() error { panic("deep") })
+	})
+
+	stacks := ae.Stacks(err)
+	if len(stacks) != 1 || !stacks[0].FramesElided {
+		t.Fatalf("stacks = %v, want one capped stack", stacks)
+	}
+	if top := stacks[0].Frames[0].Func; !strings.Contains(top, "TestRecover_DeepStackElisionMarker.func") {
+		t.Errorf("top frame = %s, want the panicking closure", top)
+	}
+
+	// the marker sits where the frames were dropped, before the outermost
+	// frames kept of the goroutine
+	var sb strings.Builder
+	if err := stacks[0].Format(&sb); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(sb.String(), "\n")
+	marker := slices.IndexFunc(lines, func(line string) bool { return strings.Contains(line, "frames elided") })
+	if marker < 0 || marker+1 >= len(lines) {
+		t.Fatalf("no elision marker:\n%s", sb.String())
+	}
+
+	frames := stacks[0].Frames
+	const tail = 8 // stackTailFrames
+	outermost := frames[len(frames)-tail]
+	if want := fmt.Sprintf("%s:%d", outermost.File, outermost.Line); !strings.Contains(lines[marker+2], want) {
+		t.Errorf("frame after the marker = %q, want %s:\n%s", lines[marker+2], want, sb.String())
+	}
+	if n := strings.Count(strings.Join(lines[marker+1:], "\n"), "testing.tRunner"); n != 1 {
+		t.Errorf("testing.tRunner not after the marker:\n%s", sb.String())
+	}
+}
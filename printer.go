@@ -1,26 +1,47 @@
 package ae
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
 	"strings"
-
-	"github.com/fatih/color"
+	"sync"
+	"time"
 )
 
 // Printer provides functionality for formatting and printing errors with various options.
 // It supports both plain text and JSON output formats, and can include stack traces
 // and error causes in the output.
+//
+// A Printer is configured once by NewPrinter and never modified afterwards,
+// so one Printer may be shared and used concurrently by multiple goroutines.
 type Printer struct {
 	// colors determines whether colored output is enabled.
 	colors bool
 	// json determines whether the output should be formatted as JSON
 	json bool
+	// plain spells the text output out line by line, see PrintPlain.
+	plain bool
+	// quiet renders only what end users need, see PrintQuiet, followed by
+	// quietPointer unless empty.
+	quiet        bool
+	quietPointer string
+	// jsonStyle spells the keys of the JSON output.
+	jsonStyle JSONFieldStyle
 	// indent is the number of spaces to indent by.
 	indent int
 	// maxDepth controls how deep to traverse the error chain when printing causes.
 	// A negative value indicates infinite depth.
 	maxDepth int
+	// relatedDepth limits related errors to as many levels below the error
+	// they are attached to, see PrintRelatedDepth. A negative value follows
+	// maxDepth.
+	relatedDepth int
 
 	// flags for error fields
 	userMsg    bool
@@ -30,16 +51,63 @@ type Printer struct {
 	exitCode   bool
 	traceId    bool
 	spanId     bool
+	requestId  bool
 	tags       bool
+	tagGroups  bool
 	attributes bool
-	causes     bool
-	related    bool
-	stacks     bool
+	// nestedAttrs groups dotted attribute keys into objects in the JSON
+	// output, see PrintNestedAttrs.
+	nestedAttrs bool
+	// attrTable wraps long attribute values, see PrintAttrTable.
+	attrTable bool
+	// elideInheritedAttrs prints the attributes of nested errors that
+	// differ from their ancestors', see PrintElideInheritedAttrs.
+	elideInheritedAttrs bool
+	// width is the line width attribute values are wrapped at.
+	width   int
+	causes  bool
+	related bool
+	stacks  bool
+	// stackSummary renders goroutines other than the first as one line, see
+	// PrintStackSummary.
+	stackSummary bool
+
+	// summary replaces the tree of causes by an aggregation, see
+	// PrintSummary.
+	summary bool
+	// summaryExamples is the number of leaf messages shown per code in the
+	// summary.
+	summaryExamples int
 
 	// frameFilters is a list of predicates. A stack frame is dropped from the
 	// rendered output when any filter returns true. The default set hides
 	// internal ae/runtime frames; callers extend the list via PrintFrameFilters.
 	frameFilters []func(frame *StackFrame) bool
+	// stackOpts configures stack rendering, shared with Stack.Format.
+	stackOpts []StackFormatOption
+
+	// labels are the literals of the text output, see PrintLabels.
+	labels Labels
+	// labelWidth is the width of the label column, fitting the longest
+	// label, and continuation the column multi-line content and wrapped
+	// values begin at: textLead + label column + textLabelGap, all spaces.
+	labelWidth   int
+	continuation string
+
+	// deterministic scrubs run-dependent details, see PrintDeterministic.
+	deterministic bool
+	// clock, when set, supplies the rendered timestamps.
+	clock func() time.Time
+	// timeLoc, when set, is the time zone timestamps are rendered in.
+	timeLoc *time.Location
+	// scrubbers rewrite the rendered output, in order.
+	scrubbers []func(string) string
+	// tees are the secondary destinations of Fprint, see PrintAlso.
+	tees []tee
+
+	// exitCodes memoizes the exit codes computed during one Prints call; it
+	// is only set on the per-call copy of the Printer.
+	exitCodes map[error]int
 }
 
 // NewPrinter creates a new Printer with the given options.
@@ -48,14 +116,19 @@ type Printer struct {
 //   - Colors enabled when stdout is a terminal, disabled otherwise (via fatih/color.NoColor).
 //   - Plain text output (json = false).
 //   - Verbose field set (PrintVerbose enables every field).
-//   - Infinite error-chain traversal (maxDepth = -1).
+//   - Infinite error-chain traversal (maxDepth = -1), related errors
+//     included (relatedDepth = -1).
 //   - Indent = 2.
+//   - No summary; 3 examples per code when enabled.
+//   - Width = 100.
+//   - English labels (DefaultLabels).
+//   - "Run with --verbose for details." as the pointer of PrintQuiet.
 //
 // Defaults can be overridden by passing options. Later options win over earlier ones,
 // so user-supplied options always override the built-in defaults.
 func NewPrinter(opts ...PrinterOption) *Printer {
 	colorsDefault := PrintColors()
-	if color.NoColor {
+	if !terminalColors() {
 		colorsDefault = NoPrintColors()
 	}
 
@@ -65,6 +138,10 @@ func NewPrinter(opts ...PrinterOption) *Printer {
 		PrintIndent(2),
 		PrintVerbose(),
 		PrintDepthInfinite(),
+		PrintRelatedDepth(-1),
+		PrintSummaryExamples(3),
+		PrintWidth(100),
+		PrintQuietPointer(defaultQuietPointer),
 	}, opts...)
 
 	p := &Printer{
@@ -76,9 +153,23 @@ func NewPrinter(opts ...PrinterOption) *Printer {
 		opt(p)
 	}
 
+	p.labels = p.labels.withDefaults()
+	p.labelWidth = p.labels.rowWidth()
+	p.continuation = textLead + strings.Repeat(" ", p.labelWidth) + textLabelGap
+
 	return p
 }
 
+// relatedMaxDepth returns the depth limit of the related errors of an error
+// at depth, in a tree limited to maxDepth.
+func (p *Printer) relatedMaxDepth(depth, maxDepth int) int {
+	if p.relatedDepth < 0 || (maxDepth >= 0 && maxDepth < depth+p.relatedDepth) {
+		return maxDepth
+	}
+
+	return depth + p.relatedDepth
+}
+
 // hideInternalFrames is the default frame filter applied by NewPrinter. It
 // drops frames whose function names belong to this library or Go's runtime
 // stack-capture helpers, keeping the printed trace focused on user code.
@@ -105,10 +196,34 @@ func (p *Printer) Print(err error) {
 	p.Fprint(os.Stdout, err)
 }
 
-// Fprint writes the formatted error to w followed by a single newline.
+// Fprint writes the formatted error to w followed by a single newline, then
+// renders it to the destinations added with PrintAlso. Colored output to a
+// Windows console, or a writer implementing VirtualTerminal, enables escape
+// sequences first and falls back to plain text if that fails. Errors writing
+// to the destinations are ignored; FprintErr returns them.
 func (p *Printer) Fprint(w io.Writer, err error) {
-	io.WriteString(w, p.Prints(err))
-	io.WriteString(w, "\n")
+	_ = p.FprintErr(w, err)
+}
+
+// FprintErr is Fprint, except that it returns the errors writing to w and to
+// the destinations added with PrintAlso, joined. A failing destination
+// doesn't keep the error from being written to the others.
+func (p *Printer) FprintErr(w io.Writer, err error) error {
+	_, werr := io.WriteString(w, p.forWriter(w).Prints(err)+"\n")
+	errs := []error{werr}
+	for i, t := range p.tees {
+		if terr := t.printer.FprintErr(t.w, err); terr != nil {
+			errs = append(errs, fmt.Errorf("ae: print to secondary destination %d: %w", i+1, terr))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// tee is a secondary destination of Fprint and FprintErr, see PrintAlso.
+type tee struct {
+	w       io.Writer
+	printer *Printer
 }
 
 // Prints returns a string representation of the error based on the printer's configuration.
@@ -116,8 +231,141 @@ func (p *Printer) Fprint(w io.Writer, err error) {
 // Otherwise, it returns a plain text representation.
 // The returned string is NOT newline-terminated.
 func (p *Printer) Prints(err error) string {
-	if p.json {
-		return p.printsJson(err, 0)
+	// ExitCode walks the subtree of every error it is called for; share the
+	// results between the errors of the tree.
+	run := *p
+	run.exitCodes = make(map[error]int)
+	p = &run
+
+	var out string
+	switch {
+	case p.json:
+		out = p.printsJson(err, 0)
+	case p.quiet:
+		out = p.printsQuiet(err)
+	case p.plain:
+		out = p.printsPlain(err)
+	default:
+		out = p.PrintErrorText(err, 0)
+	}
+
+	for _, scrub := range p.scrubbers {
+		out = scrub(out)
+	}
+
+	return out
+}
+
+// printBuffers pools the buffers output is rendered into, so steady-state
+// printing allocates little beyond the returned string.
+var printBuffers = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// maxPooledBuffer is the capacity above which buffers are dropped instead of
+// returned to the pool, so one huge error doesn't pin its memory.
+const maxPooledBuffer = 64 << 10
+
+func getPrintBuffer() *bytes.Buffer {
+	return printBuffers.Get().(*bytes.Buffer)
+}
+
+func putPrintBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+
+	buf.Reset()
+	printBuffers.Put(buf)
+}
+
+// exitCodeOf returns ExitCode(err), memoized for the current Prints call.
+func (p *Printer) exitCodeOf(err error) int {
+	return exitCode(err, p.exitCodes)
+}
+
+// timestampPlaceholder replaces timestamps in deterministic output when no
+// clock is set.
+const timestampPlaceholder = "<timestamp>"
+
+// formatTime renders t, or the printer's clock in its place, in the
+// printer's time zone.
+func (p *Printer) formatTime(t time.Time) string {
+	switch {
+	case p.clock != nil:
+		t = p.clock()
+	case p.deterministic:
+		return timestampPlaceholder
 	}
-	return p.PrintErrorText(err, 0)
+	if p.timeLoc != nil {
+		t = t.In(p.timeLoc)
+	}
+
+	return t.Format(time.RFC3339)
 }
+
+// printableStacks returns the stacks as rendered by p. In deterministic mode
+// they are copies with goroutine IDs and wait times zeroed and file paths
+// trimmed by the configured StackTrimPath prefixes, so JSON output is as
+// stable as text output.
+func (p *Printer) printableStacks(stacks []*Stack) []*Stack {
+	if !p.deterministic || len(stacks) == 0 {
+		return stacks
+	}
+
+	f := newStackFormat(p.stackOpts)
+	seen := make(map[*Stack]*Stack)
+	var scrub func(st *Stack) *Stack
+	scrub = func(st *Stack) *Stack {
+		if st == nil {
+			return nil
+		}
+		if cpy, ok := seen[st]; ok {
+			return cpy
+		}
+		st.resolve()
+
+		cpy := &Stack{
+			State:        st.State,
+			Locked:       st.Locked,
+			FramesElided: st.FramesElided,
+			ElidedCount:  st.ElidedCount,
+			elidedAt:     st.elidedAt,
+		}
+		seen[st] = cpy
+
+		cpy.Frames = make([]*StackFrame, len(st.Frames))
+		for i, frame := range st.Frames {
+			cpy.Frames[i] = f.scrubFrame(frame)
+		}
+		cpy.CreatedBy = f.scrubFrame(st.CreatedBy)
+		cpy.Ancestor = scrub(st.Ancestor)
+
+		return cpy
+	}
+
+	out := make([]*Stack, len(stacks))
+	for i, st := range stacks {
+		out[i] = scrub(st)
+	}
+
+	return out
+}
+
+// gorootSrc returns the source directory of the Go installation the binary
+// was built with, e.g. "/usr/local/go/src/", derived from the runtime's own
+// file paths. Returns "" for binaries built with -trimpath.
+var gorootSrc = sync.OnceValue(func() string {
+	fn := runtime.FuncForPC(reflect.ValueOf(runtime.Gosched).Pointer())
+	if fn == nil {
+		return ""
+	}
+
+	file, _ := fn.FileLine(fn.Entry())
+	dir, ok := strings.CutSuffix(file, "runtime/proc.go")
+	if !ok || !filepath.IsAbs(dir) {
+		return ""
+	}
+
+	return dir
+})
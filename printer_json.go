@@ -1,61 +1,304 @@
 package ae
 
 import (
+	"bytes"
 	"encoding/json"
+	"io"
+	"iter"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// JSONFieldStyle is the spelling of the keys of the JSON output, see
+// PrintJSONFieldStyle.
+type JSONFieldStyle int
+
+const (
+	// SnakeCase spells keys like "user_message" and "exit_code".
+	SnakeCase JSONFieldStyle = iota
+	// CamelCase spells keys like "userMessage" and "exitCode".
+	CamelCase
+)
+
+// jsonDataKeys are the keys of the JSON output whose values hold data keyed
+// by the user (attributes, locales, codes and tags), which CamelCase must
+// not rename.
+var jsonDataKeys = map[string]bool{
+	"attrs":         true,
+	"user_messages": true,
+	"codes":         true,
+	"tags":          true,
+	"examples":      true,
+}
+
 type jsonError struct {
-	Message     string         `json:"message,omitempty"`
-	UserMessage string         `json:"user_message,omitempty"`
-	Hint        string         `json:"hint,omitempty"`
-	Code        string         `json:"code,omitempty"`
-	ExitCode    int            `json:"exit_code,omitempty"`
-	TraceId     string         `json:"trace_id,omitempty"`
-	SpanId      string         `json:"span_id,omitempty"`
-	Tags        []string       `json:"tags,omitempty"`
-	Attrs       map[string]any `json:"attrs,omitempty"`
-	Causes      []jsonError    `json:"causes,omitempty"`
-	Related     []jsonError    `json:"related,omitempty"`
-	Stacks      []*Stack       `json:"stacks,omitempty"`
+	Message      string            `json:"message,omitempty"`
+	UserMessage  string            `json:"user_message,omitempty"`
+	UserMessages map[string]string `json:"user_messages,omitempty"`
+	Hint         string            `json:"hint,omitempty"`
+	Code         string            `json:"code,omitempty"`
+	ExitCode     int               `json:"exit_code,omitempty"`
+	Recoverable  *bool             `json:"recoverable,omitempty"`
+	TraceId      string            `json:"trace_id,omitempty"`
+	SpanId       string            `json:"span_id,omitempty"`
+	RequestId    string            `json:"request_id,omitempty"`
+	Tags         []string          `json:"tags,omitempty"`
+	Attrs        map[string]any    `json:"attrs,omitempty"`
+	Causes       []jsonError       `json:"causes,omitempty"`
+	Related      []jsonError       `json:"related,omitempty"`
+	Stacks       []*Stack          `json:"stacks,omitempty"`
+	Summary      *jsonSummary      `json:"summary,omitempty"`
 }
 
 func (p *Printer) printsJson(err error, depth int) string {
-	jsonErr := p.toJsonError(err, depth)
-	jsonStr, _ := json.MarshalIndent(jsonErr, "", strings.Repeat(" ", p.indent))
+	buf := getPrintBuffer()
+	defer putPrintBuffer(buf)
 
-	return string(jsonStr)
+	je := p.toJsonError(err, depth, p.maxDepth)
+	if p.summary && depth == 0 {
+		je.Summary = p.toJsonSummary(err)
+	}
+
+	data, jErr := json.Marshal(je)
+	if jErr == nil && p.jsonStyle == CamelCase {
+		data, jErr = camelCaseKeys(data)
+	}
+	if jErr != nil || json.Indent(buf, data, "", strings.Repeat(" ", p.indent)) != nil {
+		return ""
+	}
+
+	return buf.String()
 }
 
-func (p *Printer) toJsonError(err error, depth int) jsonError {
+// toJsonError converts err, found at depth in a tree limited to maxDepth.
+func (p *Printer) toJsonError(err error, depth, maxDepth int) jsonError {
+	attrs := jsonAttrs(attrSeq(err))
+	if p.nestedAttrs {
+		attrs = nestAttrs(attrs)
+	}
+
+	je := jsonError{
+		Message:      Message(err),
+		UserMessage:  UserMessage(err),
+		UserMessages: UserMessages(err),
+		Hint:         Hint(err),
+		Code:         Code(err),
+		ExitCode:     p.exitCodeOf(err),
+		Recoverable:  ownRecoverable(err),
+		TraceId:      TraceId(err),
+		SpanId:       SpanId(err),
+		RequestId:    ownRequestId(err),
+		Tags:         slices.Collect(tagSeq(err)),
+		Attrs:        attrs,
+		Causes:       p.toJsonErrors(Causes(err), depth, maxDepth),
+		Related:      p.toJsonErrors(Related(err), depth, p.relatedMaxDepth(depth, maxDepth)),
+		Stacks:       p.printableStacks(Stacks(err)),
+	}
+
+	return je
+}
+
+// toJsonErrors converts errs, the causes or related errors of an error at
+// depth, in a tree limited to maxDepth.
+func (p *Printer) toJsonErrors(errs []error, depth, maxDepth int) []jsonError {
+	switch {
+	case len(errs) == 0, maxDepth >= 0 && depth >= maxDepth:
+		return nil
+	case depth >= traversalDepth():
+		return []jsonError{{Message: truncatedMessage}}
+	}
+
+	out := make([]jsonError, 0, len(errs))
+	for _, e := range errs {
+		out = append(out, p.toJsonError(e, depth+1, maxDepth))
+	}
+
+	return out
+}
+
+// ownRecoverable returns false if err itself is marked as not recoverable,
+// and nil otherwise, so only the errors that are not recoverable render the
+// field.
+func ownRecoverable(err error) *bool {
+	if x, ok := err.(ErrorRecoverable); ok && !x.ErrorIsRecoverable() {
+		return new(bool)
+	}
+
+	return nil
+}
+
+// ownRequestId returns the request ID of err itself, not searching its
+// causes as RequestId does, since every error of the tree is rendered.
+func ownRequestId(err error) string {
+	if x, ok := err.(ErrorRequestId); ok {
+		return x.ErrorRequestId()
+	}
+
+	return ""
+}
+
+// jsonAttrs collects attrs for the JSON output. time.Duration values, which
+// encoding/json would render as nanoseconds, are rendered as milliseconds.
+func jsonAttrs(attrs iter.Seq2[string, any]) map[string]any {
+	out := make(map[string]any)
+	for k, v := range attrs {
+		if d, ok := v.(time.Duration); ok {
+			v = float64(d) / float64(time.Millisecond)
+		}
+		out[k] = v
+	}
+
+	return out
+}
+
+// attrValueKey holds the value of a dotted attribute key that is also the
+// prefix of other keys, e.g. "http" next to "http.method", in the output of
+// nestAttrs.
+const attrValueKey = "_value"
+
+// attrObject is an object built by nestAttrs, told apart from attribute
+// values that are maps themselves.
+type attrObject map[string]any
+
+// nestAttrs groups the dotted keys of attrs into nested objects, so that
+// "http.method" becomes {"http": {"method": …}}. A key that is also the
+// prefix of other keys keeps its value under "_value". Keys with an empty
+// or "_value" segment are kept as they are, so the result can always be
+// flattened back into attrs.
+func nestAttrs(attrs map[string]any) map[string]any {
+	out := make(map[string]any, len(attrs))
+	for k, v := range attrs {
+		path := strings.Split(k, ".")
+		if len(path) > 1 && (slices.Contains(path, "") || slices.Contains(path, attrValueKey)) {
+			out[k] = v
+			continue
+		}
+
+		obj := out
+		for _, seg := range path[:len(path)-1] {
+			child, exists := obj[seg]
+			next, ok := child.(attrObject)
+			if !ok {
+				next = attrObject{}
+				if exists {
+					next[attrValueKey] = child
+				}
+				obj[seg] = next
+			}
+			obj = next
+		}
+
+		last := path[len(path)-1]
+		if child, ok := obj[last].(attrObject); ok {
+			child[attrValueKey] = v
+		} else {
+			obj[last] = v
+		}
+	}
+
+	return out
+}
+
+// jsonContainer is an object or array being re-encoded by camelCaseKeys.
+type jsonContainer struct {
+	object bool
+	// tokens counts the keys and values written so far.
+	tokens int
+}
+
+// camelCaseKeys re-encodes the JSON document data with its keys converted
+// from snake case to camel case, except below the jsonDataKeys. Values are
+// copied unchanged.
+func camelCaseKeys(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
 	var (
-		causes  []jsonError
-		related []jsonError
+		out   bytes.Buffer
+		stack []jsonContainer
+		// dataAt is the depth of the object whose current value is data, or
+		// -1 outside of data.
+		dataAt = -1
 	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			stack = stack[:len(stack)-1]
+			out.WriteByte(byte(d))
+			if len(stack) == dataAt {
+				dataAt = -1
+			}
+			continue
+		}
+
+		isKey := false
+		if n := len(stack); n > 0 {
+			top := &stack[n-1]
+			switch {
+			case top.object && top.tokens%2 == 1:
+				out.WriteByte(':')
+			case top.tokens > 0:
+				out.WriteByte(',')
+			}
+			isKey = top.object && top.tokens%2 == 0
+			top.tokens++
+		}
 
-	if p.maxDepth < 0 || depth < p.maxDepth {
-		for _, c := range Causes(err) {
-			causes = append(causes, p.toJsonError(c, depth+1))
+		switch v := tok.(type) {
+		case json.Delim:
+			out.WriteByte(byte(v))
+			stack = append(stack, jsonContainer{object: v == '{'})
+			continue
+		case string:
+			if isKey && dataAt < 0 {
+				if jsonDataKeys[v] {
+					dataAt = len(stack)
+				}
+				v = snakeToCamel(v)
+			}
+			s, _ := json.Marshal(v)
+			out.Write(s)
+		case json.Number:
+			out.WriteString(v.String())
+		case bool:
+			out.WriteString(strconv.FormatBool(v))
+		case nil:
+			out.WriteString("null")
 		}
-		for _, r := range Related(err) {
-			related = append(related, p.toJsonError(r, depth+1))
+
+		if !isKey && len(stack) == dataAt {
+			dataAt = -1
 		}
 	}
 
-	je := jsonError{
-		Message:     Message(err),
-		UserMessage: UserMessage(err),
-		Hint:        Hint(err),
-		Code:        Code(err),
-		ExitCode:    ExitCode(err),
-		TraceId:     TraceId(err),
-		SpanId:      SpanId(err),
-		Tags:        Tags(err),
-		Attrs:       Attributes(err),
-		Causes:      causes,
-		Related:     related,
-		Stacks:      Stacks(err),
+	return out.Bytes(), nil
+}
+
+// snakeToCamel converts a snake case key such as "user_message" to camel
+// case, "userMessage".
+func snakeToCamel(s string) string {
+	first, rest, ok := strings.Cut(s, "_")
+	if !ok {
+		return s
 	}
 
-	return je
+	var sb strings.Builder
+	sb.WriteString(first)
+	for part := range strings.SplitSeq(rest, "_") {
+		if part != "" {
+			sb.WriteString(strings.ToUpper(part[:1]))
+			sb.WriteString(part[1:])
+		}
+	}
+
+	return sb.String()
 }
@@ -1,5 +1,13 @@
 package ae
 
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
 // PrinterOption defines a function type that configures a Printer.
 // It is used to customize the behavior of a Printer instance through functional options.
 type PrinterOption func(p *Printer)
@@ -76,9 +84,28 @@ func NoPrintTimestamp() PrinterOption {
 }
 
 // PrintStacks returns a PrinterOption that enables stack trace inclusion in the output.
+// Every goroutine is rendered with all its frames, undoing PrintStackSummary.
 func PrintStacks() PrinterOption {
 	return func(p *Printer) {
 		p.stacks = true
+		p.stackSummary = false
+	}
+}
+
+// PrintStackSummary returns a PrinterOption that enables stack trace
+// inclusion in the text output, rendering all frames only for the first
+// stack, the goroutine that captured the error, and one line per other
+// goroutine:
+//
+//	goroutine 42 [running]: pkg.Func (file.go:88)
+//
+// The frame shown is the goroutine's first application frame not hidden by
+// the frame filters, or its first frame not hidden if none is application
+// code. PrintStacks renders every goroutine in full again.
+func PrintStackSummary() PrinterOption {
+	return func(p *Printer) {
+		p.stacks = true
+		p.stackSummary = true
 	}
 }
 
@@ -99,6 +126,20 @@ func PrintFrameFilters(filters ...func(frame *StackFrame) bool) PrinterOption {
 	}
 }
 
+// PrintStackFormat applies Stack.Format options, such as StackMaxFrames and
+// StackTrimPath, to the rendered stack traces. Multiple calls accumulate.
+func PrintStackFormat(opts ...StackFormatOption) PrinterOption {
+	return func(p *Printer) {
+		p.stackOpts = append(p.stackOpts, opts...)
+	}
+}
+
+// PrintStackAncestry adds the chain of ancestor goroutines below each stack,
+// see StackAncestry.
+func PrintStackAncestry() PrinterOption {
+	return PrintStackFormat(StackAncestry())
+}
+
 // PrintJSON returns a PrinterOption that enables JSON formatting of the output.
 func PrintJSON() PrinterOption {
 	return func(p *Printer) {
@@ -113,6 +154,15 @@ func NoPrintJSON() PrinterOption {
 	}
 }
 
+// PrintJSONFieldStyle returns a PrinterOption that sets how the keys of the
+// JSON output are spelled, SnakeCase by default. The keys of attributes,
+// user messages and summary counts are data and kept as they are.
+func PrintJSONFieldStyle(style JSONFieldStyle) PrinterOption {
+	return func(p *Printer) {
+		p.jsonStyle = style
+	}
+}
+
 // PrintIndent configures the Printer to use the specified number of spaces for indentation when formatting output.
 // A minimum indentation of 1 is enforced.
 func PrintIndent(indent int) PrinterOption {
@@ -163,12 +213,54 @@ func PrintDepthInfinite() PrinterOption {
 
 // PrintDepth returns a PrinterOption that sets a specific maximum depth for error chain traversal.
 // The printer will stop traversing the error chain after reaching the specified depth.
+// The limit applies to causes and related errors alike; see PrintRelatedDepth.
 func PrintDepth(depth int) PrinterOption {
 	return func(p *Printer) {
 		p.maxDepth = depth
 	}
 }
 
+// PrintRelatedDepth returns a PrinterOption that limits related errors to depth
+// levels below the error they are attached to, so they can be rendered
+// shallower than causes: with 1 the related errors are shown without their
+// causes, with 0 they are omitted. The limit set with PrintDepth still
+// applies. A negative depth, the default, follows PrintDepth.
+func PrintRelatedDepth(depth int) PrinterOption {
+	return func(p *Printer) {
+		p.relatedDepth = depth
+	}
+}
+
+// PrintSummary replaces the tree of causes in the text output by an
+// aggregation of the whole tree below the root error's line: the number of
+// errors and leaves, how many errors carry each code and tag, the earliest
+// and latest timestamps and a few distinct leaf messages per code (see
+// PrintSummaryExamples). A leaf without a code is filed under the nearest
+// code above it. The JSON output keeps the tree and adds the aggregation as a
+// "summary" object. Tags and timestamps are left out when their fields are
+// disabled.
+func PrintSummary() PrinterOption {
+	return func(p *Printer) {
+		p.summary = true
+	}
+}
+
+// NoPrintSummary returns a PrinterOption that prints the error tree in full,
+// the default.
+func NoPrintSummary() PrinterOption {
+	return func(p *Printer) {
+		p.summary = false
+	}
+}
+
+// PrintSummaryExamples sets the number of leaf messages PrintSummary shows per
+// code, 3 by default. A negative n is treated as zero.
+func PrintSummaryExamples(n int) PrinterOption {
+	return func(p *Printer) {
+		p.summaryExamples = max(n, 0)
+	}
+}
+
 // PrintColors returns a PrinterOption that enables colored output formatting.
 func PrintColors() PrinterOption {
 	return func(p *Printer) {
@@ -211,6 +303,20 @@ func NoPrintSpanId() PrinterOption {
 	}
 }
 
+// PrintRequestId enables inclusion of the request ID.
+func PrintRequestId() PrinterOption {
+	return func(p *Printer) {
+		p.requestId = true
+	}
+}
+
+// NoPrintRequestId disables inclusion of the request ID.
+func NoPrintRequestId() PrinterOption {
+	return func(p *Printer) {
+		p.requestId = false
+	}
+}
+
 // PrintOtel enables both the OTel trace ID and span ID.
 func PrintOtel() PrinterOption {
 	return withChained(PrintTraceId(), PrintSpanId())
@@ -235,6 +341,23 @@ func NoPrintTags() PrinterOption {
 	}
 }
 
+// PrintTagGroups returns a PrinterOption that groups the tags of an error in
+// the text output by namespace, the part before the first "/":
+// [db/conn, db/timeout, retry] is rendered as [db/{conn, timeout}, retry].
+func PrintTagGroups() PrinterOption {
+	return func(p *Printer) {
+		p.tagGroups = true
+	}
+}
+
+// NoPrintTagGroups returns a PrinterOption that renders every tag on its own,
+// the default.
+func NoPrintTagGroups() PrinterOption {
+	return func(p *Printer) {
+		p.tagGroups = false
+	}
+}
+
 // PrintAttributes returns a PrinterOption that enables inclusion of error attributes in the output.
 func PrintAttributes() PrinterOption {
 	return func(p *Printer) {
@@ -249,9 +372,144 @@ func NoPrintAttributes() PrinterOption {
 	}
 }
 
+// PrintAttrTable returns a PrinterOption that renders the attributes of the
+// text output as a table: keys are padded to the longest key as usual, and
+// values longer than the width set with PrintWidth, or spanning several
+// lines, continue on lines indented under the value column.
+func PrintAttrTable() PrinterOption {
+	return func(p *Printer) {
+		p.attrTable = true
+	}
+}
+
+// NoPrintAttrTable returns a PrinterOption that renders every attribute value
+// on a single line, the default.
+func NoPrintAttrTable() PrinterOption {
+	return func(p *Printer) {
+		p.attrTable = false
+	}
+}
+
+// PrintElideInheritedAttrs returns a PrinterOption that prints the
+// attributes of the causes and related errors in the tree of the text
+// output, leaving out those an ancestor already displayed with the same key
+// and value, such as the request ID context enrichment stamps on every
+// error. The attributes left out are counted in a dim "(3 inherited
+// attributes)" note, see Labels.InheritedAttrs. It requires PrintAttributes;
+// the JSON and plain outputs are not affected.
+func PrintElideInheritedAttrs() PrinterOption {
+	return func(p *Printer) {
+		p.elideInheritedAttrs = true
+	}
+}
+
+// NoPrintElideInheritedAttrs returns a PrinterOption that prints no
+// attributes of the nested errors of the tree, the default.
+func NoPrintElideInheritedAttrs() PrinterOption {
+	return func(p *Printer) {
+		p.elideInheritedAttrs = false
+	}
+}
+
+// PrintNestedAttrs returns a PrinterOption that groups dotted attribute keys
+// into nested objects in the JSON output: "http.method" and "http.route"
+// become {"http": {"method": …, "route": …}}. A key that is also the prefix
+// of other keys, such as "http" next to "http.method", keeps its value under
+// "_value". Keys without dots are left as they are. The text output is not
+// affected.
+func PrintNestedAttrs() PrinterOption {
+	return func(p *Printer) {
+		p.nestedAttrs = true
+	}
+}
+
+// NoPrintNestedAttrs returns a PrinterOption that keeps the dotted attribute
+// keys of the JSON output flat, the default.
+func NoPrintNestedAttrs() PrinterOption {
+	return func(p *Printer) {
+		p.nestedAttrs = false
+	}
+}
+
+// PrintPlain returns a PrinterOption that enables the accessibility mode, a
+// text layout suited to screen readers and braille displays. Colors are
+// disabled, every field is spelled out on its own line ("Hint: retry later",
+// "Attribute user_id: 42") without alignment or separators, and causes and
+// related errors are numbered instead of drawn as a tree: "Cause 1.2:
+// message" is the second cause of the first cause. The field, depth and
+// summary options apply as in the tree layout; the labels set with
+// PrintLabels do not. PrintJSON takes precedence.
+func PrintPlain() PrinterOption {
+	return withChained(
+		NoPrintColors(),
+		func(p *Printer) {
+			p.plain = true
+		},
+	)
+}
+
+// NoPrintPlain returns a PrinterOption that restores the tree layout of the
+// text output. Colors stay disabled unless enabled with PrintColors.
+func NoPrintPlain() PrinterOption {
+	return func(p *Printer) {
+		p.plain = false
+	}
+}
+
+// PrintQuiet returns a PrinterOption that reduces the output to what the end
+// user of a CLI needs: the user message, or a generic message if no error in
+// the tree has one, the hints, the code, the reference to quote to support
+// (see UserReport) and a pointer to the detailed output, set with
+// PrintQuietPointer. Internal messages, attributes and stacks are never
+// printed, and colors are not used. PrintJSON takes precedence.
+//
+// Together with PrintExit it is the whole error handling of a polished CLI's
+// main function:
+//
+//	ae.PrintExit(run(), ae.PrintQuiet())
+func PrintQuiet() PrinterOption {
+	return func(p *Printer) {
+		p.quiet = true
+	}
+}
+
+// NoPrintQuiet returns a PrinterOption that restores the full output.
+func NoPrintQuiet() PrinterOption {
+	return func(p *Printer) {
+		p.quiet = false
+	}
+}
+
+// PrintQuietPointer returns a PrinterOption that sets the last line of the
+// PrintQuiet output, "Run with --verbose for details." by default. An empty
+// text omits the line.
+func PrintQuietPointer(text string) PrinterOption {
+	return func(p *Printer) {
+		p.quietPointer = text
+	}
+}
+
+// PrintLabels returns a PrinterOption that replaces the literals of the text
+// output, e.g. to translate it. Fields left empty keep their default, see
+// DefaultLabels. The label column widens to fit the longest label.
+func PrintLabels(labels Labels) PrinterOption {
+	return func(p *Printer) {
+		p.labels = labels
+	}
+}
+
+// PrintWidth returns a PrinterOption that sets the line width of the text
+// output, 100 columns by default. It is used by PrintAttrTable; n <= 0
+// disables wrapping.
+func PrintWidth(n int) PrinterOption {
+	return func(p *Printer) {
+		p.width = n
+	}
+}
+
 // PrintVerbose enables every printable field: user message, hint, timestamp,
-// code, exit code, trace ID, span ID, tags, attributes, causes, related errors,
-// and stack traces.
+// code, exit code, trace ID, span ID, request ID, tags, attributes, causes,
+// related errors, and stack traces.
 //
 // Colors are not forced by PrintVerbose — they follow NewPrinter's TTY-aware default
 // (on when stdout is a terminal) unless the caller sets PrintColors()/NoPrintColors()
@@ -264,6 +522,7 @@ func PrintVerbose() PrinterOption {
 		PrintCode(),
 		PrintExitCode(),
 		PrintOtel(),
+		PrintRequestId(),
 		PrintTags(),
 		PrintAttributes(),
 		PrintCauses(),
@@ -274,7 +533,7 @@ func PrintVerbose() PrinterOption {
 
 // PrintCompact enables a minimal, high-signal field set suitable for terse logs:
 // user message, hint, code, exit code, tags, attributes, causes, related.
-// Timestamps, trace IDs, and stack traces are omitted.
+// Timestamps, trace and request IDs, and stack traces are omitted.
 func PrintCompact() PrinterOption {
 	return withChained(
 		PrintUserMessage(),
@@ -285,9 +544,87 @@ func PrintCompact() PrinterOption {
 		PrintTags(),
 		PrintCauses(),
 		PrintRelated(),
+		NoPrintTimestamp(),
+		NoPrintOtel(),
+		NoPrintRequestId(),
+		NoPrintStacks(),
 	)
 }
 
+// PrintDeterministic makes the output reproducible across runs, for golden
+// tests: colors are disabled, timestamps are rendered by the clock set with
+// PrintClock or as a fixed placeholder, goroutine IDs and wait times are
+// zeroed and stack file paths are trimmed of the working directory and the Go
+// installation's source root. Tags and attributes are always rendered sorted.
+// Further redactions can be added with PrintScrub.
+func PrintDeterministic() PrinterOption {
+	var trim []string
+	if wd, err := os.Getwd(); err == nil {
+		trim = append(trim, wd+string(filepath.Separator))
+	}
+	if src := gorootSrc(); src != "" {
+		trim = append(trim, src)
+	}
+
+	return withChained(
+		NoPrintColors(),
+		PrintStackFormat(StackTrimPath(trim...)),
+		func(p *Printer) {
+			p.deterministic = true
+		},
+	)
+}
+
+// PrintClock renders every timestamp as the time returned by now instead of
+// the error's own timestamp.
+func PrintClock(now func() time.Time) PrinterOption {
+	return func(p *Printer) {
+		p.clock = now
+	}
+}
+
+// PrintTimeUTC renders every timestamp in UTC, whatever the time zone it
+// was taken in. Shorthand for PrintTimeLocation(time.UTC).
+func PrintTimeUTC() PrinterOption {
+	return PrintTimeLocation(time.UTC)
+}
+
+// PrintTimeLocation renders every timestamp in the time zone loc. A nil loc,
+// the default, renders timestamps in the zone they were taken in.
+func PrintTimeLocation(loc *time.Location) PrinterOption {
+	return func(p *Printer) {
+		p.timeLoc = loc
+	}
+}
+
+// PrintScrub adds a function rewriting the rendered output, e.g. to redact
+// hostnames or temporary paths in golden files. Scrubbers run in the order
+// they were added, after the output is fully rendered.
+func PrintScrub(scrub func(string) string) PrinterOption {
+	return func(p *Printer) {
+		p.scrubbers = append(p.scrubbers, scrub)
+	}
+}
+
+// PrintAlso returns a PrinterOption that renders every printed error to w as
+// well, with a printer configured by opts independently of the primary one,
+// so one call can write colored text to the terminal and JSON to a log file:
+//
+//	p := ae.NewPrinter(ae.PrintCompact(), ae.PrintAlso(logFile, ae.PrintJSON(), ae.PrintVerbose()))
+//	p.Print(err)
+//
+// The secondary destinations are written after the primary one, in the order
+// they were added; errors writing to them are returned by Printer.FprintErr
+// and don't affect the other destinations. Colors are off unless opts enable
+// them. Prints renders the primary format only.
+func PrintAlso(w io.Writer, opts ...PrinterOption) PrinterOption {
+	secondary := NewPrinter(append([]PrinterOption{NoPrintColors()}, opts...)...)
+
+	return func(p *Printer) {
+		p.tees = append(slices.Clip(p.tees), tee{w: w, printer: secondary})
+	}
+}
+
 // withChained combines multiple PrinterOptions into a single option that applies all of them.
 func withChained(opts ...PrinterOption) PrinterOption {
 	return func(p *Printer) {
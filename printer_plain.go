@@ -0,0 +1,241 @@
+package ae
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// printsPlain renders err for screen readers, see PrintPlain: one
+// "Section: value" line per field, and numbered causes and related errors
+// instead of a drawn tree.
+func (p *Printer) printsPlain(err error) string {
+	sb := getPrintBuffer()
+	defer putPrintBuffer(sb)
+
+	p.writePlainError(sb, "Error", err, 0, p.maxDepth)
+	if p.summary {
+		p.writePlainSummary(sb, err)
+	} else {
+		p.writePlainSections(sb, err)
+	}
+
+	return strings.TrimPrefix(sb.String(), "\n")
+}
+
+// writePlainError writes the message of err, found at depth, as the line
+// "<name>: message", followed by its code, exit code and tags and, for errors
+// below the top, by its hint and its causes numbered after name.
+func (p *Printer) writePlainError(sb *bytes.Buffer, name string, err error, depth, maxDepth int) {
+	msg := Message(err)
+	if msg == "" {
+		msg = "no message"
+	}
+	fmt.Fprintf(sb, "\n%s: %s", name, msg)
+
+	// the fields of the top-level error read "Code: X", those of nested
+	// errors "Cause 1.2 code: X"
+	field := func(label string) string {
+		if depth == 0 {
+			return strings.ToUpper(label[:1]) + label[1:]
+		}
+		return name + " " + label
+	}
+
+	if p.code {
+		if code := Code(err); code != "" {
+			fmt.Fprintf(sb, "\n%s: %s", field("code"), code)
+		}
+	}
+	if p.exitCode {
+		if exit := p.exitCodeOf(err); exit > 1 {
+			fmt.Fprintf(sb, "\n%s: %d", field("exit code"), exit)
+		}
+	}
+	if p.tags {
+		if tags := slices.Collect(tagSeq(err)); len(tags) > 0 {
+			fmt.Fprintf(sb, "\n%s: %s", field("tags"), strings.Join(tags, ", "))
+		}
+	}
+	if depth == 0 {
+		return
+	}
+
+	if p.hint {
+		if h := Hint(err); h != "" {
+			fmt.Fprintf(sb, "\n%s: %s", field("hint"), h)
+		}
+	}
+	p.writePlainTree(sb, name, printedCauses(err), depth, maxDepth)
+}
+
+// writePlainTree writes errs, the causes or related errors of the error at
+// depth named prefix, numbered after prefix: the causes of "Cause 1" are
+// "Cause 1.1", "Cause 1.2" and so on.
+func (p *Printer) writePlainTree(sb *bytes.Buffer, prefix string, errs []error, depth, maxDepth int) {
+	switch {
+	case len(errs) == 0, maxDepth >= 0 && depth >= maxDepth:
+		return
+	case depth >= traversalDepth():
+		fmt.Fprintf(sb, "\n%s.1: deeper errors truncated", prefix)
+		return
+	}
+
+	for i, e := range errs {
+		p.writePlainError(sb, fmt.Sprintf("%s.%d", prefix, i+1), e, depth+1, maxDepth)
+	}
+}
+
+// writePlainSections writes the fields of err below its message line.
+func (p *Printer) writePlainSections(sb *bytes.Buffer, err error) {
+	if p.hint {
+		if h := Hint(err); h != "" {
+			fmt.Fprintf(sb, "\nHint: %s", h)
+		}
+	}
+	if p.userMsg {
+		if u := UserMessage(err); u != "" && u != Message(err) {
+			fmt.Fprintf(sb, "\nUser message: %s", u)
+		}
+	}
+	if p.timestamp {
+		if t := Timestamp(err); !t.IsZero() {
+			fmt.Fprintf(sb, "\nTime: %s", p.formatTime(t))
+		}
+	}
+	if p.traceId {
+		if id := TraceId(err); id != "" {
+			fmt.Fprintf(sb, "\nTrace ID: %s", id)
+		}
+	}
+	if p.spanId {
+		if id := SpanId(err); id != "" {
+			fmt.Fprintf(sb, "\nSpan ID: %s", id)
+		}
+	}
+	if p.requestId {
+		if id := RequestId(err); id != "" {
+			fmt.Fprintf(sb, "\nRequest ID: %s", id)
+		}
+	}
+
+	if p.attributes {
+		attrs := maps.Collect(attrSeq(err))
+		for _, k := range slices.Sorted(maps.Keys(attrs)) {
+			fmt.Fprintf(sb, "\nAttribute %s: %v", k, attrs[k])
+		}
+	}
+
+	if p.causes {
+		p.writePlainList(sb, "Cause", printedCauses(err), p.maxDepth)
+	}
+	if p.related {
+		p.writePlainList(sb, "Related", Related(err), p.relatedMaxDepth(0, p.maxDepth))
+	}
+
+	if p.stacks {
+		p.writePlainStacks(sb, p.printableStacks(Stacks(err)))
+	}
+}
+
+// writePlainList writes the causes or related errors of the top-level
+// error as "<name> 1", "<name> 2" and so on.
+func (p *Printer) writePlainList(sb *bytes.Buffer, name string, errs []error, maxDepth int) {
+	if maxDepth >= 0 && maxDepth < 1 {
+		return
+	}
+
+	for i, e := range errs {
+		p.writePlainError(sb, fmt.Sprintf("%s %d", name, i+1), e, 1, maxDepth)
+	}
+}
+
+// writePlainStacks writes every stack as a "Stack N" line followed by its
+// numbered frames. Frames are filtered as in the tree layout; a stack whose
+// frames are all filtered out is omitted.
+func (p *Printer) writePlainStacks(sb *bytes.Buffer, stacks []*Stack) {
+	f := newStackFormat(p.stackOpts)
+	f.filters = append(f.filters, p.frameFilters...)
+
+	n := 0
+	for i, st := range stacks {
+		st.resolve()
+
+		var frames []*StackFrame
+		truncated := false
+		for _, frame := range st.Frames {
+			if f.drop(frame) {
+				continue
+			}
+			if f.maxFrames > 0 && len(frames) == f.maxFrames {
+				truncated = true
+				break
+			}
+			frames = append(frames, frame)
+		}
+		if len(frames) == 0 && len(st.Frames) > 0 {
+			continue
+		}
+
+		n++
+		name := fmt.Sprintf("Stack %d", n)
+		fmt.Fprintf(sb, "\n%s: goroutine %d, %s", name, st.ID, cmp.Or(st.State, "unknown"))
+		if p.stackSummary && i > 0 {
+			if len(frames) > 0 {
+				top := frames[0]
+				if j := slices.IndexFunc(frames, func(frame *StackFrame) bool { return frame.InApp }); j >= 0 {
+					top = frames[j]
+				}
+				fmt.Fprintf(sb, ", in %s", top.Func)
+			}
+			continue
+		}
+
+		for j, frame := range frames {
+			fmt.Fprintf(sb, "\n%s frame %d: %s, %s line %d", name, j+1, frame.Func, f.trimPath(frame.File), frame.Line)
+		}
+		switch {
+		case truncated:
+			fmt.Fprintf(sb, "\n%s: more frames omitted", name)
+		case st.ElidedCount > 0:
+			fmt.Fprintf(sb, "\n%s: %d frames elided", name, st.ElidedCount)
+		case st.FramesElided:
+			fmt.Fprintf(sb, "\n%s: more frames elided", name)
+		}
+		if cb := st.CreatedBy; cb != nil {
+			fmt.Fprintf(sb, "\n%s created by: %s, %s line %d", name, cb.Func, f.trimPath(cb.File), cb.Line)
+		}
+	}
+}
+
+// writePlainSummary writes the summary of err's tree, see PrintSummary.
+func (p *Printer) writePlainSummary(sb *bytes.Buffer, err error) {
+	s := summarize(err, p.summaryExamples)
+
+	fmt.Fprintf(sb, "\nErrors in tree: %d", s.errors)
+	fmt.Fprintf(sb, "\nLeaf errors: %d", s.leaves)
+	for _, code := range byCount(s.codes) {
+		fmt.Fprintf(sb, "\nCode %s: %d errors", code, s.codes[code])
+	}
+	if p.tags {
+		for _, tag := range byCount(s.tags) {
+			fmt.Fprintf(sb, "\nTag %s: %d errors", tag, s.tags[tag])
+		}
+	}
+	if p.timestamp && !s.first.IsZero() {
+		fmt.Fprintf(sb, "\nFirst error at: %s", p.formatTime(s.first))
+		fmt.Fprintf(sb, "\nLast error at: %s", p.formatTime(s.last))
+	}
+	for _, code := range summaryExampleCodes(s) {
+		name := "Example for code " + code
+		if code == "" {
+			name = "Example without code"
+		}
+		for _, msg := range s.examples[code] {
+			fmt.Fprintf(sb, "\n%s: %s", name, msg)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package ae_test
+
+import (
+	"testing"
+	"time"
+
+	"go.aledante.io/ae"
+)
+
+// plainTreeErr is the nested error of the print example's tree scenario.
+func plainTreeErr() error {
+	return ae.New().
+		Cause(
+			ae.New().
+				Cause(
+					ae.New().Tag("timeout").
+						Cause(ae.New().Code("DEEP").Msg("deep nested error")).
+						Msg("timeout"),
+					ae.New().Tag("timeout").Msg("timeout2"),
+				).
+				Msg("database connection failed"),
+			ae.New().Msg("cache miss"),
+		).
+		Related(ae.New().Code("AUDIT").Hint("check the audit log").Msg("audit write failed")).
+		Code("AUTH_FAILED").
+		ExitCode(77).
+		Timestamp(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)).
+		TraceId("trace-abc123").
+		SpanId("span-def456").
+		Attr("path", "/api/v1/login").
+		Attr("user_id", 42).
+		Tag("security").
+		Tag("auth").
+		Hint("try rotating the service token").
+		UserMsg(
+			"authentication failed",
+			"Something went wrong on our end. Please retry.",
+		)
+}
+
+func TestPrintPlain_Golden(t *testing.T) {
+	t.Parallel()
+
+	got := ae.NewPrinter(ae.PrintPlain(), ae.PrintTimeUTC()).Prints(plainTreeErr())
+	want := `Error: authentication failed
+Code: AUTH_FAILED
+Exit code: 77
+Tags: auth, security
+Hint: try rotating the service token
+User message: Something went wrong on our end. Please retry.
+Time: 2025-03-01T12:00:00Z
+Trace ID: trace-abc123
+Span ID: span-def456
+Attribute path: /api/v1/login
+Attribute user_id: 42
+Cause 1: database connection failed
+Cause 1.1: timeout
+Cause 1.1 tags: timeout
+Cause 1.1.1: deep nested error
+Cause 1.1.1 code: DEEP
+Cause 1.2: timeout2
+Cause 1.2 tags: timeout
+Cause 2: cache miss
+Related 1: audit write failed
+Related 1 code: AUDIT
+Related 1 hint: check the audit log`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPrintPlain_ComposesWithFieldOptions(t *testing.T) {
+	t.Parallel()
+
+	got := ae.NewPrinter(ae.PrintColors(), ae.PrintPlain(), ae.PrintCompact(), ae.NoPrintTags(), ae.NoPrintRelated(), ae.PrintDepth(2)).
+		Prints(plainTreeErr())
+	want := `Error: authentication failed
+Code: AUTH_FAILED
+Exit code: 77
+Hint: try rotating the service token
+User message: Something went wrong on our end. Please retry.
+Attribute path: /api/v1/login
+Attribute user_id: 42
+Cause 1: database connection failed
+Cause 1.1: timeout
+Cause 1.2: timeout2
+Cause 2: cache miss`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPrintPlain_Stacks(t *testing.T) {
+	t.Parallel()
+
+	got := ae.NewPrinter(ae.PrintPlain(), ae.NoPrintTimestamp(), ae.PrintStackSummary()).Prints(threeGoroutinesErr())
+	want := `Error: query failed
+Stack 1: goroutine 1, running
+Stack 1 frame 1: example.com/app/db.query, /src/app/db/query.go line 88
+Stack 1 frame 2: example.com/app/api.handle, /src/app/api/handle.go line 21
+Stack 2: goroutine 7, chan receive, in example.com/app/worker.loop
+Stack 3: goroutine 9, IO wait, in internal/poll.(*FD).Read`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPrintPlain_Summary(t *testing.T) {
+	t.Parallel()
+
+	got := ae.NewPrinter(ae.PrintPlain(), ae.PrintSummary(), ae.PrintSummaryExamples(1)).Prints(batchErr())
+	want := `Error: 100 rows failed
+Errors in tree: 121
+Leaf errors: 100
+Code DB_TIMEOUT: 50 errors
+Code NOT_FOUND: 30 errors
+Code INVALID: 20 errors
+Tag db: 80 errors
+Tag retry: 50 errors
+First error at: 2024-05-01T12:00:00Z
+Last error at: 2024-05-01T13:39:00Z
+Example for code DB_TIMEOUT: row 0 timed out
+Example for code NOT_FOUND: row 1 not found
+Example for code INVALID: bad input`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPrintPlain_JSONTakesPrecedence(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Code("X").Msg("boom")
+	got := ae.NewPrinter(ae.PrintPlain(), ae.PrintJSON()).Prints(err)
+	if want := ae.NewPrinter(ae.PrintJSON()).Prints(err); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
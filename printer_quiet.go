@@ -0,0 +1,18 @@
+package ae
+
+// defaultQuietPointer is the last line of the PrintQuiet output by default.
+const defaultQuietPointer = "Run with --verbose for details."
+
+// printsQuiet renders err for end users, see PrintQuiet.
+func (p *Printer) printsQuiet(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	out := userReport(err, &reportOptions{code: true})
+	if p.quietPointer != "" {
+		out += "\n" + p.quietPointer
+	}
+
+	return out
+}
@@ -0,0 +1,59 @@
+package ae_test
+
+import (
+	"strings"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+func TestPrintQuiet_NeverIncludesInternals(t *testing.T) {
+	t.Parallel()
+
+	err := internalError()
+	out := ae.NewPrinter(ae.PrintQuiet(), ae.PrintVerbose(), ae.PrintColors()).Prints(err)
+
+	want := "The service is temporarily unavailable.\n" +
+		"Hint: Try again in a minute.\n" +
+		"Hint: Check your network connection.\n" +
+		"Code: DB_UNREACHABLE\n" +
+		"Reference: " + ae.Fingerprint(err)[:8] + "\n" +
+		"Run with --verbose for details."
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+	for _, internal := range []string{"10.0.0.3", "users/42", "hunter2", "dsn", "4bf92f35", "connection refused", ".go:", "\x1b["} {
+		if strings.Contains(out, internal) {
+			t.Errorf("quiet output contains internal %q:\n%s", internal, out)
+		}
+	}
+}
+
+func TestPrintQuiet_GenericMessageAndPointer(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Attr("path", "/etc/app.conf").Msg("reading /etc/app.conf")
+
+	got := ae.NewPrinter(ae.PrintQuiet(), ae.PrintQuietPointer("See app --help.")).Prints(err)
+	if !strings.HasPrefix(got, "An unexpected error occurred.\nReference: ") || !strings.HasSuffix(got, "\nSee app --help.") {
+		t.Errorf("got %q, want the generic message and the custom pointer", got)
+	}
+	if strings.Contains(got, "/etc/app.conf") {
+		t.Errorf("quiet output contains the internal message: %q", got)
+	}
+
+	got = ae.NewPrinter(ae.PrintQuiet(), ae.PrintQuietPointer("")).Prints(err)
+	if strings.Count(got, "\n") != 1 {
+		t.Errorf("got %q, want no pointer line", got)
+	}
+}
+
+func TestPrintQuiet_NoPrintQuiet(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().UserMsg("internal", "Shown.")
+	got := ae.NewPrinter(ae.PrintQuiet(), ae.NoPrintQuiet(), ae.NoPrintColors()).Prints(err)
+	if !strings.HasPrefix(got, "[ERROR] internal") {
+		t.Errorf("got %q, want the full output", got)
+	}
+}
@@ -0,0 +1,204 @@
+package ae
+
+import (
+	"bytes"
+	"cmp"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// errorSummary aggregates the errors of a tree, see PrintSummary.
+type errorSummary struct {
+	// errors and leaves count the errors in the tree and those without
+	// causes.
+	errors int
+	leaves int
+	// codes and tags count the errors carrying each code and tag.
+	codes map[string]int
+	tags  map[string]int
+	// first and last are the earliest and latest timestamps.
+	first time.Time
+	last  time.Time
+	// examples holds up to the configured number of distinct leaf messages
+	// per code; a leaf without a code is filed under the nearest code above
+	// it, or "" if there is none.
+	examples map[string][]string
+}
+
+// summarize aggregates err and every error in its cause tree, keeping up to
+// maxExamples leaf messages per code.
+func summarize(err error, maxExamples int) errorSummary {
+	s := errorSummary{
+		codes:    make(map[string]int),
+		tags:     make(map[string]int),
+		examples: make(map[string][]string),
+	}
+
+	// inherited holds the code of the nearest coded error above a cause;
+	// walkCauses visits errors before their causes.
+	inherited := make(map[error]string)
+	walkCauses(err, func(err error) bool {
+		s.errors++
+
+		code := Code(err)
+		if code != "" {
+			s.codes[code]++
+		} else if reflect.TypeOf(err).Comparable() {
+			code = inherited[err]
+		}
+
+		for tag := range tagSeq(err) {
+			s.tags[tag]++
+		}
+
+		if ts := Timestamp(err); !ts.IsZero() {
+			if s.first.IsZero() || ts.Before(s.first) {
+				s.first = ts
+			}
+			if ts.After(s.last) {
+				s.last = ts
+			}
+		}
+
+		causes := Causes(err)
+		if len(causes) == 0 {
+			s.leaves++
+			if msg := Message(err); len(s.examples[code]) < maxExamples && !slices.Contains(s.examples[code], msg) {
+				s.examples[code] = append(s.examples[code], msg)
+			}
+		}
+		if code != "" {
+			for _, cause := range causes {
+				if cause == nil || !reflect.TypeOf(cause).Comparable() {
+					continue
+				}
+				if _, ok := inherited[cause]; !ok {
+					inherited[cause] = code
+				}
+			}
+		}
+
+		return true
+	})
+
+	return s
+}
+
+// byCount returns the keys of counts, the most frequent first and ties in
+// alphabetical order.
+func byCount(counts map[string]int) []string {
+	return slices.SortedFunc(maps.Keys(counts), func(a, b string) int {
+		if c := cmp.Compare(counts[b], counts[a]); c != 0 {
+			return c
+		}
+		return cmp.Compare(a, b)
+	})
+}
+
+// writeSummary writes the summary of err's tree as labeled rows below the
+// header.
+func (p *Printer) writeSummary(sb *bytes.Buffer, err error) {
+	s := summarize(err, p.summaryExamples)
+
+	p.writeRow(sb, p.labels.Errors, p.fmt(p.labels.ErrorCounts, colDim, s.errors, s.leaves))
+
+	p.writeCounts(sb, p.labels.Codes, s.codes, colCode)
+	if p.tags {
+		p.writeCounts(sb, p.labels.Tags, s.tags, colTag)
+	}
+
+	if p.timestamp && !s.first.IsZero() {
+		p.writeRow(sb, p.labels.First, p.fmt("%s", colDim, p.formatTime(s.first)))
+		p.writeRow(sb, p.labels.Last, p.fmt("%s", colDim, p.formatTime(s.last)))
+	}
+
+	label := p.labels.Examples
+	for _, code := range summaryExampleCodes(s) {
+		sb.WriteString("\n")
+		if label != "" {
+			sb.WriteString(p.labelPrefix(label))
+			label = ""
+		} else {
+			sb.WriteString(p.continuation)
+		}
+		if code == "" {
+			p.write(sb, "%s", colDim, p.labels.NoCode)
+		} else {
+			p.write(sb, "%s", colCode, code)
+		}
+
+		for _, msg := range s.examples[code] {
+			sb.WriteString("\n")
+			sb.WriteString(p.continuation)
+			sb.WriteString("  ")
+			p.write(sb, "%s", colMsg, msg)
+		}
+	}
+}
+
+// writeCounts writes counts as a single row, the most frequent first.
+func (p *Printer) writeCounts(sb *bytes.Buffer, label string, counts map[string]int, c *textColor) {
+	if len(counts) == 0 {
+		return
+	}
+
+	sb.WriteString("\n")
+	sb.WriteString(p.labelPrefix(label))
+	for i, key := range byCount(counts) {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		p.write(sb, "%s", c, key)
+		p.write(sb, " (%d)", colDim, counts[key])
+	}
+}
+
+// summaryExampleCodes returns the codes with examples in the order they are
+// printed: by how often the code occurs, leaves without a code last.
+func summaryExampleCodes(s errorSummary) []string {
+	var codes []string
+	for _, code := range byCount(s.codes) {
+		if len(s.examples[code]) > 0 {
+			codes = append(codes, code)
+		}
+	}
+	if len(s.examples[""]) > 0 {
+		codes = append(codes, "")
+	}
+
+	return codes
+}
+
+// jsonSummary is the "summary" object of the JSON output.
+type jsonSummary struct {
+	Errors   int                 `json:"errors"`
+	Leaves   int                 `json:"leaves"`
+	Codes    map[string]int      `json:"codes,omitempty"`
+	Tags     map[string]int      `json:"tags,omitempty"`
+	First    string              `json:"first,omitempty"`
+	Last     string              `json:"last,omitempty"`
+	Examples map[string][]string `json:"examples,omitempty"`
+}
+
+// toJsonSummary returns the summary of err's tree for the JSON output.
+func (p *Printer) toJsonSummary(err error) *jsonSummary {
+	s := summarize(err, p.summaryExamples)
+
+	js := &jsonSummary{
+		Errors:   s.errors,
+		Leaves:   s.leaves,
+		Codes:    s.codes,
+		Examples: s.examples,
+	}
+	if p.tags {
+		js.Tags = s.tags
+	}
+	if p.timestamp && !s.first.IsZero() {
+		js.First = p.formatTime(s.first)
+		js.Last = p.formatTime(s.last)
+	}
+
+	return js
+}
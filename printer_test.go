@@ -3,8 +3,16 @@ package ae_test
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"regexp"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"go.aledante.io/ae"
 )
@@ -208,6 +216,23 @@ func TestPrinter_PrintCompactIncludesDocumentedCompactFields(t *testing.T) {
 	}
 }
 
+func TestPrinter_PrintCompactOmitsTimestampTraceAndStacks(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().
+		Now().
+		TraceId("trace-x").
+		Stack().
+		Msg("m")
+
+	out := ae.NewPrinter(ae.NoPrintColors(), ae.PrintCompact()).Prints(err)
+	for _, w := range []string{"time", "trace-x", "stack"} {
+		if strings.Contains(out, w) {
+			t.Errorf("PrintCompact output contains omitted field %q:\n%s", w, out)
+		}
+	}
+}
+
 func TestPrinter_PrintColorsInjectsAnsiEscapes(t *testing.T) {
 	t.Parallel()
 
@@ -354,3 +379,972 @@ func TestPrinter_PrintTraceIdAndPrintSpanIdIndependent(t *testing.T) {
 		t.Errorf("PrintSpanId alone emitted trace id:\n%s", out)
 	}
 }
+
+// deterministicErr is called from different goroutines with different
+// timestamps; its rendering under PrintDeterministic must not change.
+func deterministicErr(ts time.Time) error {
+	return ae.New().
+		Timestamp(ts).
+		Code("E_DET").
+		Tags("b", "a").
+		Attrs(map[string]any{"z": 1, "a": map[string]any{"y": 2, "x": 1}}).
+		Cause(ae.New().Timestamp(ts).Stack().Msg("inner")).
+		Stack().
+		Msg("outer")
+}
+
+func TestPrintDeterministic_StableAcrossRuns(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range []ae.PrinterOption{ae.NoPrintJSON(), ae.PrintJSON()} {
+		p := ae.NewPrinter(ae.PrintColors(), format, ae.PrintDeterministic())
+
+		// Render from distinct goroutines started at the same call site, with
+		// different timestamps, so only run-dependent details differ.
+		outs := make([]string, 2)
+		for i := range outs {
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				outs[i] = p.Prints(deterministicErr(time.Now().Add(time.Duration(i) * time.Hour)))
+			}()
+			<-done
+		}
+		first, second := outs[0], outs[1]
+
+		if first != second {
+			t.Errorf("output differs between runs:\n%s\n---\n%s", first, second)
+		}
+		if strings.Contains(first, "\x1b[") {
+			t.Error("deterministic output contains ANSI escapes")
+		}
+		if wd, _ := os.Getwd(); strings.Contains(first, wd) {
+			t.Errorf("output contains the working directory:\n%s", first)
+		}
+	}
+}
+
+func TestPrintDeterministic_TimestampPlaceholderAndClock(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Now().Msg("boom")
+
+	out := ae.NewPrinter(ae.PrintDeterministic()).Prints(err)
+	if !strings.Contains(out, "<timestamp>") {
+		t.Errorf("want timestamp placeholder, got:\n%s", out)
+	}
+
+	clock := func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+	out = ae.NewPrinter(ae.PrintDeterministic(), ae.PrintClock(clock)).Prints(err)
+	if !strings.Contains(out, "2024-01-02T03:04:05Z") {
+		t.Errorf("want clock time, got:\n%s", out)
+	}
+}
+
+func TestPrintScrub_RewritesOutput(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Attr("host", "db-7.internal").Msg("connect failed")
+	out := ae.NewPrinter(
+		ae.NoPrintColors(),
+		ae.PrintScrub(func(s string) string { return strings.ReplaceAll(s, "db-7.internal", "<host>") }),
+		ae.PrintScrub(func(s string) string { return strings.ReplaceAll(s, "<host>", "HOST") }),
+	).Prints(err)
+
+	if !strings.Contains(out, "HOST") || strings.Contains(out, "db-7") {
+		t.Errorf("scrubbers not applied in order:\n%s", out)
+	}
+}
+
+func TestPrintTagGroups(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Tags("db/timeout", "db/conn", "retry", "net/dns").Msg("boom")
+
+	out := ae.NewPrinter(ae.NoPrintColors(), ae.PrintTagGroups()).Prints(err)
+	if !strings.Contains(out, "boom [db/{conn, timeout}, net/dns, retry]") {
+		t.Errorf("tags not grouped:\n%s", out)
+	}
+
+	out = ae.NewPrinter(ae.NoPrintColors()).Prints(err)
+	if !strings.Contains(out, "boom [db/conn, db/timeout, net/dns, retry]") {
+		t.Errorf("tags grouped without PrintTagGroups:\n%s", out)
+	}
+}
+
+func TestPrintJSON_UserMessages(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().UserMsgLocale("de", "Fehler").UserMsg("boom", "Error")
+
+	var got map[string]any
+	if jErr := json.Unmarshal([]byte(ae.NewPrinter(ae.PrintJSON()).Prints(err)), &got); jErr != nil {
+		t.Fatal(jErr)
+	}
+	if msgs, _ := got["user_messages"].(map[string]any); msgs["de"] != "Fehler" {
+		t.Errorf("JSON[user_messages] = %v", got["user_messages"])
+	}
+
+	got = nil
+	if jErr := json.Unmarshal([]byte(ae.NewPrinter(ae.PrintJSON()).Prints(ae.Msg("boom"))), &got); jErr != nil {
+		t.Fatal(jErr)
+	}
+	if _, ok := got["user_messages"]; ok {
+		t.Error("user_messages present without translations")
+	}
+}
+
+func TestPrinter_ConcurrentUse(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().
+		Code("DB_TIMEOUT").
+		Tags("db", "retry").
+		Attr("attempts", 3).
+		Hint("try again").
+		Stack().
+		Cause(ae.Wrap("dial", errors.New("timeout")), errors.New("rollback failed")).
+		Msg("query failed")
+
+	for _, opts := range [][]ae.PrinterOption{
+		{ae.NoPrintColors()},
+		{ae.PrintColors()},
+		{ae.PrintJSON()},
+	} {
+		p := ae.NewPrinter(opts...)
+		want := p.Prints(err)
+
+		var wg sync.WaitGroup
+		for range 32 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 50 {
+					if got := p.Prints(err); got != want {
+						t.Errorf("concurrent output differs:\n%s\nwant\n%s", got, want)
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+func BenchmarkPrinter_Parallel(b *testing.B) {
+	err := ae.New().
+		Code("DB_TIMEOUT").
+		Tags("db", "retry").
+		Attr("attempts", 3).
+		Hint("try again").
+		Cause(ae.Wrap("dial", errors.New("timeout")), errors.New("rollback failed")).
+		Msg("query failed")
+
+	for _, bc := range []struct {
+		name string
+		opts []ae.PrinterOption
+	}{
+		{"text", []ae.PrinterOption{ae.NoPrintColors()}},
+		{"json", []ae.PrinterOption{ae.PrintJSON()}},
+	} {
+		p := ae.NewPrinter(bc.opts...)
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_ = p.Prints(err)
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkPrinter_FiftyAttributes(b *testing.B) {
+	builder := ae.New()
+	for i := range 50 {
+		builder = builder.Attr(fmt.Sprintf("key_%02d", i), map[string]any{"n": i})
+	}
+	err := builder.Msg("boom")
+
+	for _, bc := range []struct {
+		name string
+		opts []ae.PrinterOption
+	}{
+		{"text", []ae.PrinterOption{ae.NoPrintColors()}},
+		{"json", []ae.PrinterOption{ae.PrintJSON()}},
+	} {
+		p := ae.NewPrinter(bc.opts...)
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				_ = p.Prints(err)
+			}
+		})
+	}
+}
+
+func BenchmarkPrinter_InlineError(b *testing.B) {
+	err := ae.New().Code("DB_TIMEOUT").Tags("db", "retry").Msg("query failed")
+	p := ae.NewPrinter(ae.NoPrintColors(), ae.PrintCompact(), ae.NoPrintHint(), ae.NoPrintAttributes(), ae.NoPrintCauses())
+
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = p.Prints(err)
+	}
+}
+
+func TestPrinter_ExitCodesOfNestedErrors(t *testing.T) {
+	t.Parallel()
+
+	inner := ae.New().ExitCode(4).Msg("inner")
+	err := ae.New().Cause(ae.Wrap("middle", inner), errors.New("other")).Msg("outer")
+
+	out := ae.NewPrinter(ae.NoPrintColors()).Prints(err)
+	for _, want := range []string{"[ERROR] {exit 4} outer", "{exit 4} middle", "{exit 4} inner"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "} other") {
+		t.Errorf("exit code rendered for an error without one:\n%s", out)
+	}
+}
+
+func BenchmarkPrinter_ManyCauses(b *testing.B) {
+	joined := make([]error, 500)
+	for i := range joined {
+		joined[i] = ae.Wrap(fmt.Sprintf("shard %d", i), errors.New("timeout"))
+	}
+
+	var chain error = ae.Msg("root")
+	for i := range 500 {
+		chain = ae.New().Cause(chain).Msgf("level %d", i)
+	}
+
+	for _, bc := range []struct {
+		name string
+		err  error
+	}{
+		{"joined", ae.WrapMany("500 shards failed", joined...)},
+		{"chain", chain},
+	} {
+		p := ae.NewPrinter(ae.NoPrintColors())
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				_ = p.Prints(bc.err)
+			}
+		})
+	}
+}
+
+// batchErr returns a synthetic batch of 100 failed rows across three codes.
+// The INVALID rows wrap an uncoded error, which is filed under INVALID.
+func batchErr() error {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	errs := make([]error, 100)
+	for i := range errs {
+		ts := base.Add(time.Duration(i) * time.Minute)
+		switch {
+		case i%2 == 0:
+			errs[i] = ae.New().Code("DB_TIMEOUT").Tags("db", "retry").Timestamp(ts).Msgf("row %d timed out", i)
+		case i%10 < 6:
+			errs[i] = ae.New().Code("NOT_FOUND").Tag("db").Timestamp(ts).Msgf("row %d not found", i)
+		default:
+			errs[i] = ae.New().Code("INVALID").Timestamp(ts).Cause(errors.New("bad input")).Msgf("row %d invalid", i)
+		}
+	}
+
+	return ae.New().Timestamp(base).Causes(errs).Msg("100 rows failed")
+}
+
+func TestPrintSummary_Text(t *testing.T) {
+	t.Parallel()
+
+	got := ae.NewPrinter(ae.NoPrintColors(), ae.PrintSummary()).Prints(batchErr())
+	want := `[ERROR] 100 rows failed
+  errors     121 in tree, 100 leaves
+  codes      DB_TIMEOUT (50), NOT_FOUND (30), INVALID (20)
+  tags       db (80), retry (50)
+  first      2024-05-01T12:00:00Z
+  last       2024-05-01T13:39:00Z
+  examples   DB_TIMEOUT
+               row 0 timed out
+               row 2 timed out
+               row 4 timed out
+             NOT_FOUND
+               row 1 not found
+               row 3 not found
+               row 5 not found
+             INVALID
+               bad input`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPrintSummary_RespectsFieldsAndExamples(t *testing.T) {
+	t.Parallel()
+
+	got := ae.NewPrinter(ae.NoPrintColors(), ae.PrintSummary(), ae.PrintSummaryExamples(1),
+		ae.NoPrintTags(), ae.NoPrintTimestamp(), ae.PrintScrub(func(s string) string {
+			return strings.ReplaceAll(s, "row ", "row #")
+		})).Prints(batchErr())
+
+	for _, unwanted := range []string{"tags", "first", "row #2 timed out"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("output contains %q:\n%s", unwanted, got)
+		}
+	}
+	if !strings.Contains(got, "row #0 timed out") {
+		t.Errorf("output is not scrubbed:\n%s", got)
+	}
+}
+
+func TestPrintSummary_JSON(t *testing.T) {
+	t.Parallel()
+
+	out := ae.NewPrinter(ae.PrintJSON(), ae.PrintSummary(), ae.PrintSummaryExamples(2)).Prints(batchErr())
+
+	var got struct {
+		Causes  []json.RawMessage `json:"causes"`
+		Summary struct {
+			Errors   int                 `json:"errors"`
+			Leaves   int                 `json:"leaves"`
+			Codes    map[string]int      `json:"codes"`
+			Tags     map[string]int      `json:"tags"`
+			First    string              `json:"first"`
+			Last     string              `json:"last"`
+			Examples map[string][]string `json:"examples"`
+		} `json:"summary"`
+	}
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+
+	if len(got.Causes) != 100 {
+		t.Errorf("got %d causes, want the full tree of 100", len(got.Causes))
+	}
+	s := got.Summary
+	if s.Errors != 121 || s.Leaves != 100 {
+		t.Errorf("errors, leaves = %d, %d, want 121, 100", s.Errors, s.Leaves)
+	}
+	if s.Codes["DB_TIMEOUT"] != 50 || s.Codes["NOT_FOUND"] != 30 || s.Codes["INVALID"] != 20 {
+		t.Errorf("codes = %v", s.Codes)
+	}
+	if s.Tags["db"] != 80 || s.Tags["retry"] != 50 {
+		t.Errorf("tags = %v", s.Tags)
+	}
+	if s.First != "2024-05-01T12:00:00Z" || s.Last != "2024-05-01T13:39:00Z" {
+		t.Errorf("first, last = %s, %s", s.First, s.Last)
+	}
+	if got := s.Examples["NOT_FOUND"]; len(got) != 2 || got[0] != "row 1 not found" {
+		t.Errorf("NOT_FOUND examples = %q", got)
+	}
+
+	plain := ae.NewPrinter(ae.PrintJSON()).Prints(batchErr())
+	if strings.Contains(plain, `"summary"`) {
+		t.Error("summary printed without PrintSummary")
+	}
+}
+
+func TestPrintSummary_CyclicTree(t *testing.T) {
+	t.Parallel()
+
+	a := ae.New().Msg("a").(*ae.Ae)
+	b := ae.New().Cause(a).Msg("b")
+	*a = *ae.New().Cause(b).Msg("a").(*ae.Ae)
+
+	got := ae.NewPrinter(ae.NoPrintColors(), ae.PrintSummary()).Prints(ae.Wrap("top", a))
+	if !strings.Contains(got, "3 in tree, 0 leaves") {
+		t.Errorf("got:\n%s", got)
+	}
+}
+
+func attrTableErr() error {
+	return ae.New().
+		Attrs(map[string]any{
+			"id":                         7,
+			"request.headers.user_agent": "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36",
+			"query":                      "SELECT *\nFROM users\nWHERE id = $1",
+			"password":                   ae.Secret("hunter2"),
+		}).
+		Msg("request failed")
+}
+
+func TestPrintAttrTable_Text(t *testing.T) {
+	t.Parallel()
+
+	got := ae.NewPrinter(ae.NoPrintColors(), ae.NoPrintTimestamp(), ae.PrintAttrTable(), ae.PrintWidth(80)).Prints(attrTableErr())
+	want := `[ERROR] request failed
+  attrs      id                          7
+             password                    [REDACTED]
+             query                       SELECT *
+                                         FROM users
+                                         WHERE id = $1
+             request.headers.user_agent  Mozilla/5.0 (X11; Linux x86_64)
+                                         AppleWebKit/537.36 (KHTML, like Gecko)
+                                         Chrome/124.0 Safari/537.36`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPrintAttrTable_ShortKeysAndNoWrap(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Attr("a", "x").Attr("bb", strings.Repeat("y", 120)).Msg("m")
+
+	got := ae.NewPrinter(ae.NoPrintColors(), ae.NoPrintTimestamp(), ae.PrintAttrTable(), ae.PrintWidth(0)).Prints(err)
+	want := "[ERROR] m\n  attrs      a   x\n             bb  " + strings.Repeat("y", 120)
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	got = ae.NewPrinter(ae.NoPrintColors(), ae.NoPrintTimestamp(), ae.PrintAttrTable(), ae.PrintWidth(60)).Prints(err)
+	want = "[ERROR] m\n  attrs      a   x\n             bb  " + strings.Repeat("y", 43) +
+		"\n                 " + strings.Repeat("y", 43) +
+		"\n                 " + strings.Repeat("y", 34)
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPrintAttrTable_ColorsDoNotAffectPadding(t *testing.T) {
+	t.Parallel()
+	if !colorsBuild {
+		t.Skip("colors are always off in minimal builds")
+	}
+
+	plain := ae.NewPrinter(ae.NoPrintColors(), ae.NoPrintTimestamp(), ae.PrintAttrTable(), ae.PrintWidth(80)).Prints(attrTableErr())
+	colored := ae.NewPrinter(ae.PrintColors(), ae.NoPrintTimestamp(), ae.PrintAttrTable(), ae.PrintWidth(80)).Prints(attrTableErr())
+
+	if !strings.Contains(colored, "\x1b[") {
+		t.Fatal("colored output has no ANSI sequences")
+	}
+	if stripped := regexp.MustCompile("\x1b\\[[0-9;]*m").ReplaceAllString(colored, ""); stripped != plain {
+		t.Errorf("colored output differs once stripped:\n%s\nwant:\n%s", stripped, plain)
+	}
+}
+
+func threeGoroutinesErr() error {
+	frame := func(fn, file string, line int, inApp bool) *ae.StackFrame {
+		return &ae.StackFrame{Func: fn, File: file, Line: line, InApp: inApp}
+	}
+
+	return ae.New().StacksFrom(
+		&ae.Stack{ID: 1, State: "running", Frames: []*ae.StackFrame{
+			frame("example.com/app/db.query", "/src/app/db/query.go", 88, true),
+			frame("example.com/app/api.handle", "/src/app/api/handle.go", 21, true),
+		}},
+		&ae.Stack{ID: 7, State: "chan receive", Frames: []*ae.StackFrame{
+			frame("runtime.gopark", "/go/src/runtime/proc.go", 424, false),
+			frame("example.com/app/worker.loop", "/src/app/worker/loop.go", 42, true),
+		}},
+		&ae.Stack{ID: 9, State: "IO wait", Frames: []*ae.StackFrame{
+			frame("internal/poll.(*FD).Read", "/go/src/internal/poll/fd_unix.go", 165, false),
+			frame("net.(*conn).Read", "/go/src/net/net.go", 189, false),
+		}},
+	).Msg("query failed")
+}
+
+func TestPrintStackSummary_Text(t *testing.T) {
+	t.Parallel()
+
+	got := ae.NewPrinter(ae.NoPrintColors(), ae.NoPrintTimestamp(), ae.PrintStackSummary()).Prints(threeGoroutinesErr())
+	want := `[ERROR] query failed
+  stack      goroutine 1 [running]:
+             example.com/app/db.query()
+               /src/app/db/query.go:88
+             example.com/app/api.handle()
+               /src/app/api/handle.go:21
+             goroutine 7 [chan receive]: example.com/app/worker.loop (loop.go:42)
+             goroutine 9 [IO wait]: internal/poll.(*FD).Read (fd_unix.go:165)`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPrintStackSummary_UsesFrameFiltersAndExpands(t *testing.T) {
+	t.Parallel()
+
+	err := threeGoroutinesErr()
+	hidePoll := ae.PrintFrameFilters(func(f *ae.StackFrame) bool {
+		return strings.HasPrefix(f.Func, "internal/poll.")
+	})
+
+	got := ae.NewPrinter(ae.NoPrintColors(), ae.PrintStackSummary(), hidePoll).Prints(err)
+	if !strings.Contains(got, "goroutine 9 [IO wait]: net.(*conn).Read (net.go:189)") {
+		t.Errorf("summary does not skip filtered frames:\n%s", got)
+	}
+
+	full := ae.NewPrinter(ae.NoPrintColors(), ae.PrintStackSummary(), ae.PrintStacks()).Prints(err)
+	if !strings.Contains(full, "example.com/app/worker.loop()\n               /src/app/worker/loop.go:42") {
+		t.Errorf("PrintStacks does not expand the summary:\n%s", full)
+	}
+}
+
+func fieldStyleErr() error {
+	return ae.New().
+		Code("E_STYLE").
+		ExitCode(3).
+		Hint("check it").
+		RequestId("req-1").
+		TraceId("trace-1").
+		SpanId("span-1").
+		UserMsgLocale("de_AT", "Fehler").
+		Tag("some_tag").
+		Attr("user_id", 42).
+		Attr("nested", map[string]any{"inner_key": "inner_value"}).
+		StacksFrom(&ae.Stack{ID: 1, State: "running", FramesElided: true, Frames: []*ae.StackFrame{
+			{Func: "example.com/app.run", File: "/src/app/run.go", Line: 7, InApp: true},
+		}}).
+		Cause(ae.New().UserMsg("inner", "try later")).
+		UserMsg("outer", "Something failed")
+}
+
+// jsonKeys collects the paths of every object key in the JSON document s,
+// array indexes left out.
+func jsonKeys(t *testing.T, s string) map[string]bool {
+	t.Helper()
+
+	var doc any
+	if err := json.Unmarshal([]byte(s), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, s)
+	}
+
+	keys := make(map[string]bool)
+	var walk func(prefix string, v any)
+	walk = func(prefix string, v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for k, child := range v {
+				keys[prefix+k] = true
+				walk(prefix+k+".", child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(prefix, child)
+			}
+		}
+	}
+	walk("", doc)
+
+	return keys
+}
+
+func TestPrintJSONFieldStyle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		style ae.JSONFieldStyle
+		want  []string
+	}{
+		{ae.SnakeCase, []string{
+			"user_message", "user_messages.de_AT", "exit_code", "trace_id", "span_id", "request_id",
+			"attrs.user_id", "attrs.nested.inner_key", "causes.user_message",
+			"stacks.frames_elided", "stacks.elided_count", "stacks.frames.in_app",
+		}},
+		{ae.CamelCase, []string{
+			"userMessage", "userMessages.de_AT", "exitCode", "traceId", "spanId", "requestId",
+			"attrs.user_id", "attrs.nested.inner_key", "causes.userMessage",
+			"stacks.framesElided", "stacks.elidedCount", "stacks.frames.inApp",
+		}},
+	}
+	for _, tt := range tests {
+		out := ae.NewPrinter(ae.PrintJSON(), ae.PrintJSONFieldStyle(tt.style)).Prints(fieldStyleErr())
+		keys := jsonKeys(t, out)
+		for _, k := range tt.want {
+			if !keys[k] {
+				t.Errorf("style %d: key %s missing:\n%s", tt.style, k, out)
+			}
+		}
+	}
+
+	camel := ae.NewPrinter(ae.PrintJSON(), ae.PrintJSONFieldStyle(ae.CamelCase)).Prints(fieldStyleErr())
+	if !strings.Contains(camel, `"some_tag"`) || !strings.Contains(camel, `"inner_value"`) {
+		t.Errorf("CamelCase changed values:\n%s", camel)
+	}
+}
+
+func TestPrintJSONFieldStyle_Summary(t *testing.T) {
+	t.Parallel()
+
+	out := ae.NewPrinter(ae.PrintJSON(), ae.PrintSummary(), ae.PrintJSONFieldStyle(ae.CamelCase)).Prints(batchErr())
+	keys := jsonKeys(t, out)
+	for _, k := range []string{"summary.codes.DB_TIMEOUT", "summary.examples.NOT_FOUND", "summary.tags.db"} {
+		if !keys[k] {
+			t.Errorf("key %s missing:\n%s", k, out)
+		}
+	}
+}
+
+func TestPrintTimeLocation(t *testing.T) {
+	t.Parallel()
+
+	cest := time.FixedZone("CEST", 2*60*60)
+	err := ae.New().Timestamp(time.Date(2024, 5, 1, 14, 0, 0, 0, cest)).Msg("x")
+
+	tests := []struct {
+		name string
+		opt  ae.PrinterOption
+		want string
+	}{
+		{"as taken", ae.PrintTimeLocation(nil), "2024-05-01T14:00:00+02:00"},
+		{"utc", ae.PrintTimeUTC(), "2024-05-01T12:00:00Z"},
+		{"fixed zone", ae.PrintTimeLocation(time.FixedZone("EST", -5*60*60)), "2024-05-01T07:00:00-05:00"},
+	}
+	for _, tt := range tests {
+		out := ae.NewPrinter(ae.NoPrintColors(), tt.opt).Prints(err)
+		if !strings.Contains(out, "time       "+tt.want) {
+			t.Errorf("%s: output lacks %s:\n%s", tt.name, tt.want, out)
+		}
+	}
+
+	summary := ae.NewPrinter(ae.PrintJSON(), ae.PrintSummary(), ae.PrintTimeUTC()).Prints(ae.Wrap("batch", err))
+	if !strings.Contains(summary, `"first": "2024-05-01T12:00:00Z"`) {
+		t.Errorf("JSON summary not in UTC:\n%s", summary)
+	}
+}
+
+// relatedDepthErr has causes and related errors three levels deep.
+func relatedDepthErr() error {
+	cause := ae.New().Cause(ae.New().Cause(errors.New("cause-3")).Msg("cause-2")).Msg("cause-1")
+	related := ae.New().Cause(ae.New().Cause(errors.New("related-3")).Msg("related-2")).Msg("related-1")
+
+	return ae.New().Cause(cause).Related(related).Msg("outer")
+}
+
+func TestPrinter_PrintRelatedDepth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts []ae.PrinterOption
+		want []string
+		omit []string
+	}{
+		{
+			name: "default follows PrintDepth",
+			opts: []ae.PrinterOption{ae.PrintDepth(2)},
+			want: []string{"cause-2", "related-2"},
+			omit: []string{"cause-3", "related-3"},
+		},
+		{
+			name: "related shallower than causes",
+			opts: []ae.PrinterOption{ae.PrintRelatedDepth(1)},
+			want: []string{"cause-3", "related-1"},
+			omit: []string{"related-2"},
+		},
+		{
+			name: "related deeper than causes",
+			opts: []ae.PrinterOption{ae.PrintDepth(1), ae.PrintRelatedDepth(3)},
+			want: []string{"cause-1", "related-1"},
+			omit: []string{"cause-2", "related-2"},
+		},
+		{
+			name: "zero omits related",
+			opts: []ae.PrinterOption{ae.PrintRelatedDepth(0)},
+			want: []string{"cause-3"},
+			omit: []string{"related-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, format := range []ae.PrinterOption{ae.NoPrintJSON(), ae.PrintJSON()} {
+				opts := append([]ae.PrinterOption{ae.NoPrintColors(), format}, tt.opts...)
+				out := ae.NewPrinter(opts...).Prints(relatedDepthErr())
+
+				for _, s := range tt.want {
+					if !strings.Contains(out, s) {
+						t.Errorf("missing %s:\n%s", s, out)
+					}
+				}
+				for _, s := range tt.omit {
+					if strings.Contains(out, s) {
+						t.Errorf("unexpected %s:\n%s", s, out)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPrinter_PrintRelatedDepthTruncation(t *testing.T) {
+	t.Parallel()
+
+	out := ae.NewPrinter(ae.PrintJSON(), ae.PrintRelatedDepth(1)).Prints(relatedDepthErr())
+
+	var doc struct {
+		Related []struct {
+			Message string           `json:"message"`
+			Causes  []map[string]any `json:"causes"`
+		} `json:"related"`
+	}
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if len(doc.Related) != 1 || doc.Related[0].Message != "related-1" {
+		t.Fatalf("related = %+v, want related-1", doc.Related)
+	}
+	if doc.Related[0].Causes != nil {
+		t.Errorf("related causes = %v, want none past the related depth", doc.Related[0].Causes)
+	}
+}
+
+// nestedAttrsOf prints err as JSON with PrintNestedAttrs and returns its
+// decoded attributes.
+func nestedAttrsOf(t *testing.T, err error) map[string]any {
+	t.Helper()
+
+	out := ae.NewPrinter(ae.PrintJSON(), ae.PrintNestedAttrs()).Prints(err)
+	var doc struct {
+		Attrs map[string]any `json:"attrs"`
+	}
+	if jErr := json.Unmarshal([]byte(out), &doc); jErr != nil {
+		t.Fatalf("invalid JSON: %v\n%s", jErr, out)
+	}
+
+	return doc.Attrs
+}
+
+// flattenNestedAttrs reverses PrintNestedAttrs, joining nested keys with dots and
+// moving "_value" entries to the key of their object.
+func flattenNestedAttrs(out map[string]any, prefix string, nested map[string]any) {
+	for k, v := range nested {
+		key := prefix + k
+		if k == "_value" && prefix != "" {
+			key = strings.TrimSuffix(prefix, ".")
+		}
+		if obj, ok := v.(map[string]any); ok {
+			flattenNestedAttrs(out, key+".", obj)
+			continue
+		}
+		out[key] = v
+	}
+}
+
+func TestPrinter_PrintNestedAttrs(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().
+		Attr("http.method", "GET").
+		Attr("http.route", "/users/{id}").
+		Attr("db.statement", "SELECT 1").
+		Attr("db", "primary").
+		Attr("retries", 3).
+		Msg("request failed")
+
+	got, _ := json.Marshal(nestedAttrsOf(t, err))
+	want := `{"db":{"_value":"primary","statement":"SELECT 1"},"http":{"method":"GET","route":"/users/{id}"},"retries":3}`
+	if string(got) != want {
+		t.Errorf("attrs = %s\nwant     %s", got, want)
+	}
+
+	text := ae.NewPrinter(ae.NoPrintColors(), ae.PrintNestedAttrs()).Prints(err)
+	if !strings.Contains(text, "http.method") {
+		t.Errorf("text output changed:\n%s", text)
+	}
+}
+
+func TestPrinter_PrintNestedAttrsLossless(t *testing.T) {
+	t.Parallel()
+
+	segments := []string{"a", "b", "c", "_value", ""}
+	rng := rand.New(rand.NewPCG(1, 2))
+	for i := range 200 {
+		attrs := make(map[string]any)
+		for n := range 1 + rng.IntN(8) {
+			parts := make([]string, 1+rng.IntN(3))
+			for j := range parts {
+				parts[j] = segments[rng.IntN(len(segments))]
+			}
+			if key := strings.Join(parts, "."); key != "" {
+				attrs[key] = fmt.Sprintf("v%d", n)
+			}
+		}
+
+		got := make(map[string]any)
+		flattenNestedAttrs(got, "", nestedAttrsOf(t, ae.New().Attrs(attrs).Msg("x")))
+		if !maps.Equal(got, attrs) {
+			t.Fatalf("case %d: flatten(nest(%v)) = %v", i, attrs, got)
+		}
+	}
+}
+
+// threeLevelErr chains three errors with the given attributes, top first.
+func threeLevelErr(top, mid, leaf map[string]any) error {
+	return ae.New().Attrs(top).Cause(
+		ae.New().Attrs(mid).Cause(
+			ae.New().Attrs(leaf).Msg("leaf")).Msg("mid")).Msg("top")
+}
+
+func TestPrinter_PrintElideInheritedAttrs(t *testing.T) {
+	t.Parallel()
+
+	ctx := map[string]any{"request_id": "r-1", "tenant": "acme"}
+	tests := []struct {
+		name           string
+		top, mid, leaf map[string]any
+		want           string
+	}{
+		{"identical", ctx, ctx, ctx, `[ERROR] top
+  attrs      request_id  r-1
+             tenant      acme
+  caused by  mid
+                (2 inherited attributes)
+                └─ leaf
+                   (2 inherited attributes)`},
+		{"overlapping", ctx,
+			map[string]any{"request_id": "r-1", "tenant": "globex", "table": "orders"},
+			map[string]any{"request_id": "r-1", "tenant": "acme", "table": "orders", "attempt": 3},
+			`[ERROR] top
+  attrs      request_id  r-1
+             tenant      acme
+  caused by  mid
+                table   orders
+                tenant  globex
+                (1 inherited attributes)
+                └─ leaf
+                   attempt  3
+                   tenant   acme
+                   (2 inherited attributes)`},
+		{"disjoint", ctx,
+			map[string]any{"table": "orders"},
+			map[string]any{"attempt": 3},
+			`[ERROR] top
+  attrs      request_id  r-1
+             tenant      acme
+  caused by  mid
+                table  orders
+                └─ leaf
+                   attempt  3`},
+	}
+	for _, tt := range tests {
+		err := threeLevelErr(tt.top, tt.mid, tt.leaf)
+		got := ae.NewPrinter(ae.NoPrintColors(), ae.PrintDeterministic(), ae.NoPrintTimestamp(), ae.PrintElideInheritedAttrs()).Prints(err)
+		if got != tt.want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+
+		// the JSON output stays complete
+		js := ae.NewPrinter(ae.PrintJSON(), ae.PrintDeterministic(), ae.PrintElideInheritedAttrs()).Prints(err)
+		if full := ae.NewPrinter(ae.PrintJSON(), ae.PrintDeterministic()).Prints(err); js != full {
+			t.Errorf("%s: JSON output changed:\n%s\nwant:\n%s", tt.name, js, full)
+		}
+	}
+}
+
+func TestPrinter_PrintElideInheritedAttrsRelated(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().
+		Attr("request_id", "r-1").
+		Cause(
+			ae.New().Attr("request_id", "r-1").Msg("first"),
+			ae.New().Attr("request_id", "r-2").Msg("second")).
+		Related(ae.New().Attr("request_id", "r-1").Msg("cleanup")).
+		Msg("top")
+
+	got := ae.NewPrinter(ae.NoPrintColors(), ae.PrintDeterministic(), ae.NoPrintTimestamp(), ae.PrintElideInheritedAttrs()).Prints(err)
+	want := `[ERROR] top
+  attrs      request_id  r-1
+  caused by  ┬─ first
+             │  (1 inherited attributes)
+             └─ second
+                request_id  r-2
+  related    cleanup
+                (1 inherited attributes)`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// without attributes, nothing is printed for the nested errors
+	got = ae.NewPrinter(ae.NoPrintColors(), ae.PrintDeterministic(), ae.NoPrintAttributes(), ae.PrintElideInheritedAttrs()).Prints(err)
+	if strings.Contains(got, "request_id") || strings.Contains(got, "inherited") {
+		t.Errorf("attributes printed without PrintAttributes:\n%s", got)
+	}
+}
+
+// failingWriter fails every write with err.
+type failingWriter struct{ err error }
+
+func (w failingWriter) Write([]byte) (int, error) { return 0, w.err }
+
+func TestPrinter_PrintAlso(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Code("E_TEE").Attr("k", "v").Msg("boom")
+
+	var text, jsonOut strings.Builder
+	p := ae.NewPrinter(ae.NoPrintColors(), ae.PrintCompact(), ae.PrintAlso(&jsonOut, ae.PrintJSON(), ae.PrintVerbose()))
+	if werr := p.FprintErr(&text, err); werr != nil {
+		t.Fatalf("FprintErr: %v", werr)
+	}
+
+	if want := ae.NewPrinter(ae.NoPrintColors(), ae.PrintCompact()).Prints(err) + "\n"; text.String() != want {
+		t.Errorf("primary output = %q, want %q", text.String(), want)
+	}
+	if want := ae.NewPrinter(ae.PrintJSON(), ae.PrintVerbose()).Prints(err) + "\n"; jsonOut.String() != want {
+		t.Errorf("secondary output = %q, want %q", jsonOut.String(), want)
+	}
+	if !json.Valid([]byte(jsonOut.String())) {
+		t.Errorf("secondary output is not JSON:\n%s", jsonOut.String())
+	}
+	if got := p.Prints(err); got+"\n" != text.String() {
+		t.Errorf("Prints = %q, want the primary rendering only", got)
+	}
+}
+
+func TestPrinter_PrintAlsoFailureIsolation(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Msg("boom")
+	errDisk := errors.New("disk full")
+
+	var primary, last strings.Builder
+	p := ae.NewPrinter(
+		ae.NoPrintColors(),
+		ae.PrintAlso(failingWriter{errDisk}),
+		ae.PrintAlso(&last, ae.PrintJSON()),
+	)
+	werr := p.FprintErr(&primary, err)
+
+	if !errors.Is(werr, errDisk) {
+		t.Errorf("FprintErr error = %v, want %v", werr, errDisk)
+	}
+	if !strings.Contains(primary.String(), "boom") {
+		t.Errorf("primary output missing after a secondary failure: %q", primary.String())
+	}
+	if !strings.Contains(last.String(), `"boom"`) {
+		t.Errorf("later secondary output missing after a failure: %q", last.String())
+	}
+
+	var secondary strings.Builder
+	werr = ae.NewPrinter(ae.PrintAlso(&secondary)).FprintErr(failingWriter{errDisk}, err)
+	if !errors.Is(werr, errDisk) {
+		t.Errorf("FprintErr error = %v, want %v", werr, errDisk)
+	}
+	if !strings.Contains(secondary.String(), "boom") {
+		t.Errorf("secondary output missing after a primary failure: %q", secondary.String())
+	}
+}
+
+func TestPrinter_FprintWritesPrintAlso(t *testing.T) {
+	t.Parallel()
+
+	var primary, secondary strings.Builder
+	p := ae.NewPrinter(ae.NoPrintColors(), ae.NoPrintTimestamp(),
+		ae.PrintAlso(failingWriter{errors.New("disk full")}),
+		ae.PrintAlso(&secondary, ae.NoPrintTimestamp()))
+
+	// Fprint keeps its signature, so it still works as a method value
+	var fprint func(io.Writer, error) = p.Fprint
+	fprint(&primary, ae.New().Msg("boom"))
+
+	if primary.String() != "[ERROR] boom\n" || secondary.String() != "[ERROR] boom\n" {
+		t.Errorf("primary, secondary = %q, %q, want both written", primary.String(), secondary.String())
+	}
+}
@@ -1,99 +1,81 @@
 package ae
 
 import (
+	"bytes"
 	"fmt"
-	"sort"
+	"iter"
+	"maps"
+	"slices"
 	"strings"
-	"time"
-
-	"github.com/fatih/color"
-)
-
-// Color roles for text-mode rendering. Each call through Printer.fmt becomes a
-// no-op when Printer.colors is false — the formatted string is returned verbatim.
-// EnableColor is called on every instance so fatih/color does not second-guess
-// our decision based on its own TTY detection: the Printer.colors flag is the
-// single source of truth.
-var (
-	colBadge    = forceColor(color.New(color.FgRed, color.Bold))
-	colMsg      = forceColor(color.New(color.FgRed, color.Bold))
-	colCode     = forceColor(color.New(color.FgHiYellow))
-	colBrace    = forceColor(color.New(color.FgYellow))
-	colTag      = forceColor(color.New(color.FgHiMagenta))
-	colBracket  = forceColor(color.New(color.FgMagenta))
-	colLabel    = forceColor(color.New(color.FgCyan))
-	colHint     = forceColor(color.New(color.FgHiCyan))
-	colShown    = forceColor(color.New(color.FgWhite, color.Bold))
-	colDim      = forceColor(color.New(color.FgHiBlack))
-	colAttrKey  = forceColor(color.New(color.FgHiBlue))
-	colAttrVal  = forceColor(color.New(color.FgHiGreen))
-	colStackFn  = forceColor(color.New(color.FgHiYellow))
-	colStackLoc = forceColor(color.New(color.FgHiBlack))
-	colStackLn  = forceColor(color.New(color.FgYellow))
+	"unicode/utf8"
 )
 
-// forceColor returns c after calling EnableColor so fatih/color will emit ANSI
-// regardless of the package-level NoColor/TTY detection. The Printer.colors
-// flag still gates whether these instances get called at all.
-func forceColor(c *color.Color) *color.Color {
-	c.EnableColor()
-	return c
-}
-
 const (
 	// textLead is the indent before a section label.
 	textLead = "  "
-	// textLabelWidth is the padded width of the label column (fits "caused by").
-	textLabelWidth = 9
 	// textLabelGap is the spacing between label and value.
 	textLabelGap = "  "
 )
 
-// textContinuationPrefix is the column where multi-line content and wrapped
-// values begin — textLead + label column + gap, all spaces.
-var textContinuationPrefix = textLead +
-	strings.Repeat(" ", textLabelWidth) +
-	textLabelGap
-
 // fmt formats s with a...and colorizes with c when colors are enabled. It is the
 // single funnel for every piece of text so color-on and color-off produce the
 // same string content, only the ANSI wrapping changes.
-func (p *Printer) fmt(format string, c *color.Color, a ...any) string {
-	s := fmt.Sprintf(format, a...)
+func (p *Printer) fmt(format string, c *textColor, a ...any) string {
+	s := format
+	if len(a) > 0 || strings.IndexByte(format, '%') >= 0 {
+		s = fmt.Sprintf(format, a...)
+	}
 	if p.colors {
 		return c.Sprint(s)
 	}
 	return s
 }
 
+// write is fmt writing to buf, without the intermediate string when colors
+// are disabled.
+func (p *Printer) write(buf *bytes.Buffer, format string, c *textColor, a ...any) {
+	switch {
+	case p.colors:
+		buf.WriteString(p.fmt(format, c, a...))
+	case len(a) == 0 && strings.IndexByte(format, '%') < 0:
+		buf.WriteString(format)
+	default:
+		fmt.Fprintf(buf, format, a...)
+	}
+}
+
 // PrintErrorText renders err as a human-readable, labeled block. depth == 0
 // marks the top-level call; nested calls (via causes / related) render through
 // writeErrorTree using the inline form.
 // The returned string is NOT newline-terminated.
 func (p *Printer) PrintErrorText(err error, depth int) string {
-	var sb strings.Builder
-	p.writeHeader(&sb, err, depth == 0)
-	p.writeSections(&sb, err, depth)
+	sb := getPrintBuffer()
+	defer putPrintBuffer(sb)
+
+	p.writeHeader(sb, err, depth == 0)
+	if p.summary && depth == 0 {
+		p.writeSummary(sb, err)
+	} else {
+		p.writeSections(sb, err, depth)
+	}
 	return sb.String()
 }
 
 // writeHeader renders the first line: optional "[ERROR]" badge + inline summary.
-func (p *Printer) writeHeader(sb *strings.Builder, err error, topLevel bool) {
+func (p *Printer) writeHeader(sb *bytes.Buffer, err error, topLevel bool) {
 	if topLevel {
-		sb.WriteString(p.fmt("[ERROR]", colBadge))
+		p.write(sb, "%s", colBadge, p.labels.Error)
 		sb.WriteString(" ")
 	}
-	sb.WriteString(p.formatInlineError(err))
+	p.writeInlineError(sb, err)
 }
 
-// formatInlineError renders the compact one-line form of an error:
+// writeInlineError renders the compact one-line form of an error:
 //
 //	{CODE/EXIT} message [tags]
 //
 // Used for both the top-level header and nested errors inside trees.
-func (p *Printer) formatInlineError(err error) string {
-	var sb strings.Builder
-
+func (p *Printer) writeInlineError(sb *bytes.Buffer, err error) {
 	code := ""
 	exit := 0
 	if p.code {
@@ -103,68 +85,101 @@ func (p *Printer) formatInlineError(err error) string {
 		// ExitCode(err) defaults to 1 for any non-nil error; that conventional
 		// "error exit" is noise, so only render when the caller explicitly set
 		// a distinct non-default value.
-		if e := ExitCode(err); e > 1 {
+		if e := p.exitCodeOf(err); e > 1 {
 			exit = e
 		}
 	}
 	if code != "" || exit > 0 {
-		sb.WriteString(p.fmt("{", colBrace))
+		p.write(sb, "{", colBrace)
 		switch {
 		case code != "" && exit > 0:
-			sb.WriteString(p.fmt("%s", colCode, code))
-			sb.WriteString(p.fmt("/", colBrace))
-			sb.WriteString(p.fmt("%d", colCode, exit))
+			p.write(sb, "%s", colCode, code)
+			p.write(sb, "/", colBrace)
+			p.write(sb, "%d", colCode, exit)
 		case code != "":
-			sb.WriteString(p.fmt("%s", colCode, code))
+			p.write(sb, "%s", colCode, code)
 		default:
-			sb.WriteString(p.fmt("exit ", colBrace))
-			sb.WriteString(p.fmt("%d", colCode, exit))
+			p.write(sb, "%s ", colBrace, p.labels.Exit)
+			p.write(sb, "%d", colCode, exit)
 		}
-		sb.WriteString(p.fmt("}", colBrace))
+		p.write(sb, "}", colBrace)
 		sb.WriteString(" ")
 	}
 
 	if msg := Message(err); msg != "" {
-		sb.WriteString(p.fmt("%s", colMsg, msg))
+		p.write(sb, "%s", colMsg, msg)
 	} else {
-		sb.WriteString(p.fmt("(no message)", colDim))
+		p.write(sb, "%s", colDim, p.labels.NoMessage)
 	}
 
 	if p.tags {
-		if tags := Tags(err); len(tags) > 0 {
-			sort.Strings(tags)
-			sb.WriteString(" ")
-			sb.WriteString(p.fmt("[", colBracket))
-			for i, tag := range tags {
-				if i > 0 {
-					sb.WriteString(p.fmt(", ", colBracket))
-				}
-				sb.WriteString(p.fmt("%s", colTag, tag))
+		tags := tagSeq(err)
+		if p.tagGroups {
+			tags = slices.Values(groupTags(slices.Collect(tags)))
+		}
+
+		first := true
+		for tag := range tags {
+			if first {
+				sb.WriteString(" ")
+				p.write(sb, "[", colBracket)
+				first = false
+			} else {
+				p.write(sb, ", ", colBracket)
 			}
-			sb.WriteString(p.fmt("]", colBracket))
+			p.write(sb, "%s", colTag, tag)
+		}
+		if !first {
+			p.write(sb, "]", colBracket)
 		}
 	}
 
-	return sb.String()
+}
+
+// groupTags merges sorted tags sharing a namespace, the part before the first
+// "/", into one entry: db/conn and db/timeout become db/{conn, timeout}.
+// Tags alone in their namespace are kept as they are.
+func groupTags(tags []string) []string {
+	var grouped []string
+	for i := 0; i < len(tags); {
+		ns, _, ok := strings.Cut(tags[i], "/")
+		j := i + 1
+		for ok && j < len(tags) && strings.HasPrefix(tags[j], ns+"/") {
+			j++
+		}
+
+		if j-i < 2 {
+			grouped = append(grouped, tags[i])
+		} else {
+			names := make([]string, 0, j-i)
+			for _, tag := range tags[i:j] {
+				names = append(names, tag[len(ns)+1:])
+			}
+			grouped = append(grouped, ns+"/{"+strings.Join(names, ", ")+"}")
+		}
+		i = j
+	}
+
+	return grouped
 }
 
 // writeSections emits the labeled rows below the header.
-func (p *Printer) writeSections(sb *strings.Builder, err error, depth int) {
+func (p *Printer) writeSections(sb *bytes.Buffer, err error, depth int) {
 	if p.hint {
 		if h := Hint(err); h != "" {
-			p.writeRow(sb, "hint", p.fmt("%s", colHint, h))
+			p.writeRow(sb, p.labels.Hint, p.fmt("%s", colHint, h))
 		}
 	}
 
 	if p.userMsg {
 		if u := UserMessage(err); u != "" && u != Message(err) {
-			p.writeRow(sb, "shown", p.fmt("%s", colShown, u))
+			p.writeRow(sb, p.labels.Shown, p.fmt("%s", colShown, u))
 		}
 	}
 
 	if p.timestamp {
 		if t := Timestamp(err); !t.IsZero() {
-			p.writeRow(sb, "time", p.fmt("%s", colDim, t.Format(time.RFC3339)))
+			p.writeRow(sb, p.labels.Time, p.fmt("%s", colDim, p.formatTime(t)))
 		}
 	}
 
@@ -178,41 +193,52 @@ func (p *Printer) writeSections(sb *strings.Builder, err error, depth int) {
 		if p.spanId {
 			if id := SpanId(err); id != "" {
 				parts = append(parts,
-					p.fmt("span ", colLabel)+p.fmt("%s", colDim, id))
+					p.fmt("%s ", colLabel, p.labels.Span)+p.fmt("%s", colDim, id))
 			}
 		}
 		if len(parts) > 0 {
-			p.writeRow(sb, "trace", strings.Join(parts, "  "))
+			p.writeRow(sb, p.labels.Trace, strings.Join(parts, "  "))
 		}
 	}
 
-	if p.attributes {
-		if attrs := Attributes(err); len(attrs) > 0 {
-			p.writeAttrs(sb, attrs)
+	if p.requestId {
+		if id := RequestId(err); id != "" {
+			p.writeRow(sb, p.labels.Request, p.fmt("%s", colDim, id))
 		}
 	}
 
+	if p.attributes {
+		p.writeAttrs(sb, attrSeq(err))
+	}
+
+	// the attributes displayed above, which nested errors inherit under
+	// PrintElideInheritedAttrs
+	var inherited map[string]string
+	if p.attributes && p.elideInheritedAttrs {
+		inherited = inheritAttrs(nil, err)
+	}
+
 	if p.causes && (p.maxDepth < 0 || depth < p.maxDepth) {
-		if causes := Causes(err); len(causes) > 0 {
-			p.writeErrorTree(sb, "caused by", causes, depth+1)
+		if causes := printedCauses(err); len(causes) > 0 {
+			p.writeErrorTree(sb, p.labels.CausedBy, causes, depth+1, p.maxDepth, inherited)
 		}
 	}
 
-	if p.related {
+	if maxDepth := p.relatedMaxDepth(depth, p.maxDepth); p.related && (maxDepth < 0 || depth < maxDepth) {
 		if related := Related(err); len(related) > 0 {
-			p.writeErrorTree(sb, "related", related, depth+1)
+			p.writeErrorTree(sb, p.labels.Related, related, depth+1, maxDepth, inherited)
 		}
 	}
 
 	if p.stacks {
-		if stacks := Stacks(err); len(stacks) > 0 {
+		if stacks := p.printableStacks(Stacks(err)); len(stacks) > 0 {
 			p.writeStacks(sb, stacks)
 		}
 	}
 }
 
 // writeRow writes a single labeled row on its own line.
-func (p *Printer) writeRow(sb *strings.Builder, label, value string) {
+func (p *Printer) writeRow(sb *bytes.Buffer, label, value string) {
 	sb.WriteString("\n")
 	sb.WriteString(p.labelPrefix(label))
 	sb.WriteString(value)
@@ -220,44 +246,93 @@ func (p *Printer) writeRow(sb *strings.Builder, label, value string) {
 
 // labelPrefix returns the prefix for the first line of a labeled block:
 // leading indent + colored left-padded label + label gap. Its visual width
-// matches textContinuationPrefix so subsequent lines align cleanly under it.
+// matches p.continuation so subsequent lines align cleanly under it.
 func (p *Printer) labelPrefix(label string) string {
-	return textLead + p.fmt("%-*s", colLabel, textLabelWidth, label) + textLabelGap
+	return textLead + p.fmt("%-*s", colLabel, p.labelWidth, label) + textLabelGap
+}
+
+// attrPair is a single attribute, as collected for sorting.
+type attrPair struct {
+	key   string
+	value any
 }
 
 // writeAttrs writes attributes sorted by key. The first pair shares the line
 // with the "attrs" label so the block stays visually connected; subsequent
-// pairs align under the first at textContinuationPrefix.
-func (p *Printer) writeAttrs(sb *strings.Builder, attrs map[string]any) {
-	keys := make([]string, 0, len(attrs))
-	for k := range attrs {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
+// pairs align under the first at p.continuation.
+func (p *Printer) writeAttrs(sb *bytes.Buffer, attrs iter.Seq2[string, any]) {
+	var pairs []attrPair
 	maxKey := 0
-	for _, k := range keys {
-		if len(k) > maxKey {
-			maxKey = len(k)
-		}
+	for k, v := range attrs {
+		pairs = append(pairs, attrPair{k, v})
+		maxKey = max(maxKey, len(k))
 	}
+	slices.SortFunc(pairs, func(a, b attrPair) int { return strings.Compare(a.key, b.key) })
 
-	for i, k := range keys {
+	for i, pair := range pairs {
 		sb.WriteString("\n")
 		if i == 0 {
-			sb.WriteString(p.labelPrefix("attrs"))
+			sb.WriteString(p.labelPrefix(p.labels.Attrs))
 		} else {
-			sb.WriteString(textContinuationPrefix)
+			sb.WriteString(p.continuation)
 		}
-		sb.WriteString(p.fmt("%-*s", colAttrKey, maxKey, k))
+		p.write(sb, "%-*s", colAttrKey, maxKey, pair.key)
 		sb.WriteString("  ")
-		sb.WriteString(p.fmt("%v", colAttrVal, attrs[k]))
+		if !p.attrTable {
+			p.write(sb, "%v", colAttrVal, pair.value)
+			continue
+		}
+
+		// the value column; continuation lines are padded up to it
+		valueCol := len(p.continuation) + maxKey + 2
+		wrapWidth := 0
+		if p.width > 0 {
+			wrapWidth = max(p.width-valueCol, minAttrValueWidth)
+		}
+		for j, line := range wrapText(fmt.Sprintf("%v", pair.value), wrapWidth) {
+			if j > 0 {
+				sb.WriteString("\n")
+				sb.WriteString(strings.Repeat(" ", valueCol))
+			}
+			p.write(sb, "%s", colAttrVal, line)
+		}
 	}
 }
 
+// minAttrValueWidth is the narrowest column attribute values are wrapped to,
+// however long the keys.
+const minAttrValueWidth = 20
+
+// wrapText splits s into lines at its newlines and breaks lines longer than
+// width runes, at the last space before width if there is one. width <= 0
+// only splits at newlines.
+func wrapText(s string, width int) []string {
+	var lines []string
+	for line := range strings.SplitSeq(s, "\n") {
+		for width > 0 && utf8.RuneCountInString(line) > width {
+			cut := 0
+			for range width {
+				_, size := utf8.DecodeRuneInString(line[cut:])
+				cut += size
+			}
+
+			if sp := strings.LastIndexByte(line[:cut], ' '); sp > 0 {
+				lines = append(lines, line[:sp])
+				line = line[sp+1:]
+			} else {
+				lines = append(lines, line[:cut])
+				line = line[cut:]
+			}
+		}
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
 // writeErrorTree prints a tree of errors (used for "caused by" and "related").
 // The first top-level branch shares the line with the label; subsequent
-// siblings and all nested children sit at textContinuationPrefix with the
+// siblings and all nested children sit at p.continuation with the
 // accumulated branch-accumulator for correct tree alignment.
 //
 // Glyph rules:
@@ -272,11 +347,15 @@ func (p *Printer) writeAttrs(sb *strings.Builder, attrs map[string]any) {
 //   - First of multiple nested: "├─" — its up-stroke correctly lands on the
 //     parent's down-stem, so the tree stays connected.
 //   - Middle: "├─", last: "└─".
-func (p *Printer) writeErrorTree(sb *strings.Builder, label string, errs []error, depth int) {
-	p.writeErrorTreeRec(sb, label, errs, depth, "", true)
+//
+// Under PrintElideInheritedAttrs each error is followed by its attributes
+// that differ from those inherited, the attributes its ancestors displayed
+// by key; the others are counted in a dim note.
+func (p *Printer) writeErrorTree(sb *bytes.Buffer, label string, errs []error, depth, maxDepth int, inherited map[string]string) {
+	p.writeErrorTreeRec(sb, label, errs, depth, maxDepth, "", true, inherited)
 }
 
-func (p *Printer) writeErrorTreeRec(sb *strings.Builder, label string, errs []error, depth int, branchAccum string, topLevel bool) {
+func (p *Printer) writeErrorTreeRec(sb *bytes.Buffer, label string, errs []error, depth, maxDepth int, branchAccum string, topLevel bool, inherited map[string]string) {
 	single := len(errs) == 1
 
 	for i, e := range errs {
@@ -313,99 +392,118 @@ func (p *Printer) writeErrorTreeRec(sb *strings.Builder, label string, errs []er
 		if label != "" && isFirst {
 			sb.WriteString(p.labelPrefix(label))
 		} else {
-			sb.WriteString(textContinuationPrefix)
+			sb.WriteString(p.continuation)
 		}
 		sb.WriteString(branchAccum)
 		sb.WriteString(glyph)
-		sb.WriteString(p.formatInlineError(e))
+		p.writeInlineError(sb, e)
 
 		if p.hint {
 			if h := Hint(e); h != "" {
 				sb.WriteString(" ")
-				sb.WriteString(p.fmt("(%s)", colHint, h))
+				p.write(sb, "(%s)", colHint, h)
 			}
 		}
 
-		if p.maxDepth < 0 || depth < p.maxDepth {
-			if nested := Causes(e); len(nested) > 0 {
-				p.writeErrorTreeRec(sb, "", nested, depth+1, nextAccum, false)
+		nestedInherited := inherited
+		if p.attributes && p.elideInheritedAttrs {
+			nestedInherited = p.writeTreeAttrs(sb, p.continuation+nextAccum, e, inherited)
+		}
+
+		if maxDepth < 0 || depth < maxDepth {
+			if nested := printedCauses(e); len(nested) > 0 {
+				if depth >= traversalDepth() {
+					sb.WriteString("\n")
+					sb.WriteString(p.continuation)
+					sb.WriteString(nextAccum)
+					p.write(sb, "└─ %s", colDim, p.labels.Truncated)
+					continue
+				}
+				p.writeErrorTreeRec(sb, "", nested, depth+1, maxDepth, nextAccum, false, nestedInherited)
 			}
 		}
 	}
 }
 
-// writeStacks prints captured goroutine stacks. The first goroutine header
-// shares the line with the "stack" label; frames indent two columns further
-// so the hierarchy is visually obvious. Frames are filtered through
-// p.frameFilters — any frame for which a filter returns true is dropped, and
-// a goroutine whose frames are all filtered out is omitted entirely.
-func (p *Printer) writeStacks(sb *strings.Builder, stacks []*Stack) {
-	frameIndent := textContinuationPrefix + "  "
-
-	first := true
-	for _, st := range stacks {
-		frames := p.filterFrames(st.Frames)
-		if len(frames) == 0 {
+// writeTreeAttrs writes the attributes of err, nested in a tree, that differ
+// from inherited, one per line after indent, and a note counting the others.
+// It returns the attributes the nested errors of err inherit.
+func (p *Printer) writeTreeAttrs(sb *bytes.Buffer, indent string, err error, inherited map[string]string) map[string]string {
+	var pairs []attrPair
+	maxKey, elided := 0, 0
+	for k, v := range attrSeq(err) {
+		if value, ok := inherited[k]; ok && value == fmt.Sprint(v) {
+			elided++
 			continue
 		}
+		pairs = append(pairs, attrPair{k, v})
+		maxKey = max(maxKey, len(k))
+	}
+	slices.SortFunc(pairs, func(a, b attrPair) int { return strings.Compare(a.key, b.key) })
 
+	for _, pair := range pairs {
 		sb.WriteString("\n")
-		if first {
-			sb.WriteString(p.labelPrefix("stack"))
-			first = false
-		} else {
-			sb.WriteString(textContinuationPrefix)
-		}
-		sb.WriteString(p.fmt("goroutine %d (%s)", colDim, st.ID, st.State))
-		if st.Locked {
-			sb.WriteString(p.fmt(" [locked]", colDim))
-		}
-		if st.Wait > 0 {
-			sb.WriteString(p.fmt(" [wait=%s]", colDim, st.Wait))
-		}
+		sb.WriteString(indent)
+		p.write(sb, "%-*s", colAttrKey, maxKey, pair.key)
+		sb.WriteString("  ")
+		p.write(sb, "%v", colAttrVal, pair.value)
+	}
+	if elided > 0 {
+		sb.WriteString("\n")
+		sb.WriteString(indent)
+		p.write(sb, p.labels.InheritedAttrs, colDim, elided)
+	}
 
-		maxFn := 0
-		for _, f := range frames {
-			if len(f.Func) > maxFn {
-				maxFn = len(f.Func)
-			}
-		}
-		for _, f := range frames {
-			sb.WriteString("\n")
-			sb.WriteString(frameIndent)
-			sb.WriteString(p.fmt("%-*s", colStackFn, maxFn, f.Func))
-			sb.WriteString(p.fmt("  at  ", colDim))
-			sb.WriteString(p.fmt("%s", colStackLoc, f.File))
-			sb.WriteString(p.fmt(":", colDim))
-			sb.WriteString(p.fmt("%d", colStackLn, f.Line))
-		}
+	if len(pairs) == 0 {
+		return inherited
+	}
+	return inheritAttrs(inherited, err)
+}
 
-		if st.FramesElided {
-			sb.WriteString("\n")
-			sb.WriteString(frameIndent)
-			sb.WriteString(p.fmt("(frames elided)", colDim))
-		}
+// inheritAttrs returns inherited overlaid with the attributes of err, as
+// rendered, for the nested errors of err.
+func inheritAttrs(inherited map[string]string, err error) map[string]string {
+	attrs := maps.Clone(inherited)
+	if attrs == nil {
+		attrs = make(map[string]string)
+	}
+	for k, v := range attrSeq(err) {
+		attrs[k] = fmt.Sprint(v)
 	}
+
+	return attrs
 }
 
-// filterFrames returns the subset of frames that survive every predicate in
-// p.frameFilters — a frame is kept only when every filter returns false.
-func (p *Printer) filterFrames(frames []*StackFrame) []*StackFrame {
-	if len(p.frameFilters) == 0 {
-		return frames
-	}
-	kept := make([]*StackFrame, 0, len(frames))
-	for _, f := range frames {
-		drop := false
-		for _, filter := range p.frameFilters {
-			if filter(f) {
-				drop = true
-				break
-			}
+// writeStacks prints captured goroutine stacks, rendered by the same code as
+// Stack.Format so the two can't diverge. The first goroutine header shares
+// the line with the "stack" label; the remaining lines align under it, with
+// frame locations indented two columns further. Frames are filtered through
+// p.frameFilters — any frame for which a filter returns true is dropped, and
+// a goroutine whose frames are all filtered out is omitted entirely. Under
+// PrintStackSummary goroutines after the first take a single line.
+func (p *Printer) writeStacks(sb *bytes.Buffer, stacks []*Stack) {
+	f := newStackFormat(p.stackOpts)
+	f.filters = append(f.filters, p.frameFilters...)
+	f.locIndent = "  "
+	f.paint = func(s string, c *textColor) string {
+		return p.fmt("%s", c, s)
+	}
+
+	first := true
+	for i, st := range stacks {
+		lines := f.lines(st)
+		if p.stackSummary && i > 0 {
+			lines = f.summaryLines(st)
 		}
-		if !drop {
-			kept = append(kept, f)
+		for _, line := range lines {
+			sb.WriteString("\n")
+			if first {
+				sb.WriteString(p.labelPrefix(p.labels.Stack))
+				first = false
+			} else {
+				sb.WriteString(p.continuation)
+			}
+			sb.WriteString(line)
 		}
 	}
-	return kept
 }
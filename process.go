@@ -0,0 +1,72 @@
+package ae
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Attributes stamped onto errors by SetCaptureProcessInfo and
+// Builder.ProcessInfo.
+const (
+	AttrHostName         = "host.name"
+	AttrProcessPID       = "process.pid"
+	AttrProcessStartTime = "process.start_time"
+	AttrGoroutineID      = "goroutine.id"
+)
+
+// captureProcessInfo is set with SetCaptureProcessInfo.
+var captureProcessInfo atomic.Bool
+
+// processStart approximates the start of the process by the initialization
+// of the package.
+var processStart = time.Now().UTC().Format(time.RFC3339Nano)
+
+// hostname and pid are looked up once, when first stamped. An empty hostname
+// means it couldn't be determined.
+var (
+	hostname = sync.OnceValue(func() string {
+		name, _ := os.Hostname()
+		return name
+	})
+	pid = sync.OnceValue(os.Getpid)
+)
+
+// SetCaptureProcessInfo enables stamping every error finalized by a Builder
+// with the attributes host.name, process.pid, process.start_time and
+// goroutine.id, to tell which instance and goroutine produced it, see
+// Builder.ProcessInfo. Disabled by default.
+func SetCaptureProcessInfo(enabled bool) {
+	captureProcessInfo.Store(enabled)
+}
+
+// ProcessInfo stamps the error with the attributes host.name, process.pid,
+// process.start_time and goroutine.id, as SetCaptureProcessInfo does for
+// every error. The hostname and PID are looked up once per process, the start
+// time is when the ae package was initialized, and the goroutine is the
+// calling one. Attributes set on the error itself win over the stamped ones.
+func (b Builder) ProcessInfo() Builder {
+	b.stampProcessInfo()
+	return b
+}
+
+// stampProcessInfo adds the process attributes the error doesn't have yet.
+func (b *Builder) stampProcessInfo() {
+	for _, attr := range [...]struct {
+		key   string
+		value func() any
+	}{
+		{AttrHostName, func() any { return hostname() }},
+		{AttrProcessPID, func() any { return pid() }},
+		{AttrProcessStartTime, func() any { return processStart }},
+		{AttrGoroutineID, func() any { return currentGoroutineID() }},
+	} {
+		if _, ok := b.attributes[attr.key]; ok {
+			continue
+		}
+		if v := attr.value(); v != "" && v != 0 {
+			b.setAttr(attr.key, v)
+		}
+	}
+}
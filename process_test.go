@@ -0,0 +1,100 @@
+package ae_test
+
+import (
+	"os"
+	"testing"
+	"time"
+	"unsafe"
+
+	"go.aledante.io/ae"
+)
+
+func setCaptureProcessInfo(t *testing.T, enabled bool) {
+	t.Helper()
+
+	ae.SetCaptureProcessInfo(enabled)
+	withPackageState(t, func() { ae.SetCaptureProcessInfo(false) })
+}
+
+func TestSetCaptureProcessInfo_DefaultOff(t *testing.T) {
+	attrs := ae.Attributes(ae.New().Msg("x"))
+	for _, key := range []string{ae.AttrHostName, ae.AttrProcessPID, ae.AttrProcessStartTime, ae.AttrGoroutineID} {
+		if _, ok := attrs[key]; ok {
+			t.Errorf("%s stamped by default", key)
+		}
+	}
+}
+
+func TestSetCaptureProcessInfo_Stamps(t *testing.T) {
+	setCaptureProcessInfo(t, true)
+
+	attrs := ae.Attributes(ae.Wrap("outer", ae.New().Msg("inner")))
+
+	if host, err := os.Hostname(); err == nil && attrs[ae.AttrHostName] != host {
+		t.Errorf("host.name = %v, want %q", attrs[ae.AttrHostName], host)
+	}
+	if attrs[ae.AttrProcessPID] != os.Getpid() {
+		t.Errorf("process.pid = %v, want %d", attrs[ae.AttrProcessPID], os.Getpid())
+	}
+	start, err := time.Parse(time.RFC3339Nano, attrs[ae.AttrProcessStartTime].(string))
+	if err != nil || start.After(time.Now()) {
+		t.Errorf("process.start_time = %v (%v)", attrs[ae.AttrProcessStartTime], err)
+	}
+	if id, ok := attrs[ae.AttrGoroutineID].(int); !ok || id <= 0 {
+		t.Errorf("goroutine.id = %v", attrs[ae.AttrGoroutineID])
+	}
+
+	ae.SetCaptureProcessInfo(false)
+	if attrs := ae.Attributes(ae.New().Msg("x")); len(attrs) != 0 {
+		t.Errorf("attributes after disabling = %v, want none", attrs)
+	}
+}
+
+func TestSetCaptureProcessInfo_Cached(t *testing.T) {
+	setCaptureProcessInfo(t, true)
+
+	first, _ := ae.Attributes(ae.New().Msg("a"))[ae.AttrHostName].(string)
+	second, _ := ae.Attributes(ae.New().Msg("b"))[ae.AttrHostName].(string)
+	if first == "" {
+		t.Skip("hostname unavailable")
+	}
+
+	// the hostname is looked up once, not per error
+	if unsafe.StringData(first) != unsafe.StringData(second) {
+		t.Error("hostname looked up again for the second error")
+	}
+	if a, b := ae.Attributes(ae.New().Msg("a"))[ae.AttrProcessStartTime], ae.Attributes(ae.New().Msg("b"))[ae.AttrProcessStartTime]; a != b {
+		t.Errorf("process.start_time changed: %v, %v", a, b)
+	}
+}
+
+func TestSetCaptureProcessInfo_GoroutineID(t *testing.T) {
+	setCaptureProcessInfo(t, true)
+
+	here := ae.Attributes(ae.New().Msg("here"))[ae.AttrGoroutineID]
+	done := make(chan any)
+	go func() { done <- ae.Attributes(ae.New().Msg("there"))[ae.AttrGoroutineID] }()
+
+	if there := <-done; there == here {
+		t.Errorf("goroutine.id = %v in both goroutines", here)
+	}
+}
+
+func TestSetCaptureProcessInfo_ErrorAttrsWin(t *testing.T) {
+	setCaptureProcessInfo(t, true)
+
+	attrs := ae.Attributes(ae.New().Attr(ae.AttrHostName, "replica-7").Msg("x"))
+	if attrs[ae.AttrHostName] != "replica-7" {
+		t.Errorf("host.name = %v, want the error's own", attrs[ae.AttrHostName])
+	}
+}
+
+func TestBuilder_ProcessInfo(t *testing.T) {
+	attrs := ae.Attributes(ae.New().ProcessInfo().Msg("x"))
+	if attrs[ae.AttrProcessPID] != os.Getpid() {
+		t.Errorf("process.pid = %v, want %d", attrs[ae.AttrProcessPID], os.Getpid())
+	}
+	if _, ok := attrs[ae.AttrGoroutineID]; !ok {
+		t.Error("goroutine.id missing")
+	}
+}
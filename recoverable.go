@@ -18,19 +18,8 @@ type ErrorRecoverable interface {
 //
 // If any error in the chain implements ErrorRecoverable and its ErrorIsRecoverable() returns false, then the overall error is not recoverable.
 func IsRecoverable(err error) bool {
-	if err == nil {
-		return true
-	}
-
-	if ae, ok := err.(ErrorRecoverable); ok && !ae.ErrorIsRecoverable() {
-		return false
-	}
-
-	for _, cause := range Causes(err) {
-		if !IsRecoverable(cause) {
-			return false
-		}
-	}
-
-	return true
+	return !walkCauses(err, func(err error) bool {
+		x, ok := err.(ErrorRecoverable)
+		return !ok || x.ErrorIsRecoverable()
+	})
 }
@@ -1,5 +1,10 @@
 package ae
 
+import (
+	"errors"
+	"reflect"
+)
+
 // ErrorRelated defines an interface for errors that can provide a list of related errors.
 // Related errors are those that are not direct causes but are somehow connected to the error,
 // including errors that occurred during the handling of the cause(s).
@@ -24,3 +29,33 @@ func Related(err error) []error {
 
 	return nil
 }
+
+// IsRelated reports whether any error related to err, or to any error in
+// err's cause tree, matches target according to errors.Is. errors.Is itself
+// only follows causes and never considers related errors.
+func IsRelated(err, target error) bool {
+	return walkCauses(err, func(err error) bool {
+		for _, related := range Related(err) {
+			if errors.Is(related, target) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// containsError reports whether errs contains err itself, compared with ==.
+// Nil and errors of non-comparable types are never found.
+func containsError(errs []error, err error) bool {
+	if err == nil || !reflect.TypeOf(err).Comparable() {
+		return false
+	}
+
+	for _, e := range errs {
+		if e == err {
+			return true
+		}
+	}
+
+	return false
+}
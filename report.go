@@ -0,0 +1,114 @@
+package ae
+
+import (
+	"slices"
+	"strings"
+)
+
+// reportGenericMessage is reported when no error in the tree has a user
+// message.
+const reportGenericMessage = "An unexpected error occurred."
+
+// reportRefLen is the length of the reference derived from the fingerprint.
+const reportRefLen = 8
+
+// ReportOption configures UserReport.
+type ReportOption func(*reportOptions)
+
+type reportOptions struct {
+	code    bool
+	maxLen  int
+	locales []string
+}
+
+// ReportCode includes the code of the error, or of the first error in its
+// cause tree that has one, in the report.
+func ReportCode() ReportOption {
+	return func(o *reportOptions) {
+		o.code = true
+	}
+}
+
+// ReportMaxLen limits the report to n bytes; a longer report is cut and ends
+// with "…". A non-positive n means unlimited, the default.
+func ReportMaxLen(n int) ReportOption {
+	return func(o *reportOptions) {
+		o.maxLen = n
+	}
+}
+
+// ReportLocale selects the translated user messages to report, see
+// UserMessageFor.
+func ReportLocale(locales ...string) ReportOption {
+	return func(o *reportOptions) {
+		o.locales = append(o.locales, locales...)
+	}
+}
+
+// UserReport renders err for end users: its user message, the hints found in
+// its cause tree and a short reference to quote to support, one per line:
+//
+//	Something went wrong. Please try again.
+//	Hint: Check your network connection.
+//	Reference: 3f2a9c1b
+//
+// The user message is that of the first error in the cause tree that has
+// one, searched depth-first, or a generic message if none has. The
+// reference is the start of the Fingerprint. Internal messages, attributes,
+// stacks and trace IDs are never included. Returns "" if err is nil.
+func UserReport(err error, opts ...ReportOption) string {
+	if err == nil {
+		return ""
+	}
+
+	o := &reportOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return userReport(err, o)
+}
+
+// userReport renders the UserReport of the non-nil err.
+func userReport(err error, o *reportOptions) string {
+	var (
+		msg   string
+		code  string
+		hints []string
+	)
+	walkCauses(err, func(err error) bool {
+		if msg == "" {
+			msg = UserMessageFor(err, o.locales...)
+		}
+		if code == "" {
+			code = Code(err)
+		}
+		if h := Hint(err); h != "" && !slices.Contains(hints, h) {
+			hints = append(hints, h)
+		}
+		return true
+	})
+	if msg == "" {
+		msg = reportGenericMessage
+	}
+
+	var sb strings.Builder
+	sb.WriteString(msg)
+	for _, h := range hints {
+		sb.WriteString("\nHint: ")
+		sb.WriteString(h)
+	}
+	if o.code && code != "" {
+		sb.WriteString("\nCode: ")
+		sb.WriteString(code)
+	}
+	sb.WriteString("\nReference: ")
+	sb.WriteString(truncateUTF8(Fingerprint(err), reportRefLen))
+
+	report := sb.String()
+	if o.maxLen > 0 && len(report) > o.maxLen {
+		report = truncateUTF8(report, max(o.maxLen-len("…"), 0)) + "…"
+	}
+
+	return report
+}
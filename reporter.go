@@ -0,0 +1,213 @@
+package ae
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Reporter sends errors to an error tracker, a log file or another sink
+// outside the process, see RegisterReporter.
+type Reporter interface {
+	// Report delivers err. It should give up when ctx is done.
+	Report(ctx context.Context, err error) error
+}
+
+// ReporterFunc adapts a function to the Reporter interface.
+type ReporterFunc func(ctx context.Context, err error) error
+
+// Report calls f(ctx, err).
+func (f ReporterFunc) Report(ctx context.Context, err error) error {
+	return f(ctx, err)
+}
+
+// CodeReportFailed is the code of the errors returned by Report when a
+// reporter fails.
+const CodeReportFailed = "REPORT_FAILED"
+
+// registeredReporter wraps a reporter so it can be identified for removal.
+type registeredReporter struct {
+	r Reporter
+}
+
+var (
+	reportersMu sync.Mutex
+	reporters   []*registeredReporter
+
+	// reportTimeout bounds Report, in nanoseconds.
+	reportTimeout atomic.Int64
+)
+
+func init() {
+	reportTimeout.Store(int64(5 * time.Second))
+}
+
+// RegisterReporter registers r to be called by Report, and therefore with the
+// error passed to Exit (and PrintExit and Main) before the process exits, so
+// fatal errors reach the error tracker before the process dies. Reporters run
+// in registration order. Registration is meant for init time; the returned
+// function unregisters the reporter.
+func RegisterReporter(r Reporter) (remove func()) {
+	if r == nil {
+		return func() {}
+	}
+
+	reg := &registeredReporter{r: r}
+
+	reportersMu.Lock()
+	defer reportersMu.Unlock()
+	reporters = append(reporters, reg)
+
+	return func() {
+		reportersMu.Lock()
+		defer reportersMu.Unlock()
+
+		for i, other := range reporters {
+			if other == reg {
+				reporters = append(reporters[:i:i], reporters[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// SetReportTimeout sets how long Report waits for all reporters together.
+// The default is 5 seconds; d <= 0 waits indefinitely.
+func SetReportTimeout(d time.Duration) {
+	reportTimeout.Store(int64(d))
+}
+
+// Report calls every reporter registered with RegisterReporter with err, in
+// registration order, each after the previous one returned. The reporters
+// share a context derived from ctx and bounded by the timeout set with
+// SetReportTimeout: a reporter still running when it is done is abandoned,
+// and the reporters after it are skipped. A reporter that panics is
+// recovered.
+//
+// Returns nil if every reporter succeeded, or an error with the code
+// CodeReportFailed and the failures as related errors. Does nothing if err is
+// nil.
+func Report(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	reportersMu.Lock()
+	regs := append([]*registeredReporter(nil), reporters...)
+	reportersMu.Unlock()
+	if len(regs) == 0 {
+		return nil
+	}
+
+	if timeout := time.Duration(reportTimeout.Load()); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var failures []error
+	for i, reg := range regs {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			failures = append(failures, fmt.Errorf("reporter %d (%T) skipped: %w", i+1, reg.r, ctxErr))
+			continue
+		}
+		if rerr := runReporter(ctx, reg.r, err); rerr != nil {
+			failures = append(failures, fmt.Errorf("reporter %d (%T): %w", i+1, reg.r, rerr))
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+
+	return New().
+		Code(CodeReportFailed).
+		Related(failures...).
+		Msgf("%d of %d reporters failed", len(failures), len(regs))
+}
+
+// runReporter calls r, returning early with the error of ctx when it is done
+// before r returns.
+func runReporter(ctx context.Context, r Reporter, err error) error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("panicked: %v", p)
+			}
+		}()
+
+		done <- r.Report(ctx, err)
+	}()
+
+	select {
+	case rerr := <-done:
+		return rerr
+	case <-ctx.Done():
+		return fmt.Errorf("did not finish: %w", ctx.Err())
+	}
+}
+
+// reportOnExit reports err for Exit, logging failures to stderr: the process
+// exits with the exit code of err whatever the reporters do.
+func reportOnExit(err error) {
+	rerr := Report(context.Background(), err)
+	if rerr == nil {
+		return
+	}
+
+	msgs := []string{Message(rerr)}
+	for _, failure := range Related(rerr) {
+		msgs = append(msgs, failure.Error())
+	}
+	fmt.Fprintf(os.Stderr, "ae: %s\n", strings.Join(msgs, "; "))
+}
+
+// FileReporter is a Reporter appending every reported error to a file as one
+// line of JSON, as rendered by PrintJSON, e.g. for a log shipper to pick up.
+// It is safe for concurrent use.
+type FileReporter struct {
+	path    string
+	printer *Printer
+	mu      sync.Mutex
+}
+
+// NewFileReporter returns a FileReporter appending to the file at path,
+// which is created if it doesn't exist. The errors are rendered with every
+// field, see PrintVerbose; opts are applied on top.
+func NewFileReporter(path string, opts ...PrinterOption) *FileReporter {
+	opts = append([]PrinterOption{PrintVerbose()}, opts...)
+	opts = append(opts, PrintJSON(), NoPrintColors())
+
+	return &FileReporter{path: path, printer: NewPrinter(opts...)}
+}
+
+// Report appends err to the file as a line of JSON.
+func (r *FileReporter) Report(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	var line bytes.Buffer
+	if cerr := json.Compact(&line, []byte(r.printer.Prints(err))); cerr != nil {
+		return cerr
+	}
+	line.WriteByte('\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ferr := os.OpenFile(r.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if ferr != nil {
+		return ferr
+	}
+	_, werr := f.Write(line.Bytes())
+
+	return errors.Join(werr, f.Close())
+}
@@ -0,0 +1,186 @@
+package ae_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.aledante.io/ae"
+)
+
+// recordReporter returns a reporter appending name to *calls.
+func recordReporter(mu *sync.Mutex, calls *[]string, name string) ae.Reporter {
+	return ae.ReporterFunc(func(context.Context, error) error {
+		mu.Lock()
+		defer mu.Unlock()
+		*calls = append(*calls, name)
+		return nil
+	})
+}
+
+func TestReport_Order(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	for _, name := range []string{"first", "second", "third"} {
+		withPackageState(t, ae.RegisterReporter(recordReporter(&mu, &calls, name)))
+	}
+
+	if err := ae.Report(context.Background(), ae.New().Msg("boom")); err != nil {
+		t.Fatalf("Report = %v, want nil", err)
+	}
+	if got := strings.Join(calls, ","); got != "first,second,third" {
+		t.Errorf("reporters called in order %s, want first,second,third", got)
+	}
+}
+
+func TestReport_NilAndUnregistered(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	remove := ae.RegisterReporter(recordReporter(&mu, &calls, "r"))
+
+	if err := ae.Report(context.Background(), nil); err != nil || len(calls) != 0 {
+		t.Errorf("Report(nil) = %v with %d calls, want nil without calls", err, len(calls))
+	}
+
+	remove()
+	if err := ae.Report(context.Background(), ae.New().Msg("boom")); err != nil || len(calls) != 0 {
+		t.Errorf("Report after removal = %v with %d calls, want nil without calls", err, len(calls))
+	}
+}
+
+func TestReport_Failing(t *testing.T) {
+	errTracker := errors.New("tracker unavailable")
+
+	var mu sync.Mutex
+	var calls []string
+	withPackageState(t, ae.RegisterReporter(ae.ReporterFunc(func(context.Context, error) error { return errTracker })))
+	withPackageState(t, ae.RegisterReporter(ae.ReporterFunc(func(context.Context, error) error { panic("oops") })))
+	withPackageState(t, ae.RegisterReporter(recordReporter(&mu, &calls, "last")))
+
+	err := ae.Report(context.Background(), ae.New().Msg("boom"))
+
+	if ae.Code(err) != ae.CodeReportFailed {
+		t.Fatalf("Report = %v, want an error with code %s", err, ae.CodeReportFailed)
+	}
+	related := ae.Related(err)
+	if len(related) != 2 {
+		t.Fatalf("related = %v, want 2 failures", related)
+	}
+	if !errors.Is(related[0], errTracker) {
+		t.Errorf("first failure = %v, want %v", related[0], errTracker)
+	}
+	if !strings.Contains(related[1].Error(), "panicked: oops") {
+		t.Errorf("second failure = %v, want the panic", related[1])
+	}
+	if len(calls) != 1 {
+		t.Errorf("reporter after the failures called %d times, want 1", len(calls))
+	}
+}
+
+func TestReport_Slow(t *testing.T) {
+	ae.SetReportTimeout(50 * time.Millisecond)
+	withPackageState(t, func() { ae.SetReportTimeout(5 * time.Second) })
+
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+
+	var mu sync.Mutex
+	var calls []string
+	withPackageState(t, ae.RegisterReporter(recordReporter(&mu, &calls, "before")))
+	withPackageState(t, ae.RegisterReporter(ae.ReporterFunc(func(context.Context, error) error {
+		<-release // ignores the context
+		return nil
+	})))
+	withPackageState(t, ae.RegisterReporter(recordReporter(&mu, &calls, "after")))
+
+	start := time.Now()
+	err := ae.Report(context.Background(), ae.New().Msg("boom"))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Report took %s despite the 50ms timeout", elapsed)
+	}
+
+	related := ae.Related(err)
+	if len(related) != 2 {
+		t.Fatalf("related = %v, want the slow and the skipped reporter", related)
+	}
+	for _, failure := range related {
+		if !errors.Is(failure, context.DeadlineExceeded) {
+			t.Errorf("failure = %v, want %v", failure, context.DeadlineExceeded)
+		}
+	}
+	if !strings.Contains(related[1].Error(), "skipped") {
+		t.Errorf("failure = %v, want the reporter skipped", related[1])
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := strings.Join(calls, ","); got != "before" {
+		t.Errorf("calls = %s, want before", got)
+	}
+}
+
+func TestExit_Reports(t *testing.T) {
+	code := captureExit(t)
+
+	var order []string
+	withPackageState(t, ae.OnExit(func(error) { order = append(order, "hook") }))
+	withPackageState(t, ae.RegisterReporter(ae.ReporterFunc(func(_ context.Context, err error) error {
+		order = append(order, "reporter: "+ae.Message(err))
+		return errors.New("tracker unavailable")
+	})))
+
+	out := captureStderr(t, func() {
+		ae.PrintExit(ae.New().ExitCode(4).Msg("boom"), ae.NoPrintColors())
+	})
+
+	if got := strings.Join(order, ","); got != "reporter: boom,hook" {
+		t.Errorf("order = %s, want the reporter before the exit hook", got)
+	}
+	if !strings.Contains(out, "ae: 1 of 1 reporters failed; reporter 1 (ae.ReporterFunc): tracker unavailable\n") {
+		t.Errorf("stderr has no log line for the failed reporter:\n%s", out)
+	}
+	if *code != 4 {
+		t.Errorf("exit code = %d, want 4 despite the failed reporter", *code)
+	}
+}
+
+func TestFileReporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "errors.jsonl")
+	r := ae.NewFileReporter(path)
+
+	for _, msg := range []string{"first", "second"} {
+		if err := r.Report(context.Background(), ae.New().Code("E_FILE").Msg(msg)); err != nil {
+			t.Fatalf("Report: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("file has %d lines, want 2:\n%s", len(lines), data)
+	}
+	for i, want := range []string{"first", "second"} {
+		decoded, err := ae.DecodeJSON(lines[i], ae.DecodeJSONLimits{})
+		if err != nil {
+			t.Fatalf("line %d: %v", i+1, err)
+		}
+		if decoded.ErrorMessage() != want || decoded.ErrorCode() != "E_FILE" {
+			t.Errorf("line %d = %q %q, want %q E_FILE", i+1, decoded.ErrorMessage(), decoded.ErrorCode(), want)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := r.Report(ctx, ae.New().Msg("late")); !errors.Is(err, context.Canceled) {
+		t.Errorf("Report with a canceled context = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,43 @@
+package ae
+
+import "context"
+
+// ErrorRequestId defines an interface for errors that can provide the ID of
+// the request being served when they occurred.
+type ErrorRequestId interface {
+	// ErrorRequestId returns the request ID.
+	// Returns an empty string if no request ID is set.
+	ErrorRequestId() string
+}
+
+// RequestId extracts the request ID from an error: the one of err if it
+// implements ErrorRequestId with a non-empty value, otherwise the first one
+// found in its cause tree, searched depth-first. Returns an empty string if
+// err is nil or no error in the tree carries a request ID.
+func RequestId(err error) string {
+	var id string
+	walkCauses(err, func(err error) bool {
+		if x, ok := err.(ErrorRequestId); ok {
+			id = x.ErrorRequestId()
+		}
+		return id == ""
+	})
+
+	return id
+}
+
+type requestIdKey struct{}
+
+// WithRequestIdValue returns a new context carrying the request ID id, which
+// Builder.Context (and therefore NewC, FromC and the other context-aware
+// constructors) sets on the errors it builds.
+func WithRequestIdValue(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, id)
+}
+
+// RequestIdFromContext returns the request ID carried by ctx, or an empty
+// string if there is none.
+func RequestIdFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey{}).(string)
+	return id
+}
@@ -0,0 +1,99 @@
+package ae_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+func TestRequestId_FromContext(t *testing.T) {
+	t.Parallel()
+
+	ctx := ae.WithRequestIdValue(context.Background(), "req-42")
+	if got := ae.RequestIdFromContext(ctx); got != "req-42" {
+		t.Errorf("RequestIdFromContext = %q, want req-42", got)
+	}
+
+	err := ae.NewC(ctx).Msg("boom")
+	if got := err.(*ae.Ae).ErrorRequestId(); got != "req-42" {
+		t.Errorf("ErrorRequestId = %q, want req-42", got)
+	}
+	if got := ae.New().RequestId("explicit").Context(ctx).Msg("x"); ae.RequestId(got) != "req-42" {
+		t.Errorf("Context did not override the request ID: %q", ae.RequestId(got))
+	}
+	if got := ae.RequestIdFromContext(context.Background()); got != "" {
+		t.Errorf("RequestIdFromContext(empty) = %q, want empty", got)
+	}
+}
+
+func TestRequestId_DeepFallback(t *testing.T) {
+	t.Parallel()
+
+	inner := ae.New().RequestId("req-inner").Msg("inner")
+	err := ae.Wrap("outer", ae.Wrap("middle", inner))
+	if got := ae.RequestId(err); got != "req-inner" {
+		t.Errorf("RequestId = %q, want req-inner", got)
+	}
+
+	own := ae.New().RequestId("req-outer").Cause(inner).Msg("outer")
+	if got := ae.RequestId(own); got != "req-outer" {
+		t.Errorf("RequestId = %q, want the outermost req-outer", got)
+	}
+
+	if got := ae.RequestId(errors.New("plain")); got != "" {
+		t.Errorf("RequestId(plain) = %q, want empty", got)
+	}
+	if got := ae.RequestId(nil); got != "" {
+		t.Errorf("RequestId(nil) = %q, want empty", got)
+	}
+}
+
+func TestRequestId_FromPreserves(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().RequestId("req-1").Msg("x")
+	if got := ae.RequestId(ae.From(err).Msg("y")); got != "req-1" {
+		t.Errorf("From lost the request ID: %q", got)
+	}
+}
+
+func TestRequestId_Printed(t *testing.T) {
+	t.Parallel()
+
+	err := ae.Wrap("handling", ae.New().RequestId("req-7").Msg("failed"))
+
+	out := ae.NewPrinter(ae.NoPrintColors()).Prints(err)
+	if !strings.Contains(out, "request") || !strings.Contains(out, "req-7") {
+		t.Errorf("text output lacks the request ID:\n%s", out)
+	}
+	if out := ae.NewPrinter(ae.NoPrintColors(), ae.PrintCompact()).Prints(err); strings.Contains(out, "req-7") {
+		t.Errorf("compact output shows the request ID:\n%s", out)
+	}
+
+	var doc struct {
+		RequestId string `json:"request_id"`
+		Causes    []struct {
+			RequestId string `json:"request_id"`
+		} `json:"causes"`
+	}
+	js := ae.NewPrinter(ae.PrintJSON()).Prints(err)
+	if jerr := json.Unmarshal([]byte(js), &doc); jerr != nil {
+		t.Fatalf("invalid JSON: %v\n%s", jerr, js)
+	}
+	if doc.RequestId != "" || len(doc.Causes) != 1 || doc.Causes[0].RequestId != "req-7" {
+		t.Errorf("request_id not on the cause only:\n%s", js)
+	}
+}
+
+func TestRequestId_LogValue(t *testing.T) {
+	t.Parallel()
+
+	attrs := flattenAttrs(logValue(t, ae.New().RequestId("req-9").Msg("x")))
+	if attrs["request_id"] != "req-9" {
+		t.Errorf("request_id = %v, want req-9", attrs["request_id"])
+	}
+}
@@ -0,0 +1,55 @@
+package ae
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// unixResetThreshold separates the two forms of X-RateLimit-Reset seen in
+// the wild: values above it are Unix timestamps, values below are delays in
+// seconds.
+const unixResetThreshold = 1_000_000_000
+
+// maxDelaySeconds is the longest delay in seconds a time.Duration holds;
+// longer delays are treated as malformed.
+const maxDelaySeconds = math.MaxInt64 / int64(time.Second)
+
+// RetryAfterFromHeader returns the delay after which a request may be retried,
+// as advertised by the Retry-After header in either delta-seconds or HTTP-date
+// form, falling back to X-RateLimit-Reset (delta-seconds or Unix timestamp).
+// Dates in the past yield zero. Missing or malformed headers, including
+// delays too long for a time.Duration, yield false. Dates are resolved
+// relative to the clock set with SetClock.
+func RetryAfterFromHeader(h http.Header) (time.Duration, bool) {
+	return RetryAfterFromHeaderAt(h, now())
+}
+
+// RetryAfterFromHeaderAt is like RetryAfterFromHeader but resolves absolute
+// dates relative to now instead of the current time.
+func RetryAfterFromHeaderAt(h http.Header, now time.Time) (time.Duration, bool) {
+	if v := strings.TrimSpace(h.Get("Retry-After")); v != "" {
+		if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+			if secs >= 0 && secs <= maxDelaySeconds {
+				return time.Duration(secs) * time.Second, true
+			}
+		} else if at, err := http.ParseTime(v); err == nil {
+			return max(at.Sub(now), 0), true
+		}
+	}
+
+	if v := strings.TrimSpace(h.Get("X-RateLimit-Reset")); v != "" {
+		secs, err := strconv.ParseInt(v, 10, 64)
+		switch {
+		case err != nil || secs < 0 || secs > maxDelaySeconds:
+		case secs > unixResetThreshold:
+			return max(time.Unix(secs, 0).Sub(now), 0), true
+		default:
+			return time.Duration(secs) * time.Second, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,57 @@
+package ae_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.aledante.io/ae"
+)
+
+func TestRetryAfterFromHeader(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+		wantOk bool
+	}{
+		{"delta seconds", http.Header{"Retry-After": {"120"}}, 2 * time.Minute, true},
+		{"http date", http.Header{"Retry-After": {now.Add(90 * time.Second).Format(http.TimeFormat)}}, 90 * time.Second, true},
+		{"past date clamps to zero", http.Header{"Retry-After": {now.Add(-time.Hour).Format(http.TimeFormat)}}, 0, true},
+		{"ratelimit reset delta", http.Header{"X-Ratelimit-Reset": {"30"}}, 30 * time.Second, true},
+		{"ratelimit reset unix", http.Header{"X-Ratelimit-Reset": {strconv.FormatInt(now.Add(time.Minute).Unix(), 10)}}, time.Minute, true},
+		{"retry-after wins", http.Header{"Retry-After": {"5"}, "X-Ratelimit-Reset": {"30"}}, 5 * time.Second, true},
+		{"garbage falls back", http.Header{"Retry-After": {"soon"}, "X-Ratelimit-Reset": {"30"}}, 30 * time.Second, true},
+		{"garbage", http.Header{"Retry-After": {"soon"}, "X-Ratelimit-Reset": {"-1"}}, 0, false},
+		{"negative", http.Header{"Retry-After": {"-5"}}, 0, false},
+		{"overflowing delta", http.Header{"Retry-After": {"99999999999"}}, 0, false},
+		{"overflowing delta falls back", http.Header{"Retry-After": {"99999999999"}, "X-Ratelimit-Reset": {"30"}}, 30 * time.Second, true},
+		{"overflowing reset", http.Header{"X-Ratelimit-Reset": {"9223372036854775807"}}, 0, false},
+		{"missing", http.Header{}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := ae.RetryAfterFromHeaderAt(tt.header, now)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("RetryAfterFromHeaderAt = %v, %v; want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestRetryAfterFromHeader_Clock(t *testing.T) {
+	clock := setClock(t)
+
+	h := http.Header{"Retry-After": {clock.Now().Add(90 * time.Second).UTC().Format(http.TimeFormat)}}
+	if got, ok := ae.RetryAfterFromHeader(h); got != 90*time.Second || !ok {
+		t.Errorf("RetryAfterFromHeader = %v, %v; want 1m30s, true", got, ok)
+	}
+}
@@ -0,0 +1,69 @@
+package ae
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// SamplingPolicy decides whether Builder.Stack captures a stack. It is called
+// concurrently and must be safe for concurrent use.
+type SamplingPolicy func() bool
+
+// stackSampling holds the policy set via SetStackSampling; nil means Always.
+var stackSampling atomic.Pointer[SamplingPolicy]
+
+// SetStackSampling sets the policy consulted by Builder.Stack and
+// Builder.StackLimit before capturing. When the policy declines, no stack is
+// captured and the attribute "stack_sampled" is set to false instead, so
+// consumers know a stack was skipped. A nil policy restores Always.
+// Builder.StackAll is never sampled.
+func SetStackSampling(policy SamplingPolicy) {
+	if policy == nil {
+		stackSampling.Store(nil)
+		return
+	}
+
+	stackSampling.Store(&policy)
+}
+
+// sampleStack reports whether the current policy allows capturing a stack.
+func sampleStack() bool {
+	policy := stackSampling.Load()
+	return policy == nil || (*policy)()
+}
+
+// Always is a SamplingPolicy capturing every stack.
+func Always() bool {
+	return true
+}
+
+// EveryN returns a SamplingPolicy capturing the first and then every n-th
+// stack. n <= 1 captures every stack.
+func EveryN(n int) SamplingPolicy {
+	if n <= 1 {
+		return Always
+	}
+
+	var count atomic.Uint64
+	return func() bool {
+		return (count.Add(1)-1)%uint64(n) == 0
+	}
+}
+
+// PerSecond returns a SamplingPolicy capturing at most n stacks per wall
+// clock second. n <= 0 captures none.
+func PerSecond(n int) SamplingPolicy {
+	var (
+		window atomic.Int64
+		count  atomic.Int64
+	)
+
+	return func() bool {
+		now := time.Now().Unix()
+		if cur := window.Load(); cur != now && window.CompareAndSwap(cur, now) {
+			count.Store(0)
+		}
+
+		return count.Add(1) <= int64(n)
+	}
+}
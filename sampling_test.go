@@ -0,0 +1,88 @@
+package ae_test
+
+import (
+	"sync"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+// captureMany builds n errors with Stack() and counts those that carry a
+// stack and those that carry the stack_sampled marker.
+func captureMany(n int) (stacks, markers int) {
+	for range n {
+		err := ae.New().Stack().Msg("retry")
+		if len(ae.Stacks(err)) > 0 {
+			stacks++
+		}
+		if sampled, ok := ae.Attributes(err)["stack_sampled"]; ok && sampled == false {
+			markers++
+		}
+	}
+
+	return stacks, markers
+}
+
+func TestSetStackSampling_EveryN(t *testing.T) {
+	withPackageState(t, func() { ae.SetStackSampling(nil) })
+
+	ae.SetStackSampling(ae.EveryN(100))
+	stacks, markers := captureMany(1000)
+	if stacks != 10 || markers != 990 {
+		t.Errorf("got %d stacks and %d markers, want 10 and 990", stacks, markers)
+	}
+}
+
+func TestSetStackSampling_PerSecond(t *testing.T) {
+	withPackageState(t, func() { ae.SetStackSampling(nil) })
+
+	ae.SetStackSampling(ae.PerSecond(5))
+	stacks, markers := captureMany(100)
+	// The loop may straddle a second boundary, allowing a second batch.
+	if stacks < 5 || stacks > 10 || stacks+markers != 100 {
+		t.Errorf("got %d stacks and %d markers, want 5-10 stacks and markers for the rest", stacks, markers)
+	}
+}
+
+func TestSetStackSampling_AlwaysAndReset(t *testing.T) {
+	withPackageState(t, func() { ae.SetStackSampling(nil) })
+
+	ae.SetStackSampling(ae.Always)
+	if stacks, markers := captureMany(10); stacks != 10 || markers != 0 {
+		t.Errorf("Always: got %d stacks and %d markers", stacks, markers)
+	}
+
+	ae.SetStackSampling(ae.EveryN(1000))
+	ae.SetStackSampling(nil)
+	if stacks, _ := captureMany(10); stacks != 10 {
+		t.Errorf("after reset: got %d stacks, want 10", stacks)
+	}
+}
+
+func TestEveryN_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	policy := ae.EveryN(10)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		sampled int
+	)
+	for range 10 {
+		wg.Go(func() {
+			for range 100 {
+				if policy() {
+					mu.Lock()
+					sampled++
+					mu.Unlock()
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	if sampled != 100 {
+		t.Errorf("sampled %d of 1000, want 100", sampled)
+	}
+}
@@ -0,0 +1,63 @@
+package ae
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// redacted is how a Secret renders.
+const redacted = "[REDACTED]"
+
+// secret wraps a sensitive value, see Secret.
+type secret struct {
+	v any
+}
+
+// Secret wraps v so that it renders as "[REDACTED]" wherever it ends up:
+// fmt verbs, JSON, text marshaling and slog all see the redacted form, so an
+// attribute set with Builder.Attr("password", ae.Secret(pw)) never leaks
+// through printers, loggers or transports. RevealSecret returns v.
+func Secret(v any) any {
+	return secret{v: v}
+}
+
+// RevealSecret returns the value wrapped by Secret, or v itself if it is not
+// a secret.
+func RevealSecret(v any) any {
+	if s, ok := v.(secret); ok {
+		return s.v
+	}
+
+	return v
+}
+
+// String implements fmt.Stringer.
+func (s secret) String() string {
+	return redacted
+}
+
+// Format implements fmt.Formatter, so that no verb, including %#v, prints
+// the wrapped value.
+func (s secret) Format(f fmt.State, verb rune) {
+	if verb == 'q' {
+		fmt.Fprintf(f, "%q", redacted)
+		return
+	}
+
+	_, _ = f.Write([]byte(redacted))
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + redacted + `"`), nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s secret) MarshalText() ([]byte, error) {
+	return []byte(redacted), nil
+}
+
+// LogValue implements slog.LogValuer.
+func (s secret) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
@@ -0,0 +1,80 @@
+package ae_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+const rawSecret = "hunter2-s3cr3t"
+
+func TestSecret_NeverRendersRawValue(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().
+		Attr("password", ae.Secret(rawSecret)).
+		Attr("nested", map[string]any{"token": ae.Secret(rawSecret)}).
+		Cause(ae.New().Attr("api_key", ae.Secret(rawSecret)).Msg("inner")).
+		Msg("login failed")
+
+	var slogBuf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&slogBuf, nil)).Error("failed", "err", err)
+	var slogText bytes.Buffer
+	slog.New(slog.NewTextHandler(&slogText, nil)).Error("failed", "err", err)
+
+	graphql, jsonErr := json.Marshal(ae.GraphQLExtensions(err, "password"))
+	if jsonErr != nil {
+		t.Fatalf("marshal GraphQL extensions: %v", jsonErr)
+	}
+
+	outputs := map[string]string{
+		"text printer": ae.NewPrinter(ae.NoPrintColors()).Prints(err),
+		"json printer": ae.NewPrinter(ae.PrintJSON()).Prints(err),
+		"slog json":    slogBuf.String(),
+		"slog text":    slogText.String(),
+		"graphql":      string(graphql),
+		"fmt %v":       fmt.Sprintf("%v", ae.Attributes(err)),
+		"fmt %+v":      fmt.Sprintf("%+v", ae.Attributes(err)),
+		"fmt %#v":      fmt.Sprintf("%#v", ae.Attributes(err)),
+		"fmt %s":       fmt.Sprintf("%s", ae.Attributes(err)["password"]),
+		"fmt %q":       fmt.Sprintf("%q", ae.Attributes(err)["password"]),
+		"json marshal": mustJSON(t, ae.Attributes(err)),
+	}
+
+	for name, out := range outputs {
+		if strings.Contains(out, rawSecret) {
+			t.Errorf("%s leaks the secret:\n%s", name, out)
+		}
+		if !strings.Contains(out, "[REDACTED]") {
+			t.Errorf("%s does not render the redacted form:\n%s", name, out)
+		}
+	}
+}
+
+func TestRevealSecret(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Attr("password", ae.Secret(rawSecret)).Msg("login failed")
+
+	if got := ae.RevealSecret(ae.Attributes(err)["password"]); got != rawSecret {
+		t.Errorf("RevealSecret = %v, want the raw value", got)
+	}
+	if got := ae.RevealSecret(42); got != 42 {
+		t.Errorf("RevealSecret(42) = %v, want 42", got)
+	}
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	return string(b)
+}
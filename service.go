@@ -0,0 +1,80 @@
+package ae
+
+import (
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// Attributes stamped onto every error once SetServiceInfo is called.
+const (
+	AttrServiceName    = "service.name"
+	AttrServiceVersion = "service.version"
+	AttrServiceEnv     = "service.env"
+)
+
+// serviceInfo holds the values set with SetServiceInfo.
+type serviceInfo struct {
+	name, version, env string
+}
+
+// service holds the active serviceInfo; nil means none is set.
+var service atomic.Pointer[serviceInfo]
+
+// SetServiceInfo identifies the service producing errors, so that errors
+// passed across services say where they come from: every error finalized by
+// a Builder afterwards gets the attributes service.name, service.version and
+// service.env, leaving out empty values. An empty version is read from the
+// build info: the main module version, or the VCS revision for development
+// builds. Attributes set on the error itself win over the stamped ones.
+// Calling SetServiceInfo with only empty values stops the stamping.
+func SetServiceInfo(name, version, environment string) {
+	if name == "" && version == "" && environment == "" {
+		service.Store(nil)
+		return
+	}
+
+	if version == "" {
+		version = buildVersion()
+	}
+	service.Store(&serviceInfo{name: name, version: version, env: environment})
+}
+
+// buildVersion returns the version of the main module from the build info,
+// falling back to the VCS revision when it is "(devel)".
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" {
+			return s.Value
+		}
+	}
+
+	return info.Main.Version
+}
+
+// stampServiceInfo adds the service attributes the error doesn't have yet.
+func (b *Builder) stampServiceInfo() {
+	s := service.Load()
+	if s == nil {
+		return
+	}
+
+	for _, attr := range [...]struct{ key, value string }{
+		{AttrServiceName, s.name},
+		{AttrServiceVersion, s.version},
+		{AttrServiceEnv, s.env},
+	} {
+		if attr.value == "" {
+			continue
+		}
+		if _, ok := b.attributes[attr.key]; !ok {
+			b.setAttr(attr.key, attr.value)
+		}
+	}
+}
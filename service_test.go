@@ -0,0 +1,91 @@
+package ae_test
+
+import (
+	"runtime/debug"
+	"sync"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+func setServiceInfo(t *testing.T, name, version, env string) {
+	t.Helper()
+
+	ae.SetServiceInfo(name, version, env)
+	withPackageState(t, func() { ae.SetServiceInfo("", "", "") })
+}
+
+func TestSetServiceInfo_Stamps(t *testing.T) {
+	setServiceInfo(t, "billing", "v1.4.2", "prod")
+
+	attrs := ae.Attributes(ae.Wrap("charging", ae.New().Msg("declined")))
+	want := map[string]string{
+		"service.name":    "billing",
+		"service.version": "v1.4.2",
+		"service.env":     "prod",
+	}
+	for k, v := range want {
+		if attrs[k] != v {
+			t.Errorf("%s = %v, want %v", k, attrs[k], v)
+		}
+	}
+
+	ae.SetServiceInfo("", "", "")
+	if attrs := ae.Attributes(ae.New().Msg("x")); len(attrs) != 0 {
+		t.Errorf("attributes after clearing = %v, want none", attrs)
+	}
+}
+
+func TestSetServiceInfo_BuildInfoVersion(t *testing.T) {
+	setServiceInfo(t, "billing", "", "")
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		t.Skip("no build info")
+	}
+
+	// test binaries carry no VCS information
+	attrs := ae.Attributes(ae.New().Msg("x"))
+	if attrs["service.version"] != info.Main.Version {
+		t.Errorf("service.version = %v, want %q", attrs["service.version"], info.Main.Version)
+	}
+	if _, ok := attrs["service.env"]; ok {
+		t.Error("empty environment stamped")
+	}
+}
+
+func TestSetServiceInfo_ErrorAttrsWin(t *testing.T) {
+	setServiceInfo(t, "billing", "v1", "prod")
+
+	err := ae.New().Attr("service.name", "ledger").Msg("x")
+	if got := ae.Attributes(err)["service.name"]; got != "ledger" {
+		t.Errorf("service.name = %v, want the error's own ledger", got)
+	}
+
+	if got := ae.Attributes(ae.From(err).Msg("y"))["service.name"]; got != "ledger" {
+		t.Errorf("service.name after From = %v, want ledger", got)
+	}
+}
+
+func TestSetServiceInfo_SharedBuilder(t *testing.T) {
+	base := ae.New().Attr("user_id", 42).Tag("auth")
+	before := base.Msg("before")
+
+	setServiceInfo(t, "billing", "v1.4.2", "prod")
+
+	// finalizing a shared builder concurrently doesn't write to the maps the
+	// builder and the errors finalized from it share
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			if got := ae.Attributes(base.Msg("after"))["service.name"]; got != "billing" {
+				t.Errorf("service.name = %v, want billing", got)
+			}
+		})
+	}
+	wg.Wait()
+
+	if _, ok := ae.Attributes(before)["service.name"]; ok {
+		t.Error("error finalized before SetServiceInfo gained service.name")
+	}
+}
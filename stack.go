@@ -2,12 +2,14 @@ package ae
 
 import (
 	"bytes"
-	"maps"
-	"runtime/debug"
+	"errors"
+	"reflect"
+	"runtime"
 	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
-
-	"github.com/DataDog/gostackparse"
 )
 
 // Stack represents a stack trace with associated metadata for a single goroutine.
@@ -24,10 +26,20 @@ type Stack struct {
 	Frames []*StackFrame `json:"frames"`
 	// FramesElided indicates whether some frames were omitted from the trace
 	FramesElided bool `json:"frames_elided"`
+	// ElidedCount is the number of omitted frames, if known
+	ElidedCount int `json:"elided_count"`
 	// CreatedBy points to the exact frame that created this stack.
 	CreatedBy *StackFrame `json:"parent"`
 	// Ancestor points to the root ancestor, which is the stack that crated this stack.
 	Ancestor *Stack `json:"ancestor"`
+
+	// elidedAt is the index in Frames where frames were elided; 0 means
+	// they were elided after the last frame.
+	elidedAt int
+
+	// lazy holds the program counters Frames are resolved from on first
+	// access, for stacks captured via runtime.Callers.
+	lazy *lazyFrames
 }
 
 // StackFrame represents a single frame in a stack trace.
@@ -38,70 +50,331 @@ type StackFrame struct {
 	File string `json:"file"`
 	// Line is the line number in the source file
 	Line int `json:"line"`
+	// Module is the path of the module the function belongs to, if it could
+	// be resolved from the build info
+	Module string `json:"module,omitempty"`
+	// InApp indicates whether the function is application code rather than a
+	// dependency or the standard library (see SetInAppPrefixes)
+	InApp bool `json:"in_app"`
 }
 
-// newStack captures the current stack trace of all goroutines and returns them as a slice of Stack objects.
-// It parses the debug stack information to extract goroutine details including their state, wait times,
-// locked status, and stack frames. The function also establishes relationships between goroutines
-// by linking them to their creating frames and ancestor stacks.
-//
-// Returns a slice of Stack objects representing all active goroutines.
-func newStack() []*Stack {
-	goRoutines, _ := gostackparse.Parse(bytes.NewReader(debug.Stack()))
-
-	stacks := make(map[int]*Stack)
-	ancestors := make(map[int]int)
-
-	for _, g := range goRoutines {
-		var frames []*StackFrame
-		for _, frame := range g.Stack {
-			frames = append(frames, &StackFrame{
-				Func: frame.Func,
-				File: frame.File,
-				Line: frame.Line,
-			})
-		}
+// defaultMaxStackFrames is the default cap on captured frames.
+const defaultMaxStackFrames = 64
+
+// stackTailFrames is the number of outermost frames kept when a stack is
+// capped, so the goroutine's origin (main, the go statement's function)
+// stays visible.
+const stackTailFrames = 8
+
+// maxStackFrames holds the cap set via SetMaxStackFrames.
+var maxStackFrames atomic.Int64
+
+func init() {
+	maxStackFrames.Store(defaultMaxStackFrames)
+}
+
+// SetMaxStackFrames sets how many frames Builder.Stack captures (64 by
+// default). Deeper stacks keep their innermost frames plus a few outermost
+// ones; the frames in between are dropped, FramesElided is set and
+// ElidedCount records how many were dropped. n <= 0 disables the cap.
+// Builder.StackLimit overrides the cap for a single capture.
+func SetMaxStackFrames(n int) {
+	maxStackFrames.Store(int64(n))
+}
+
+// lazyFrames holds the program counters of a stack captured via
+// runtime.Callers. They are only resolved into StackFrame values when the
+// stack is first read, since most errors are never printed.
+type lazyFrames struct {
+	once sync.Once
+	// pcs are the innermost program counters.
+	pcs []uintptr
+	// tail are the outermost program counters, kept after the elided ones.
+	tail []uintptr
+}
+
+// resolve fills s.Frames from the captured program counters, if any. It is
+// safe for concurrent use and a no-op for stacks not captured lazily.
+func (s *Stack) resolve() {
+	if s == nil || s.lazy == nil {
+		return
+	}
 
-		stack := &Stack{
-			ID:        g.ID,
-			State:     g.State,
-			Wait:      g.Wait,
-			Locked:    g.LockedToThread,
-			Frames:    frames,
-			CreatedBy: nil,
-			Ancestor:  nil,
+	s.lazy.once.Do(func() {
+		s.Frames = framesFromPCs(s.lazy.pcs)
+		if len(s.lazy.tail) > 0 {
+			s.elidedAt = len(s.Frames)
+			s.Frames = append(s.Frames, framesFromPCs(s.lazy.tail)...)
 		}
+	})
+}
+
+// newStack captures the stack of the calling goroutine, skipping skip frames
+// above the caller of newStack, and keeps at most limit frames (see
+// SetMaxStackFrames); limit <= 0 keeps all. Only program counters are
+// recorded; frames are resolved on first access.
+func newStack(skip int, limit int) *Stack {
+	pcs := make([]uintptr, 128)
+	n := runtime.Callers(skip+2, pcs)
+	for n == len(pcs) {
+		pcs = make([]uintptr, 2*len(pcs))
+		n = runtime.Callers(skip+2, pcs)
+	}
+	pcs = pcs[:n:n]
+
+	st := &Stack{
+		ID:    currentGoroutineID(),
+		State: "running",
+		lazy:  &lazyFrames{pcs: pcs},
+	}
+
+	if limit > 0 && n > limit {
+		tail := min(stackTailFrames, limit/4)
+		st.FramesElided = true
+		st.ElidedCount = n - limit
+		st.lazy.pcs = pcs[:limit-tail]
+		st.lazy.tail = pcs[n-tail:]
+	}
+
+	return st
+}
 
-		if g.CreatedBy != nil {
-			stack.CreatedBy = &StackFrame{
-				Func: g.CreatedBy.Func,
-				File: g.CreatedBy.File,
-				Line: g.CreatedBy.Line,
+// stackDedupeOff disables stack deduplication, see SetStackDedupe.
+var stackDedupeOff atomic.Bool
+
+// SetStackDedupe enables or disables stack deduplication, which is enabled by
+// default. With deduplication, capturing a stack on a builder that already
+// holds a stack of the same goroutine with the same callers — typically one
+// inherited via From when an error is re-wrapped further up the call chain —
+// keeps only the more complete of the two, in the original position.
+func SetStackDedupe(enabled bool) {
+	stackDedupeOff.Store(!enabled)
+}
+
+// attachStacks returns a new slice holding existing followed by added,
+// skipping or merging added stacks equivalent to one already present unless
+// deduplication is disabled. existing is never modified.
+func attachStacks(existing []*Stack, added ...*Stack) []*Stack {
+	stacks := slices.Clone(existing)
+
+outer:
+	for _, st := range added {
+		if !stackDedupeOff.Load() {
+			for i, have := range stacks {
+				if !sameGoroutineStack(have, st) {
+					continue
+				}
+				if stackDepth(st) > stackDepth(have) {
+					stacks[i] = st
+				}
+				continue outer
 			}
 		}
-		if g.Ancestor != nil {
-			ancestors[g.ID] = g.Ancestor.ID
+
+		stacks = append(stacks, st)
+	}
+
+	return stacks
+}
+
+// sameGoroutineStack reports whether a and b were captured on the same
+// goroutine from the same callers: apart from the innermost frame of the
+// shallower stack, the outermost frames of both are identical. Only the
+// frames known to be contiguous are compared for capped stacks.
+func sameGoroutineStack(a, b *Stack) bool {
+	if a == b {
+		return true
+	}
+	if a == nil || b == nil || a.ID != b.ID {
+		return false
+	}
+
+	if a.lazy != nil && b.lazy != nil {
+		return sameOuter(a.lazy.outer(), b.lazy.outer(), func(x, y uintptr) bool { return x == y })
+	}
+
+	a.resolve()
+	b.resolve()
+
+	return sameOuter(a.outerFrames(), b.outerFrames(), func(x, y *StackFrame) bool {
+		return x.Func == y.Func && x.File == y.File && x.Line == y.Line
+	})
+}
+
+// sameOuter reports whether the last min(len(a), len(b))-1 elements of a and
+// b are equal.
+func sameOuter[T any](a, b []T, eq func(x, y T) bool) bool {
+	n := min(len(a), len(b)) - 1
+	if n < 1 {
+		return false
+	}
+
+	a, b = a[len(a)-n:], b[len(b)-n:]
+	for i := range a {
+		if !eq(a[i], b[i]) {
+			return false
 		}
+	}
+
+	return true
+}
 
-		stacks[g.ID] = stack
+// outer returns the contiguous run of outermost program counters.
+func (l *lazyFrames) outer() []uintptr {
+	if len(l.tail) > 0 {
+		return l.tail
 	}
 
-	for stackID, ancestorID := range ancestors {
-		stack, ok := stacks[stackID]
-		if !ok {
-			// This is a bug, but let's not panic a prod system in the error path
-			continue
+	return l.pcs
+}
+
+// outerFrames returns the contiguous run of outermost frames.
+func (s *Stack) outerFrames() []*StackFrame {
+	if s.FramesElided && s.elidedAt > 0 && s.elidedAt < len(s.Frames) {
+		return s.Frames[s.elidedAt:]
+	}
+
+	return s.Frames
+}
+
+// stackDepth returns the depth of the goroutine's stack at capture time.
+func stackDepth(s *Stack) int {
+	if s.lazy != nil {
+		return len(s.lazy.pcs) + len(s.lazy.tail) + s.ElidedCount
+	}
+
+	return len(s.Frames) + s.ElidedCount
+}
+
+// currentGoroutineID returns the ID of the calling goroutine, read from the
+// "goroutine N [" header of its traceback. Returns 0 if it can't be parsed.
+func currentGoroutineID() int {
+	var buf [64]byte
+	header := buf[:runtime.Stack(buf[:], false)]
+
+	header, ok := bytes.CutPrefix(header, []byte("goroutine "))
+	if !ok {
+		return 0
+	}
+	if i := bytes.IndexByte(header, ' '); i >= 0 {
+		header = header[:i]
+	}
+
+	id, err := strconv.Atoi(string(header))
+	if err != nil {
+		return 0
+	}
+
+	return id
+}
+
+// framesFromPCs resolves program counters into stack frames, dropping the
+// runtime.goexit frame every goroutine ends in.
+func framesFromPCs(pcs []uintptr) []*StackFrame {
+	if len(pcs) == 0 {
+		return nil
+	}
+
+	frames := make([]*StackFrame, 0, len(pcs))
+	callers := runtime.CallersFrames(pcs)
+	for {
+		frame, more := callers.Next()
+		if frame.Function != "runtime.goexit" {
+			frames = append(frames, newStackFrame(frame.Function, frame.File, frame.Line))
 		}
 
-		ancestorStack, ok := stacks[ancestorID]
-		if !ok {
-			// This is a bug, but let's not panic a prod system in the error path
-			continue
+		if !more {
+			break
 		}
+	}
+
+	return frames
+}
+
+// newStacksAll captures the stack traces of all goroutines and returns them as a slice of Stack objects.
+//
+// This is considerably more expensive than newStack, as the whole process is
+// stopped, formatted into text and parsed back.
+func newStacksAll() []*Stack {
+	stacks, _ := parseTraceback(allGoroutinesTrace())
+	return stacks
+}
+
+// ParseStacks parses Go traceback text — panic output, goroutine dumps as
+// written by runtime.Stack or SIGQUIT — into stacks, keeping the goroutine
+// state, wait duration, created-by frame and elided-frame marker. Lines
+// before the first goroutine header, such as the panic message, are ignored.
+//
+// Malformed goroutines are skipped: the stacks parsed successfully are
+// returned together with an error describing every skipped goroutine.
+func ParseStacks(text []byte) ([]*Stack, error) {
+	stacks, errs := parseTraceback(text)
+
+	if len(stacks) == 0 && len(errs) == 0 && len(bytes.TrimSpace(text)) > 0 {
+		return nil, New().Tag("invalid").Msg("no goroutine found in stack trace text")
+	}
+	if len(errs) > 0 {
+		return stacks, WrapMany("parsing stack trace text", errs...)
+	}
+
+	return stacks, nil
+}
+
+// allGoroutinesTrace returns the traceback of all goroutines as printed by
+// runtime.Stack, growing the buffer until it fits.
+func allGoroutinesTrace() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// stackFromStackTracer converts the stack trace of a github.com/pkg/errors
+// style error into a Stack. Such errors expose StackTrace() errors.StackTrace,
+// a slice of program counters with a package-specific element type, so the
+// method is detected via reflection instead of an interface assertion to
+// avoid depending on the package.
+//
+// The Unwrap chain is searched and the deepest stack trace is used, since it
+// points at the origin of the error. Returns nil if none is found.
+func stackFromStackTracer(err error) *Stack {
+	var pcs []uintptr
+	for ; err != nil; err = errors.Unwrap(err) {
+		if found := stackTracerPCs(err); len(found) > 0 {
+			pcs = found
+		}
+	}
+
+	if len(pcs) == 0 {
+		return nil
+	}
+
+	return &Stack{lazy: &lazyFrames{pcs: pcs}}
+}
+
+// stackTracerPCs returns the program counters reported by err's
+// StackTrace() method, or nil if err has no such method returning a slice
+// of uintptr-kinded values.
+func stackTracerPCs(err error) []uintptr {
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return nil
+	}
+
+	out := m.Type().Out(0)
+	if out.Kind() != reflect.Slice || out.Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
 
-		stack.Ancestor = ancestorStack
-		stacks[stackID] = stack
+	trace := m.Call(nil)[0]
+	pcs := make([]uintptr, trace.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(trace.Index(i).Uint())
 	}
 
-	return slices.Collect(maps.Values(stacks))
+	return pcs
 }
@@ -0,0 +1,292 @@
+package ae
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+)
+
+// StackFormatOption configures how Stack.Format renders a stack. The same
+// options are accepted by the text printer via PrintStackFormat.
+type StackFormatOption func(f *stackFormat)
+
+// StackMaxFrames limits rendering to the first n frames (after filtering);
+// the rest are replaced by an elision marker. n <= 0 means no limit.
+func StackMaxFrames(n int) StackFormatOption {
+	return func(f *stackFormat) {
+		f.maxFrames = n
+	}
+}
+
+// StackTrimPath removes the first matching prefix from every rendered file
+// path, e.g. a module root or GOPATH.
+func StackTrimPath(prefixes ...string) StackFormatOption {
+	return func(f *stackFormat) {
+		f.trimPrefixes = append(f.trimPrefixes, prefixes...)
+	}
+}
+
+// StackFrameFilters hides frames for which any of filters returns true.
+func StackFrameFilters(filters ...func(frame *StackFrame) bool) StackFormatOption {
+	return func(f *stackFormat) {
+		f.filters = append(f.filters, filters...)
+	}
+}
+
+// StackAncestry adds a line listing the goroutine's ancestry, following the
+// Ancestor links, e.g. "ancestry: goroutine 12 ← goroutine 5 ← goroutine 1".
+// Ancestors are only recorded when running with
+// GODEBUG=tracebackancestors=N.
+func StackAncestry() StackFormatOption {
+	return func(f *stackFormat) {
+		f.ancestry = true
+	}
+}
+
+// stackFormat holds the rendering settings shared by Stack.Format and the
+// text printer.
+type stackFormat struct {
+	// maxFrames limits the number of rendered frames; <= 0 means no limit.
+	maxFrames int
+	// trimPrefixes are removed from file paths.
+	trimPrefixes []string
+	// filters drop frames for which any returns true.
+	filters []func(frame *StackFrame) bool
+	// ancestry adds the chain of ancestor goroutines.
+	ancestry bool
+	// locIndent precedes the file:line line of every frame.
+	locIndent string
+	// paint colorizes a piece of text; nil renders plain text.
+	paint func(s string, c *textColor) string
+}
+
+func newStackFormat(opts []StackFormatOption) *stackFormat {
+	f := &stackFormat{locIndent: "\t"}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	return f
+}
+
+// String renders s in the conventional Go traceback style:
+//
+//	goroutine 12 [running]:
+//	main.work()
+//		/src/main.go:42
+//	created by main.main
+//		/src/main.go:10
+func (s *Stack) String() string {
+	var sb strings.Builder
+	_ = s.Format(&sb)
+	return sb.String()
+}
+
+// Format writes s to w in the style of String, adjusted by opts. Nothing is
+// written if filters hide every frame of a non-empty stack.
+func (s *Stack) Format(w io.Writer, opts ...StackFormatOption) error {
+	lines := newStackFormat(opts).lines(s)
+	if len(lines) == 0 {
+		return nil
+	}
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+// lines renders s line by line, without trailing newlines.
+func (f *stackFormat) lines(s *Stack) []string {
+	if s == nil {
+		return nil
+	}
+	s.resolve()
+
+	// Highlight application code by dimming other frames, unless no frame
+	// is known to be application code.
+	dimLibs := slices.ContainsFunc(s.Frames, func(frame *StackFrame) bool { return frame.InApp })
+
+	// Frames elided in the middle of the stack are marked where they were
+	// dropped, frames elided at the end (or beyond maxFrames) after the last
+	// frame.
+	midElided := s.FramesElided && s.elidedAt > 0 && s.elidedAt < len(s.Frames)
+	endElided := s.FramesElided && !midElided
+
+	lines := make([]string, 0, 2*len(s.Frames)+4)
+	lines = append(lines, f.color(f.header(s), colDim))
+
+	shown, truncated := 0, false
+	for i, frame := range s.Frames {
+		if midElided && i == s.elidedAt {
+			lines = append(lines, f.color(elisionMarker(s.ElidedCount), colDim))
+		}
+		if f.drop(frame) {
+			continue
+		}
+		if f.maxFrames > 0 && shown == f.maxFrames {
+			truncated = true
+			break
+		}
+
+		lines = append(lines, f.frameLines("", frame, dimLibs && !frame.InApp)...)
+		shown++
+	}
+
+	if shown == 0 && len(s.Frames) > 0 {
+		return nil
+	}
+	switch {
+	case truncated:
+		lines = append(lines, f.color(elisionMarker(0), colDim))
+	case endElided:
+		lines = append(lines, f.color(elisionMarker(s.ElidedCount), colDim))
+	}
+	if s.CreatedBy != nil {
+		lines = append(lines, f.frameLines("created by ", s.CreatedBy, false)...)
+	}
+	if f.ancestry && s.Ancestor != nil {
+		lines = append(lines, f.color(ancestryLine(s), colDim))
+	}
+
+	return lines
+}
+
+// summaryLines renders s as the single line
+//
+//	goroutine 42 [running]: pkg.Func (file.go:88)
+//
+// naming its first application frame not dropped by the filters, or its
+// first frame not dropped if none is application code. Like lines, it
+// returns nothing if the filters drop every frame of a non-empty stack.
+func (f *stackFormat) summaryLines(s *Stack) []string {
+	if s == nil {
+		return nil
+	}
+	s.resolve()
+
+	var top *StackFrame
+	for _, frame := range s.Frames {
+		if f.drop(frame) {
+			continue
+		}
+		if top == nil {
+			top = frame
+		}
+		if frame.InApp {
+			top = frame
+			break
+		}
+	}
+
+	line := f.color(f.header(s), colDim)
+	switch {
+	case top != nil:
+		line += " " + f.color(top.Func, colStackFn) + " " +
+			f.color("("+path.Base(top.File)+":", colStackLoc) +
+			f.color(fmt.Sprintf("%d", top.Line), colStackLn) +
+			f.color(")", colStackLoc)
+	case len(s.Frames) > 0:
+		return nil
+	}
+
+	return []string{line}
+}
+
+// ancestryLine renders the chain of goroutines from s to its oldest ancestor.
+// Cycles, which can only come from hand-built stacks, end the chain.
+func ancestryLine(s *Stack) string {
+	seen := make(map[*Stack]bool)
+	ids := make([]string, 0, 4)
+	for st := s; st != nil && !seen[st]; st = st.Ancestor {
+		seen[st] = true
+		ids = append(ids, fmt.Sprintf("goroutine %d", st.ID))
+	}
+
+	return "ancestry: " + strings.Join(ids, " ← ")
+}
+
+// elisionMarker returns the line marking elided frames, mentioning their
+// count when known.
+func elisionMarker(count int) string {
+	if count > 0 {
+		return fmt.Sprintf("...%d frames elided...", count)
+	}
+
+	return "...additional frames elided..."
+}
+
+// header renders the "goroutine N [state, wait, locked to thread]:" line.
+func (f *stackFormat) header(s *Stack) string {
+	state := []string{cmp.Or(s.State, "unknown")}
+	if s.Wait > 0 {
+		state = append(state, fmt.Sprintf("%d minutes", int(s.Wait.Minutes())))
+	}
+	if s.Locked {
+		state = append(state, "locked to thread")
+	}
+
+	return fmt.Sprintf("goroutine %d [%s]:", s.ID, strings.Join(state, ", "))
+}
+
+// frameLines renders frame as its function line (prefixed by prefix) and its
+// indented location line. dim renders the function name de-emphasized.
+func (f *stackFormat) frameLines(prefix string, frame *StackFrame, dim bool) []string {
+	fn := frame.Func
+	if prefix == "" {
+		fn += "()"
+	}
+
+	fnColor := colStackFn
+	if dim {
+		fnColor = colDim
+	}
+
+	return []string{
+		prefix + f.color(fn, fnColor),
+		f.locIndent + f.color(f.trimPath(frame.File), colStackLoc) +
+			f.color(":", colDim) + f.color(fmt.Sprintf("%d", frame.Line), colStackLn),
+	}
+}
+
+func (f *stackFormat) trimPath(file string) string {
+	for _, prefix := range f.trimPrefixes {
+		if trimmed, ok := strings.CutPrefix(file, prefix); ok {
+			return trimmed
+		}
+	}
+
+	return file
+}
+
+// scrubFrame returns a copy of frame with its file path trimmed.
+func (f *stackFormat) scrubFrame(frame *StackFrame) *StackFrame {
+	if frame == nil {
+		return nil
+	}
+
+	cpy := *frame
+	cpy.File = f.trimPath(frame.File)
+
+	return &cpy
+}
+
+func (f *stackFormat) color(s string, c *textColor) string {
+	if f.paint == nil {
+		return s
+	}
+
+	return f.paint(s, c)
+}
+
+// drop reports whether any predicate in f.filters hides frame.
+func (f *stackFormat) drop(frame *StackFrame) bool {
+	for _, filter := range f.filters {
+		if filter(frame) {
+			return true
+		}
+	}
+
+	return false
+}
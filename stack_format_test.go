@@ -0,0 +1,146 @@
+package ae_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.aledante.io/ae"
+)
+
+// syntheticStack returns a fixed stack with a creator and elided frames.
+func syntheticStack() *ae.Stack {
+	return &ae.Stack{
+		ID:     12,
+		State:  "chan receive",
+		Wait:   3 * time.Minute,
+		Locked: true,
+		Frames: []*ae.StackFrame{
+			{Func: "example.com/app/worker.(*Pool).run", File: "/src/app/worker/pool.go", Line: 88},
+			{Func: "example.com/app/worker.loop", File: "/src/app/worker/loop.go", Line: 21},
+			{Func: "example.com/app/internal.helper", File: "/src/app/internal/helper.go", Line: 5},
+		},
+		FramesElided: true,
+		CreatedBy:    &ae.StackFrame{Func: "example.com/app/worker.Start", File: "/src/app/worker/pool.go", Line: 40},
+	}
+}
+
+func TestStack_String(t *testing.T) {
+	t.Parallel()
+
+	want := `goroutine 12 [chan receive, 3 minutes, locked to thread]:
+example.com/app/worker.(*Pool).run()
+	/src/app/worker/pool.go:88
+example.com/app/worker.loop()
+	/src/app/worker/loop.go:21
+example.com/app/internal.helper()
+	/src/app/internal/helper.go:5
+...additional frames elided...
+created by example.com/app/worker.Start
+	/src/app/worker/pool.go:40
+`
+	if got := syntheticStack().String(); got != want {
+		t.Errorf("String() mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestStack_FormatWithOptions(t *testing.T) {
+	t.Parallel()
+
+	st := syntheticStack()
+	st.FramesElided = false
+
+	var sb strings.Builder
+	err := st.Format(&sb,
+		ae.StackMaxFrames(1),
+		ae.StackTrimPath("/src/app/"),
+		ae.StackFrameFilters(func(f *ae.StackFrame) bool {
+			return strings.Contains(f.Func, "(*Pool)")
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `goroutine 12 [chan receive, 3 minutes, locked to thread]:
+example.com/app/worker.loop()
+	worker/loop.go:21
+...additional frames elided...
+created by example.com/app/worker.Start
+	worker/pool.go:40
+`
+	if got := sb.String(); got != want {
+		t.Errorf("Format mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestStack_FormatAllFramesFiltered(t *testing.T) {
+	t.Parallel()
+
+	var sb strings.Builder
+	_ = syntheticStack().Format(&sb, ae.StackFrameFilters(func(*ae.StackFrame) bool { return true }))
+	if sb.Len() != 0 {
+		t.Errorf("Format wrote %q, want nothing when every frame is filtered", sb.String())
+	}
+}
+
+func TestPrinter_StacksMatchStackFormat(t *testing.T) {
+	t.Parallel()
+
+	st := syntheticStack()
+	err := stubErr{msg: "x", stacks: []*ae.Stack{st}}
+	out := ae.NewPrinter(
+		ae.NoPrintColors(),
+		ae.PrintStackFormat(ae.StackTrimPath("/src/app/"), ae.StackMaxFrames(2)),
+	).Prints(err)
+
+	var sb strings.Builder
+	_ = st.Format(&sb, ae.StackTrimPath("/src/app/"), ae.StackMaxFrames(2))
+
+	for _, line := range strings.Split(strings.TrimSpace(sb.String()), "\n") {
+		if !strings.Contains(out, strings.TrimSpace(line)) {
+			t.Errorf("printer output lacks %q:\n%s", line, out)
+		}
+	}
+	if strings.Contains(out, "helper.go") {
+		t.Errorf("printer ignored StackMaxFrames:\n%s", out)
+	}
+}
+
+func TestPrinter_StackCreatedByAndAncestry(t *testing.T) {
+	t.Parallel()
+
+	root := &ae.Stack{ID: 1, State: "running"}
+	parent := &ae.Stack{ID: 5, State: "running", Ancestor: root}
+	st := syntheticStack()
+	st.Ancestor = parent
+	err := stubErr{msg: "x", stacks: []*ae.Stack{st}}
+
+	out := ae.NewPrinter(ae.NoPrintColors()).Prints(err)
+	if !strings.Contains(out, "created by example.com/app/worker.Start\n") ||
+		!strings.Contains(out, "/src/app/worker/pool.go:40") {
+		t.Errorf("created-by frame missing:\n%s", out)
+	}
+	if strings.Contains(out, "ancestry:") {
+		t.Errorf("ancestry printed without PrintStackAncestry:\n%s", out)
+	}
+
+	out = ae.NewPrinter(ae.NoPrintColors(), ae.PrintStackAncestry()).Prints(err)
+	if !strings.Contains(out, "ancestry: goroutine 12 ← goroutine 5 ← goroutine 1") {
+		t.Errorf("ancestry chain missing:\n%s", out)
+	}
+}
+
+func TestStack_FormatAncestryCycle(t *testing.T) {
+	t.Parallel()
+
+	a := &ae.Stack{ID: 1}
+	b := &ae.Stack{ID: 2, Ancestor: a}
+	a.Ancestor = b
+
+	var sb strings.Builder
+	_ = b.Format(&sb, ae.StackAncestry())
+	if !strings.Contains(sb.String(), "ancestry: goroutine 2 ← goroutine 1\n") {
+		t.Errorf("Format = %q, want the cycle cut after goroutine 1", sb.String())
+	}
+}
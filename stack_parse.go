@@ -0,0 +1,65 @@
+//go:build !ae_minimal && !js && !tinygo
+
+package ae
+
+import (
+	"bytes"
+
+	"github.com/DataDog/gostackparse"
+)
+
+// parseTraceback parses Go traceback text into stacks with gostackparse,
+// returning an error for every malformed goroutine skipped.
+func parseTraceback(text []byte) ([]*Stack, []error) {
+	goroutines, errs := gostackparse.Parse(bytes.NewReader(text))
+	return stacksFromGoroutines(goroutines), errs
+}
+
+// stacksFromGoroutines converts goroutines parsed by gostackparse into
+// stacks, in the same order. It also establishes relationships between
+// goroutines by linking them to their ancestor stacks.
+func stacksFromGoroutines(goroutines []*gostackparse.Goroutine) []*Stack {
+	byID := make(map[int]*Stack, len(goroutines))
+	stacks := make([]*Stack, 0, len(goroutines))
+	for _, g := range goroutines {
+		stack := stackFromGoroutine(g)
+		byID[g.ID] = stack
+		stacks = append(stacks, stack)
+	}
+
+	for i, g := range goroutines {
+		if g.Ancestor == nil {
+			continue
+		}
+
+		if ancestor, ok := byID[g.Ancestor.ID]; ok {
+			stacks[i].Ancestor = ancestor
+		} else {
+			stacks[i].Ancestor = stackFromGoroutine(g.Ancestor)
+		}
+	}
+
+	return stacks
+}
+
+func stackFromGoroutine(g *gostackparse.Goroutine) *Stack {
+	frames := make([]*StackFrame, 0, len(g.Stack))
+	for _, frame := range g.Stack {
+		frames = append(frames, newStackFrame(frame.Func, frame.File, frame.Line))
+	}
+
+	stack := &Stack{
+		ID:           g.ID,
+		State:        g.State,
+		Wait:         g.Wait,
+		Locked:       g.LockedToThread,
+		Frames:       frames,
+		FramesElided: g.FramesElided,
+	}
+
+	if g.CreatedBy != nil {
+		stack.CreatedBy = newStackFrame(g.CreatedBy.Func, g.CreatedBy.File, g.CreatedBy.Line)
+	}
+
+	return stack
+}
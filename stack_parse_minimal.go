@@ -0,0 +1,160 @@
+//go:build ae_minimal || js || tinygo
+
+package ae
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseTraceback parses Go traceback text into stacks without gostackparse,
+// which minimal builds leave out, returning an error for every malformed
+// goroutine skipped. Ancestor goroutines, printed with
+// GODEBUG=tracebackancestors, are not parsed.
+func parseTraceback(text []byte) ([]*Stack, []error) {
+	var p tracebackParser
+	for n, line := range strings.Split(string(text), "\n") {
+		p.parseLine(n+1, strings.TrimSuffix(line, "\r"))
+	}
+	p.finish()
+
+	return p.stacks, p.errs
+}
+
+// tracebackParser holds the state of parseTraceback.
+type tracebackParser struct {
+	stacks []*Stack
+	errs   []error
+	// cur is the goroutine being parsed, nil outside of goroutines and in
+	// a malformed one.
+	cur *Stack
+	// fn is the function whose location line comes next, created the
+	// function of a "created by" line.
+	fn      string
+	created bool
+}
+
+func (p *tracebackParser) parseLine(n int, line string) {
+	switch {
+	case strings.HasPrefix(line, "goroutine "):
+		p.finish()
+		st, ok := parseGoroutineHeader(line)
+		if !ok {
+			p.fail(n, "invalid goroutine header", line)
+			return
+		}
+		p.cur = st
+	case p.cur == nil:
+		// text before the first goroutine or in a malformed one
+	case line == "":
+		p.finish()
+	case p.fn != "":
+		file, ln, ok := parseFrameLocation(line)
+		if !ok {
+			p.fail(n, "invalid frame location", line)
+			return
+		}
+		frame := newStackFrame(p.fn, file, ln)
+		if p.created {
+			p.cur.CreatedBy = frame
+		} else {
+			p.cur.Frames = append(p.cur.Frames, frame)
+		}
+		p.fn, p.created = "", false
+	case line == "...additional frames elided...":
+		p.cur.FramesElided = true
+	case strings.HasPrefix(line, "created by "):
+		fn, _, _ := strings.Cut(strings.TrimPrefix(line, "created by "), " in goroutine ")
+		p.fn, p.created = fn, true
+	case strings.HasPrefix(line, "[originating from goroutine "):
+		p.finish()
+	default:
+		i := strings.LastIndexByte(line, '(')
+		if i <= 0 || !strings.HasSuffix(line, ")") {
+			p.fail(n, "invalid function call", line)
+			return
+		}
+		p.fn = line[:i]
+	}
+}
+
+// finish ends the goroutine being parsed.
+func (p *tracebackParser) finish() {
+	if p.cur == nil {
+		return
+	}
+	if p.fn != "" {
+		p.fail(0, "missing frame location of goroutine "+strconv.Itoa(p.cur.ID), p.fn)
+		return
+	}
+
+	p.stacks = append(p.stacks, p.cur)
+	p.cur = nil
+}
+
+// fail drops the goroutine being parsed, recording why.
+func (p *tracebackParser) fail(n int, reason, line string) {
+	b := New().Tag("invalid").Attr("text", line)
+	if n > 0 {
+		b = b.Attr("line", n)
+	}
+	p.errs = append(p.errs, b.Msg(reason))
+	p.cur, p.fn, p.created = nil, "", false
+}
+
+// parseGoroutineHeader parses a "goroutine 1 [chan receive, 2 minutes,
+// locked to thread]:" line.
+func parseGoroutineHeader(line string) (*Stack, bool) {
+	id, status, ok := strings.Cut(strings.TrimPrefix(line, "goroutine "), " ")
+	if !ok || !strings.HasPrefix(status, "[") || !strings.HasSuffix(status, "]:") {
+		return nil, false
+	}
+
+	st := &Stack{}
+	var err error
+	if st.ID, err = strconv.Atoi(id); err != nil {
+		return nil, false
+	}
+
+	parts := strings.Split(status[1:len(status)-2], ", ")
+	st.State = parts[0]
+	for _, part := range parts[1:] {
+		if part == "locked to thread" {
+			st.Locked = true
+			continue
+		}
+
+		minutes, ok := strings.CutSuffix(part, " minutes")
+		if !ok {
+			minutes, ok = strings.CutSuffix(part, " minute")
+		}
+		if m, err := strconv.Atoi(minutes); ok && err == nil {
+			st.Wait = time.Duration(m) * time.Minute
+		}
+	}
+
+	return st, true
+}
+
+// parseFrameLocation parses a "\t/src/app/main.go:16 +0x45" line.
+func parseFrameLocation(line string) (string, int, bool) {
+	loc, ok := strings.CutPrefix(line, "\t")
+	if !ok {
+		return "", 0, false
+	}
+	if i := strings.LastIndex(loc, " +0x"); i >= 0 {
+		loc = loc[:i]
+	}
+
+	i := strings.LastIndexByte(loc, ':')
+	if i <= 0 {
+		return "", 0, false
+	}
+	ln, err := strconv.Atoi(loc[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+
+	return loc[:i], ln, true
+}
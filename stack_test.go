@@ -1,10 +1,16 @@
 package ae_test
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"maps"
 	"reflect"
+	"runtime"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"go.aledante.io/ae"
 )
@@ -65,6 +71,319 @@ func TestBuilder_StackHidesInternalFramesInRenderedOutput(t *testing.T) {
 	}
 }
 
+func TestBuilder_StackFramesMatchCallSites(t *testing.T) {
+	t.Parallel()
+
+	var line int
+	capture := func() error {
+		_, _, line, _ = runtime.Caller(0)
+		return ae.New().Stack().Msg("captured")
+	}
+	_, file, outerLine, _ := runtime.Caller(0)
+	err := capture()
+
+	stacks := ae.Stacks(err)
+	if len(stacks) != 1 {
+		t.Fatalf("got %d stacks, want 1", len(stacks))
+	}
+	st := stacks[0]
+	if st.ID <= 0 || st.State != "running" {
+		t.Errorf("goroutine = %d (%s), want a positive ID and running", st.ID, st.State)
+	}
+	if len(st.Frames) < 2 {
+		t.Fatalf("got %d frames, want at least 2", len(st.Frames))
+	}
+
+	top, caller := st.Frames[0], st.Frames[1]
+	if !strings.Contains(top.Func, "TestBuilder_StackFramesMatchCallSites.func") || top.File != file || top.Line != line+1 {
+		t.Errorf("top frame = %s %s:%d, want the closure at %s:%d", top.Func, top.File, top.Line, file, line+1)
+	}
+	if !strings.HasSuffix(caller.Func, ".TestBuilder_StackFramesMatchCallSites") || caller.Line != outerLine+1 {
+		t.Errorf("second frame = %s:%d, want the test at line %d", caller.Func, caller.Line, outerLine+1)
+	}
+	for _, f := range st.Frames {
+		if f.Func == "runtime.goexit" {
+			t.Error("runtime.goexit frame was not dropped")
+		}
+	}
+}
+
+func TestBuilder_StackAllCapturesOtherGoroutines(t *testing.T) {
+	t.Parallel()
+
+	block := make(chan struct{})
+	defer close(block)
+	go func() { <-block }()
+
+	stacks := ae.Stacks(ae.New().StackAll().Msg("all"))
+	if len(stacks) < 2 {
+		t.Fatalf("got %d stacks, want at least 2", len(stacks))
+	}
+	for _, st := range stacks {
+		if len(st.Frames) == 0 {
+			t.Errorf("goroutine %d has no frames", st.ID)
+		}
+	}
+}
+
+func TestStack_JSONShapeWithLazyFrames(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Stack().Msg("json")
+
+	data, jErr := json.Marshal(ae.Stacks(err))
+	if jErr != nil {
+		t.Fatal(jErr)
+	}
+
+	var decoded []map[string]any
+	if jErr := json.Unmarshal(data, &decoded); jErr != nil {
+		t.Fatal(jErr)
+	}
+	if len(decoded) != 1 {
+		t.Fatalf("got %d stacks, want 1", len(decoded))
+	}
+
+	keys := slices.Sorted(maps.Keys(decoded[0]))
+	want := []string{"ancestor", "elided_count", "frames", "frames_elided", "id", "locked", "parent", "state", "wait"}
+	if !slices.Equal(keys, want) {
+		t.Errorf("stack keys = %v, want %v", keys, want)
+	}
+	frames, _ := decoded[0]["frames"].([]any)
+	if len(frames) == 0 {
+		t.Fatal("frames were not resolved before marshalling")
+	}
+	frameKeys := slices.Sorted(maps.Keys(frames[0].(map[string]any)))
+	if !slices.Equal(frameKeys, []string{"file", "func", "in_app", "line", "module"}) {
+		t.Errorf("frame keys = %v, want [file func in_app line module]", frameKeys)
+	}
+}
+
+// panicTrace is the output of a real panic (GOTRACEBACK=all) with the
+// source directory rewritten.
+const panicTrace = `panic: runtime error: index out of range [2] with length 2
+
+goroutine 7 [running]:
+main.(*worker).process(...)
+	/src/app/main.go:7
+main.main.func2()
+	/src/app/main.go:16 +0x45
+created by main.main in goroutine 1
+	/src/app/main.go:14 +0xdc
+
+goroutine 1 [semacquire, 2 minutes, locked to thread]:
+sync.runtime_SemacquireWaitGroup(0x10f70f16c060?, 0xe0?)
+	/usr/local/go/src/runtime/sema.go:114 +0x2e
+sync.(*WaitGroup).Wait(0x10f70f16a120)
+	/usr/local/go/src/sync/waitgroup.go:206 +0x85
+main.main()
+	/src/app/main.go:18 +0xe6
+
+goroutine 6 [chan receive]:
+main.main.func1()
+	/src/app/main.go:13
+...additional frames elided...
+created by main.main in goroutine 1
+	/src/app/main.go:13 +0x96
+`
+
+func TestParseStacks_PanicTrace(t *testing.T) {
+	t.Parallel()
+
+	stacks, err := ae.ParseStacks([]byte(panicTrace))
+	if err != nil {
+		t.Fatalf("ParseStacks: %v", err)
+	}
+	if len(stacks) != 3 {
+		t.Fatalf("got %d stacks, want 3", len(stacks))
+	}
+
+	first := stacks[0]
+	if first.ID != 7 || first.State != "running" || len(first.Frames) != 2 {
+		t.Errorf("first stack = goroutine %d (%s) with %d frames, want 7 (running) with 2", first.ID, first.State, len(first.Frames))
+	}
+	if f := first.Frames[0]; f.Func != "main.(*worker).process" || f.File != "/src/app/main.go" || f.Line != 7 {
+		t.Errorf("top frame = %+v", f)
+	}
+	if cb := first.CreatedBy; cb == nil || cb.Func != "main.main" || cb.Line != 14 {
+		t.Errorf("created by = %+v, want main.main at line 14", cb)
+	}
+
+	main := stacks[1]
+	if main.State != "semacquire" || main.Wait != 2*time.Minute || !main.Locked || main.CreatedBy != nil {
+		t.Errorf("main stack = %+v, want semacquire, 2m, locked, no creator", main)
+	}
+
+	if !stacks[2].FramesElided {
+		t.Error("FramesElided = false for the goroutine with the elision marker")
+	}
+}
+
+func TestParseStacks_TruncatedTraceReturnsPartialResult(t *testing.T) {
+	t.Parallel()
+
+	truncated := panicTrace[:strings.Index(panicTrace, "sync.(*WaitGroup).Wait")] + "sync.(*Wait"
+
+	stacks, err := ae.ParseStacks([]byte(truncated))
+	if err == nil {
+		t.Fatal("ParseStacks returned no error for a truncated trace")
+	}
+	if len(stacks) != 1 || stacks[0].ID != 7 {
+		t.Fatalf("got %d stacks, want the complete goroutine 7 only", len(stacks))
+	}
+
+	attached := ae.Stacks(ae.New().StacksFrom(stacks...).Msg("crash report"))
+	if len(attached) != 1 || attached[0] != stacks[0] {
+		t.Errorf("StacksFrom attached %v, want the parsed stack", attached)
+	}
+}
+
+func TestParseStacks_NoGoroutine(t *testing.T) {
+	t.Parallel()
+
+	if stacks, err := ae.ParseStacks([]byte("just some log line\n")); err == nil || len(stacks) != 0 {
+		t.Errorf("ParseStacks = %v, %v; want no stacks and an error", stacks, err)
+	}
+	if stacks, err := ae.ParseStacks(nil); err != nil || len(stacks) != 0 {
+		t.Errorf("ParseStacks(nil) = %v, %v; want nothing", stacks, err)
+	}
+}
+
+// recurse calls itself depth times and then returns the error built by fn.
+func recurse(depth int, fn func() error) error {
+	if depth == 0 {
+		return fn()
+	}
+	return recurse(depth-1, fn)
+}
+
+func TestBuilder_StackLimitCapsDeepRecursion(t *testing.T) {
+	t.Parallel()
+
+	err := recurse(500, func() error { return ae.New().StackLimit(40).Msg("deep") })
+
+	st := ae.Stacks(err)[0]
+	if !st.FramesElided {
+		t.Error("FramesElided = false for a capped stack")
+	}
+	if st.ElidedCount < 460 {
+		t.Errorf("ElidedCount = %d, want at least 460", st.ElidedCount)
+	}
+	if len(st.Frames) > 40 {
+		t.Errorf("got %d frames, want at most 40", len(st.Frames))
+	}
+
+	// The outermost frames survive the cap, so the origin stays visible.
+	last := st.Frames[len(st.Frames)-1]
+	if last.Func != "testing.tRunner" {
+		t.Errorf("outermost frame = %s, want testing.tRunner", last.Func)
+	}
+
+	text := st.String()
+	marker := fmt.Sprintf("...%d frames elided...", st.ElidedCount)
+	if !strings.Contains(text, marker) {
+		t.Fatalf("String() lacks %q:\n%s", marker, text)
+	}
+	if strings.Index(text, marker) > strings.Index(text, "testing.tRunner") {
+		t.Errorf("elision marker is not placed before the outermost frames:\n%s", text)
+	}
+
+	out := ae.NewPrinter(ae.NoPrintColors()).Prints(err)
+	if !strings.Contains(out, marker) {
+		t.Errorf("printer output lacks %q:\n%s", marker, out)
+	}
+	data, _ := json.Marshal(st)
+	if !strings.Contains(string(data), fmt.Sprintf(`"elided_count":%d`, st.ElidedCount)) {
+		t.Errorf("JSON lacks elided_count: %s", data)
+	}
+}
+
+func TestSetMaxStackFrames(t *testing.T) {
+	withPackageState(t, func() { ae.SetMaxStackFrames(64) })
+
+	ae.SetMaxStackFrames(20)
+	st := ae.Stacks(recurse(100, func() error { return ae.New().Stack().Msg("deep") }))[0]
+	if len(st.Frames) > 20 || !st.FramesElided {
+		t.Errorf("got %d frames (elided: %v), want at most 20 and elided", len(st.Frames), st.FramesElided)
+	}
+
+	ae.SetMaxStackFrames(0)
+	st = ae.Stacks(recurse(100, func() error { return ae.New().Stack().Msg("deep") }))[0]
+	if len(st.Frames) < 100 || st.FramesElided || st.ElidedCount != 0 {
+		t.Errorf("got %d frames (elided: %v, %d), want all frames without the cap", len(st.Frames), st.FramesElided, st.ElidedCount)
+	}
+}
+
+func deepStackErr() error {
+	return ae.New().Stack().Msg("deep")
+}
+
+func TestBuilder_StackDedupesRewrappedStacks(t *testing.T) {
+	t.Parallel()
+
+	inner := deepStackErr()
+	middle := ae.From(inner).Stack().Msg("middle")
+	outer := ae.From(middle).Stack().Msg("outer")
+
+	stacks := ae.Stacks(outer)
+	if len(stacks) != 1 {
+		t.Fatalf("got %d stacks, want 1", len(stacks))
+	}
+	if top := stacks[0].Frames[0]; !strings.HasSuffix(top.Func, ".deepStackErr") {
+		t.Errorf("top frame = %s, want the deeper stack of deepStackErr to be kept", top.Func)
+	}
+}
+
+func TestBuilder_StackKeepsStacksOfDifferentGoroutines(t *testing.T) {
+	t.Parallel()
+
+	errc := make(chan error)
+	go func() { errc <- deepStackErr() }()
+	inner := <-errc
+
+	outer := ae.From(inner).Stack().Msg("outer")
+
+	stacks := ae.Stacks(outer)
+	if len(stacks) != 2 {
+		t.Fatalf("got %d stacks, want 2", len(stacks))
+	}
+	if stacks[0].ID == stacks[1].ID {
+		t.Errorf("both stacks belong to goroutine %d", stacks[0].ID)
+	}
+	if got := ae.Stacks(inner)[0].ID; stacks[0].ID != got {
+		t.Errorf("first stack is goroutine %d, want the inherited goroutine %d first", stacks[0].ID, got)
+	}
+}
+
+func TestSetStackDedupe(t *testing.T) {
+	withPackageState(t, func() { ae.SetStackDedupe(true) })
+
+	ae.SetStackDedupe(false)
+	outer := ae.From(deepStackErr()).Stack().Msg("outer")
+	if got := len(ae.Stacks(outer)); got != 2 {
+		t.Errorf("got %d stacks with deduplication disabled, want 2", got)
+	}
+}
+
+func BenchmarkBuilder_Stack(b *testing.B) {
+	for b.Loop() {
+		_ = ae.New().Stack().Msg("bench")
+	}
+}
+
+func BenchmarkBuilder_StackPrinted(b *testing.B) {
+	p := ae.NewPrinter(ae.NoPrintColors())
+	for b.Loop() {
+		_ = p.Prints(ae.New().Stack().Msg("bench"))
+	}
+}
+
+func BenchmarkBuilder_StackAll(b *testing.B) {
+	for b.Loop() {
+		_ = ae.New().StackAll().Msg("bench")
+	}
+}
+
 func TestStackFrame_FieldsExported(t *testing.T) {
 	t.Parallel()
 
@@ -89,3 +408,75 @@ func TestStackFrame_FieldsExported(t *testing.T) {
 		t.Errorf("Stack.Frames not as expected: %+v", s.Frames)
 	}
 }
+
+// pkgFrame and pkgStackTrace mirror github.com/pkg/errors' Frame and
+// StackTrace types: a named slice of named uintptr program counters.
+type pkgFrame uintptr
+type pkgStackTrace []pkgFrame
+
+// pkgStackErr mirrors a github.com/pkg/errors error carrying a stack.
+type pkgStackErr struct {
+	msg   string
+	cause error
+	stack []uintptr
+}
+
+func newPkgStackErr(msg string, cause error) *pkgStackErr {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	return &pkgStackErr{msg: msg, cause: cause, stack: pcs[:n]}
+}
+
+func (e *pkgStackErr) Error() string { return e.msg }
+func (e *pkgStackErr) Unwrap() error { return e.cause }
+
+func (e *pkgStackErr) StackTrace() pkgStackTrace {
+	st := make(pkgStackTrace, len(e.stack))
+	for i, pc := range e.stack {
+		st[i] = pkgFrame(pc)
+	}
+	return st
+}
+
+func TestFrom_ConvertsPkgErrorsStackTrace(t *testing.T) {
+	t.Parallel()
+
+	_, file, line, _ := runtime.Caller(0)
+	src := newPkgStackErr("boom", nil)
+
+	stacks := ae.Stacks(ae.From(src).Msg("converted"))
+	if len(stacks) != 1 {
+		t.Fatalf("got %d stacks, want 1", len(stacks))
+	}
+
+	top := stacks[0].Frames[0]
+	if !strings.HasSuffix(top.Func, ".TestFrom_ConvertsPkgErrorsStackTrace") {
+		t.Errorf("top frame func = %q, want the test function", top.Func)
+	}
+	if top.File != file || top.Line != line+1 {
+		t.Errorf("top frame = %s:%d, want %s:%d", top.File, top.Line, file, line+1)
+	}
+}
+
+func TestFrom_UsesDeepestPkgErrorsStackTrace(t *testing.T) {
+	t.Parallel()
+
+	inner := func() error { return newPkgStackErr("inner", nil) }()
+	outer := newPkgStackErr("outer", fmt.Errorf("ctx: %w", inner))
+
+	stacks := ae.Stacks(ae.From(outer).Msg("converted"))
+	if len(stacks) != 1 {
+		t.Fatalf("got %d stacks, want 1", len(stacks))
+	}
+	if top := stacks[0].Frames[0]; !strings.Contains(top.Func, "TestFrom_UsesDeepestPkgErrorsStackTrace.func") {
+		t.Errorf("top frame func = %q, want the closure creating the inner error", top.Func)
+	}
+}
+
+func TestFrom_NoStackTraceMethodNoStacks(t *testing.T) {
+	t.Parallel()
+
+	if got := ae.Stacks(ae.From(errors.New("plain")).Msg("x")); len(got) != 0 {
+		t.Errorf("From(plain) produced stacks: %v", got)
+	}
+}
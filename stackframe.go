@@ -0,0 +1,198 @@
+package ae
+
+import (
+	"runtime/debug"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// inAppPrefixes holds the function prefixes considered application code, as
+// set by SetInAppPrefixes. nil means the main module is used.
+var inAppPrefixes atomic.Pointer[[]string]
+
+// SetInAppPrefixes configures which frames are marked InApp: those whose
+// function's package path equals one of prefixes or lies below it. By
+// default the main module's path (from the build info) is used. Calling it
+// without arguments restores the default. Frames are marked when a stack is
+// first read, e.g. printed, so stacks captured before the call but not read
+// yet are affected as well; frames already read keep their marking. Call it
+// at startup, before errors are created.
+func SetInAppPrefixes(prefixes ...string) {
+	if len(prefixes) == 0 {
+		inAppPrefixes.Store(nil)
+		return
+	}
+
+	cpy := append([]string(nil), prefixes...)
+	inAppPrefixes.Store(&cpy)
+}
+
+// buildModules returns the main module path and the paths of all modules
+// the binary was built with, read once from the build info.
+var buildModules = sync.OnceValues(func() (string, []string) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "", nil
+	}
+
+	modules := make([]string, 0, len(info.Deps)+1)
+	if info.Main.Path != "" {
+		modules = append(modules, info.Main.Path)
+	}
+	for _, dep := range info.Deps {
+		modules = append(modules, dep.Path)
+	}
+
+	return info.Main.Path, modules
+})
+
+// newStackFrame creates a frame and derives its Module and InApp fields.
+func newStackFrame(fn, file string, line int) *StackFrame {
+	f := &StackFrame{
+		Func: fn,
+		File: file,
+		Line: line,
+	}
+
+	// External test packages ("pkg_test") belong to the module of pkg.
+	pkg := strings.TrimSuffix(f.Package(), "_test")
+	_, modules := buildModules()
+	for _, mod := range modules {
+		if hasPathPrefix(pkg, mod) && len(mod) > len(f.Module) {
+			f.Module = mod
+		}
+	}
+
+	f.InApp = isInApp(pkg)
+
+	return f
+}
+
+// isInApp reports whether pkg belongs to the application code.
+func isInApp(pkg string) bool {
+	if pkg == "main" {
+		return true
+	}
+
+	if prefixes := inAppPrefixes.Load(); prefixes != nil {
+		for _, prefix := range *prefixes {
+			if hasPathPrefix(pkg, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	mainMod, _ := buildModules()
+	return mainMod != "" && hasPathPrefix(pkg, mainMod)
+}
+
+// hasPathPrefix reports whether path equals prefix or is below it.
+func hasPathPrefix(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// Package returns the import path of the package the frame's function
+// belongs to, e.g. "github.com/me/app/server" for
+// "github.com/me/app/server.(*Handler).Get".
+func (f *StackFrame) Package() string {
+	pkg, _ := splitFunc(f.Func)
+	return pkg
+}
+
+// Receiver returns the receiver type of the frame's method, e.g. "*Handler"
+// for "server.(*Handler).Get" and "Handler" for "server.Handler.Get", without
+// generic type arguments. Returns "" for plain functions.
+func (f *StackFrame) Receiver() string {
+	_, rest := splitFunc(f.Func)
+
+	if strings.HasPrefix(rest, "(") {
+		if end := strings.IndexByte(rest, ')'); end > 0 {
+			return rest[1:end]
+		}
+		return ""
+	}
+
+	name, sub, ok := strings.Cut(rest, ".")
+	if !ok || strings.HasPrefix(sub, ".") || isClosureName(sub) {
+		return ""
+	}
+
+	return name
+}
+
+// FuncName returns the name of the frame's function without package and
+// receiver, keeping closure suffixes, e.g. "Get.func1" for
+// "server.(*Handler).Get.func1". Generic type arguments are removed.
+func (f *StackFrame) FuncName() string {
+	_, rest := splitFunc(f.Func)
+
+	if strings.HasPrefix(rest, "(") {
+		if end := strings.Index(rest, ")."); end > 0 {
+			return rest[end+2:]
+		}
+		return rest
+	}
+
+	if recv := f.Receiver(); recv != "" {
+		return rest[len(recv)+1:]
+	}
+
+	return rest
+}
+
+// splitFunc splits a fully qualified function name into the package path and
+// the remainder, after removing generic type arguments ("[...]" or
+// "[go.shape.int]"), which may contain dots and slashes of their own. Dots in
+// the last element of the package path are escaped as "%2e" by the compiler,
+// so the first dot after the last slash ends the package path.
+func splitFunc(fn string) (pkg, rest string) {
+	fn = stripTypeArgs(fn)
+
+	slash := strings.LastIndexByte(fn, '/')
+	dot := strings.IndexByte(fn[slash+1:], '.')
+	if dot < 0 {
+		return "", fn
+	}
+
+	return strings.ReplaceAll(fn[:slash+1+dot], "%2e", "."), fn[slash+1+dot+1:]
+}
+
+// stripTypeArgs removes bracketed generic type arguments from fn.
+func stripTypeArgs(fn string) string {
+	if !strings.Contains(fn, "[") {
+		return fn
+	}
+
+	var sb strings.Builder
+	depth := 0
+	for _, r := range fn {
+		switch {
+		case r == '[':
+			depth++
+		case r == ']' && depth > 0:
+			depth--
+		case depth == 0:
+			sb.WriteRune(r)
+		}
+	}
+
+	return sb.String()
+}
+
+// isClosureName reports whether s, the part after the first dot of a
+// function name, names a closure ("func1", "func1.2"), a compiler generated
+// wrapper ("deferwrap1", "gowrap1") or a numbered function such as "init.0"
+// rather than a method.
+func isClosureName(s string) bool {
+	s, _, _ = strings.Cut(s, ".")
+	for _, prefix := range []string{"func", "deferwrap", "gowrap"} {
+		if trimmed, ok := strings.CutPrefix(s, prefix); ok {
+			s = trimmed
+			break
+		}
+	}
+
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
@@ -0,0 +1,83 @@
+package ae_test
+
+import (
+	"strings"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+func TestStackFrame_SymbolParts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		fn       string
+		pkg      string
+		receiver string
+		name     string
+	}{
+		{"main.main", "main", "", "main"},
+		{"main.main.func1", "main", "", "main.func1"},
+		{"main.main.func1.2", "main", "", "main.func1.2"},
+		{"main.init.0", "main", "", "init.0"},
+		{"main.glob..func1", "main", "", "glob..func1"},
+		{"main.run.deferwrap1", "main", "", "run.deferwrap1"},
+		{"main.run.gowrap2", "main", "", "run.gowrap2"},
+		{"runtime.goexit", "runtime", "", "goexit"},
+		{"net/http.(*Server).Serve", "net/http", "*Server", "Serve"},
+		{"net/http.HandlerFunc.ServeHTTP", "net/http", "HandlerFunc", "ServeHTTP"},
+		{"github.com/me/app/server.(*Handler).Get", "github.com/me/app/server", "*Handler", "Get"},
+		{"github.com/me/app/server.(*Handler).Get.func1", "github.com/me/app/server", "*Handler", "Get.func1"},
+		{"github.com/me/app/server.Handler.Get-fm", "github.com/me/app/server", "Handler", "Get-fm"},
+		{"github.com/me/app.v2/x.Map[...]", "github.com/me/app.v2/x", "", "Map"},
+		{"github.com/me/app/x.Map[go.shape.string,go.shape.int]", "github.com/me/app/x", "", "Map"},
+		{"github.com/me/app/x.(*List[...]).Push", "github.com/me/app/x", "*List", "Push"},
+		{"github.com/me/app/x.(*List[go.shape.*example.com/y.T]).Push.func2", "github.com/me/app/x", "*List", "Push.func2"},
+		{"github.com/me/app/x.Set[...].Add", "github.com/me/app/x", "Set", "Add"},
+		{"gopkg.in/yaml%2ev3.(*parser).parse", "gopkg.in/yaml.v3", "*parser", "parse"},
+		{"main.main-range1", "main", "", "main-range1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fn, func(t *testing.T) {
+			t.Parallel()
+
+			f := &ae.StackFrame{Func: tt.fn}
+			if got := f.Package(); got != tt.pkg {
+				t.Errorf("Package() = %q, want %q", got, tt.pkg)
+			}
+			if got := f.Receiver(); got != tt.receiver {
+				t.Errorf("Receiver() = %q, want %q", got, tt.receiver)
+			}
+			if got := f.FuncName(); got != tt.name {
+				t.Errorf("FuncName() = %q, want %q", got, tt.name)
+			}
+		})
+	}
+}
+
+func TestSetInAppPrefixes(t *testing.T) {
+	withPackageState(t, func() { ae.SetInAppPrefixes() })
+
+	frames := func() []*ae.StackFrame {
+		return ae.Stacks(ae.New().Stack().Msg("x"))[0].Frames
+	}
+
+	// By default the main module (this one, in tests) is application code.
+	top := frames()[0]
+	if !top.InApp || top.Module != "go.aledante.io/ae" {
+		t.Errorf("top frame %s: InApp = %v, Module = %q; want true, go.aledante.io/ae", top.Func, top.InApp, top.Module)
+	}
+	for _, f := range frames() {
+		if strings.HasPrefix(f.Func, "testing.") && (f.InApp || f.Module != "") {
+			t.Errorf("stdlib frame %s: InApp = %v, Module = %q", f.Func, f.InApp, f.Module)
+		}
+	}
+
+	ae.SetInAppPrefixes("testing")
+	for _, f := range frames() {
+		if want := f.Package() == "testing"; f.InApp != want {
+			t.Errorf("frame %s: InApp = %v, want %v", f.Func, f.InApp, want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package ae
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+)
+
+// StatsSnapshot holds the error counters maintained once EnableStats is
+// called, as returned by Stats.
+type StatsSnapshot struct {
+	// Total is the number of errors finalized.
+	Total int64 `json:"total"`
+	// Recoverable and NonRecoverable split Total by whether the errors
+	// themselves are recoverable, see Builder.Recoverable.
+	Recoverable    int64 `json:"recoverable"`
+	NonRecoverable int64 `json:"non_recoverable"`
+	// ByCode counts the errors by code, "none" for errors without one.
+	ByCode map[string]int64 `json:"by_code"`
+	// ByTag counts the errors by their first tag in sorted order, "none" for
+	// errors without tags.
+	ByTag map[string]int64 `json:"by_tag"`
+}
+
+const (
+	// statsNone counts the errors without a code or tag.
+	statsNone = "none"
+	// statsOther counts the errors whose code or tag exceeds statsMaxKeys.
+	statsOther = "other"
+	// statsMaxKeys bounds the distinct codes and tags counted, so dynamic
+	// values can't grow the counters without bound.
+	statsMaxKeys = 1000
+)
+
+// statsCounters are the counters behind Stats.
+type statsCounters struct {
+	total          atomic.Int64
+	recoverable    atomic.Int64
+	nonRecoverable atomic.Int64
+	byCode         statsCounterMap
+	byTag          statsCounterMap
+}
+
+// statsCounterMap counts by key. Counting an existing key only loads from
+// the map and adds atomically.
+type statsCounterMap struct {
+	counters sync.Map // string -> *atomic.Int64
+	keys     atomic.Int64
+}
+
+var (
+	// stats holds the current counters; ResetStats replaces them.
+	stats atomic.Pointer[statsCounters]
+
+	// statsMu guards removeStatsHook, the removal of the finalize hook
+	// installed by EnableStats, and statsPublished.
+	statsMu         sync.Mutex
+	removeStatsHook func()
+	statsPublished  bool
+)
+
+func init() {
+	stats.Store(&statsCounters{})
+}
+
+// EnableStats starts counting every error finalized by a Builder (see
+// OnFinalize) for Stats, and publishes the counters as the expvar "ae", so a
+// server exposing expvar's /debug/vars shows them, unless another package
+// published that name first. Counting is cheap but not free, which is why it
+// is opt-in. Calling EnableStats again has no effect; DisableStats stops
+// counting.
+func EnableStats() {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	if removeStatsHook != nil {
+		return
+	}
+	removeStatsHook = OnFinalize(countStats)
+
+	if !statsPublished && expvar.Get("ae") == nil {
+		expvar.Publish("ae", expvar.Func(func() any { return Stats() }))
+	}
+	statsPublished = true
+}
+
+// DisableStats stops counting errors for Stats; the counters keep their
+// values until ResetStats. The expvar stays published, as expvar can't
+// unpublish a name.
+func DisableStats() {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	if removeStatsHook != nil {
+		removeStatsHook()
+		removeStatsHook = nil
+	}
+}
+
+// Stats returns a snapshot of the error counters, all zero unless
+// EnableStats was called. At most 1000 distinct codes and tags are counted
+// individually; further ones are counted as "other".
+func Stats() StatsSnapshot {
+	s := stats.Load()
+
+	return StatsSnapshot{
+		Total:          s.total.Load(),
+		Recoverable:    s.recoverable.Load(),
+		NonRecoverable: s.nonRecoverable.Load(),
+		ByCode:         s.byCode.snapshot(),
+		ByTag:          s.byTag.snapshot(),
+	}
+}
+
+// ResetStats sets every counter back to zero, e.g. between tests.
+func ResetStats() {
+	stats.Store(&statsCounters{})
+}
+
+// countStats is the finalize hook installed by EnableStats. It reads the
+// fields of the error itself rather than walking its causes, so wrapping
+// stays linear in the depth of the tree.
+func countStats(err error) {
+	a, ok := err.(*Ae)
+	if !ok {
+		return
+	}
+	s := stats.Load()
+
+	s.total.Add(1)
+	if a.recoverable {
+		s.recoverable.Add(1)
+	} else {
+		s.nonRecoverable.Add(1)
+	}
+
+	code := a.code
+	if code == "" {
+		code = statsNone
+	}
+	s.byCode.inc(code)
+
+	tag := statsNone
+	if tags := a.tagList(); len(tags) > 0 {
+		tag = tags[0]
+	}
+	s.byTag.inc(tag)
+}
+
+// inc increments the counter for key, or for "other" once statsMaxKeys keys
+// are counted.
+func (m *statsCounterMap) inc(key string) {
+	if c, ok := m.counters.Load(key); ok {
+		c.(*atomic.Int64).Add(1)
+		return
+	}
+
+	if m.keys.Load() >= statsMaxKeys {
+		key = statsOther
+	}
+	c, loaded := m.counters.LoadOrStore(key, new(atomic.Int64))
+	if !loaded {
+		m.keys.Add(1)
+	}
+	c.(*atomic.Int64).Add(1)
+}
+
+func (m *statsCounterMap) snapshot() map[string]int64 {
+	out := make(map[string]int64)
+	m.counters.Range(func(key, c any) bool {
+		out[key.(string)] = c.(*atomic.Int64).Load()
+		return true
+	})
+
+	return out
+}
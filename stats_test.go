@@ -0,0 +1,118 @@
+package ae_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"maps"
+	"sync"
+	"testing"
+
+	"go.aledante.io/ae"
+)
+
+func enableStats(t *testing.T) {
+	t.Helper()
+
+	ae.EnableStats()
+	ae.ResetStats()
+	withPackageState(t, func() {
+		ae.DisableStats()
+		ae.ResetStats()
+	})
+}
+
+func TestStats_CountsFinalizedErrors(t *testing.T) {
+	enableStats(t)
+
+	ae.New().Code("DB_TIMEOUT").Tags("retry", "db").Msg("a")
+	ae.New().Code("DB_TIMEOUT").Tag("db").Fatal().Msg("b")
+	ae.Wrap("importing", ae.New().Code("NOT_FOUND").Msg("c"))
+
+	want := ae.StatsSnapshot{
+		Total:          4,
+		Recoverable:    3,
+		NonRecoverable: 1,
+		ByCode:         map[string]int64{"DB_TIMEOUT": 2, "NOT_FOUND": 1, "none": 1},
+		ByTag:          map[string]int64{"db": 2, "none": 2},
+	}
+	got := ae.Stats()
+	if got.Total != want.Total || got.Recoverable != want.Recoverable || got.NonRecoverable != want.NonRecoverable {
+		t.Errorf("totals = %d/%d/%d, want %d/%d/%d", got.Total, got.Recoverable, got.NonRecoverable,
+			want.Total, want.Recoverable, want.NonRecoverable)
+	}
+	if !maps.Equal(got.ByCode, want.ByCode) {
+		t.Errorf("ByCode = %v, want %v", got.ByCode, want.ByCode)
+	}
+	if !maps.Equal(got.ByTag, want.ByTag) {
+		t.Errorf("ByTag = %v, want %v", got.ByTag, want.ByTag)
+	}
+
+	v := expvar.Get("ae")
+	if v == nil {
+		t.Fatal("stats not published to expvar")
+	}
+	var published ae.StatsSnapshot
+	if err := json.Unmarshal([]byte(v.String()), &published); err != nil {
+		t.Fatalf("invalid expvar JSON: %v\n%s", err, v.String())
+	}
+	if published.Total != 4 || !maps.Equal(published.ByCode, want.ByCode) {
+		t.Errorf("expvar = %s", v.String())
+	}
+
+	ae.ResetStats()
+	if got := ae.Stats(); got.Total != 0 || len(got.ByCode) != 0 {
+		t.Errorf("after ResetStats: %+v", got)
+	}
+}
+
+func TestStats_Concurrent(t *testing.T) {
+	enableStats(t)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 100 {
+				ae.New().Code("C").Msg("x")
+			}
+		})
+	}
+	wg.Wait()
+
+	if got := ae.Stats(); got.Total != 800 || got.ByCode["C"] != 800 {
+		t.Errorf("Stats = %+v, want 800 errors with code C", got)
+	}
+}
+
+func TestStats_Disable(t *testing.T) {
+	enableStats(t)
+
+	ae.New().Msg("counted")
+	ae.DisableStats()
+	ae.New().Msg("not counted")
+
+	if got := ae.Stats().Total; got != 1 {
+		t.Errorf("Total = %d, want 1", got)
+	}
+
+	ae.EnableStats()
+	ae.New().Msg("counted again")
+	if got := ae.Stats().Total; got != 2 {
+		t.Errorf("Total after re-enabling = %d, want 2", got)
+	}
+}
+
+func TestStats_CountsOwnFields(t *testing.T) {
+	enableStats(t)
+
+	// the wrapper is counted by its own fields, not those of its cause
+	inner := ae.New().Code("INNER").Tag("db").Fatal().Msg("inner")
+	ae.Wrap("outer", inner)
+
+	got := ae.Stats()
+	if got.Recoverable != 1 || got.NonRecoverable != 1 {
+		t.Errorf("recoverable/non-recoverable = %d/%d, want 1/1", got.Recoverable, got.NonRecoverable)
+	}
+	if want := map[string]int64{"INNER": 1, "none": 1}; !maps.Equal(got.ByCode, want) {
+		t.Errorf("ByCode = %v, want %v", got.ByCode, want)
+	}
+}
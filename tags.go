@@ -3,6 +3,7 @@ package ae
 import (
 	"context"
 	"slices"
+	"strings"
 )
 
 // ErrorTags defines an interface for errors that can provide a list of tags.
@@ -13,7 +14,7 @@ type ErrorTags interface {
 }
 
 // Tags extracts the list of tags from an error.
-// If the error implements ErrorTags, returns its ErrorTags().
+// If the error implements ErrorTags, returns its ErrorTags(), sorted lexically.
 // Returns nil if err is nil or if the error does not implement ErrorTags.
 func Tags(err error) []string {
 	if err == nil {
@@ -21,12 +22,67 @@ func Tags(err error) []string {
 	}
 
 	if ae, ok := err.(ErrorTags); ok {
-		return ae.ErrorTags()
+		tags := ae.ErrorTags()
+		if !slices.IsSorted(tags) {
+			tags = slices.Sorted(slices.Values(tags))
+		}
+		return tags
 	}
 
 	return nil
 }
 
+// HasTagPrefix reports whether err or any error in its cause tree has a tag
+// in the namespace prefix: the tag prefix itself or one below it, such as
+// "db/timeout" for the prefix "db". "dbx" is not under "db". A trailing "/"
+// in prefix is ignored.
+func HasTagPrefix(err error, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	return walkCauses(err, func(err error) bool {
+		for tag := range tagSeq(err) {
+			if tagUnder(tag, prefix) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// TagsUnder returns the tags in the namespace prefix (see HasTagPrefix) of
+// err and every error in its cause tree, sorted and without duplicates.
+func TagsUnder(err error, prefix string) []string {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	var tags []string
+	walkCauses(err, func(err error) bool {
+		for tag := range tagSeq(err) {
+			if tagUnder(tag, prefix) {
+				tags = append(tags, tag)
+			}
+		}
+		return true
+	})
+	slices.Sort(tags)
+
+	return slices.Compact(tags)
+}
+
+// MatchTagPrefix returns a predicate reporting whether an error has a tag in
+// the namespace prefix, see HasTagPrefix. It can be passed where a matcher
+// is expected, e.g. aetest.AssertMatches.
+func MatchTagPrefix(prefix string) func(err error) bool {
+	return func(err error) bool {
+		return HasTagPrefix(err, prefix)
+	}
+}
+
+// tagUnder reports whether tag is prefix or a tag below it.
+func tagUnder(tag, prefix string) bool {
+	rest, ok := strings.CutPrefix(tag, prefix)
+	return ok && (rest == "" || rest[0] == '/')
+}
+
 type tagKey struct{}
 
 // WithTagsValue returns a new context with the given tags added to it.
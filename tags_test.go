@@ -1,10 +1,13 @@
 package ae_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"log/slog"
 	"reflect"
 	"slices"
+	"strings"
 	"testing"
 
 	"go.aledante.io/ae"
@@ -93,3 +96,160 @@ func TestBuilder_ContextPullsTagsIntoError(t *testing.T) {
 		t.Errorf("Tags after NewC = %v, want to contain %q", got, "ctx-tag")
 	}
 }
+
+// dropErrTimestamp drops the timestamp of the logged error, which differs
+// between errors built one after the other.
+func dropErrTimestamp(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 && a.Key == "timestamp" {
+		return slog.Attr{}
+	}
+	return a
+}
+
+func TestTags_SortedAndDeterministicOutput(t *testing.T) {
+	t.Parallel()
+
+	tags := []string{"j", "c", "h", "a", "f", "e", "i", "b", "g", "d"}
+	want := slices.Sorted(slices.Values(tags))
+
+	var first [3]string
+	for i := range 100 {
+		err := ae.New().Tags(tags...).Attr("k1", 1).Attr("k2", 2).Attr("k3", 3).Msg("x")
+
+		if got := ae.Tags(err); !slices.Equal(got, want) {
+			t.Fatalf("Tags = %v, want %v", got, want)
+		}
+
+		var logBuf bytes.Buffer
+		slog.New(slog.NewTextHandler(&logBuf, &slog.HandlerOptions{ReplaceAttr: dropErrTimestamp})).Error("failed", "err", err)
+		out := [3]string{
+			ae.NewPrinter(ae.NoPrintColors(), ae.NoPrintTimestamp()).Prints(err),
+			ae.NewPrinter(ae.PrintJSON(), ae.NoPrintTimestamp()).Prints(err),
+			strings.SplitN(logBuf.String(), " ", 2)[1], // drop the time
+		}
+
+		if i == 0 {
+			first = out
+			continue
+		}
+		if out != first {
+			t.Fatalf("output differs on repetition %d:\n%q\nvs\n%q", i, out, first)
+		}
+	}
+}
+
+func TestTags_SortsCustomErrorTags(t *testing.T) {
+	t.Parallel()
+
+	err := stubErr{msg: "x", tags: []string{"b", "a"}}
+	if got := ae.Tags(err); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("Tags = %v, want [a b]", got)
+	}
+}
+
+func TestHasTagPrefix(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().
+		Tag("http").
+		Cause(ae.New().Cause(ae.New().Tags("db/pool/exhausted", "dbx").Msg("acquire")).Msg("query")).
+		Msg("handler")
+
+	tests := []struct {
+		prefix string
+		want   bool
+	}{
+		{"http", true},
+		{"db", true},
+		{"db/", true},
+		{"db/pool", true},
+		{"db/pool/exhausted", true},
+		{"db/po", false},
+		{"dbx", true},
+		{"d", false},
+		{"cache", false},
+	}
+	for _, tt := range tests {
+		if got := ae.HasTagPrefix(err, tt.prefix); got != tt.want {
+			t.Errorf("HasTagPrefix(%q) = %t, want %t", tt.prefix, got, tt.want)
+		}
+	}
+
+	if ae.HasTagPrefix(nil, "db") {
+		t.Error("HasTagPrefix(nil) = true")
+	}
+}
+
+func TestHasTagPrefix_PrefixCollision(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Tags("dbx", "dbx/replica").Msg("x")
+	if ae.HasTagPrefix(err, "db") {
+		t.Error(`"dbx" matched the prefix "db"`)
+	}
+	if got := ae.TagsUnder(err, "db"); len(got) != 0 {
+		t.Errorf("TagsUnder(db) = %v, want none", got)
+	}
+}
+
+func TestHasTagPrefix_Cycle(t *testing.T) {
+	t.Parallel()
+
+	a := &ae.Ae{}
+	b := ae.New().Tag("net/dns").Cause(a).Msg("b")
+	*a = *ae.New().Cause(b).Msg("a").(*ae.Ae)
+
+	if !ae.HasTagPrefix(a, "net") {
+		t.Error("HasTagPrefix(net) = false")
+	}
+	if ae.HasTagPrefix(a, "db") {
+		t.Error("HasTagPrefix(db) = true")
+	}
+	if got := ae.TagsUnder(a, "net"); !slices.Equal(got, []string{"net/dns"}) {
+		t.Errorf("TagsUnder = %v", got)
+	}
+}
+
+func TestTagsUnder_SortedAndDeduplicated(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().
+		Tags("db/timeout", "retry").
+		Cause(
+			ae.New().Tags("db/conn", "db/timeout").Msg("a"),
+			ae.New().Tags("db", "dbx/conn").Msg("b"),
+		).
+		Msg("top")
+
+	want := []string{"db", "db/conn", "db/timeout"}
+	if got := ae.TagsUnder(err, "db"); !slices.Equal(got, want) {
+		t.Errorf("TagsUnder(db) = %v, want %v", got, want)
+	}
+}
+
+func TestMatchTagPrefix(t *testing.T) {
+	t.Parallel()
+
+	match := ae.MatchTagPrefix("db")
+	if !match(ae.New().Cause(ae.New().Tag("db/timeout").Msg("inner")).Msg("outer")) {
+		t.Error("MatchTagPrefix(db) does not match a nested db/timeout")
+	}
+	if match(ae.New().Tag("dbx").Msg("x")) {
+		t.Error("MatchTagPrefix(db) matches dbx")
+	}
+}
+
+func TestAe_TagSeq(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Tags("b", "c", "a").Msg("boom").(*ae.Ae)
+	if got := slices.Collect(err.TagSeq()); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("TagSeq = %v, want [a b c]", got)
+	}
+
+	tags := err.ErrorTags()
+	tags[0] = "z"
+	if got := slices.Collect(err.TagSeq()); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("tags changed through the ErrorTags copy: %v", got)
+	}
+}
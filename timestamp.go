@@ -1,6 +1,11 @@
 package ae
 
-import "time"
+import (
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+)
 
 // ErrorTimestamp defines an interface for errors that can provide a timestamp.
 type ErrorTimestamp interface {
@@ -16,3 +21,91 @@ func Timestamp(err error) time.Time {
 
 	return time.Time{}
 }
+
+var (
+	// clock holds the function set with SetClock; nil means time.Now.
+	clock atomic.Pointer[func() time.Time]
+	// timestampUTC is set with SetTimestampUTC.
+	timestampUTC atomic.Bool
+	// autoTimestamp is set with SetAutoTimestamp.
+	autoTimestamp atomic.Bool
+	// initDone is set once an error is stamped outside of package
+	// initialization; stamps taken before are checked by stampedAtInit.
+	initDone atomic.Bool
+)
+
+func init() {
+	autoTimestamp.Store(true)
+}
+
+// SetClock sets the function Builder.Now and Builder.Since read the current
+// time from, e.g. to make timestamps and durations predictable in tests.
+// A nil now restores time.Now.
+func SetClock(now func() time.Time) {
+	if now == nil {
+		clock.Store(nil)
+		return
+	}
+
+	clock.Store(&now)
+}
+
+// SetTimestampUTC sets whether the timestamps taken by Builder.Now are in
+// UTC rather than the local time zone, and whether LogValue logs timestamps
+// in UTC. It is off by default. Printers normalize the rendered timestamps
+// independently, see PrintTimeUTC.
+func SetTimestampUTC(enabled bool) {
+	timestampUTC.Store(enabled)
+}
+
+// SetAutoTimestamp sets whether Builder terminal methods (Msg, Msgf,
+// UserMsg) stamp errors without a timestamp with the current time, read from
+// the clock set with SetClock. It is on by default; timestamps set with
+// Builder.Timestamp or Builder.Now are kept, and so are automatic ones
+// copied by From. The exception are errors stamped while packages are
+// initialized, such as package-level sentinels: From drops their timestamp,
+// so the errors derived from them are stamped when they are built.
+func SetAutoTimestamp(enabled bool) {
+	autoTimestamp.Store(enabled)
+}
+
+// stampedAtInit reports whether its caller runs as part of package
+// initialization. Once called outside of it, it doesn't look at the stack
+// anymore and reports false.
+func stampedAtInit() bool {
+	if initDone.Load() {
+		return false
+	}
+
+	pcs := make([]uintptr, 128)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if strings.HasPrefix(frame.Function, "runtime.doInit") {
+			return true
+		}
+		if !more {
+			break
+		}
+	}
+
+	if n < len(pcs) {
+		initDone.Store(true)
+	}
+	return false
+}
+
+// now returns the current time of the clock set with SetClock, in UTC if
+// SetTimestampUTC is enabled.
+func now() time.Time {
+	t := time.Now()
+	if f := clock.Load(); f != nil {
+		t = (*f)()
+	}
+	if timestampUTC.Load() {
+		t = t.UTC()
+	}
+
+	return t
+}
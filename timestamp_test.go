@@ -1,7 +1,9 @@
 package ae_test
 
 import (
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -49,3 +51,174 @@ func TestTimestamp_AeBuilderNowSetsCurrentTime(t *testing.T) {
 		t.Errorf("Timestamp(Now()) = %v, want between %v and %v", got, before, after)
 	}
 }
+
+// setClock installs a fake package clock for the duration of the test.
+func setClock(t *testing.T) *fakeClock {
+	t.Helper()
+
+	clock := newFakeClock()
+	ae.SetClock(clock.Now)
+	withPackageState(t, func() { ae.SetClock(nil) })
+	return clock
+}
+
+func TestSetClock_Now(t *testing.T) {
+	clock := setClock(t)
+
+	if got := ae.Timestamp(ae.New().Now().Msg("fail")); !got.Equal(clock.Now()) {
+		t.Errorf("Timestamp(Now()) = %v, want the fake clock's %v", got, clock.Now())
+	}
+}
+
+func TestBuilder_Since(t *testing.T) {
+	clock := setClock(t)
+
+	start := clock.Now()
+	clock.Advance(1284 * time.Millisecond)
+	err := ae.New().Since(start).Msg("query failed")
+
+	if got := ae.Attributes(err)["duration"]; got != 1284*time.Millisecond {
+		t.Errorf("duration = %#v, want 1.284s", got)
+	}
+
+	out := ae.NewPrinter(ae.NoPrintColors()).Prints(err)
+	if !strings.Contains(out, "duration  1.284s") {
+		t.Errorf("text output lacks the duration:\n%s", out)
+	}
+
+	var doc struct {
+		Attrs map[string]any `json:"attrs"`
+	}
+	js := ae.NewPrinter(ae.PrintJSON()).Prints(err)
+	if jerr := json.Unmarshal([]byte(js), &doc); jerr != nil {
+		t.Fatalf("invalid JSON: %v\n%s", jerr, js)
+	}
+	if doc.Attrs["duration"] != 1284.0 {
+		t.Errorf("JSON duration = %v, want 1284 milliseconds", doc.Attrs["duration"])
+	}
+}
+
+func TestBuilder_Duration(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Duration(1500 * time.Microsecond).Msg("slow")
+	if got := ae.Attributes(err)["duration"]; got != 1500*time.Microsecond {
+		t.Errorf("duration = %#v, want 1.5ms", got)
+	}
+
+	js := ae.NewPrinter(ae.PrintJSON()).Prints(err)
+	if !strings.Contains(js, `"duration": 1.5`) {
+		t.Errorf("JSON lacks the duration in milliseconds:\n%s", js)
+	}
+}
+
+// Not parallel: sets the package clock and SetTimestampUTC.
+func TestSetTimestampUTC(t *testing.T) {
+	cest := time.FixedZone("CEST", 2*60*60)
+	local := time.Date(2024, 5, 1, 14, 0, 0, 0, cest)
+	ae.SetClock(func() time.Time { return local })
+	withPackageState(t, func() { ae.SetClock(nil) })
+
+	if got := ae.Timestamp(ae.New().Now().Msg("x")); got.Location() != cest {
+		t.Errorf("Now() without SetTimestampUTC in %v, want CEST", got.Location())
+	}
+
+	ae.SetTimestampUTC(true)
+	withPackageState(t, func() { ae.SetTimestampUTC(false) })
+
+	got := ae.Timestamp(ae.New().Now().Msg("x"))
+	if got.Location() != time.UTC || !got.Equal(local) {
+		t.Errorf("Now() = %v, want %v in UTC", got, local)
+	}
+
+	attrs := flattenAttrs(logValue(t, ae.New().Timestamp(local).Msg("x")))
+	if ts, _ := attrs["timestamp"].(time.Time); ts.Location() != time.UTC || !ts.Equal(local) {
+		t.Errorf("logged timestamp = %v, want %v in UTC", attrs["timestamp"], local)
+	}
+}
+
+// setAutoTimestamp turns the automatic timestamps on or off until the end of
+// the test.
+func setAutoTimestamp(t *testing.T, enabled bool) {
+	t.Helper()
+
+	ae.SetAutoTimestamp(enabled)
+	withPackageState(t, func() { ae.SetAutoTimestamp(true) })
+}
+
+func TestAutoTimestamp(t *testing.T) {
+	// on by default
+	clock := setClock(t)
+
+	for name, err := range map[string]error{
+		"Msg":     ae.New().Msg("x"),
+		"Msgf":    ae.New().Msgf("x %d", 1),
+		"UserMsg": ae.New().UserMsg("x", "y"),
+		"From":    ae.From(errors.New("plain")).Msg(""),
+	} {
+		if got := ae.Timestamp(err); !got.Equal(clock.Now()) {
+			t.Errorf("%s: Timestamp = %v, want the clock's %v", name, got, clock.Now())
+		}
+	}
+}
+
+func TestAutoTimestamp_KeepsExplicitValues(t *testing.T) {
+	clock := setClock(t)
+
+	when := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	err := ae.New().Timestamp(when).Msg("x")
+	if got := ae.Timestamp(err); !got.Equal(when) {
+		t.Errorf("Timestamp = %v, want the explicit %v", got, when)
+	}
+
+	clock.Advance(time.Hour)
+	if got := ae.Timestamp(ae.WithHint(err, "h")); !got.Equal(when) {
+		t.Errorf("Timestamp after From = %v, want the copied %v", got, when)
+	}
+
+	now := ae.New().Now().Msg("x")
+	clock.Advance(time.Hour)
+	if got := ae.Timestamp(ae.From(now).Msg("y")); !got.Equal(clock.Now().Add(-time.Hour)) {
+		t.Errorf("Timestamp = %v, want the one set by Now", got)
+	}
+}
+
+// sentinelErr is finalized at init, as generated by cmd/aegen.
+var sentinelErr = ae.New().Code("SENTINEL").Msg("sentinel")
+
+func TestAutoTimestamp_FromSentinel(t *testing.T) {
+	clock := setClock(t)
+
+	if got := ae.Timestamp(sentinelErr); got.IsZero() || got.Equal(clock.Now()) {
+		t.Fatalf("sentinel Timestamp = %v, want the init time", got)
+	}
+	for name, err := range map[string]error{
+		"From":     ae.From(sentinelErr).Msg(""),
+		"WithHint": ae.WithHint(sentinelErr, "h"),
+	} {
+		if got := ae.Timestamp(err); !got.Equal(clock.Now()) {
+			t.Errorf("%s: Timestamp = %v, want the clock's %v", name, got, clock.Now())
+		}
+	}
+
+	// errors stamped after init keep their timestamp when derived from
+	derived := ae.From(sentinelErr).Msg("")
+	stamped := clock.Now()
+	clock.Advance(time.Hour)
+	for name, err := range map[string]error{
+		"From":     ae.From(derived).Msg(""),
+		"WithAttr": ae.WithAttr(derived, "k", "v"),
+	} {
+		if got := ae.Timestamp(err); !got.Equal(stamped) {
+			t.Errorf("%s: Timestamp = %v, want the derived error's %v", name, got, stamped)
+		}
+	}
+}
+
+func TestAutoTimestamp_OptOut(t *testing.T) {
+	setAutoTimestamp(t, false)
+
+	if got := ae.Timestamp(ae.New().Msg("x")); !got.IsZero() {
+		t.Errorf("Timestamp = %v, want zero without auto timestamps", got)
+	}
+}
@@ -1,5 +1,7 @@
 package ae
 
+import "strings"
+
 // ErrorUserMessage defines an interface for errors that can an error message for end-users.
 type ErrorUserMessage interface {
 	// ErrorUserMessage returns an error message for end-users.
@@ -21,3 +23,56 @@ func UserMessage(err error) string {
 
 	return ""
 }
+
+// ErrorUserMessages defines an interface for errors that can provide
+// translations of their end-user message.
+type ErrorUserMessages interface {
+	// ErrorUserMessages returns the end-user messages keyed by locale, such
+	// as "de" or "de-AT".
+	// Returns nil if no translations are set.
+	ErrorUserMessages() map[string]string
+}
+
+// UserMessages extracts the translated end-user messages from an error.
+// If the error implements ErrorUserMessages, returns its ErrorUserMessages().
+// Returns nil if err is nil or if the error does not implement ErrorUserMessages.
+func UserMessages(err error) map[string]string {
+	if err == nil {
+		return nil
+	}
+
+	if ae, ok := err.(ErrorUserMessages); ok {
+		return ae.ErrorUserMessages()
+	}
+
+	return nil
+}
+
+// UserMessageFor returns the end-user message of err for the first of
+// locales it has a translation for, in order of preference. For each locale
+// the exact locale is tried first, then its language alone ("de" for
+// "de-AT"). Locales are matched case-insensitively, and "_" is accepted in
+// place of "-". Without a matching translation the default user message is
+// returned, see UserMessage, which may be empty.
+func UserMessageFor(err error, locales ...string) string {
+	if msgs := UserMessages(err); len(msgs) > 0 {
+		for _, locale := range locales {
+			locale = normalizeLocale(locale)
+			language, _, _ := strings.Cut(locale, "-")
+			for _, want := range []string{locale, language} {
+				for l, msg := range msgs {
+					if normalizeLocale(l) == want {
+						return msg
+					}
+				}
+			}
+		}
+	}
+
+	return UserMessage(err)
+}
+
+// normalizeLocale lower-cases locale and separates its subtags with "-".
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
@@ -43,3 +43,66 @@ func TestUserMessage_AeBuilderSetsUserMessage(t *testing.T) {
 		t.Errorf("Message on builder = %q, want %q", got, "internal")
 	}
 }
+
+func TestUserMessageFor_Fallback(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().
+		UserMsgLocale("de", "Bitte später erneut versuchen.").
+		UserMsgLocale("de-CH", "Bitte später nochmals versuchen.").
+		UserMsgLocale("pt_BR", "Tente novamente mais tarde.").
+		UserMsg("db timeout", "Please try again later.")
+
+	tests := []struct {
+		name    string
+		locales []string
+		want    string
+	}{
+		{"exact", []string{"de-CH"}, "Bitte später nochmals versuchen."},
+		{"language only", []string{"de-AT"}, "Bitte später erneut versuchen."},
+		{"language", []string{"de"}, "Bitte später erneut versuchen."},
+		{"case and separator", []string{"DE_ch"}, "Bitte später nochmals versuchen."},
+		{"stored with underscore", []string{"pt-br"}, "Tente novamente mais tarde."},
+		{"region only stored", []string{"pt"}, "Please try again later."},
+		{"preference order", []string{"fr", "de-AT"}, "Bitte später erneut versuchen."},
+		{"default", []string{"fr-FR"}, "Please try again later."},
+		{"no locales", nil, "Please try again later."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ae.UserMessageFor(err, tt.locales...); got != tt.want {
+				t.Errorf("UserMessageFor(%q) = %q, want %q", tt.locales, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserMessageFor_NoUserMessage(t *testing.T) {
+	t.Parallel()
+
+	if got := ae.UserMessageFor(ae.Msg("internal"), "de"); got != "" {
+		t.Errorf("UserMessageFor = %q, want empty", got)
+	}
+	if got := ae.UserMessageFor(nil, "de"); got != "" {
+		t.Errorf("UserMessageFor(nil) = %q, want empty", got)
+	}
+}
+
+func TestUserMessages_CopiedAndPreservedByFrom(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().UserMsgLocale("de", "Fehler").Msg("boom")
+
+	msgs := ae.UserMessages(err)
+	msgs["de"] = "changed"
+	if got := ae.UserMessageFor(err, "de"); got != "Fehler" {
+		t.Errorf("modifying the returned map changed the error: %q", got)
+	}
+
+	wrapped := ae.From(err).Code("X").Msg("")
+	if got := ae.UserMessageFor(wrapped, "de"); got != "Fehler" {
+		t.Errorf("From lost the translation: %q", got)
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"slices"
+	"sync"
 )
 
 // Wrap creates a new error with the given message and wraps the provided error as a cause.
+// A cause that is not an *Ae is passed through the classifiers registered with RegisterClassifier.
 // Returns nil if the provided error is nil.
 func Wrap(msg string, err error) error {
 	if err == nil {
@@ -15,9 +18,37 @@ func Wrap(msg string, err error) error {
 
 	return New().
 		Cause(err).
+		classify(err).
 		Msg(msg)
 }
 
+// WrapKeep is Wrap, except that the returned error also carries the code,
+// exit code, user message and its translations, hint and tags of err, and is
+// marked not recoverable if err is, so shallow extractors such as Code and
+// UserMessage see them without searching the cause tree. The values kept
+// from err take precedence over those of the classifiers; tags are merged.
+// err remains the cause. Returns nil if the provided error is nil.
+func WrapKeep(msg string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	b := New().Cause(err).classify(err)
+	b.code = Code(err)
+	// only an exit code set in the tree of err; the defaults and the
+	// registered exit codes still apply to the wrapper through ExitCode
+	b.exitCode = explicitExitCode(err, nil, 0)
+	b.userMsg = UserMessage(err)
+	b.userMsgs = UserMessages(err)
+	b.hint = Hint(err)
+	if x, ok := err.(ErrorRecoverable); ok && !x.ErrorIsRecoverable() {
+		b.recoverable = false
+		b.recoverableSet = true
+	}
+
+	return b.Tags(Tags(err)...).Msg(msg)
+}
+
 // ReWrap creates a new error with the provided message and re-wraps all the underlying causes of the given error.
 // If the given error is nil or has no causes, it returns nil.
 // The resulting error contains the same causes as the input error but with a new top-level message.
@@ -29,6 +60,7 @@ func ReWrap(msg string, err error) error {
 
 	return New().
 		Causes(causes).
+		classify(causes...).
 		Msg(msg)
 }
 
@@ -39,7 +71,7 @@ func WrapC(ctx context.Context, msg string, err error) error {
 		return nil
 	}
 
-	return NewC(ctx).Cause(err).Msg(msg)
+	return NewC(ctx).Cause(err).classify(err).Msg(msg)
 }
 
 // Wrapf creates a new error with the given formatted message and wraps the provided errors as causes.
@@ -53,7 +85,7 @@ func Wrapf(msg string, err error, args ...any) error {
 // WrapCf creates a new error with the given formatted message and context and wraps the provided error as a cause.
 // Returns nil if the provided error is nil.
 func WrapCf(ctx context.Context, msg string, err error, args ...any) error {
-	return NewC(ctx).Cause(err).Msgf(msg, args...)
+	return NewC(ctx).Cause(err).classify(err).Msgf(msg, args...)
 }
 
 // WrapMany creates a new error with the given message and wraps the provided errors as causes.
@@ -74,9 +106,79 @@ func WrapMany(msg string, errs ...error) error {
 
 	return New().
 		Causes(filtered).
+		classify(filtered...).
 		Msg(msg)
 }
 
+// DeferWrap wraps the error pointed to by errp with msg, see Wrap. It is meant
+// to be deferred in functions with a named error result:
+//
+//	func load() (err error) {
+//		defer ae.DeferWrap(&err, "loading config")
+//		...
+//	}
+//
+// A nil errp or *errp is left untouched. DeferWrap does not recover, so a
+// panic passes through unchanged.
+func DeferWrap(errp *error, msg string) {
+	if errp == nil || *errp == nil {
+		return
+	}
+
+	*errp = Wrap(msg, *errp)
+}
+
+// DeferWrapf is DeferWrap with a formatted message, see Wrapf.
+func DeferWrapf(errp *error, msg string, args ...any) {
+	if errp == nil || *errp == nil {
+		return
+	}
+
+	*errp = Wrapf(msg, *errp, args...)
+}
+
+// DeferBuild replaces the error pointed to by errp with fn(From(*errp)),
+// keeping its message, e.g. to attach tags or attributes known only when the
+// function returns:
+//
+//	defer ae.DeferBuild(&err, func(b ae.Builder) ae.Builder {
+//		return b.Attr("rows", rows)
+//	})
+//
+// A nil errp or *errp is left untouched, and panics pass through as with
+// DeferWrap.
+func DeferBuild(errp *error, fn func(b Builder) Builder) {
+	if errp == nil || *errp == nil {
+		return
+	}
+
+	*errp = fn(From(*errp)).Msg("")
+}
+
+// First returns the first non-nil error in errs, unchanged, or nil if all
+// are nil.
+func First(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AllOrNone returns nil if any of errs is nil, i.e. at least one of several
+// alternatives succeeded, or when errs is empty. Otherwise it returns an
+// error whose causes are all of errs, e.g. after every configuration source
+// failed to load.
+func AllOrNone(errs ...error) error {
+	if slices.Contains(errs, nil) {
+		return nil
+	}
+
+	return WrapMany(fmt.Sprintf("all %d alternatives failed", len(errs)), errs...)
+}
+
 // Msg creates a new error with the given message.
 // It is a convenience function that wraps New().Msg(msg).
 func Msg(msg string) error {
@@ -101,23 +203,105 @@ func MsgCf(ctx context.Context, msg string, args ...any) error {
 	return NewC(ctx).Msgf(msg, args...)
 }
 
-// Exit exits the program with the exit code returned by ExitCode.
+var (
+	exiterMu sync.RWMutex
+	// exiter terminates the process; replaced via SetExiter.
+	exiter = os.Exit
+)
+
+// SetExiter replaces the function used by Exit, PrintExit and Main to
+// terminate the process. Passing nil restores os.Exit.
+// Mainly intended for tests that need to observe the exit code.
+func SetExiter(fn func(code int)) {
+	if fn == nil {
+		fn = os.Exit
+	}
+
+	exiterMu.Lock()
+	defer exiterMu.Unlock()
+	exiter = fn
+}
+
+// Exit reports the error to the reporters registered with RegisterReporter
+// (see Report), runs the hooks registered with OnExit, then exits the program
+// with the exit code returned by ExitCode through the exiter (see SetExiter).
+// Reporter failures are logged to stderr and don't change the exit code.
 // Does nothing if the error is nil.
 func Exit(err error) {
 	if err == nil {
 		return
 	}
 
-	os.Exit(ExitCode(err))
+	reportOnExit(err)
+	runExitHooks(err)
+
+	exiterMu.RLock()
+	exit := exiter
+	exiterMu.RUnlock()
+
+	exit(ExitCode(err))
+}
+
+// Main runs run and handles its error the way a CLI's main function should:
+// a non-nil error is printed to stderr and the process exits with the exit
+// code returned by ExitCode. Returns normally if run returns nil.
+//
+// The error is printed with PrintCompact, which shows the user message and
+// hint but omits timestamps, trace IDs and stacks. opts are applied on top,
+// so passing PrintVerbose() (e.g. behind a --verbose flag) restores the
+// full output.
+func Main(run func() error, opts ...PrinterOption) {
+	err := run()
+	if err == nil {
+		return
+	}
+
+	NewPrinter(append([]PrinterOption{PrintCompact()}, opts...)...).Fprint(os.Stderr, err)
+	Exit(err)
 }
 
-// PrintExit prints the error to stderr and exits the program with the exit code returned by ExitCode.
-// Does not print anything and exits with code 0 if the error is nil.
+// PrintExit prints the error to stderr using a printer configured with opts
+// and exits the program with the exit code returned by ExitCode, see Exit.
+// Destinations added with PrintAlso, e.g. a log file, are written as well;
+// errors writing to them are ignored, as the program exits anyway. Does
+// nothing if the error is nil: nothing is printed and the program does not
+// exit.
 func PrintExit(err error, opts ...PrinterOption) {
-	Print(err, opts...)
+	if err == nil {
+		return
+	}
+
+	NewPrinter(opts...).Fprint(os.Stderr, err)
 	Exit(err)
 }
 
+// PrintExitCompact is PrintExit with PrintCompact: the message, user message,
+// hint, code, tags, attributes and causes, without timestamps, trace IDs or
+// stacks. opts are applied on top.
+func PrintExitCompact(err error, opts ...PrinterOption) {
+	PrintExit(err, append([]PrinterOption{PrintCompact()}, opts...)...)
+}
+
+// PrintExitReport prints the UserReport of err to stderr and exits the
+// program with the exit code returned by ExitCode, see Exit. If verbose is
+// true (e.g. behind a --verbose flag), the full verbose rendering of err
+// follows the report. Does nothing if the error is nil.
+func PrintExitReport(err error, verbose bool, opts ...ReportOption) {
+	if err == nil {
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, UserReport(err, opts...))
+	if verbose {
+		fmt.Fprintln(os.Stderr)
+		NewPrinter(PrintVerbose()).Fprint(os.Stderr, err)
+	}
+	Exit(err)
+}
+
+// CodeMustFailed is the code of the errors Must and its variants panic with.
+const CodeMustFailed = "MUST_FAILED"
+
 // Must panics if the provided error is not nil.
 // If nil, returns the provided value.
 //
@@ -125,16 +309,50 @@ func PrintExit(err error, opts ...PrinterOption) {
 // Example:
 //
 //	v := Must(SomeFunction())
+//
+// The panic value is an *Ae with the code CodeMustFailed, err as its cause
+// and the stack of the calling goroutine, so FromPanic and Recover restore the
+// full chain. Its Error() text is unchanged from earlier versions, which
+// panicked with that text as a plain string.
 func Must[T any](v T, err error) T {
 	if err != nil {
-		panic(fmt.Sprintf("error must not be present: %v", err))
+		panic(mustFailed(err))
 	}
 
 	return v
 }
 
+// Must2 is Must for functions returning two values and an error, e.g.
+//
+//	host, port := Must2(net.SplitHostPort(addr))
+func Must2[T1, T2 any](v1 T1, v2 T2, err error) (T1, T2) {
+	if err != nil {
+		panic(mustFailed(err))
+	}
+
+	return v1, v2
+}
+
+// Must3 is Must for functions returning three values and an error.
+func Must3[T1, T2, T3 any](v1 T1, v2 T2, v3 T3, err error) (T1, T2, T3) {
+	if err != nil {
+		panic(mustFailed(err))
+	}
+
+	return v1, v2, v3
+}
+
 // MustFunc calls the provided function and panics if the returned error is not nil.
 // Returns the value returned by the function.
 func MustFunc[T any](fn func() (T, error)) T {
 	return Must(fn())
 }
+
+// mustFailed builds the panic value of the Must family.
+func mustFailed(err error) error {
+	return New().
+		Code(CodeMustFailed).
+		Cause(err).
+		Stack().
+		Msg("error must not be present")
+}
@@ -1,14 +1,14 @@
 package ae_test
 
-// Note: Exit and PrintExit call os.Exit, which terminates the test process.
-// They are thin wrappers over ExitCode (which is exhaustively tested in
-// exitcode_test.go) plus os.Exit, and testing them directly would require a
-// subprocess harness that is disproportionate to the two lines of logic they
-// add. They are deliberately left untested here.
+// Note: Exit, PrintExit and Main terminate the process through the exiter
+// installed with ae.SetExiter, which the tests replace with captureExit.
 
 import (
 	"context"
 	"errors"
+	"io"
+	"net"
+	"os"
 	"slices"
 	"strings"
 	"testing"
@@ -127,6 +127,150 @@ func TestWrapMany_FiltersNilsAndWraps(t *testing.T) {
 	}
 }
 
+func TestDeferWrap(t *testing.T) {
+	t.Parallel()
+
+	cause := errors.New("file not found")
+	load := func(fail bool) (err error) {
+		defer ae.DeferWrap(&err, "loading config")
+		if fail {
+			return cause
+		}
+		return nil
+	}
+
+	if err := load(false); err != nil {
+		t.Errorf("success path: got %v, want nil", err)
+	}
+
+	err := load(true)
+	if !errors.Is(err, cause) {
+		t.Error("errors.Is(err, cause) = false")
+	}
+	if got := ae.Message(err); got != "loading config" {
+		t.Errorf("Message = %q, want %q", got, "loading config")
+	}
+
+	ae.DeferWrap(nil, "no-op")
+}
+
+func TestDeferWrapf(t *testing.T) {
+	t.Parallel()
+
+	load := func(name string) (err error) {
+		defer ae.DeferWrapf(&err, "loading %s", name)
+		return errors.New("boom")
+	}
+
+	if got := load("app.yaml").Error(); got != "loading app.yaml: boom" {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestDeferBuild(t *testing.T) {
+	t.Parallel()
+
+	rows := 0
+	query := func() (err error) {
+		defer ae.DeferBuild(&err, func(b ae.Builder) ae.Builder {
+			return b.Tag("db").Attr("rows", rows)
+		})
+		rows = 7
+		return ae.New().Code("DB_TIMEOUT").Msg("query timed out")
+	}
+
+	err := query()
+	if got := ae.Message(err); got != "query timed out" {
+		t.Errorf("Message = %q, want the original message", got)
+	}
+	if got := ae.Code(err); got != "DB_TIMEOUT" {
+		t.Errorf("Code = %q, want DB_TIMEOUT", got)
+	}
+	if !slices.Contains(ae.Tags(err), "db") {
+		t.Error("tag computed at defer time missing")
+	}
+	if got := ae.Attributes(err)["rows"]; got != 7 {
+		t.Errorf("rows = %v, want 7", got)
+	}
+
+	var nilErr error
+	ae.DeferBuild(&nilErr, func(b ae.Builder) ae.Builder { return b.Tag("x") })
+	if nilErr != nil {
+		t.Errorf("nil error was replaced by %v", nilErr)
+	}
+}
+
+func TestDeferWrap_PanicPassesThrough(t *testing.T) {
+	t.Parallel()
+
+	var err error
+	recovered := func() (r any) {
+		defer func() { r = recover() }()
+		func() {
+			defer ae.DeferWrap(&err, "wrapping")
+			panic("kaboom")
+		}()
+		return nil
+	}()
+
+	if recovered != "kaboom" {
+		t.Errorf("recovered %v, want the original panic value", recovered)
+	}
+	if err != nil {
+		t.Errorf("err = %v, want nil", err)
+	}
+}
+
+func TestFirst(t *testing.T) {
+	t.Parallel()
+
+	a, b := errors.New("a"), errors.New("b")
+	tests := []struct {
+		name string
+		errs []error
+		want error
+	}{
+		{"empty", nil, nil},
+		{"all nil", []error{nil, nil}, nil},
+		{"mixed", []error{nil, a, nil, b}, a},
+		{"all failed", []error{b, a}, b},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ae.First(tt.errs...); got != tt.want {
+				t.Errorf("First = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllOrNone(t *testing.T) {
+	t.Parallel()
+
+	a, b := errors.New("env: not set"), errors.New("file: not found")
+
+	if err := ae.AllOrNone(); err != nil {
+		t.Errorf("empty: got %v, want nil", err)
+	}
+	if err := ae.AllOrNone(nil, nil); err != nil {
+		t.Errorf("all nil: got %v, want nil", err)
+	}
+	if err := ae.AllOrNone(a, nil, b); err != nil {
+		t.Errorf("mixed: got %v, want nil", err)
+	}
+
+	err := ae.AllOrNone(a, b)
+	if got := err.Error(); got != "all 2 alternatives failed: [env: not set; file: not found]" {
+		t.Errorf("Error() = %q", got)
+	}
+	if causes := ae.Causes(err); len(causes) != 2 || causes[0] != a || causes[1] != b {
+		t.Errorf("causes = %v, want the original instances", causes)
+	}
+}
+
 func TestMsg_ProducesErrorWithMessage(t *testing.T) {
 	t.Parallel()
 
@@ -258,3 +402,375 @@ func TestMustFunc_PanicsOnError(t *testing.T) {
 	}()
 	_ = ae.MustFunc(func() (int, error) { return 0, errors.New("boom") })
 }
+
+func TestMust_PanicValueIsAeError(t *testing.T) {
+	t.Parallel()
+
+	cause := errors.New("boom")
+	r := func() (r any) {
+		defer func() { r = recover() }()
+		_ = ae.Must(0, cause)
+		return nil
+	}()
+
+	err, ok := r.(*ae.Ae)
+	if !ok {
+		t.Fatalf("panic value is %T, want *ae.Ae", r)
+	}
+	if got := ae.Code(err); got != ae.CodeMustFailed {
+		t.Errorf("Code = %q, want %q", got, ae.CodeMustFailed)
+	}
+	if got := err.Error(); got != "error must not be present: boom" {
+		t.Errorf("Error() = %q", got)
+	}
+	if len(ae.Stacks(err)) != 1 {
+		t.Errorf("got %d stacks, want 1", len(ae.Stacks(err)))
+	}
+}
+
+func TestMust2AndMust3(t *testing.T) {
+	t.Parallel()
+
+	host, port := ae.Must2(net.SplitHostPort("localhost:8080"))
+	if host != "localhost" || port != "8080" {
+		t.Errorf("Must2 = %q, %q", host, port)
+	}
+
+	a, b, c := ae.Must3(1, "two", 3.0, nil)
+	if a != 1 || b != "two" || c != 3.0 {
+		t.Errorf("Must3 = %v, %v, %v", a, b, c)
+	}
+}
+
+func TestMust_RecoverRestoresChain(t *testing.T) {
+	t.Parallel()
+
+	cause := errors.New("missing port")
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{"Must", func() { ae.Must(0, cause) }},
+		{"Must2", func() { ae.Must2(0, 0, cause) }},
+		{"Must3", func() { ae.Must3(0, 0, 0, cause) }},
+		{"MustFunc", func() { ae.MustFunc(func() (int, error) { return 0, cause }) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := recovered(tt.fn)
+			if !errors.Is(err, cause) {
+				t.Error("errors.Is(recovered, cause) = false")
+			}
+			if !errors.Is(err, ae.New().Code(ae.CodeMustFailed).Msg("")) {
+				t.Error("recovered error does not carry the MUST_FAILED code")
+			}
+		})
+	}
+}
+
+// captureExit installs a fake exiter for the duration of the test and
+// returns a pointer to the captured exit code (-1 until an exit happens).
+func captureExit(t *testing.T) *int {
+	t.Helper()
+
+	code := -1
+	ae.SetExiter(func(c int) { code = c })
+	withPackageState(t, func() { ae.SetExiter(nil) })
+
+	return &code
+}
+
+// captureStderr redirects os.Stderr while fn runs and returns what was written.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe: %v", err)
+	}
+
+	orig := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = orig }()
+
+	fn()
+	_ = w.Close()
+
+	out, _ := io.ReadAll(r)
+	return string(out)
+}
+
+func TestExit_NilDoesNotExit(t *testing.T) {
+	code := captureExit(t)
+
+	ae.Exit(nil)
+	if *code != -1 {
+		t.Errorf("Exit(nil) exited with %d", *code)
+	}
+}
+
+func TestExit_UsesExiterWithExitCode(t *testing.T) {
+	code := captureExit(t)
+
+	ae.Exit(ae.New().ExitCode(9).Msg("boom"))
+	if *code != 9 {
+		t.Errorf("exit code = %d, want 9", *code)
+	}
+}
+
+func TestMain_NilErrorReturnsWithoutPrinting(t *testing.T) {
+	code := captureExit(t)
+
+	out := captureStderr(t, func() {
+		ae.Main(func() error { return nil })
+	})
+	if out != "" {
+		t.Errorf("Main printed %q for a nil error", out)
+	}
+	if *code != -1 {
+		t.Errorf("Main exited with %d for a nil error", *code)
+	}
+}
+
+func TestMain_PrintsCompactErrorToStderrAndExits(t *testing.T) {
+	code := captureExit(t)
+
+	out := captureStderr(t, func() {
+		ae.Main(func() error {
+			return ae.New().
+				ExitCode(4).
+				Hint("check the config path").
+				Stack().
+				UserMsg("open /etc/app.yaml", "The configuration file is missing.")
+		}, ae.NoPrintColors())
+	})
+
+	if *code != 4 {
+		t.Errorf("exit code = %d, want 4", *code)
+	}
+	for _, want := range []string{"open /etc/app.yaml", "The configuration file is missing.", "check the config path"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("stderr missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "stack") {
+		t.Errorf("compact CLI output contains a stack section:\n%s", out)
+	}
+}
+
+func TestMain_VerboseOptionRestoresFullOutput(t *testing.T) {
+	captureExit(t)
+
+	out := captureStderr(t, func() {
+		ae.Main(func() error {
+			return ae.New().Stack().Msg("boom")
+		}, ae.NoPrintColors(), ae.PrintVerbose())
+	})
+
+	if !strings.Contains(out, "stack") {
+		t.Errorf("verbose output has no stack section:\n%s", out)
+	}
+}
+
+func TestPrintExit_NilDoesNothing(t *testing.T) {
+	code := captureExit(t)
+
+	out := captureStderr(t, func() {
+		ae.PrintExit(nil)
+		ae.PrintExitCompact(nil)
+	})
+	if out != "" {
+		t.Errorf("printed %q for a nil error", out)
+	}
+	if *code != -1 {
+		t.Errorf("exited with %d for a nil error", *code)
+	}
+}
+
+func TestPrintExit_WritesToStderrWithOptions(t *testing.T) {
+	code := captureExit(t)
+
+	err := ae.New().ExitCode(5).Stack().Msg("boom")
+	out := captureStderr(t, func() {
+		ae.PrintExit(err, ae.NoPrintColors(), ae.NoPrintStacks())
+	})
+
+	if !strings.HasPrefix(out, "[ERROR] {exit 5} boom") {
+		t.Errorf("stderr = %q, want the rendered error", out)
+	}
+	if strings.Contains(out, "stack") {
+		t.Errorf("NoPrintStacks ignored:\n%s", out)
+	}
+	if *code != 5 {
+		t.Errorf("exit code = %d, want 5", *code)
+	}
+}
+
+func TestPrintExitCompact(t *testing.T) {
+	code := captureExit(t)
+
+	err := ae.New().
+		ExitCode(2).
+		Hint("check the flags").
+		Stack().
+		UserMsg("parse args", "Invalid arguments.")
+	out := captureStderr(t, func() {
+		ae.PrintExitCompact(err, ae.NoPrintColors())
+	})
+
+	for _, want := range []string{"parse args", "Invalid arguments.", "check the flags"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("stderr missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "stack") {
+		t.Errorf("compact output contains a stack section:\n%s", out)
+	}
+	if *code != 2 {
+		t.Errorf("exit code = %d, want 2", *code)
+	}
+}
+
+func TestPrintExit_PrintAlso(t *testing.T) {
+	code := captureExit(t)
+
+	err := ae.New().ExitCode(3).Msg("boom")
+	var log strings.Builder
+	out := captureStderr(t, func() {
+		ae.PrintExit(err, ae.NoPrintColors(), ae.PrintAlso(&log, ae.PrintJSON()))
+	})
+
+	if !strings.HasPrefix(out, "[ERROR] {exit 3} boom") {
+		t.Errorf("stderr = %q, want the rendered error", out)
+	}
+	if !strings.Contains(log.String(), `"boom"`) {
+		t.Errorf("secondary output = %q, want the JSON rendering", log.String())
+	}
+	if *code != 3 {
+		t.Errorf("exit code = %d, want 3", *code)
+	}
+}
+
+func TestPrintExitReport(t *testing.T) {
+	code := captureExit(t)
+
+	err := ae.New().ExitCode(3).Stack().UserMsg("open /etc/app.conf", "Configuration missing.")
+	out := captureStderr(t, func() {
+		ae.PrintExitReport(err, false)
+	})
+
+	if !strings.HasPrefix(out, "Configuration missing.\nReference: ") {
+		t.Errorf("stderr = %q, want the user report", out)
+	}
+	if strings.Contains(out, "/etc/app.conf") {
+		t.Errorf("stderr contains the internal message:\n%s", out)
+	}
+	if *code != 3 {
+		t.Errorf("exit code = %d, want 3", *code)
+	}
+}
+
+func TestPrintExitReport_Verbose(t *testing.T) {
+	captureExit(t)
+
+	err := ae.New().Stack().UserMsg("open /etc/app.conf", "Configuration missing.")
+	out := captureStderr(t, func() {
+		ae.PrintExitReport(err, true)
+	})
+
+	for _, want := range []string{"Configuration missing.", "open /etc/app.conf", "stack"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("stderr missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWrapKeep_InheritsMetadata(t *testing.T) {
+	t.Parallel()
+
+	inner := ae.New().
+		Code("DB_TIMEOUT").
+		ExitCode(4).
+		Hint("Retry later.").
+		Tags("db", "timeout").
+		Fatal().
+		UserMsgLocale("de", "Zeitüberschreitung.").
+		UserMsg("query timed out", "The database timed out.")
+
+	tests := []struct {
+		name string
+		got  func(error) any
+		wrap any
+		keep any
+	}{
+		{"code", func(e error) any { return ae.Code(e) }, "", "DB_TIMEOUT"},
+		{"hint", func(e error) any { return ae.Hint(e) }, "", "Retry later."},
+		{"user message", func(e error) any { return ae.UserMessage(e) }, "", "The database timed out."},
+		{"user message for", func(e error) any { return ae.UserMessageFor(e, "de") }, "", "Zeitüberschreitung."},
+		{"tags", func(e error) any { return strings.Join(ae.Tags(e), ",") }, "", "db,timeout"},
+		{"recoverable", func(e error) any { return e.(ae.ErrorRecoverable).ErrorIsRecoverable() }, true, false},
+		{"exit code", func(e error) any { return ae.ExitCode(e) }, 4, 4},
+	}
+
+	wrapped := ae.Wrap("loading user", inner)
+	kept := ae.WrapKeep("loading user", inner)
+	for _, tt := range tests {
+		if got := tt.got(wrapped); got != tt.wrap {
+			t.Errorf("Wrap: %s = %v, want %v", tt.name, got, tt.wrap)
+		}
+		if got := tt.got(kept); got != tt.keep {
+			t.Errorf("WrapKeep: %s = %v, want %v", tt.name, got, tt.keep)
+		}
+	}
+
+	if got := ae.Message(kept); got != "loading user" {
+		t.Errorf("Message = %q, want the wrapper's message", got)
+	}
+	if causes := ae.Causes(kept); len(causes) != 1 || causes[0] != inner {
+		t.Errorf("causes = %v, want the wrapped error", causes)
+	}
+}
+
+func TestWrapKeep_PlainError(t *testing.T) {
+	t.Parallel()
+
+	cause := errors.New("boom")
+	err := ae.WrapKeep("ctx", cause)
+	if !errors.Is(err, cause) {
+		t.Error("errors.Is does not find the cause")
+	}
+	if got := ae.Code(err); got != "" {
+		t.Errorf("Code = %q, want none", got)
+	}
+	if ae.WrapKeep("ctx", nil) != nil {
+		t.Error("WrapKeep(nil) != nil")
+	}
+}
+
+func TestPrintExit_Quiet(t *testing.T) {
+	code := captureExit(t)
+
+	err := ae.New().
+		ExitCode(3).
+		Code("CONFIG_INVALID").
+		Attr("path", "/etc/app.conf").
+		Stack().
+		UserMsg("parsing /etc/app.conf", "The configuration file is invalid.")
+	out := captureStderr(t, func() {
+		ae.PrintExit(err, ae.PrintQuiet())
+	})
+
+	want := "The configuration file is invalid.\n" +
+		"Code: CONFIG_INVALID\n" +
+		"Reference: " + ae.Fingerprint(err)[:8] + "\n" +
+		"Run with --verbose for details.\n"
+	if out != want {
+		t.Errorf("stderr =\n%s\nwant\n%s", out, want)
+	}
+	if *code != 3 {
+		t.Errorf("exit code = %d, want 3", *code)
+	}
+}
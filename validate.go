@@ -0,0 +1,289 @@
+package ae
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProblemKind classifies the problems reported by Validate.
+type ProblemKind string
+
+const (
+	// ProblemCycle marks a cause or related error that is also one of its
+	// own ancestors.
+	ProblemCycle ProblemKind = "cycle"
+	// ProblemNil marks a nil cause, related error or stack.
+	ProblemNil ProblemKind = "nil"
+	// ProblemEmptyMessage marks an error without a message that doesn't
+	// join other errors, as errors.Join does.
+	ProblemEmptyMessage ProblemKind = "empty_message"
+	// ProblemDuplicateStack marks a stack equal to one attached elsewhere
+	// in the tree.
+	ProblemDuplicateStack ProblemKind = "duplicate_stack"
+	// ProblemTreeSize marks a tree too deep or too large to traverse;
+	// the errors beyond the limit are not validated.
+	ProblemTreeSize ProblemKind = "tree_size"
+	// ProblemAttrNotJSON marks an attribute value json.Marshal fails on.
+	ProblemAttrNotJSON ProblemKind = "attr_not_json"
+	// ProblemFutureTimestamp marks a timestamp ahead of the clock.
+	ProblemFutureTimestamp ProblemKind = "future_timestamp"
+	// ProblemPanic marks an error whose methods panicked.
+	ProblemPanic ProblemKind = "panic"
+)
+
+const (
+	// maxValidateErrors is the number of errors Validate inspects before
+	// reporting the tree as too large.
+	maxValidateErrors = 10000
+	// validateClockSkew is how far a timestamp may lie ahead of the clock,
+	// to allow for skew between the hosts an error passed through.
+	validateClockSkew = time.Minute
+)
+
+// Problem is a defect of an error tree found by Validate.
+type Problem struct {
+	Kind ProblemKind
+	// Path leads to the error the problem was found at, as in the output of
+	// Diff, e.g. "causes[1].related[0]"; it is empty for the validated
+	// error itself.
+	Path string
+	// Detail describes the problem.
+	Detail string
+}
+
+// String renders p as "path: detail", or detail alone at the root.
+func (p Problem) String() string {
+	if p.Path == "" {
+		return p.Detail
+	}
+
+	return p.Path + ": " + p.Detail
+}
+
+// Validate checks that err and its tree of causes and related errors are
+// well-formed before they are logged or sent elsewhere, and returns the
+// problems found, or nil if there are none. It reports cycles, nil causes,
+// related errors and stacks, errors without a message (other than joins of
+// several errors), stacks attached more than once, trees deeper than the
+// maximum traversal depth (see SetMaxTraversalDepth) or holding more than
+// 10000 errors, attribute values json.Marshal fails on, and timestamps more
+// than a minute ahead of the clock (see SetClock).
+//
+// Validate doesn't panic: a panic in the methods of an error is reported as
+// a problem. Errors reached twice without a cycle are validated once. A nil
+// err is valid.
+func Validate(err error) []Problem {
+	if err == nil {
+		return nil
+	}
+
+	v := &validator{
+		seen:    make(map[error]bool),
+		stacks:  make(map[string]string),
+		horizon: now().Add(validateClockSkew),
+	}
+	v.validate("", err, 0)
+
+	return v.problems
+}
+
+// MustValid panics if Validate reports problems for err, listing them one
+// per line. It is meant for tests.
+func MustValid(err error) {
+	problems := Validate(err)
+	if len(problems) == 0 {
+		return
+	}
+
+	lines := make([]string, len(problems))
+	for i, p := range problems {
+		lines[i] = p.String()
+	}
+	panic(fmt.Errorf("ae: invalid error tree:\n%s", strings.Join(lines, "\n")))
+}
+
+// validator walks an error tree for Validate.
+type validator struct {
+	problems []Problem
+	// seen maps the comparable errors visited to whether they are an
+	// ancestor of the error being validated.
+	seen map[error]bool
+	// stacks maps the stacks found to the path of their first error.
+	stacks  map[string]string
+	errors  int
+	stopped bool
+	horizon time.Time
+}
+
+func (v *validator) report(kind ProblemKind, path, format string, args ...any) {
+	v.problems = append(v.problems, Problem{Kind: kind, Path: path, Detail: fmt.Sprintf(format, args...)})
+}
+
+// validate checks err, found at path and depth, and its descendants.
+func (v *validator) validate(path string, err error, depth int) {
+	if v.stopped {
+		return
+	}
+
+	ancestor, seen, trackable := v.lookup(err)
+	if seen {
+		if ancestor {
+			v.report(ProblemCycle, path, "error %s is its own ancestor", describeError(err))
+		}
+		return
+	}
+
+	v.errors++
+	switch {
+	case v.errors > maxValidateErrors:
+		v.report(ProblemTreeSize, path, "tree holds more than %d errors", maxValidateErrors)
+		v.stopped = true
+		return
+	case depth >= traversalDepth():
+		v.report(ProblemTreeSize, path, "tree is deeper than %d levels", traversalDepth())
+		v.stopped = true
+		return
+	}
+
+	if trackable {
+		v.seen[err] = true
+		defer func() { v.seen[err] = false }()
+	}
+
+	causes, related, ok := v.inspect(path, err)
+	if !ok {
+		return
+	}
+
+	for i, cause := range causes {
+		v.validateChild(joinPath(path, "causes["+strconv.Itoa(i)+"]"), cause, depth)
+	}
+	for i, rel := range related {
+		v.validateChild(joinPath(path, "related["+strconv.Itoa(i)+"]"), rel, depth)
+	}
+}
+
+func (v *validator) validateChild(path string, err error, depth int) {
+	if err == nil {
+		v.report(ProblemNil, path, "nil error")
+		return
+	}
+
+	v.validate(path, err, depth+1)
+}
+
+// inspect checks the fields of err and returns its causes and related
+// errors, or ok == false if its methods panicked.
+func (v *validator) inspect(path string, err error) (causes, related []error, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			v.report(ProblemPanic, path, "%T panicked: %v", err, r)
+			ok = false
+		}
+	}()
+
+	causes = leafCauses(err, Causes(err))
+	if Message(err) == "" && !isJoin(err, causes) {
+		v.report(ProblemEmptyMessage, path, "%T has no message", err)
+	}
+
+	for key, value := range attrSeq(err) {
+		if _, mErr := json.Marshal(value); mErr != nil {
+			v.report(ProblemAttrNotJSON, joinPath(path, "attrs."+key), "%T can't be marshaled as JSON: %v", value, mErr)
+		}
+	}
+
+	if ts := Timestamp(err); ts.After(v.horizon) {
+		v.report(ProblemFutureTimestamp, path, "timestamp %s lies in the future", ts.Format(time.RFC3339))
+	}
+
+	for i, st := range Stacks(err) {
+		stackPath := joinPath(path, "stacks["+strconv.Itoa(i)+"]")
+		if st == nil {
+			v.report(ProblemNil, stackPath, "nil stack")
+			continue
+		}
+
+		key := stackKey(st)
+		if first, ok := v.stacks[key]; ok {
+			v.report(ProblemDuplicateStack, stackPath, "stack of goroutine %d is also attached at %s", st.ID, first)
+			continue
+		}
+		v.stacks[key] = stackPath
+	}
+
+	return causes, Related(err), true
+}
+
+// lookup reports whether err was visited before and is an ancestor of the
+// error being validated. Errors that can't be map keys are not trackable.
+func (v *validator) lookup(err error) (ancestor, seen, trackable bool) {
+	if !reflect.TypeOf(err).Comparable() {
+		return false, false, false
+	}
+	defer func() {
+		// a comparable type holding an incomparable value
+		if recover() != nil {
+			ancestor, seen, trackable = false, false, false
+		}
+	}()
+
+	ancestor, seen = v.seen[err]
+	return ancestor, seen, true
+}
+
+// describeError names err in problem details, without calling its methods.
+func describeError(err error) string {
+	if a, ok := asAe(err); ok {
+		return strconv.Quote(a.msg)
+	}
+
+	return fmt.Sprintf("%T", err)
+}
+
+// isJoin reports whether err merely joins several errors, such as the errors
+// returned by errors.Join, and needs no message of its own.
+func isJoin(err error, causes []error) bool {
+	if _, ok := asAe(err); ok {
+		return false
+	}
+	_, ok := err.(interface{ Unwrap() []error })
+
+	return ok && len(causes) > 0
+}
+
+// leafCauses drops the nil cause Causes returns for an error whose
+// single-error Unwrap or Cause method returns nil: like the errors package,
+// it means the error has no cause. Nil entries of cause lists are kept.
+func leafCauses(err error, causes []error) []error {
+	if len(causes) != 1 || causes[0] != nil {
+		return causes
+	}
+
+	switch err.(type) {
+	case ErrorCauses, interface{ Unwrap() []error }, multiError:
+		return causes
+	}
+
+	return nil
+}
+
+// stackKey identifies st by its goroutine and frames.
+func stackKey(st *Stack) string {
+	st.resolve()
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d", st.ID)
+	for _, frame := range st.Frames {
+		if frame == nil {
+			continue
+		}
+		fmt.Fprintf(&sb, "\x00%s\x00%s:%d", frame.Func, frame.File, frame.Line)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,194 @@
+package ae_test
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"go.aledante.io/ae"
+)
+
+// linkErr is a foreign error whose cause can be set after the error is used,
+// to close a cycle.
+type linkErr struct{ next error }
+
+func (e *linkErr) Error() string { return "link" }
+func (e *linkErr) Unwrap() error { return e.next }
+
+// listErr is a foreign error with arbitrary causes and stacks.
+type listErr struct {
+	causes []error
+	stacks []*ae.Stack
+}
+
+func (e *listErr) Error() string            { return "list" }
+func (e *listErr) Unwrap() []error          { return e.causes }
+func (e *listErr) ErrorStacks() []*ae.Stack { return e.stacks }
+
+// panicErr panics when asked for its message.
+type panicErr struct{}
+
+func (panicErr) Error() string { panic("no message today") }
+
+// assertProblem fails t unless problems hold exactly one problem of kind at
+// path.
+func assertProblem(t *testing.T, problems []ae.Problem, kind ae.ProblemKind, path string) {
+	t.Helper()
+
+	if len(problems) != 1 || problems[0].Kind != kind || problems[0].Path != path {
+		t.Errorf("problems = %v, want one %s at %q", problems, kind, path)
+	}
+}
+
+func TestValidate_WellFormed(t *testing.T) {
+	t.Parallel()
+
+	for _, err := range []error{
+		nil,
+		plainTreeErr(),
+		internalError(),
+		threeGoroutinesErr(),
+		ae.Wrap("wrapping", ae.New().Stack().Msg("captured")),
+		errors.Join(errors.New("a"), errors.New("b")),
+	} {
+		if problems := ae.Validate(err); problems != nil {
+			t.Errorf("Validate(%v) = %v, want none", err, problems)
+		}
+	}
+}
+
+func TestValidate_Cycle(t *testing.T) {
+	t.Parallel()
+
+	link := &linkErr{}
+	top := ae.New().Cause(link).Msg("top")
+	link.next = top
+
+	assertProblem(t, ae.Validate(top), ae.ProblemCycle, "causes[0].causes[0]")
+}
+
+func TestValidate_SharedErrorIsNoCycle(t *testing.T) {
+	t.Parallel()
+
+	shared := ae.Msg("shared")
+	err := ae.New().Cause(shared, ae.Wrap("again", shared)).Related(shared).Msg("top")
+
+	if problems := ae.Validate(err); problems != nil {
+		t.Errorf("problems = %v, want none", problems)
+	}
+}
+
+func TestValidate_Nil(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Cause(&listErr{causes: []error{errors.New("ok"), nil}}).Msg("top")
+	assertProblem(t, ae.Validate(err), ae.ProblemNil, "causes[0].causes[1]")
+
+	err = &listErr{causes: []error{errors.New("ok")}, stacks: []*ae.Stack{nil}}
+	assertProblem(t, ae.Validate(err), ae.ProblemNil, "stacks[0]")
+}
+
+func TestValidate_NilUnwrapIsLeaf(t *testing.T) {
+	t.Parallel()
+
+	if problems := ae.Validate(&linkErr{}); problems != nil {
+		t.Errorf("Validate(leaf) = %v, want nil", problems)
+	}
+	if problems := ae.Validate(ae.Wrap("top", &linkErr{})); problems != nil {
+		t.Errorf("Validate(Wrap(leaf)) = %v, want nil", problems)
+	}
+}
+
+func TestValidate_EmptyMessage(t *testing.T) {
+	t.Parallel()
+
+	assertProblem(t, ae.Validate(ae.Wrap("top", errors.New(""))), ae.ProblemEmptyMessage, "causes[0]")
+	assertProblem(t, ae.Validate(&ae.Ae{}), ae.ProblemEmptyMessage, "")
+}
+
+func TestValidate_DuplicateStack(t *testing.T) {
+	t.Parallel()
+
+	st := &ae.Stack{ID: 1, State: "running", Frames: []*ae.StackFrame{{Func: "main.main", File: "/src/main.go", Line: 3}}}
+	twin := &ae.Stack{ID: 1, State: "running", Frames: []*ae.StackFrame{{Func: "main.main", File: "/src/main.go", Line: 3}}}
+	err := ae.New().StacksFrom(st).Cause(ae.New().StacksFrom(twin).Msg("inner")).Msg("outer")
+
+	problems := ae.Validate(err)
+	assertProblem(t, problems, ae.ProblemDuplicateStack, "causes[0].stacks[0]")
+	if len(problems) == 1 && !strings.Contains(problems[0].Detail, "also attached at stacks[0]") {
+		t.Errorf("detail = %q", problems[0].Detail)
+	}
+}
+
+func TestValidate_TooLarge(t *testing.T) {
+	t.Parallel()
+
+	causes := make([]error, 10000)
+	for i := range causes {
+		causes[i] = ae.Msgf("cause %d", i)
+	}
+
+	assertProblem(t, ae.Validate(ae.New().Cause(causes...).Msg("top")), ae.ProblemTreeSize, "causes[9999]")
+	if problems := ae.Validate(ae.New().Cause(causes[1:]...).Msg("top")); problems != nil {
+		t.Errorf("10000 errors: problems = %v, want none", problems)
+	}
+}
+
+func TestValidate_TooDeep(t *testing.T) {
+	setTraversalDepth(t, 3)
+
+	if problems := ae.Validate(chain(3)); problems != nil {
+		t.Errorf("within the depth: problems = %v", problems)
+	}
+	assertProblem(t, ae.Validate(chain(4)), ae.ProblemTreeSize, "causes[0].causes[0].causes[0]")
+}
+
+func TestValidate_AttrNotJSON(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Attr("ok", []int{1}).Attr("nan", math.NaN()).Msg("top")
+	assertProblem(t, ae.Validate(err), ae.ProblemAttrNotJSON, "attrs.nan")
+
+	err = ae.Wrap("top", ae.New().Attr("ch", make(chan int)).Msg("inner"))
+	assertProblem(t, ae.Validate(err), ae.ProblemAttrNotJSON, "causes[0].attrs.ch")
+}
+
+func TestValidate_FutureTimestamp(t *testing.T) {
+	t.Parallel()
+
+	err := ae.New().Timestamp(time.Now().Add(time.Hour)).Msg("from tomorrow")
+	assertProblem(t, ae.Validate(err), ae.ProblemFutureTimestamp, "")
+
+	// clock skew is tolerated
+	if problems := ae.Validate(ae.New().Timestamp(time.Now().Add(time.Second)).Msg("skewed")); problems != nil {
+		t.Errorf("problems = %v, want none", problems)
+	}
+}
+
+func TestValidate_Panic(t *testing.T) {
+	t.Parallel()
+
+	problems := ae.Validate(ae.Wrap("top", panicErr{}))
+	assertProblem(t, problems, ae.ProblemPanic, "causes[0]")
+	if len(problems) == 1 && !strings.Contains(problems[0].Detail, "no message today") {
+		t.Errorf("detail = %q", problems[0].Detail)
+	}
+}
+
+func TestMustValid(t *testing.T) {
+	t.Parallel()
+
+	ae.MustValid(plainTreeErr())
+
+	defer func() {
+		r := recover()
+		err, ok := r.(error)
+		if !ok || !strings.Contains(err.Error(), "causes[0]: *errors.errorString has no message") {
+			t.Errorf("recovered %v, want the problems", r)
+		}
+	}()
+	ae.MustValid(ae.Wrap("top", errors.New("")))
+	t.Error("MustValid did not panic")
+}